@@ -4,9 +4,13 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"os"
+	"strings"
 	"time"
+	// time zones of maintenance windows don't depend on the zoneinfo of the image
+	_ "time/tzdata"
 
 	configv1client "github.com/openshift/client-go/config/clientset/versioned/typed/config/v1"
 	log "github.com/sirupsen/logrus"
@@ -17,10 +21,14 @@ import (
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/client"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/operators/catalog"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/operators/catalogtemplate"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/leaderelection"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/operatorclient"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/operatorstatus"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/queueinformer"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/server"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/signals"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/tracing"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/metrics"
 	olmversion "github.com/operator-framework/operator-lifecycle-manager/pkg/version"
 )
@@ -33,6 +41,7 @@ const (
 	defaultOPMImage             = "quay.io/operator-framework/upstream-opm-builder:latest"
 	defaultUtilImage            = "quay.io/operator-framework/olm:latest"
 	defaultOperatorName         = ""
+	leaderElectionLeaseName     = "catalog-operator-leader"
 )
 
 // config flags defined globally so that they appear on the test binary as well
@@ -75,6 +84,38 @@ var (
 
 	installPlanTimeout  = flag.Duration("install-plan-retry-timeout", 1*time.Minute, "time since first attempt at which plan execution errors are considered fatal")
 	bundleUnpackTimeout = flag.Duration("bundle-unpack-timeout", 10*time.Minute, "The time limit for bundle unpacking, after which InstallPlan execution is considered to have failed. 0 is considered as having no timeout.")
+
+	resolutionTimeout = flag.Duration("resolution-timeout", 5*time.Minute, "The time limit for a single dependency resolution attempt, after which the affected Subscriptions are marked with a ResolutionTimedOut condition. 0 is considered as having no timeout.")
+
+	installPlanRollbackTimeout = flag.Duration("install-plan-rollback-timeout", 10*time.Minute, "time after completion of an InstallPlan annotated with "+catalog.RollbackOnFailureAnnotationKey+" within which its ClusterServiceVersions must succeed before the plan is rolled back")
+
+	additionalStepKinds = flag.String("additional-step-kinds", "", "comma-separated list of resource kinds, given as Kind or Kind.group, that InstallPlans may create in addition to those supported by default")
+
+	registryWebhookAddress = flag.String("registry-webhook-address", "", "address to serve the registry push webhook on over TLS, with the --tls-cert and --tls-key, at "+catalog.RegistryWebhookPath+", which triggers an immediate update check of the CatalogSources polling the pushed image. Disabled if empty.")
+
+	registryWebhookTokenFile = flag.String("registry-webhook-token-file", "", "path to a file holding the token registry push webhook requests must carry as a bearer token in their Authorization header. Required with --registry-webhook-address.")
+
+	staleCatalogTolerance = flag.Duration("stale-catalog-tolerance", 0, "time after the last successful read of a catalog within which its last good content is used for resolution when reading it fails. 0 disables the fallback.")
+
+	ignoreUnneededCatalogErrors = flag.Bool("ignore-unneeded-catalog-errors", false, "resolve without the content of catalogs that can't be read, unless a Subscription being resolved names them, rather than failing resolution")
+
+	leaderElect = flag.Bool("leader-elect", false, "elect a leader among the replicas of catalog operator using a Lease. Followers start their informers but don't reconcile until elected.")
+
+	leaderElectionNamespace = flag.String("leader-election-namespace", "", "namespace of the leader election Lease, defaults to -namespace")
+
+	leaderElectionLeaseDuration = flag.Duration("leader-election-lease-duration", leaderelection.DefaultLeaseDuration, "time followers wait before taking over a Lease that wasn't renewed")
+
+	leaderElectionRenewDeadline = flag.Duration("leader-election-renew-deadline", leaderelection.DefaultRenewDeadline, "time the leader retries renewing its Lease before giving up leadership")
+
+	leaderElectionRetryPeriod = flag.Duration("leader-election-retry-period", leaderelection.DefaultRetryPeriod, "time between attempts to acquire or renew the Lease")
+
+	tracingEndpoint = flag.String("tracing-endpoint", "", "address of an OTLP gRPC collector to export traces to. Tracing is disabled if empty.")
+
+	tracingInsecure = flag.Bool("tracing-insecure", false, "connect to the tracing collector without TLS")
+
+	tracingSampleRatio = flag.Float64("tracing-sample-ratio", 1, "share of the resolutions that are traced, between 0 and 1")
+
+	resolutionPreference = flag.String("resolution-preference", string(resolver.PreferChannelHead), "how to choose among valid resolutions: \"channel-head\" prefers the latest bundle in each channel, \"minimal-change\" prefers installing or upgrading as few operators as possible")
 )
 
 func init() {
@@ -109,16 +150,16 @@ func main() {
 		*catalogNamespace = catalogNamespaceEnvVarValue
 	}
 
-	listenAndServe, err := server.GetListenAndServeFunc(logger, tlsCertPath, tlsKeyPath, clientCAPath)
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		ServiceName: "catalog-operator",
+		Endpoint:    *tracingEndpoint,
+		Insecure:    *tracingInsecure,
+		SampleRatio: *tracingSampleRatio,
+	})
 	if err != nil {
-		logger.Fatal("Error setting up health/metric/pprof service: %v", err)
+		log.Fatalf("error configuring tracing: %s", err.Error())
 	}
-
-	go func() {
-		if err := listenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Error(err)
-		}
-	}()
+	defer shutdownTracing(context.Background())
 
 	// create a config client for operator status
 	config, err := clientcmd.BuildConfigFromFlags("", *kubeConfigPath)
@@ -135,8 +176,68 @@ func main() {
 		log.Fatalf("error configuring client: %s", err.Error())
 	}
 
+	var elector *leaderelection.Elector
+	var serverOptions []server.Option
+	if *leaderElect {
+		leaseNamespace := *leaderElectionNamespace
+		if leaseNamespace == "" {
+			leaseNamespace = *catalogNamespace
+		}
+		elector, err = leaderelection.NewElector(opClient.KubernetesInterface(), logger, leaderelection.Config{
+			Namespace:     leaseNamespace,
+			Name:          leaderElectionLeaseName,
+			LeaseDuration: *leaderElectionLeaseDuration,
+			RenewDeadline: *leaderElectionRenewDeadline,
+			RetryPeriod:   *leaderElectionRetryPeriod,
+		})
+		if err != nil {
+			log.Fatalf("error configuring leader election: %s", err.Error())
+		}
+		serverOptions = append(serverOptions, server.WithLeaderStatus(elector))
+	}
+
+	listenAndServe, err := server.GetListenAndServeFunc(logger, tlsCertPath, tlsKeyPath, clientCAPath, serverOptions...)
+	if err != nil {
+		logger.Fatal("Error setting up health/metric/pprof service: %v", err)
+	}
+
+	go func() {
+		if err := listenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error(err)
+		}
+	}()
+
+	preference, err := resolver.ParseResolutionPreference(*resolutionPreference)
+	if err != nil {
+		log.Fatalf("error configuring resolver: %s", err.Error())
+	}
+
+	stepKinds, err := catalog.ParseStepKinds(*additionalStepKinds)
+	if err != nil {
+		log.Fatalf("error configuring installplan step kinds: %s", err.Error())
+	}
+
 	// Create a new instance of the operator.
-	op, err := catalog.NewOperator(ctx, *kubeConfigPath, utilclock.RealClock{}, logger, *wakeupInterval, *configmapServerImage, *opmImage, *utilImage, *catalogNamespace, k8sscheme.Scheme, *installPlanTimeout, *bundleUnpackTimeout)
+	op, err := catalog.NewOperator(
+		ctx,
+		catalog.WithKubeconfigPath(*kubeConfigPath),
+		catalog.WithClock(utilclock.RealClock{}),
+		catalog.WithLogger(logger),
+		catalog.WithResyncPeriod(queueinformer.ResyncWithJitter(*wakeupInterval, 0.2)),
+		catalog.WithOperatorNamespace(*catalogNamespace),
+		catalog.WithScheme(k8sscheme.Scheme),
+		catalog.WithConfigMapServerImage(*configmapServerImage),
+		catalog.WithOPMImage(*opmImage),
+		catalog.WithUtilImage(*utilImage),
+		catalog.WithInstallPlanTimeout(*installPlanTimeout),
+		catalog.WithBundleUnpackTimeout(*bundleUnpackTimeout),
+		catalog.WithResolutionPreference(preference),
+		catalog.WithResolutionTimeout(*resolutionTimeout),
+		catalog.WithAdditionalStepKinds(stepKinds),
+		catalog.WithInstallPlanRollbackTimeout(*installPlanRollbackTimeout),
+		catalog.WithStaleCatalogTolerance(*staleCatalogTolerance),
+		catalog.WithUnneededCatalogErrorsIgnored(*ignoreUnneededCatalogErrors),
+	)
 	if err != nil {
 		log.Panicf("error configuring catalog operator: %s", err.Error())
 	}
@@ -146,6 +247,53 @@ func main() {
 		log.Panicf("error configuring catalog template operator: %s", err.Error())
 	}
 
+	if *registryWebhookAddress != "" {
+		if *registryWebhookTokenFile == "" {
+			log.Fatal("--registry-webhook-token-file is required with --registry-webhook-address")
+		}
+		data, err := ioutil.ReadFile(*registryWebhookTokenFile)
+		if err != nil {
+			log.Fatalf("error reading registry webhook token: %s", err.Error())
+		}
+		token := strings.TrimSpace(string(data))
+		if token == "" {
+			log.Fatalf("registry webhook token file %s is empty", *registryWebhookTokenFile)
+		}
+		handler := op.RegistryWebhookHandler(token)
+		if elector != nil {
+			// pushes must reach the replica reconciling CatalogSources
+			handler = elector.LeaderOnly(handler)
+		}
+		mux := http.NewServeMux()
+		mux.Handle(catalog.RegistryWebhookPath, handler)
+		listenAndServeWebhook, err := server.GetTLSListenAndServeFunc(logger, *registryWebhookAddress, mux, *tlsCertPath, *tlsKeyPath)
+		if err != nil {
+			log.Fatalf("error configuring registry webhook server: %s", err.Error())
+		}
+		go func() {
+			if err := listenAndServeWebhook(); err != nil && err != http.ErrServerClosed {
+				logger.WithError(err).Error("registry webhook server failed")
+			}
+		}()
+	}
+
+	if elector != nil {
+		// Warm the caches of followers so that they can take over quickly
+		op.RunInformers(ctx)
+		opCatalogTemplate.RunInformers(ctx)
+		go func() {
+			elector.Run(ctx)
+			if ctx.Err() == nil {
+				logger.Fatal("lost leadership")
+			}
+		}()
+		select {
+		case <-elector.Elected():
+		case <-ctx.Done():
+			return
+		}
+	}
+
 	op.Run(ctx)
 	<-op.Ready()
 
@@ -18,14 +18,18 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/client/clientset/versioned"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/certs"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/install"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/operators/olm"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/operators/openshift"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/feature"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/leaderelection"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/operatorclient"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/operatorstatus"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/queueinformer"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/server"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/signals"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/tracing"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/metrics"
 	olmversion "github.com/operator-framework/operator-lifecycle-manager/pkg/version"
 )
@@ -34,6 +38,7 @@ const (
 	defaultWakeupInterval          = 5 * time.Minute
 	defaultOperatorName            = ""
 	defaultPackageServerStatusName = ""
+	leaderElectionLeaseName        = "olm-operator-leader"
 )
 
 // config flags defined globally so that they appear on the test binary as well
@@ -69,6 +74,49 @@ var (
 
 	namespace = pflag.String(
 		"namespace", "", "namespace where cleanup runs")
+
+	certIssuer = pflag.String(
+		"cert-issuer", "self-signed", "issuer of the serving certificates of operator APIServices and webhooks: "+
+			"self-signed, ca-secret:[<namespace>/]<name>, cert-manager:Issuer/<name> or cert-manager:ClusterIssuer/<name>. "+
+			"OperatorGroups can override it with the "+olm.CertIssuerAnnotationKey+" annotation.")
+
+	certValidFor = pflag.Duration(
+		"cert-valid-for", install.DefaultCertValidFor, "how long the CA of the serving certificates of operator APIServices and webhooks is valid for. "+
+			"CSVs can override it with the "+install.CertValidForAnnotationKey+" annotation.")
+
+	certMinFresh = pflag.Duration(
+		"cert-min-fresh", install.DefaultCertMinFresh, "how long before the expiry of their CA serving certificates are rotated. "+
+			"CSVs can override it with the "+install.CertMinFreshAnnotationKey+" annotation.")
+
+	certKeyAlgorithm = pflag.String(
+		"cert-key-algorithm", string(certs.DefaultKeyAlgorithm), "algorithm of the keys of serving certificates: ecdsa-p256, rsa-2048 or rsa-4096. "+
+			"CSVs can override it with the "+install.CertKeyAlgorithmAnnotationKey+" annotation.")
+
+	leaderElect = pflag.Bool(
+		"leader-elect", false, "elect a leader among the replicas of olm operator using a Lease. "+
+			"Followers start their informers but don't reconcile until elected.")
+
+	leaderElectionNamespace = pflag.String(
+		"leader-election-namespace", "", "namespace of the leader election Lease, defaults to --namespace")
+
+	leaderElectionLeaseDuration = pflag.Duration(
+		"leader-election-lease-duration", leaderelection.DefaultLeaseDuration, "time followers wait before taking over a Lease that wasn't renewed")
+
+	leaderElectionRenewDeadline = pflag.Duration(
+		"leader-election-renew-deadline", leaderelection.DefaultRenewDeadline, "time the leader retries renewing its Lease before giving up leadership")
+
+	leaderElectionRetryPeriod = pflag.Duration(
+		"leader-election-retry-period", leaderelection.DefaultRetryPeriod, "time between attempts to acquire or renew the Lease")
+
+	tracingEndpoint = pflag.String(
+		"tracing-endpoint", "", "address of an OTLP gRPC collector to export traces to. Tracing is disabled if empty.")
+
+	tracingInsecure = pflag.Bool(
+		"tracing-insecure", false, "connect to the tracing collector without TLS")
+
+	tracingSampleRatio = pflag.Float64(
+		"tracing-sample-ratio", 1, "share of the traces started by olm operator that are recorded, between 0 and 1. "+
+			"Traces continued from the catalog operator follow its sampling decision.")
 )
 
 func init() {
@@ -118,16 +166,16 @@ func main() {
 	}
 	logger.Infof("log level %s", logger.Level)
 
-	listenAndServe, err := server.GetListenAndServeFunc(logger, tlsCertPath, tlsKeyPath, clientCAPath)
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		ServiceName: "olm-operator",
+		Endpoint:    *tracingEndpoint,
+		Insecure:    *tracingInsecure,
+		SampleRatio: *tracingSampleRatio,
+	})
 	if err != nil {
-		logger.Fatal("Error setting up health/metric/pprof service: %v", err)
+		logger.WithError(err).Fatal("error configuring tracing")
 	}
-
-	go func() {
-		if err := listenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Error(err)
-		}
-	}()
+	defer shutdownTracing(context.Background())
 
 	mgr, err := Manager(ctx, *debug)
 	if err != nil {
@@ -152,6 +200,54 @@ func main() {
 		logger.WithError(err).Fatal("error configuring custom resource client")
 	}
 
+	var elector *leaderelection.Elector
+	var serverOptions []server.Option
+	if *leaderElect {
+		leaseNamespace := *leaderElectionNamespace
+		if leaseNamespace == "" {
+			leaseNamespace = *namespace
+		}
+		elector, err = leaderelection.NewElector(opClient.KubernetesInterface(), logger, leaderelection.Config{
+			Namespace:     leaseNamespace,
+			Name:          leaderElectionLeaseName,
+			LeaseDuration: *leaderElectionLeaseDuration,
+			RenewDeadline: *leaderElectionRenewDeadline,
+			RetryPeriod:   *leaderElectionRetryPeriod,
+		})
+		if err != nil {
+			logger.WithError(err).Fatal("error configuring leader election")
+		}
+		serverOptions = append(serverOptions, server.WithLeaderStatus(elector))
+	}
+
+	listenAndServe, err := server.GetListenAndServeFunc(logger, tlsCertPath, tlsKeyPath, clientCAPath, serverOptions...)
+	if err != nil {
+		logger.Fatal("Error setting up health/metric/pprof service: %v", err)
+	}
+
+	go func() {
+		if err := listenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error(err)
+		}
+	}()
+
+	certIssuerConfig, err := certs.ParseIssuerConfig(*certIssuer)
+	if err != nil {
+		logger.WithError(err).Fatal("invalid cert issuer")
+	}
+	keyAlgorithm, err := certs.ParseKeyAlgorithm(*certKeyAlgorithm)
+	if err != nil {
+		logger.WithError(err).Fatal("invalid cert key algorithm")
+	}
+	certConfig := install.CertConfig{
+		ValidFor:     *certValidFor,
+		MinFresh:     *certMinFresh,
+		KeyAlgorithm: keyAlgorithm,
+	}
+	if err := certConfig.Validate(); err != nil {
+		logger.WithError(err).Fatal("invalid cert config")
+	}
+
 	// Create a new instance of the operator.
 	op, err := olm.NewOperator(
 		ctx,
@@ -162,12 +258,30 @@ func main() {
 		olm.WithOperatorClient(opClient),
 		olm.WithRestConfig(config),
 		olm.WithConfigClient(versionedConfigClient),
+		olm.WithCertIssuer(certIssuerConfig),
+		olm.WithCertConfig(certConfig),
 	)
 	if err != nil {
 		logger.WithError(err).Fatalf("error configuring operator")
 		return
 	}
 
+	if elector != nil {
+		// Warm the caches of followers so that they can take over quickly
+		op.RunInformers(ctx)
+		go func() {
+			elector.Run(ctx)
+			if ctx.Err() == nil {
+				logger.Fatal("lost leadership")
+			}
+		}()
+		select {
+		case <-elector.Elected():
+		case <-ctx.Done():
+			return
+		}
+	}
+
 	op.Run(ctx)
 	<-op.Ready()
 
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver/cache"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver/solver"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/operatorlister"
+	olmversion "github.com/operator-framework/operator-lifecycle-manager/pkg/version"
+)
+
+const (
+	defaultCatalogNamespace = "olm"
+)
+
+// whatif runs the resolver against a local snapshot of a catalog and
+// of a namespace's ClusterServiceVersions and Subscriptions, and prints
+// the operators that would be selected and the InstallPlan steps that
+// would be generated, without contacting a cluster.
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("whatif", flag.ContinueOnError)
+	var (
+		namespace = fs.String(
+			"namespace", "default", "namespace in which to resolve")
+		globalCatalogNamespace = fs.String(
+			"global-catalog-namespace", defaultCatalogNamespace, "namespace whose catalogs are available to all namespaces")
+		catalogDir = fs.String(
+			"catalog-dir", "", "path to a directory containing a file-based catalog")
+		catalogName = fs.String(
+			"catalog-name", "", "name of the CatalogSource that serves the catalog (as referenced by Subscriptions)")
+		catalogNamespace = fs.String(
+			"catalog-namespace", defaultCatalogNamespace, "namespace of the CatalogSource that serves the catalog")
+		clusterState = fs.String(
+			"cluster-state", "", "path to a file or directory of YAML manifests for existing ClusterServiceVersions and Subscriptions")
+		resolutionPreference = fs.String(
+			"resolution-preference", string(resolver.PreferChannelHead), "how to choose among valid resolutions: \"channel-head\" or \"minimal-change\"")
+		debug = fs.Bool(
+			"debug", false, "use debug log level")
+		version = fs.Bool("version", false, "displays olm version")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *version {
+		fmt.Fprint(out, olmversion.String())
+		return nil
+	}
+
+	if *catalogDir == "" || *catalogName == "" {
+		return fmt.Errorf("both -catalog-dir and -catalog-name are required")
+	}
+
+	preference, err := resolver.ParseResolutionPreference(*resolutionPreference)
+	if err != nil {
+		return err
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(os.Stderr)
+	if *debug {
+		logger.SetLevel(logrus.DebugLevel)
+	}
+
+	fbc, err := resolver.LoadFileBasedCatalog(os.DirFS(*catalogDir))
+	if err != nil {
+		return fmt.Errorf("error loading catalog: %w", err)
+	}
+	key := cache.SourceKey{Name: *catalogName, Namespace: *catalogNamespace}
+	source, err := resolver.NewFileBasedSource(key, fbc)
+	if err != nil {
+		return fmt.Errorf("error loading catalog: %w", err)
+	}
+	for _, reason := range source.Skipped() {
+		logger.Warnf("skipping invalid %s", reason)
+	}
+
+	var csvs []*v1alpha1.ClusterServiceVersion
+	var subs []*v1alpha1.Subscription
+	if *clusterState != "" {
+		csvs, subs, err = loadClusterState(*clusterState, *namespace)
+		if err != nil {
+			return fmt.Errorf("error loading cluster state: %w", err)
+		}
+	}
+
+	r := resolver.NewDefaultSatResolver(cache.StaticSourceProvider{key: source}, operatorlister.NewLister().OperatorsV1alpha1().CatalogSourceLister(), logger, resolver.WithResolutionPreference(preference))
+	operators, err := r.SolveOperators(context.Background(), []string{*namespace, *globalCatalogNamespace}, csvs, subs)
+	if conflicts, ok := err.(solver.NotSatisfiable); ok {
+		return fmt.Errorf("constraints not satisfiable:\n%s", resolver.Explain(conflicts, subs))
+	} else if err != nil {
+		return fmt.Errorf("resolution failed: %w", err)
+	}
+
+	return printResolution(out, *namespace, operators)
+}
+
+func printResolution(out io.Writer, namespace string, operators cache.OperatorSet) error {
+	names := make([]string, 0, len(operators))
+	for name := range operators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "OPERATOR\tPACKAGE\tCHANNEL\tCATALOG\tREPLACES")
+	for _, name := range names {
+		op := operators[name]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", op.Name, op.Package(), op.Channel(), op.SourceInfo.Catalog.String(), op.Replaces)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out)
+	w = tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "RESOLVING\tKIND\tNAME")
+	var unpack []string
+	for _, name := range names {
+		op := operators[name]
+		if op.Bundle == nil {
+			unpack = append(unpack, fmt.Sprintf("%s (%s)", op.Name, op.BundlePath))
+			continue
+		}
+		steps, err := resolver.NewStepsFromBundle(op.Bundle, namespace, op.Replaces, op.SourceInfo.Catalog.Name, op.SourceInfo.Catalog.Namespace)
+		if err != nil {
+			return fmt.Errorf("failed to turn bundle %s into steps: %w", op.Name, err)
+		}
+		for _, step := range steps {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", step.Resolving, step.Resource.Kind, step.Resource.Name)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(unpack) > 0 {
+		fmt.Fprintf(out, "\nsteps for the following bundles are only known after unpacking their images:\n")
+		for _, u := range unpack {
+			fmt.Fprintf(out, "  %s\n", u)
+		}
+	}
+
+	return nil
+}
+
+// loadClusterState decodes every ClusterServiceVersion and
+// Subscription in the YAML manifests at path that belong to namespace.
+// Objects without a namespace are assumed to belong to it.
+func loadClusterState(path, namespace string) ([]*v1alpha1.ClusterServiceVersion, []*v1alpha1.Subscription, error) {
+	var files []string
+	err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(p)) {
+		case ".yaml", ".yml", ".json":
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var csvs []*v1alpha1.ClusterServiceVersion
+	var subs []*v1alpha1.Subscription
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		dec := utilyaml.NewYAMLOrJSONDecoder(strings.NewReader(string(data)), 4096)
+		for {
+			var u unstructured.Unstructured
+			if err := dec.Decode(&u.Object); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, nil, fmt.Errorf("error decoding %s: %w", file, err)
+			}
+			if len(u.Object) == 0 {
+				continue
+			}
+			if u.GetNamespace() == "" {
+				u.SetNamespace(namespace)
+			}
+			if u.GetNamespace() != namespace {
+				continue
+			}
+
+			switch u.GetKind() {
+			case v1alpha1.ClusterServiceVersionKind:
+				var csv v1alpha1.ClusterServiceVersion
+				if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &csv); err != nil {
+					return nil, nil, fmt.Errorf("error decoding %s: %w", file, err)
+				}
+				csvs = append(csvs, &csv)
+			case v1alpha1.SubscriptionKind:
+				var sub v1alpha1.Subscription
+				if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &sub); err != nil {
+					return nil, nil, fmt.Errorf("error decoding %s: %w", file, err)
+				}
+				subs = append(subs, &sub)
+			}
+		}
+	}
+
+	return csvs, subs, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	var out bytes.Buffer
+	err := run([]string{
+		"-namespace", "operators",
+		"-catalog-dir", "testdata/catalog",
+		"-catalog-name", "community",
+		"-cluster-state", "testdata/cluster",
+	}, &out)
+	require.NoError(t, err)
+	require.Equal(t, `OPERATOR             PACKAGE  CHANNEL  CATALOG        REPLACES
+etcdoperator.v0.9.4  etcd     stable   community/olm  etcdoperator.v0.9.2
+
+RESOLVING            KIND                      NAME
+etcdoperator.v0.9.4  ClusterServiceVersion     etcdoperator.v0.9.4
+etcdoperator.v0.9.4  CustomResourceDefinition  etcdclusters.etcd.database.coreos.com
+`, out.String())
+}
+
+func TestRunNotSatisfiable(t *testing.T) {
+	var out bytes.Buffer
+	err := run([]string{
+		"-namespace", "elsewhere",
+		"-catalog-dir", "testdata/catalog",
+		"-catalog-name", "community",
+		"-cluster-state", "testdata/cluster",
+	}, &out)
+	require.EqualError(t, err, `constraints not satisfiable:
+subscription unrelated requests package unrelated from catalog community/olm
+  - no operators found in package unrelated in the catalog referenced by subscription unrelated
+  - subscription unrelated exists`)
+}
+
+func TestRunRequiresCatalog(t *testing.T) {
+	require.Error(t, run(nil, &bytes.Buffer{}))
+}
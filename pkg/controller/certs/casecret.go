@@ -0,0 +1,64 @@
+package certs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// NewCASecretIssuer returns an Issuer that signs serving certificates with the
+// CA key pair held in the given Secret, under the tls.crt and tls.key keys.
+// The serving certificates have keys of the given algorithm.
+func NewCASecretIssuer(client kubernetes.Interface, namespace, name string, generator CertGenerator, keyAlgorithm KeyAlgorithm) Issuer {
+	return &caSecretIssuer{
+		client:       client,
+		namespace:    namespace,
+		name:         name,
+		generator:    generator,
+		keyAlgorithm: keyAlgorithm,
+	}
+}
+
+type caSecretIssuer struct {
+	client       kubernetes.Interface
+	namespace    string
+	name         string
+	generator    CertGenerator
+	keyAlgorithm KeyAlgorithm
+}
+
+func (c *caSecretIssuer) CA(notAfter time.Time, organization string) (*KeyPair, error) {
+	secret, err := c.client.CoreV1().Secrets(c.namespace).Get(context.TODO(), c.name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting CA secret %s/%s: %v", c.namespace, c.name, err)
+	}
+
+	cert, err := PEMToCert(secret.Data[corev1.TLSCertKey])
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s in CA secret %s/%s: %v", corev1.TLSCertKey, c.namespace, c.name, err)
+	}
+	if !cert.IsCA {
+		return nil, fmt.Errorf("certificate in CA secret %s/%s is not a CA certificate", c.namespace, c.name)
+	}
+	if !Active(cert) {
+		return nil, fmt.Errorf("certificate in CA secret %s/%s is not active", c.namespace, c.name)
+	}
+	priv, err := PEMToPrivateKey(secret.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s in CA secret %s/%s: %v", corev1.TLSPrivateKeyKey, c.namespace, c.name, err)
+	}
+
+	return &KeyPair{Cert: cert, Priv: priv}, nil
+}
+
+func (c *caSecretIssuer) Issue(object metav1.ObjectMeta, notAfter time.Time, organization string, ca *KeyPair, hosts []string) (*KeyPair, []byte, error) {
+	// Certificates must not outlive the CA that signs them.
+	if ca != nil && ca.Cert.NotAfter.Before(notAfter) {
+		notAfter = ca.Cert.NotAfter
+	}
+	return issueSigned(c.generator, notAfter, organization, ca, hosts, c.keyAlgorithm)
+}
@@ -0,0 +1,197 @@
+package certs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// certManagerCAKey is the key cert-manager stores the certificate of the
+	// issuing CA under.
+	certManagerCAKey = "ca.crt"
+
+	// certManagerSecretSuffix is appended to the name of the Secret OLM
+	// stores a certificate in to name the Secret cert-manager issues it to.
+	certManagerSecretSuffix = "-issued"
+)
+
+// CertificateGVR is the resource of cert-manager Certificates.
+var CertificateGVR = schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+
+// NewCertManagerIssuer returns an Issuer that delegates to cert-manager: it
+// creates a Certificate for every serving certificate, referencing the given
+// cert-manager issuer, and returns a PendingError until cert-manager has issued
+// it. The Certificates request private keys of the given algorithm.
+func NewCertManagerIssuer(client kubernetes.Interface, dynamicClient dynamic.Interface, kind, name string, keyAlgorithm KeyAlgorithm) Issuer {
+	return &certManagerIssuer{
+		client:        client,
+		dynamicClient: dynamicClient,
+		kind:          kind,
+		name:          name,
+		keyAlgorithm:  keyAlgorithm,
+	}
+}
+
+type certManagerIssuer struct {
+	client        kubernetes.Interface
+	dynamicClient dynamic.Interface
+	kind          string
+	name          string
+	keyAlgorithm  KeyAlgorithm
+}
+
+func (c *certManagerIssuer) CA(notAfter time.Time, organization string) (*KeyPair, error) {
+	// cert-manager signs the certificates.
+	return nil, nil
+}
+
+func (c *certManagerIssuer) Issue(object metav1.ObjectMeta, notAfter time.Time, organization string, ca *KeyPair, hosts []string) (*KeyPair, []byte, error) {
+	secretName := object.GetName() + certManagerSecretSuffix
+	certificate, err := c.ensureCertificate(object, secretName, notAfter, organization, hosts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	servingPair, caPEM, err := c.issued(object.GetNamespace(), secretName, hosts)
+	if err != nil {
+		message := fmt.Sprintf("certificate %s/%s not issued by %s %s yet: %v", object.GetNamespace(), object.GetName(), c.kind, c.name, err)
+		if ready := certificateReadyMessage(certificate); ready != "" {
+			message = fmt.Sprintf("%s, certificate not ready: %s", message, ready)
+		}
+		return nil, nil, PendingError{Message: message}
+	}
+
+	return servingPair, caPEM, nil
+}
+
+// ensureCertificate creates or updates the Certificate that requests the
+// serving certificate stored in the given object, and returns it.
+func (c *certManagerIssuer) ensureCertificate(object metav1.ObjectMeta, secretName string, notAfter time.Time, organization string, hosts []string) (*unstructured.Unstructured, error) {
+	dnsNames := make([]interface{}, len(hosts))
+	for i, host := range hosts {
+		dnsNames[i] = host
+	}
+	spec := map[string]interface{}{
+		"secretName": secretName,
+		"dnsNames":   dnsNames,
+		"duration":   time.Until(notAfter).Round(time.Hour).String(),
+		"subject": map[string]interface{}{
+			"organizations": []interface{}{organization},
+		},
+		"usages":     []interface{}{"server auth", "client auth", "digital signature"},
+		"privateKey": certManagerPrivateKey(c.keyAlgorithm),
+		"issuerRef": map[string]interface{}{
+			"group": CertificateGVR.Group,
+			"kind":  c.kind,
+			"name":  c.name,
+		},
+	}
+
+	client := c.dynamicClient.Resource(CertificateGVR).Namespace(object.GetNamespace())
+	existing, err := client.Get(context.TODO(), object.GetName(), metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		certificate := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+		certificate.SetAPIVersion(CertificateGVR.GroupVersion().String())
+		certificate.SetKind("Certificate")
+		certificate.SetName(object.GetName())
+		certificate.SetNamespace(object.GetNamespace())
+		certificate.SetOwnerReferences(object.GetOwnerReferences())
+		certificate.SetLabels(object.GetLabels())
+		created, err := client.Create(context.TODO(), certificate, metav1.CreateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("error creating certificate %s/%s: %v", object.GetNamespace(), object.GetName(), err)
+		}
+		return created, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting certificate %s/%s: %v", object.GetNamespace(), object.GetName(), err)
+	}
+
+	// The duration changes with every install, which is only worth an update
+	// when the requested names or issuer change too.
+	current, _, _ := unstructured.NestedMap(existing.Object, "spec")
+	desired := make(map[string]interface{}, len(spec))
+	for k, v := range spec {
+		desired[k] = v
+	}
+	desired["duration"] = current["duration"]
+	if equality.Semantic.DeepEqual(current, desired) {
+		return existing, nil
+	}
+
+	existing.Object["spec"] = spec
+	updated, err := client.Update(context.TODO(), existing, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error updating certificate %s/%s: %v", object.GetNamespace(), object.GetName(), err)
+	}
+	return updated, nil
+}
+
+// certificateReadyMessage returns the message of the Ready condition of the
+// given Certificate, if it isn't ready.
+func certificateReadyMessage(certificate *unstructured.Unstructured) string {
+	conditions, _, _ := unstructured.NestedSlice(certificate.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["type"] != "Ready" || condition["status"] == string(corev1.ConditionTrue) {
+			continue
+		}
+		message, _ := condition["message"].(string)
+		return message
+	}
+	return ""
+}
+
+// certManagerPrivateKey returns the private key spec of Certificates that
+// request keys of the given algorithm.
+func certManagerPrivateKey(keyAlgorithm KeyAlgorithm) map[string]interface{} {
+	switch keyAlgorithm {
+	case RSA2048KeyAlgorithm:
+		return map[string]interface{}{"algorithm": "RSA", "size": int64(2048)}
+	case RSA4096KeyAlgorithm:
+		return map[string]interface{}{"algorithm": "RSA", "size": int64(4096)}
+	}
+	return map[string]interface{}{"algorithm": "ECDSA", "size": int64(256)}
+}
+
+// issued returns the certificate cert-manager issued to the given Secret, if
+// it is active and valid for the given hosts.
+func (c *certManagerIssuer) issued(namespace, secretName string, hosts []string) (*KeyPair, []byte, error) {
+	secret, err := c.client.CoreV1().Secrets(namespace).Get(context.TODO(), secretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cert, err := PEMToCert(secret.Data[corev1.TLSCertKey])
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid %s in secret %s: %v", corev1.TLSCertKey, secretName, err)
+	}
+	if !Active(cert) {
+		return nil, nil, fmt.Errorf("certificate in secret %s is not active", secretName)
+	}
+	for _, host := range hosts {
+		if err := cert.VerifyHostname(host); err != nil {
+			return nil, nil, fmt.Errorf("certificate in secret %s is not valid for %s", secretName, host)
+		}
+	}
+	priv, err := PEMToPrivateKey(secret.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid %s in secret %s: %v", corev1.TLSPrivateKeyKey, secretName, err)
+	}
+	caPEM := secret.Data[certManagerCAKey]
+	if _, err := PEMToCert(caPEM); err != nil {
+		return nil, nil, fmt.Errorf("invalid %s in secret %s, the issuer must provide its CA certificate: %v", certManagerCAKey, secretName, err)
+	}
+
+	return &KeyPair{Cert: cert, Priv: priv}, caPEM, nil
+}
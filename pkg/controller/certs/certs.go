@@ -1,9 +1,10 @@
 package certs
 
 import (
+	"crypto"
 	"crypto/ecdsa"
-	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/x509"
 	"crypto/x509/pkix"
@@ -16,34 +17,41 @@ import (
 )
 
 type CertGenerator interface {
-	Generate(notAfter time.Time, organization string, ca *KeyPair, hosts []string) (*KeyPair, error)
+	Generate(notAfter time.Time, organization string, ca *KeyPair, hosts []string, keyAlgorithm KeyAlgorithm) (*KeyPair, error)
 }
 
-type CertGeneratorFunc func(notAfter time.Time, organization string, ca *KeyPair, hosts []string) (*KeyPair, error)
+type CertGeneratorFunc func(notAfter time.Time, organization string, ca *KeyPair, hosts []string, keyAlgorithm KeyAlgorithm) (*KeyPair, error)
 
-func (f CertGeneratorFunc) Generate(notAfter time.Time, organization string, ca *KeyPair, hosts []string) (*KeyPair, error) {
-	return f(notAfter, organization, ca, hosts)
+func (f CertGeneratorFunc) Generate(notAfter time.Time, organization string, ca *KeyPair, hosts []string, keyAlgorithm KeyAlgorithm) (*KeyPair, error) {
+	return f(notAfter, organization, ca, hosts, keyAlgorithm)
 }
 
 var _ CertGenerator = CertGeneratorFunc(CreateSignedServingPair)
 
-// KeyPair stores an x509 certificate and its ECDSA private key
+// KeyPair stores an x509 certificate and its private key
 type KeyPair struct {
 	Cert *x509.Certificate
-	Priv *ecdsa.PrivateKey
+	Priv crypto.Signer
 }
 
 // ToPEM returns the PEM encoded cert pair
 func (kp *KeyPair) ToPEM() (certPEM []byte, privPEM []byte, err error) {
 	// PEM encode private key
-	privDER, err := x509.MarshalECPrivateKey(kp.Priv)
+	privBlock := &pem.Block{}
+	switch priv := kp.Priv.(type) {
+	case *ecdsa.PrivateKey:
+		privBlock.Type = "EC PRIVATE KEY"
+		privBlock.Bytes, err = x509.MarshalECPrivateKey(priv)
+	case *rsa.PrivateKey:
+		privBlock.Type = "RSA PRIVATE KEY"
+		privBlock.Bytes = x509.MarshalPKCS1PrivateKey(priv)
+	default:
+		privBlock.Type = "PRIVATE KEY"
+		privBlock.Bytes, err = x509.MarshalPKCS8PrivateKey(priv)
+	}
 	if err != nil {
 		return
 	}
-	privBlock := &pem.Block{
-		Type:  "EC PRIVATE KEY",
-		Bytes: privDER,
-	}
 	privPEM = pem.EncodeToMemory(privBlock)
 
 	// PEM encode cert
@@ -56,8 +64,8 @@ func (kp *KeyPair) ToPEM() (certPEM []byte, privPEM []byte, err error) {
 	return
 }
 
-// GenerateCA generates a self-signed CA cert/key pair that expires in expiresIn days
-func GenerateCA(notAfter time.Time, organization string) (*KeyPair, error) {
+// GenerateCA generates a self-signed CA cert/key pair that expires at notAfter, with a key of the given algorithm
+func GenerateCA(notAfter time.Time, organization string, keyAlgorithm KeyAlgorithm) (*KeyPair, error) {
 	notBefore := time.Now()
 	if notAfter.Before(notBefore) {
 		return nil, fmt.Errorf("invalid notAfter: %s before %s", notAfter.String(), notBefore.String())
@@ -81,12 +89,12 @@ func GenerateCA(notAfter time.Time, organization string) (*KeyPair, error) {
 		BasicConstraintsValid: true,
 	}
 
-	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	privateKey, err := GenerateKey(keyAlgorithm)
 	if err != nil {
 		return nil, err
 	}
 
-	publicKey := &privateKey.PublicKey
+	publicKey := privateKey.Public()
 	certRaw, err := x509.CreateCertificate(rand.Reader, caDetails, caDetails, publicKey, privateKey)
 	if err != nil {
 		return nil, err
@@ -105,8 +113,8 @@ func GenerateCA(notAfter time.Time, organization string) (*KeyPair, error) {
 	return ca, nil
 }
 
-// CreateSignedServingPair creates a serving cert/key pair signed by the given ca
-func CreateSignedServingPair(notAfter time.Time, organization string, ca *KeyPair, hosts []string) (*KeyPair, error) {
+// CreateSignedServingPair creates a serving cert/key pair signed by the given ca, with a key of the given algorithm
+func CreateSignedServingPair(notAfter time.Time, organization string, ca *KeyPair, hosts []string, keyAlgorithm KeyAlgorithm) (*KeyPair, error) {
 	notBefore := time.Now()
 	if notAfter.Before(notBefore) {
 		return nil, fmt.Errorf("invalid notAfter: %s before %s", notAfter.String(), notBefore.String())
@@ -130,12 +138,12 @@ func CreateSignedServingPair(notAfter time.Time, organization string, ca *KeyPai
 		DNSNames:              hosts,
 	}
 
-	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	privateKey, err := GenerateKey(keyAlgorithm)
 	if err != nil {
 		return nil, err
 	}
 
-	publicKey := &privateKey.PublicKey
+	publicKey := privateKey.Public()
 	certRaw, err := x509.CreateCertificate(rand.Reader, certDetails, ca.Cert, publicKey, ca.Priv)
 	if err != nil {
 		return nil, err
@@ -169,6 +177,32 @@ func PEMToCert(certPEM []byte) (*x509.Certificate, error) {
 	return cert, nil
 }
 
+// PEMToPrivateKey converts the PEM block of the given byte array to a private
+// key. PKCS #1, SEC 1 and PKCS #8 encoded keys are supported.
+func PEMToPrivateKey(privPEM []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(privPEM)
+	if block == nil {
+		return nil, fmt.Errorf("private key PEM empty")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
 // VerifyCert checks that the given cert is signed and trusted by the given CA
 func VerifyCert(ca, cert *x509.Certificate, host string) error {
 	roots := x509.NewCertPool()
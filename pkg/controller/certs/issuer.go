@@ -0,0 +1,159 @@
+package certs
+
+import (
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Issuer issues the serving certificates of the Services that front the
+// APIServices and webhooks of operators.
+type Issuer interface {
+	// CA returns the CA that signs the certificates issued until notAfter, or
+	// nil if the issuer does not sign certificates itself.
+	CA(notAfter time.Time, organization string) (*KeyPair, error)
+
+	// Issue returns a serving certificate for the given hosts that is valid
+	// until notAfter at most, along with the PEM encoded certificate of the CA
+	// that clients should trust. ca is the CA returned by CA. object names the
+	// Secret the certificate is stored in, and carries the owner references of
+	// any resources the issuer creates to issue it.
+	Issue(object metav1.ObjectMeta, notAfter time.Time, organization string, ca *KeyPair, hosts []string) (*KeyPair, []byte, error)
+}
+
+// PendingError is returned by Issue when the issuer has requested a
+// certificate that isn't issued yet. Issue should be called again later.
+type PendingError struct {
+	Message string
+}
+
+func (e PendingError) Error() string {
+	return e.Message
+}
+
+// IsPending reports whether err is a PendingError.
+func IsPending(err error) bool {
+	_, ok := err.(PendingError)
+	return ok
+}
+
+// IssuerType is the type of an Issuer.
+type IssuerType string
+
+const (
+	// SelfSignedIssuerType issuers generate a new CA for every install.
+	SelfSignedIssuerType IssuerType = "self-signed"
+	// CASecretIssuerType issuers sign certificates with a CA key pair held in
+	// a Secret.
+	CASecretIssuerType IssuerType = "ca-secret"
+	// CertManagerIssuerType issuers delegate to cert-manager Certificates.
+	CertManagerIssuerType IssuerType = "cert-manager"
+)
+
+// IssuerConfig selects an Issuer.
+type IssuerConfig struct {
+	Type IssuerType
+
+	// Kind is the kind of the cert-manager issuer of cert-manager issuers:
+	// Issuer or ClusterIssuer.
+	Kind string
+
+	// Namespace and Name name the CA Secret of ca-secret issuers, or the
+	// cert-manager issuer of cert-manager issuers. An empty Namespace stands
+	// for the namespace of the operator.
+	Namespace string
+	Name      string
+}
+
+// ParseIssuerConfig parses an IssuerConfig of one of the forms
+//
+//	self-signed
+//	ca-secret:[<namespace>/]<name>
+//	cert-manager:Issuer/<name>
+//	cert-manager:ClusterIssuer/<name>
+//
+// An empty value selects a self-signed issuer.
+func ParseIssuerConfig(value string) (IssuerConfig, error) {
+	typ, ref := value, ""
+	if i := strings.Index(value, ":"); i >= 0 {
+		typ, ref = value[:i], value[i+1:]
+	}
+
+	switch config := (IssuerConfig{Type: IssuerType(typ)}); config.Type {
+	case "", SelfSignedIssuerType:
+		if ref != "" {
+			return IssuerConfig{}, fmt.Errorf("%s issuers take no reference, got %q", SelfSignedIssuerType, ref)
+		}
+		config.Type = SelfSignedIssuerType
+		return config, nil
+	case CASecretIssuerType:
+		parts := strings.Split(ref, "/")
+		switch {
+		case len(parts) == 1 && parts[0] != "":
+			config.Name = parts[0]
+		case len(parts) == 2 && parts[0] != "" && parts[1] != "":
+			config.Namespace, config.Name = parts[0], parts[1]
+		default:
+			return IssuerConfig{}, fmt.Errorf("%s issuers must reference a Secret as [<namespace>/]<name>, got %q", CASecretIssuerType, ref)
+		}
+		return config, nil
+	case CertManagerIssuerType:
+		parts := strings.Split(ref, "/")
+		if len(parts) != 2 || parts[0] != "Issuer" && parts[0] != "ClusterIssuer" || parts[1] == "" {
+			return IssuerConfig{}, fmt.Errorf("%s issuers must reference an issuer as Issuer/<name> or ClusterIssuer/<name>, got %q", CertManagerIssuerType, ref)
+		}
+		config.Kind, config.Name = parts[0], parts[1]
+		return config, nil
+	}
+
+	return IssuerConfig{}, fmt.Errorf("unknown issuer type %q", typ)
+}
+
+func (c IssuerConfig) String() string {
+	switch c.Type {
+	case CASecretIssuerType:
+		if c.Namespace != "" {
+			return fmt.Sprintf("%s:%s/%s", c.Type, c.Namespace, c.Name)
+		}
+		return fmt.Sprintf("%s:%s", c.Type, c.Name)
+	case CertManagerIssuerType:
+		return fmt.Sprintf("%s:%s/%s", c.Type, c.Kind, c.Name)
+	}
+	return string(SelfSignedIssuerType)
+}
+
+// NewSelfSignedIssuer returns an Issuer that generates a new self-signed CA for
+// every install, and serving certificates signed by it with the given
+// generator. Both have keys of the given algorithm.
+func NewSelfSignedIssuer(generator CertGenerator, keyAlgorithm KeyAlgorithm) Issuer {
+	return &selfSignedIssuer{generator: generator, keyAlgorithm: keyAlgorithm}
+}
+
+type selfSignedIssuer struct {
+	generator    CertGenerator
+	keyAlgorithm KeyAlgorithm
+}
+
+func (s *selfSignedIssuer) CA(notAfter time.Time, organization string) (*KeyPair, error) {
+	return GenerateCA(notAfter, organization, s.keyAlgorithm)
+}
+
+func (s *selfSignedIssuer) Issue(object metav1.ObjectMeta, notAfter time.Time, organization string, ca *KeyPair, hosts []string) (*KeyPair, []byte, error) {
+	return issueSigned(s.generator, notAfter, organization, ca, hosts, s.keyAlgorithm)
+}
+
+// issueSigned generates a serving certificate signed by the given CA.
+func issueSigned(generator CertGenerator, notAfter time.Time, organization string, ca *KeyPair, hosts []string, keyAlgorithm KeyAlgorithm) (*KeyPair, []byte, error) {
+	if ca == nil {
+		return nil, nil, fmt.Errorf("no CA to sign certificates with")
+	}
+	servingPair, err := generator.Generate(notAfter, organization, ca, hosts, keyAlgorithm)
+	if err != nil {
+		return nil, nil, err
+	}
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Cert.Raw})
+	return servingPair, caPEM, nil
+}
@@ -0,0 +1,189 @@
+package certs
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseIssuerConfig(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    IssuerConfig
+		wantErr bool
+	}{
+		{value: "", want: IssuerConfig{Type: SelfSignedIssuerType}},
+		{value: "self-signed", want: IssuerConfig{Type: SelfSignedIssuerType}},
+		{value: "self-signed:ca", wantErr: true},
+		{value: "ca-secret:ca", want: IssuerConfig{Type: CASecretIssuerType, Name: "ca"}},
+		{value: "ca-secret:pki/ca", want: IssuerConfig{Type: CASecretIssuerType, Namespace: "pki", Name: "ca"}},
+		{value: "ca-secret:", wantErr: true},
+		{value: "ca-secret:pki/", wantErr: true},
+		{value: "cert-manager:ClusterIssuer/internal", want: IssuerConfig{Type: CertManagerIssuerType, Kind: "ClusterIssuer", Name: "internal"}},
+		{value: "cert-manager:Issuer/internal", want: IssuerConfig{Type: CertManagerIssuerType, Kind: "Issuer", Name: "internal"}},
+		{value: "cert-manager:Certificate/internal", wantErr: true},
+		{value: "cert-manager:internal", wantErr: true},
+		{value: "vault:internal", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseIssuerConfig(tt.value)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// rsaCA returns a self-signed CA with an RSA key, PEM encoded.
+func rsaCA(t *testing.T, notAfter time.Time) (certPEM, keyPEM []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "internal-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
+
+func TestCASecretIssuer(t *testing.T) {
+	caNotAfter := time.Now().Add(30 * 24 * time.Hour).Truncate(time.Second)
+	certPEM, keyPEM := rsaCA(t, caNotAfter)
+	client := k8sfake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "ca", Namespace: "pki"},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{corev1.TLSCertKey: certPEM, corev1.TLSPrivateKeyKey: keyPEM},
+	})
+
+	issuer := NewCASecretIssuer(client, "pki", "ca", CertGeneratorFunc(CreateSignedServingPair), DefaultKeyAlgorithm)
+	notAfter := time.Now().Add(365 * 24 * time.Hour)
+	ca, err := issuer.CA(notAfter, "org")
+	require.NoError(t, err)
+
+	hosts := []string{"svc.ns", "svc.ns.svc"}
+	servingPair, caPEM, err := issuer.Issue(metav1.ObjectMeta{Name: "svc-cert", Namespace: "ns"}, notAfter, "org", ca, hosts)
+	require.NoError(t, err)
+	require.Equal(t, certPEM, caPEM)
+	// The serving certificate does not outlive the CA.
+	require.Equal(t, caNotAfter.UTC(), servingPair.Cert.NotAfter.UTC())
+	for _, host := range hosts {
+		require.NoError(t, VerifyCert(ca.Cert, servingPair.Cert, host))
+	}
+
+	// The issued key pair is stored by OLM, so it must encode.
+	_, privPEM, err := servingPair.ToPEM()
+	require.NoError(t, err)
+	_, err = PEMToPrivateKey(privPEM)
+	require.NoError(t, err)
+
+	_, err = NewCASecretIssuer(client, "pki", "missing", CertGeneratorFunc(CreateSignedServingPair), DefaultKeyAlgorithm).CA(notAfter, "org")
+	require.Error(t, err)
+}
+
+func TestCertManagerIssuer(t *testing.T) {
+	object := metav1.ObjectMeta{
+		Name:            "svc-cert",
+		Namespace:       "ns",
+		OwnerReferences: []metav1.OwnerReference{{Kind: "ClusterServiceVersion", Name: "csv"}},
+	}
+	hosts := []string{"svc.ns", "svc.ns.svc"}
+
+	// The certificate cert-manager issued, signed by the issuer's CA.
+	caPEM, caKeyPEM := rsaCA(t, time.Now().Add(365*24*time.Hour))
+	caCert, err := PEMToCert(caPEM)
+	require.NoError(t, err)
+	caKey, err := PEMToPrivateKey(caKeyPEM)
+	require.NoError(t, err)
+	issued, err := CreateSignedServingPair(time.Now().Add(24*time.Hour), "org", &KeyPair{Cert: caCert, Priv: caKey}, hosts, DefaultKeyAlgorithm)
+	require.NoError(t, err)
+	issuedCertPEM, issuedKeyPEM, err := issued.ToPEM()
+	require.NoError(t, err)
+	issuedSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "svc-cert-issued", Namespace: "ns"},
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       issuedCertPEM,
+			corev1.TLSPrivateKeyKey: issuedKeyPEM,
+			certManagerCAKey:        caPEM,
+		},
+	}
+
+	t.Run("Issued", func(t *testing.T) {
+		dynamicClient := fakedynamic.NewSimpleDynamicClient(runtime.NewScheme())
+		issuer := NewCertManagerIssuer(k8sfake.NewSimpleClientset(issuedSecret), dynamicClient, "ClusterIssuer", "internal", RSA2048KeyAlgorithm)
+
+		ca, err := issuer.CA(time.Now().Add(time.Hour), "org")
+		require.NoError(t, err)
+		require.Nil(t, ca)
+
+		servingPair, gotCAPEM, err := issuer.Issue(object, time.Now().Add(24*time.Hour), "org", ca, hosts)
+		require.NoError(t, err)
+		require.Equal(t, caPEM, gotCAPEM)
+		require.Equal(t, issued.Cert.Raw, servingPair.Cert.Raw)
+
+		certificate, err := dynamicClient.Resource(CertificateGVR).Namespace("ns").Get(context.TODO(), "svc-cert", metav1.GetOptions{})
+		require.NoError(t, err)
+		require.Equal(t, object.OwnerReferences, certificate.GetOwnerReferences())
+		secretName, _, _ := unstructured.NestedString(certificate.Object, "spec", "secretName")
+		require.Equal(t, "svc-cert-issued", secretName)
+		dnsNames, _, _ := unstructured.NestedStringSlice(certificate.Object, "spec", "dnsNames")
+		require.Equal(t, hosts, dnsNames)
+		issuerRef, _, _ := unstructured.NestedStringMap(certificate.Object, "spec", "issuerRef")
+		require.Equal(t, map[string]string{"group": "cert-manager.io", "kind": "ClusterIssuer", "name": "internal"}, issuerRef)
+		privateKey, _, _ := unstructured.NestedMap(certificate.Object, "spec", "privateKey")
+		require.Equal(t, map[string]interface{}{"algorithm": "RSA", "size": int64(2048)}, privateKey)
+	})
+
+	t.Run("NotIssued", func(t *testing.T) {
+		dynamicClient := fakedynamic.NewSimpleDynamicClient(runtime.NewScheme())
+		issuer := NewCertManagerIssuer(k8sfake.NewSimpleClientset(), dynamicClient, "Issuer", "internal", DefaultKeyAlgorithm)
+
+		_, _, err := issuer.Issue(object, time.Now().Add(24*time.Hour), "org", nil, hosts)
+		require.True(t, IsPending(err), "expected pending, got %v", err)
+
+		// The reason cert-manager reports is passed on.
+		certificate, err := dynamicClient.Resource(CertificateGVR).Namespace("ns").Get(context.TODO(), "svc-cert", metav1.GetOptions{})
+		require.NoError(t, err)
+		require.NoError(t, unstructured.SetNestedSlice(certificate.Object, []interface{}{
+			map[string]interface{}{"type": "Ready", "status": "False", "message": "Issuing certificate as Secret does not exist"},
+		}, "status", "conditions"))
+		_, err = dynamicClient.Resource(CertificateGVR).Namespace("ns").Update(context.TODO(), certificate, metav1.UpdateOptions{})
+		require.NoError(t, err)
+
+		_, _, err = issuer.Issue(object, time.Now().Add(24*time.Hour), "org", nil, hosts)
+		require.True(t, IsPending(err), "expected pending, got %v", err)
+		require.Contains(t, err.Error(), "certificate not ready: Issuing certificate as Secret does not exist")
+	})
+
+	t.Run("WrongHosts", func(t *testing.T) {
+		dynamicClient := fakedynamic.NewSimpleDynamicClient(runtime.NewScheme())
+		issuer := NewCertManagerIssuer(k8sfake.NewSimpleClientset(issuedSecret), dynamicClient, "Issuer", "internal", DefaultKeyAlgorithm)
+
+		_, _, err := issuer.Issue(object, time.Now().Add(24*time.Hour), "org", nil, []string{"other.ns", "other.ns.svc"})
+		require.True(t, IsPending(err), "expected pending, got %v", err)
+	})
+}
@@ -0,0 +1,48 @@
+package certs
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+)
+
+// KeyAlgorithm is the algorithm and size of the private keys of generated
+// certificates.
+type KeyAlgorithm string
+
+const (
+	ECDSAP256KeyAlgorithm KeyAlgorithm = "ecdsa-p256"
+	RSA2048KeyAlgorithm   KeyAlgorithm = "rsa-2048"
+	RSA4096KeyAlgorithm   KeyAlgorithm = "rsa-4096"
+
+	// DefaultKeyAlgorithm is the key algorithm used unless configured otherwise.
+	DefaultKeyAlgorithm = ECDSAP256KeyAlgorithm
+)
+
+// ParseKeyAlgorithm parses one of the supported key algorithms. An empty value
+// selects the DefaultKeyAlgorithm.
+func ParseKeyAlgorithm(value string) (KeyAlgorithm, error) {
+	switch alg := KeyAlgorithm(value); alg {
+	case "":
+		return DefaultKeyAlgorithm, nil
+	case ECDSAP256KeyAlgorithm, RSA2048KeyAlgorithm, RSA4096KeyAlgorithm:
+		return alg, nil
+	}
+	return "", fmt.Errorf("unknown key algorithm %q, must be one of %s, %s or %s", value, ECDSAP256KeyAlgorithm, RSA2048KeyAlgorithm, RSA4096KeyAlgorithm)
+}
+
+// GenerateKey generates a private key with the given algorithm.
+func GenerateKey(keyAlgorithm KeyAlgorithm) (crypto.Signer, error) {
+	switch keyAlgorithm {
+	case "", ECDSAP256KeyAlgorithm:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case RSA2048KeyAlgorithm:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case RSA4096KeyAlgorithm:
+		return rsa.GenerateKey(rand.Reader, 4096)
+	}
+	return nil, fmt.Errorf("unknown key algorithm %q", keyAlgorithm)
+}
@@ -0,0 +1,77 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyAlgorithms(t *testing.T) {
+	tests := []struct {
+		value     string
+		checkKey  func(t *testing.T, key interface{})
+		wantError bool
+	}{
+		{
+			value: "",
+			checkKey: func(t *testing.T, key interface{}) {
+				require.IsType(t, &ecdsa.PublicKey{}, key)
+				require.Equal(t, elliptic.P256(), key.(*ecdsa.PublicKey).Curve)
+			},
+		},
+		{
+			value: "ecdsa-p256",
+			checkKey: func(t *testing.T, key interface{}) {
+				require.IsType(t, &ecdsa.PublicKey{}, key)
+				require.Equal(t, elliptic.P256(), key.(*ecdsa.PublicKey).Curve)
+			},
+		},
+		{
+			value: "rsa-2048",
+			checkKey: func(t *testing.T, key interface{}) {
+				require.IsType(t, &rsa.PublicKey{}, key)
+				require.Equal(t, 2048, key.(*rsa.PublicKey).N.BitLen())
+			},
+		},
+		{
+			value: "rsa-4096",
+			checkKey: func(t *testing.T, key interface{}) {
+				require.IsType(t, &rsa.PublicKey{}, key)
+				require.Equal(t, 4096, key.(*rsa.PublicKey).N.BitLen())
+			},
+		},
+		{value: "rsa-1024", wantError: true},
+		{value: "ed25519", wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			keyAlgorithm, err := ParseKeyAlgorithm(tt.value)
+			if tt.wantError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			notAfter := time.Now().Add(time.Hour)
+			ca, err := GenerateCA(notAfter, "org", keyAlgorithm)
+			require.NoError(t, err)
+			tt.checkKey(t, ca.Cert.PublicKey)
+
+			servingPair, err := CreateSignedServingPair(notAfter, "org", ca, []string{"svc.ns.svc"}, keyAlgorithm)
+			require.NoError(t, err)
+			tt.checkKey(t, servingPair.Cert.PublicKey)
+			require.NoError(t, VerifyCert(ca.Cert, servingPair.Cert, "svc.ns.svc"))
+
+			// Keys round-trip through the PEM stored in Secrets.
+			_, privPEM, err := servingPair.ToPEM()
+			require.NoError(t, err)
+			priv, err := PEMToPrivateKey(privPEM)
+			require.NoError(t, err)
+			tt.checkKey(t, priv.Public())
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package install
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/certs"
+)
+
+const (
+	// CertValidForAnnotationKey is the CSV annotation that overrides how long
+	// the CA of its serving certificates is valid for, as a duration such as 720h.
+	CertValidForAnnotationKey = "operatorframework.io/cert-valid-for"
+	// CertMinFreshAnnotationKey is the CSV annotation that overrides how long
+	// before the expiry of its CA the serving certificates are rotated.
+	CertMinFreshAnnotationKey = "operatorframework.io/cert-min-fresh"
+	// CertKeyAlgorithmAnnotationKey is the CSV annotation that overrides the
+	// algorithm of the keys of its serving certificates.
+	CertKeyAlgorithmAnnotationKey = "operatorframework.io/cert-key-algorithm"
+
+	// MinCertValidFor is the shortest lifetime of a CA, the shortest duration
+	// cert-manager issues certificates for.
+	MinCertValidFor = time.Hour
+	// MinCertRotationInterval is the shortest time between rotations of the
+	// serving certificates, ValidFor - MinFresh. Shorter intervals would have
+	// certificates rotated on nearly every sync of their CSV.
+	MinCertRotationInterval = 30 * time.Minute
+)
+
+// CertConfig configures the serving certificates OLM generates for the
+// APIServices and webhooks of operators.
+type CertConfig struct {
+	// ValidFor is how long the CA is valid for.
+	ValidFor time.Duration
+	// MinFresh is how long before the expiry of the CA the certificates are
+	// rotated. Serving certificates are valid as long as the CA, and are
+	// rotated MinFresh before they expire if that is sooner.
+	MinFresh time.Duration
+	// KeyAlgorithm is the algorithm of the generated keys.
+	KeyAlgorithm certs.KeyAlgorithm
+}
+
+// DefaultCertConfig returns the CertConfig used unless configured otherwise.
+func DefaultCertConfig() CertConfig {
+	return CertConfig{
+		ValidFor:     DefaultCertValidFor,
+		MinFresh:     DefaultCertMinFresh,
+		KeyAlgorithm: certs.DefaultKeyAlgorithm,
+	}
+}
+
+// Validate returns an error if the config can't be used to generate certificates.
+func (c CertConfig) Validate() error {
+	if c.ValidFor < MinCertValidFor {
+		return fmt.Errorf("certificate lifetime %s must be at least %s", c.ValidFor, MinCertValidFor)
+	}
+	if c.MinFresh < 0 {
+		return fmt.Errorf("certificate rotation window %s must not be negative", c.MinFresh)
+	}
+	if c.ValidFor-c.MinFresh < MinCertRotationInterval {
+		return fmt.Errorf("certificate rotation window %s must be at least %s shorter than the certificate lifetime %s", c.MinFresh, MinCertRotationInterval, c.ValidFor)
+	}
+	if _, err := certs.ParseKeyAlgorithm(string(c.KeyAlgorithm)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// RotateAt returns when certificates issued at the given time are rotated.
+func (c CertConfig) RotateAt(issuedAt time.Time) time.Time {
+	return issuedAt.Add(c.ValidFor).Add(-1 * c.MinFresh)
+}
+
+// RotateAtFor returns when certificates issued at the given time are rotated,
+// given that the first of them expires at notAfter: at RotateAt, or MinFresh
+// before notAfter if that is sooner, e.g. because the CA signing them expires
+// first or their issuer shortened their lifetime. They aren't rotated within
+// MinCertRotationInterval of being issued, since certificates issued again
+// wouldn't last any longer.
+func (c CertConfig) RotateAtFor(issuedAt, notAfter time.Time) time.Time {
+	rotateAt := c.RotateAt(issuedAt)
+	if limit := notAfter.Add(-1 * c.MinFresh); limit.Before(rotateAt) {
+		rotateAt = limit
+	}
+	if earliest := issuedAt.Add(MinCertRotationInterval); rotateAt.Before(earliest) {
+		rotateAt = earliest
+	}
+	return rotateAt
+}
+
+// CertRotator is implemented by StrategyInstallers that issue serving
+// certificates.
+type CertRotator interface {
+	// CertsRotateAt returns when the serving certificates issued or reused by
+	// the last Install are rotated, or zero if there were none.
+	CertsRotateAt() time.Time
+}
+
+// withDefaults returns the config with its unset fields set to their defaults.
+func (c CertConfig) withDefaults() CertConfig {
+	defaults := DefaultCertConfig()
+	if c.ValidFor == 0 {
+		c.ValidFor = defaults.ValidFor
+	}
+	if c.MinFresh == 0 {
+		c.MinFresh = defaults.MinFresh
+	}
+	if c.KeyAlgorithm == "" {
+		c.KeyAlgorithm = defaults.KeyAlgorithm
+	}
+	return c
+}
+
+// CertConfigFor returns the CertConfig of the given object: the given defaults,
+// overridden by any cert annotations of the object.
+func CertConfigFor(obj metav1.Object, defaults CertConfig) (CertConfig, error) {
+	config := defaults.withDefaults()
+	annotations := obj.GetAnnotations()
+
+	if value, ok := annotations[CertValidForAnnotationKey]; ok {
+		validFor, err := time.ParseDuration(value)
+		if err != nil {
+			return CertConfig{}, fmt.Errorf("invalid %s annotation: %v", CertValidForAnnotationKey, err)
+		}
+		config.ValidFor = validFor
+	}
+	if value, ok := annotations[CertMinFreshAnnotationKey]; ok {
+		minFresh, err := time.ParseDuration(value)
+		if err != nil {
+			return CertConfig{}, fmt.Errorf("invalid %s annotation: %v", CertMinFreshAnnotationKey, err)
+		}
+		config.MinFresh = minFresh
+	}
+	if value, ok := annotations[CertKeyAlgorithmAnnotationKey]; ok {
+		keyAlgorithm, err := certs.ParseKeyAlgorithm(value)
+		if err != nil {
+			return CertConfig{}, fmt.Errorf("invalid %s annotation: %v", CertKeyAlgorithmAnnotationKey, err)
+		}
+		config.KeyAlgorithm = keyAlgorithm
+	}
+
+	if err := config.Validate(); err != nil {
+		return CertConfig{}, fmt.Errorf("invalid certificate config: %v", err)
+	}
+	return config, nil
+}
+
+// ShouldRotateCerts returns true if the serving certificates of the given CSV
+// are due for rotation: either the rotation time recorded in its status has
+// passed, or the given config would have them rotated already.
+func ShouldRotateCerts(csv *v1alpha1.ClusterServiceVersion, config CertConfig) bool {
+	now := metav1.Now()
+	if !csv.Status.CertsRotateAt.IsZero() && csv.Status.CertsRotateAt.Before(&now) {
+		return true
+	}
+
+	// A shortened lifetime or a widened rotation window takes effect for
+	// certificates issued before the config changed.
+	if !csv.Status.CertsLastUpdated.IsZero() {
+		rotateAt := config.withDefaults().RotateAt(csv.Status.CertsLastUpdated.Time)
+		if rotateAt.Before(now.Time) {
+			return true
+		}
+	}
+
+	return false
+}
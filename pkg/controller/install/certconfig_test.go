@@ -0,0 +1,147 @@
+package install
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/certs"
+)
+
+func TestCertConfigFor(t *testing.T) {
+	defaults := CertConfig{ValidFor: 30 * 24 * time.Hour, MinFresh: 7 * 24 * time.Hour, KeyAlgorithm: certs.RSA2048KeyAlgorithm}
+
+	tests := []struct {
+		name        string
+		defaults    CertConfig
+		annotations map[string]string
+		want        CertConfig
+		wantErr     bool
+	}{
+		{
+			name: "Unset",
+			want: DefaultCertConfig(),
+		},
+		{
+			name:     "Defaults",
+			defaults: defaults,
+			want:     defaults,
+		},
+		{
+			name:     "Overrides",
+			defaults: defaults,
+			annotations: map[string]string{
+				CertValidForAnnotationKey:     "48h",
+				CertMinFreshAnnotationKey:     "12h",
+				CertKeyAlgorithmAnnotationKey: "rsa-4096",
+			},
+			want: CertConfig{ValidFor: 48 * time.Hour, MinFresh: 12 * time.Hour, KeyAlgorithm: certs.RSA4096KeyAlgorithm},
+		},
+		{
+			name:        "PartialOverride",
+			defaults:    defaults,
+			annotations: map[string]string{CertKeyAlgorithmAnnotationKey: "ecdsa-p256"},
+			want:        CertConfig{ValidFor: defaults.ValidFor, MinFresh: defaults.MinFresh, KeyAlgorithm: certs.ECDSAP256KeyAlgorithm},
+		},
+		{
+			name:        "InvalidDuration",
+			annotations: map[string]string{CertValidForAnnotationKey: "30d"},
+			wantErr:     true,
+		},
+		{
+			name:        "InvalidKeyAlgorithm",
+			annotations: map[string]string{CertKeyAlgorithmAnnotationKey: "dsa"},
+			wantErr:     true,
+		},
+		{
+			name:        "ValidForTooShort",
+			annotations: map[string]string{CertValidForAnnotationKey: "30m", CertMinFreshAnnotationKey: "0s"},
+			wantErr:     true,
+		},
+		{
+			name:        "RotationIntervalTooShort",
+			annotations: map[string]string{CertValidForAnnotationKey: "2h", CertMinFreshAnnotationKey: "100m"},
+			wantErr:     true,
+		},
+		{
+			name:        "NegativeMinFresh",
+			annotations: map[string]string{CertMinFreshAnnotationKey: "-1h"},
+			wantErr:     true,
+		},
+		{
+			name:        "ShortestRotationInterval",
+			annotations: map[string]string{CertValidForAnnotationKey: "2h", CertMinFreshAnnotationKey: "90m"},
+			want:        CertConfig{ValidFor: 2 * time.Hour, MinFresh: 90 * time.Minute, KeyAlgorithm: certs.DefaultKeyAlgorithm},
+		},
+		{
+			name:        "MinFreshNotShorterThanValidFor",
+			defaults:    defaults,
+			annotations: map[string]string{CertMinFreshAnnotationKey: "720h"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			csv := &v1alpha1.ClusterServiceVersion{ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations}}
+			got, err := CertConfigFor(csv, tt.defaults)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestShouldRotateCerts(t *testing.T) {
+	timePtr := func(t time.Time) *metav1.Time {
+		mt := metav1.NewTime(t)
+		return &mt
+	}
+	now := time.Now()
+	config := CertConfig{ValidFor: 30 * 24 * time.Hour, MinFresh: 7 * 24 * time.Hour}
+
+	tests := []struct {
+		name        string
+		lastUpdated *metav1.Time
+		rotateAt    *metav1.Time
+		want        bool
+	}{
+		{
+			name: "NoCerts",
+		},
+		{
+			name:        "Fresh",
+			lastUpdated: timePtr(now.Add(-time.Hour)),
+			rotateAt:    timePtr(now.Add(time.Hour)),
+		},
+		{
+			name:        "RotateAtPassed",
+			lastUpdated: timePtr(now.Add(-time.Hour)),
+			rotateAt:    timePtr(now.Add(-time.Minute)),
+			want:        true,
+		},
+		{
+			// Issued under a two year lifetime, before the config shortened it.
+			name:        "LifetimeShortened",
+			lastUpdated: timePtr(now.Add(-24 * 24 * time.Hour)),
+			rotateAt:    timePtr(now.Add(365 * 24 * time.Hour)),
+			want:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			csv := &v1alpha1.ClusterServiceVersion{
+				Status: v1alpha1.ClusterServiceVersionStatus{
+					CertsLastUpdated: tt.lastUpdated,
+					CertsRotateAt:    tt.rotateAt,
+				},
+			}
+			require.Equal(t, tt.want, ShouldRotateCerts(csv, config))
+		})
+	}
+}
@@ -147,6 +147,21 @@ func (i *webhookDescriptionWithCAPEM) getServicePort() corev1.ServicePort {
 	}
 }
 
+// certIssuer returns the issuer of the serving certificates of the owner's
+// Services, which defaults to a self-signed one.
+func (i *StrategyDeploymentInstaller) certIssuer(keyAlgorithm certs.KeyAlgorithm) (certs.Issuer, error) {
+	if i.certIssuerBuilder == nil {
+		return certs.NewSelfSignedIssuer(certGenerator, keyAlgorithm), nil
+	}
+	return i.certIssuerBuilder(i.owner, keyAlgorithm)
+}
+
+// certConfig returns the config of the serving certificates of the owner's
+// Services.
+func (i *StrategyDeploymentInstaller) certConfig() (CertConfig, error) {
+	return CertConfigFor(i.owner, i.certConfigDefaults)
+}
+
 func SecretName(serviceName string) string {
 	return serviceName + "-cert"
 }
@@ -184,15 +199,26 @@ func (i *StrategyDeploymentInstaller) installCertRequirements(strategy Strategy)
 		return nil, fmt.Errorf("unsupported InstallStrategy type")
 	}
 
+	certConfig, err := i.certConfig()
+	if err != nil {
+		return nil, err
+	}
+	issuer, err := i.certIssuer(certConfig.KeyAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create the CA
-	expiration := time.Now().Add(DefaultCertValidFor)
-	ca, err := certs.GenerateCA(expiration, Organization)
+	now := time.Now()
+	expiration := now.Add(certConfig.ValidFor)
+	ca, err := issuer.CA(expiration, Organization)
 	if err != nil {
 		logger.Debug("failed to generate CA")
 		return nil, err
 	}
-	rotateAt := expiration.Add(-1 * DefaultCertMinFresh)
 
+	i.certsRotateAt = time.Time{}
+	var notAfter time.Time
 	for n, sddSpec := range strategyDetailsDeployment.DeploymentSpecs {
 		certResources := i.certResourcesForDeployment(sddSpec.Name)
 
@@ -202,28 +228,35 @@ func (i *StrategyDeploymentInstaller) installCertRequirements(strategy Strategy)
 		}
 
 		// Update the deployment for each certResource
-		newDepSpec, caPEM, err := i.installCertRequirementsForDeployment(sddSpec.Name, ca, rotateAt, sddSpec.Spec, getServicePorts(certResources))
+		newDepSpec, caPEM, certNotAfter, err := i.installCertRequirementsForDeployment(sddSpec.Name, issuer, ca, expiration, sddSpec.Spec, getServicePorts(certResources))
 		if err != nil {
 			return nil, err
 		}
+		if notAfter.IsZero() || certNotAfter.Before(notAfter) {
+			notAfter = certNotAfter
+		}
 
 		i.updateCertResourcesForDeployment(sddSpec.Name, caPEM)
 
 		strategyDetailsDeployment.DeploymentSpecs[n].Spec = *newDepSpec
 	}
+	if !notAfter.IsZero() {
+		i.certsRotateAt = certConfig.RotateAtFor(now, notAfter)
+	}
 	return strategyDetailsDeployment, nil
 }
 
-func ShouldRotateCerts(csv *v1alpha1.ClusterServiceVersion) bool {
-	now := metav1.Now()
-	if !csv.Status.CertsRotateAt.IsZero() && csv.Status.CertsRotateAt.Before(&now) {
-		return true
-	}
-
-	return false
+// CertsRotateAt returns when the serving certificates issued or reused by the
+// last Install are rotated, or zero if there were none.
+func (i *StrategyDeploymentInstaller) CertsRotateAt() time.Time {
+	return i.certsRotateAt
 }
 
-func (i *StrategyDeploymentInstaller) installCertRequirementsForDeployment(deploymentName string, ca *certs.KeyPair, rotateAt time.Time, depSpec appsv1.DeploymentSpec, ports []corev1.ServicePort) (*appsv1.DeploymentSpec, []byte, error) {
+// installCertRequirementsForDeployment sets up the Service of the given
+// deployment and its serving certificate, valid until notAfter at most. It
+// returns the updated deployment spec, the PEM encoded CA clients should
+// trust, and when the serving certificate in use expires.
+func (i *StrategyDeploymentInstaller) installCertRequirementsForDeployment(deploymentName string, issuer certs.Issuer, ca *certs.KeyPair, notAfter time.Time, depSpec appsv1.DeploymentSpec, ports []corev1.ServicePort) (*appsv1.DeploymentSpec, []byte, time.Time, error) {
 	logger := log.WithFields(log.Fields{})
 
 	// Create a service for the deployment
@@ -240,14 +273,14 @@ func (i *StrategyDeploymentInstaller) installCertRequirementsForDeployment(deplo
 	existingService, err := i.strategyClient.GetOpLister().CoreV1().ServiceLister().Services(i.owner.GetNamespace()).Get(service.GetName())
 	if err == nil {
 		if !ownerutil.Adoptable(i.owner, existingService.GetOwnerReferences()) {
-			return nil, nil, fmt.Errorf("service %s not safe to replace: extraneous ownerreferences found", service.GetName())
+			return nil, nil, time.Time{}, fmt.Errorf("service %s not safe to replace: extraneous ownerreferences found", service.GetName())
 		}
 		service.SetOwnerReferences(existingService.GetOwnerReferences())
 
 		// Delete the Service to replace
 		deleteErr := i.strategyClient.GetOpClient().DeleteService(service.GetNamespace(), service.GetName(), &metav1.DeleteOptions{})
 		if err != nil && !k8serrors.IsNotFound(deleteErr) {
-			return nil, nil, fmt.Errorf("could not delete existing service %s", service.GetName())
+			return nil, nil, time.Time{}, fmt.Errorf("could not delete existing service %s", service.GetName())
 		}
 	}
 
@@ -255,7 +288,7 @@ func (i *StrategyDeploymentInstaller) installCertRequirementsForDeployment(deplo
 	_, err = i.strategyClient.GetOpClient().CreateService(service)
 	if err != nil {
 		logger.Warnf("could not create service %s", service.GetName())
-		return nil, nil, fmt.Errorf("could not create service %s: %s", service.GetName(), err.Error())
+		return nil, nil, time.Time{}, fmt.Errorf("could not create service %s: %s", service.GetName(), err.Error())
 	}
 
 	// Create signed serving cert
@@ -263,26 +296,31 @@ func (i *StrategyDeploymentInstaller) installCertRequirementsForDeployment(deplo
 		fmt.Sprintf("%s.%s", service.GetName(), i.owner.GetNamespace()),
 		fmt.Sprintf("%s.%s.svc", service.GetName(), i.owner.GetNamespace()),
 	}
-	servingPair, err := certGenerator.Generate(rotateAt, Organization, ca, hosts)
+	certMeta := metav1.ObjectMeta{
+		Name:      SecretName(service.GetName()),
+		Namespace: i.owner.GetNamespace(),
+		Labels:    map[string]string{OLMManagedLabelKey: OLMManagedLabelValue},
+	}
+	ownerutil.AddNonBlockingOwner(&certMeta, i.owner)
+	servingPair, caPEM, err := issuer.Issue(certMeta, notAfter, Organization, ca, hosts)
+	if certs.IsPending(err) {
+		return nil, nil, time.Time{}, StrategyError{Reason: StrategyErrReasonWaiting, Message: err.Error()}
+	}
 	if err != nil {
 		logger.Warnf("could not generate signed certs for hosts %v", hosts)
-		return nil, nil, err
+		return nil, nil, time.Time{}, err
 	}
 
 	// Create Secret for serving cert
 	certPEM, privPEM, err := servingPair.ToPEM()
 	if err != nil {
 		logger.Warnf("unable to convert serving certificate and private key to PEM format for Service %s", service.GetName())
-		return nil, nil, err
+		return nil, nil, time.Time{}, err
 	}
 
 	// Add olmcahash as a label to the caPEM
-	caPEM, _, err := ca.ToPEM()
-	if err != nil {
-		logger.Warnf("unable to convert CA certificate to PEM format for Service %s", service)
-		return nil, nil, err
-	}
 	caHash := certs.PEMSHA256(caPEM)
+	certNotAfter := servingPair.Cert.NotAfter
 
 	secret := &corev1.Secret{
 		Data: map[string][]byte{
@@ -306,14 +344,24 @@ func (i *StrategyDeploymentInstaller) installCertRequirementsForDeployment(deplo
 
 		// Attempt an update
 		// TODO: Check that the secret was not modified
-		if existingCAPEM, ok := existingSecret.Data[OLMCAPEMKey]; ok && !ShouldRotateCerts(i.owner.(*v1alpha1.ClusterServiceVersion)) {
+		certConfig, err := i.certConfig()
+		if err != nil {
+			return nil, nil, time.Time{}, err
+		}
+		if existingCAPEM, ok := existingSecret.Data[OLMCAPEMKey]; ok && !ShouldRotateCerts(i.owner.(*v1alpha1.ClusterServiceVersion), certConfig) {
 			logger.Warnf("reusing existing cert %s", secret.GetName())
 			secret = existingSecret
 			caPEM = existingCAPEM
 			caHash = certs.PEMSHA256(caPEM)
+			if existingCert, err := certs.PEMToCert(existingSecret.Data[corev1.TLSCertKey]); err == nil {
+				certNotAfter = existingCert.NotAfter
+			} else {
+				// rotate an unreadable certificate as soon as possible
+				certNotAfter = time.Now()
+			}
 		} else if _, err := i.strategyClient.GetOpClient().UpdateSecret(secret); err != nil {
 			logger.Warnf("could not update secret %s", secret.GetName())
-			return nil, nil, err
+			return nil, nil, time.Time{}, err
 		}
 
 	} else if k8serrors.IsNotFound(err) {
@@ -322,17 +370,17 @@ func (i *StrategyDeploymentInstaller) installCertRequirementsForDeployment(deplo
 		if _, err := i.strategyClient.GetOpClient().CreateSecret(secret); err != nil {
 			if !k8serrors.IsAlreadyExists(err) {
 				log.Warnf("could not create secret %s: %v", secret.GetName(), err)
-				return nil, nil, err
+				return nil, nil, time.Time{}, err
 			}
 			// if the secret isn't in the cache but exists in the cluster, it's missing the labels for the cache filter
 			// and just needs to be updated
 			if _, err := i.strategyClient.GetOpClient().UpdateSecret(secret); err != nil {
 				log.Warnf("could not update secret %s: %v", secret.GetName(), err)
-				return nil, nil, err
+				return nil, nil, time.Time{}, err
 			}
 		}
 	} else {
-		return nil, nil, err
+		return nil, nil, time.Time{}, err
 	}
 
 	// create Role and RoleBinding to allow the deployment to mount the Secret
@@ -359,7 +407,7 @@ func (i *StrategyDeploymentInstaller) installCertRequirementsForDeployment(deplo
 		// Attempt an update
 		if _, err := i.strategyClient.GetOpClient().UpdateRole(secretRole); err != nil {
 			logger.Warnf("could not update secret role %s", secretRole.GetName())
-			return nil, nil, err
+			return nil, nil, time.Time{}, err
 		}
 	} else if k8serrors.IsNotFound(err) {
 		// Create the role
@@ -367,10 +415,10 @@ func (i *StrategyDeploymentInstaller) installCertRequirementsForDeployment(deplo
 		_, err = i.strategyClient.GetOpClient().CreateRole(secretRole)
 		if err != nil {
 			log.Warnf("could not create secret role %s", secretRole.GetName())
-			return nil, nil, err
+			return nil, nil, time.Time{}, err
 		}
 	} else {
-		return nil, nil, err
+		return nil, nil, time.Time{}, err
 	}
 
 	if depSpec.Template.Spec.ServiceAccountName == "" {
@@ -405,7 +453,7 @@ func (i *StrategyDeploymentInstaller) installCertRequirementsForDeployment(deplo
 		// Attempt an update
 		if _, err := i.strategyClient.GetOpClient().UpdateRoleBinding(secretRoleBinding); err != nil {
 			logger.Warnf("could not update secret rolebinding %s", secretRoleBinding.GetName())
-			return nil, nil, err
+			return nil, nil, time.Time{}, err
 		}
 	} else if k8serrors.IsNotFound(err) {
 		// Create the role
@@ -413,10 +461,10 @@ func (i *StrategyDeploymentInstaller) installCertRequirementsForDeployment(deplo
 		_, err = i.strategyClient.GetOpClient().CreateRoleBinding(secretRoleBinding)
 		if err != nil {
 			log.Warnf("could not create secret rolebinding with dep spec: %#v", depSpec)
-			return nil, nil, err
+			return nil, nil, time.Time{}, err
 		}
 	} else {
-		return nil, nil, err
+		return nil, nil, time.Time{}, err
 	}
 
 	// create ClusterRoleBinding to system:auth-delegator Role
@@ -443,27 +491,27 @@ func (i *StrategyDeploymentInstaller) installCertRequirementsForDeployment(deplo
 		if ownerutil.AdoptableLabels(existingAuthDelegatorClusterRoleBinding.GetLabels(), true, i.owner) {
 			logger.WithFields(log.Fields{"obj": "authDelegatorCRB", "labels": existingAuthDelegatorClusterRoleBinding.GetLabels()}).Debug("adopting")
 			if err := ownerutil.AddOwnerLabels(authDelegatorClusterRoleBinding, i.owner); err != nil {
-				return nil, nil, err
+				return nil, nil, time.Time{}, err
 			}
 		}
 
 		// Attempt an update.
 		if _, err := i.strategyClient.GetOpClient().UpdateClusterRoleBinding(authDelegatorClusterRoleBinding); err != nil {
 			logger.Warnf("could not update auth delegator clusterrolebinding %s", authDelegatorClusterRoleBinding.GetName())
-			return nil, nil, err
+			return nil, nil, time.Time{}, err
 		}
 	} else if k8serrors.IsNotFound(err) {
 		// Create the role.
 		if err := ownerutil.AddOwnerLabels(authDelegatorClusterRoleBinding, i.owner); err != nil {
-			return nil, nil, err
+			return nil, nil, time.Time{}, err
 		}
 		_, err = i.strategyClient.GetOpClient().CreateClusterRoleBinding(authDelegatorClusterRoleBinding)
 		if err != nil {
 			log.Warnf("could not create auth delegator clusterrolebinding %s", authDelegatorClusterRoleBinding.GetName())
-			return nil, nil, err
+			return nil, nil, time.Time{}, err
 		}
 	} else {
-		return nil, nil, err
+		return nil, nil, time.Time{}, err
 	}
 
 	// Create RoleBinding to extension-apiserver-authentication-reader Role in the kube-system namespace.
@@ -491,33 +539,33 @@ func (i *StrategyDeploymentInstaller) installCertRequirementsForDeployment(deplo
 		if ownerutil.AdoptableLabels(existingAuthReaderRoleBinding.GetLabels(), true, i.owner) {
 			logger.WithFields(log.Fields{"obj": "existingAuthReaderRB", "labels": existingAuthReaderRoleBinding.GetLabels()}).Debug("adopting")
 			if err := ownerutil.AddOwnerLabels(authReaderRoleBinding, i.owner); err != nil {
-				return nil, nil, err
+				return nil, nil, time.Time{}, err
 			}
 		}
 		// Attempt an update.
 		if _, err := i.strategyClient.GetOpClient().UpdateRoleBinding(authReaderRoleBinding); err != nil {
 			logger.Warnf("could not update auth reader role binding %s", authReaderRoleBinding.GetName())
-			return nil, nil, err
+			return nil, nil, time.Time{}, err
 		}
 	} else if k8serrors.IsNotFound(err) {
 		// Create the role.
 		if err := ownerutil.AddOwnerLabels(authReaderRoleBinding, i.owner); err != nil {
-			return nil, nil, err
+			return nil, nil, time.Time{}, err
 		}
 		_, err = i.strategyClient.GetOpClient().CreateRoleBinding(authReaderRoleBinding)
 		if err != nil {
 			log.Warnf("could not create auth reader role binding %s", authReaderRoleBinding.GetName())
-			return nil, nil, err
+			return nil, nil, time.Time{}, err
 		}
 	} else {
-		return nil, nil, err
+		return nil, nil, time.Time{}, err
 	}
 	AddDefaultCertVolumeAndVolumeMounts(&depSpec, secret.GetName())
 
 	// Setting the olm hash label forces a rollout and ensures that the new secret
 	// is used by the apiserver if not hot reloading.
 	SetCAAnnotation(&depSpec, caHash)
-	return &depSpec, caPEM, nil
+	return &depSpec, caPEM, certNotAfter, nil
 }
 
 func SetCAAnnotation(depSpec *appsv1.DeploymentSpec, caHash string) {
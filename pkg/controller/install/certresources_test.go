@@ -15,6 +15,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
 
 	"github.com/operator-framework/api/pkg/operators/v1alpha1"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/wrappers"
@@ -26,7 +27,7 @@ import (
 )
 
 func keyPair(t *testing.T, expiration time.Time) *certs.KeyPair {
-	p, err := certs.GenerateCA(expiration, Organization)
+	p, err := certs.GenerateCA(expiration, Organization, certs.DefaultKeyAlgorithm)
 	assert.NoError(t, err)
 	return p
 }
@@ -40,11 +41,11 @@ func selector(t *testing.T, selector string) *metav1.LabelSelector {
 var staticCerts *certs.KeyPair = nil
 
 // staticCertGenerator replaces the CertGenerator to get consistent keys for testing
-func staticCertGenerator(notAfter time.Time, organization string, ca *certs.KeyPair, hosts []string) (*certs.KeyPair, error) {
+func staticCertGenerator(notAfter time.Time, organization string, ca *certs.KeyPair, hosts []string, keyAlgorithm certs.KeyAlgorithm) (*certs.KeyPair, error) {
 	if staticCerts != nil {
 		return staticCerts, nil
 	}
-	c, err := certs.CreateSignedServingPair(notAfter, organization, ca, hosts)
+	c, err := certs.CreateSignedServingPair(notAfter, organization, ca, hosts, keyAlgorithm)
 	if err != nil {
 		return nil, err
 	}
@@ -172,7 +173,7 @@ func TestInstallCertRequirementsForDeployment(t *testing.T) {
 					fmt.Sprintf("%s.%s", service.GetName(), namespace),
 					fmt.Sprintf("%s.%s.svc", service.GetName(), namespace),
 				}
-				servingPair, err := certGenerator.Generate(args.rotateAt, Organization, args.ca, hosts)
+				servingPair, err := certGenerator.Generate(args.rotateAt, Organization, args.ca, hosts, certs.DefaultKeyAlgorithm)
 				require.NoError(t, err)
 
 				// Create Secret for serving cert
@@ -396,7 +397,7 @@ func TestInstallCertRequirementsForDeployment(t *testing.T) {
 					fmt.Sprintf("%s.%s", service.GetName(), namespace),
 					fmt.Sprintf("%s.%s.svc", service.GetName(), namespace),
 				}
-				servingPair, err := certGenerator.Generate(args.rotateAt, Organization, args.ca, hosts)
+				servingPair, err := certGenerator.Generate(args.rotateAt, Organization, args.ca, hosts, certs.DefaultKeyAlgorithm)
 				require.NoError(t, err)
 
 				// Create Secret for serving cert
@@ -613,7 +614,7 @@ func TestInstallCertRequirementsForDeployment(t *testing.T) {
 					fmt.Sprintf("%s.%s", service.GetName(), namespace),
 					fmt.Sprintf("%s.%s.svc", service.GetName(), namespace),
 				}
-				servingPair, err := certGenerator.Generate(args.rotateAt, Organization, args.ca, hosts)
+				servingPair, err := certGenerator.Generate(args.rotateAt, Organization, args.ca, hosts, certs.DefaultKeyAlgorithm)
 				require.NoError(t, err)
 
 				// Create Secret for serving cert
@@ -848,7 +849,7 @@ func TestInstallCertRequirementsForDeployment(t *testing.T) {
 				apiServiceDescriptions: tt.fields.apiServiceDescriptions,
 				webhookDescriptions:    tt.fields.webhookDescriptions,
 			}
-			got, _, err := i.installCertRequirementsForDeployment(tt.args.deploymentName, tt.args.ca, tt.args.rotateAt, tt.args.depSpec, tt.args.ports)
+			got, _, _, err := i.installCertRequirementsForDeployment(tt.args.deploymentName, certs.NewSelfSignedIssuer(certGenerator, certs.DefaultKeyAlgorithm), tt.args.ca, tt.args.rotateAt, tt.args.depSpec, tt.args.ports)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("installCertRequirementsForDeployment() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -859,3 +860,95 @@ func TestInstallCertRequirementsForDeployment(t *testing.T) {
 		})
 	}
 }
+
+func TestInstallCertRequirementsRotateAt(t *testing.T) {
+	owner := &v1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "owner", Namespace: "test-namespace", UID: "123-uid"},
+	}
+	config := CertConfig{ValidFor: 30 * 24 * time.Hour, MinFresh: 24 * time.Hour, KeyAlgorithm: certs.DefaultKeyAlgorithm}
+	notFound := errors.NewNotFound(schema.GroupResource{}, "")
+	state := fakeState{
+		getServiceError:            notFound,
+		getSecretError:             notFound,
+		getRoleError:               notFound,
+		getRoleBindingError:        notFound,
+		getClusterRoleBindingError: notFound,
+	}
+
+	tests := []struct {
+		name string
+		// caValidFor is how long the CA in the CA Secret is valid for, if the
+		// certificates are issued by a ca-secret issuer.
+		caValidFor time.Duration
+		want       func(issuedAt time.Time) time.Time
+	}{
+		{
+			name: "SelfSigned",
+			want: config.RotateAt,
+		},
+		{
+			name:       "CASecretExpiringSoon",
+			caValidFor: 48 * time.Hour,
+			want: func(issuedAt time.Time) time.Time {
+				return issuedAt.Add(48 * time.Hour).Add(-config.MinFresh)
+			},
+		},
+		{
+			name:       "CASecretExpiringWithinMinFresh",
+			caValidFor: time.Hour,
+			want: func(issuedAt time.Time) time.Time {
+				return issuedAt.Add(MinCertRotationInterval)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			defer func(generator certs.CertGenerator) { certGenerator = generator }(certGenerator)
+			certGenerator = certs.CertGeneratorFunc(certs.CreateSignedServingPair)
+
+			mockOpClient := operatorclientmocks.NewMockClientInterface(ctrl)
+			mockOpClient.EXPECT().CreateService(gomock.Any()).Return(&corev1.Service{}, nil)
+			mockOpClient.EXPECT().CreateSecret(gomock.Any()).Return(&corev1.Secret{}, nil)
+			mockOpClient.EXPECT().CreateRole(gomock.Any()).Return(&rbacv1.Role{}, nil)
+			mockOpClient.EXPECT().CreateRoleBinding(gomock.Any()).Return(&rbacv1.RoleBinding{}, nil).Times(2)
+			mockOpClient.EXPECT().CreateClusterRoleBinding(gomock.Any()).Return(&rbacv1.ClusterRoleBinding{}, nil)
+
+			var builder CertIssuerBuilderFunc
+			if tt.caValidFor != 0 {
+				caPEM, keyPEM, err := keyPair(t, time.Now().Add(tt.caValidFor)).ToPEM()
+				require.NoError(t, err)
+				kubeClient := k8sfake.NewSimpleClientset(&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "ca", Namespace: "pki"},
+					Data:       map[string][]byte{corev1.TLSCertKey: caPEM, corev1.TLSPrivateKeyKey: keyPEM},
+				})
+				builder = func(owner ownerutil.Owner, keyAlgorithm certs.KeyAlgorithm) (certs.Issuer, error) {
+					return certs.NewCASecretIssuer(kubeClient, "pki", "ca", certGenerator, keyAlgorithm), nil
+				}
+			}
+
+			i := &StrategyDeploymentInstaller{
+				strategyClient: wrappers.NewInstallStrategyDeploymentClient(mockOpClient, newFakeLister(state), owner.GetNamespace()),
+				owner:          owner,
+				apiServiceDescriptions: []certResource{
+					&apiServiceDescriptionsWithCAPEM{v1alpha1.APIServiceDescription{Name: "v1.test.io", DeploymentName: "dep", ContainerPort: 443}, nil},
+				},
+				certIssuerBuilder:  builder,
+				certConfigDefaults: config,
+			}
+			strategy := &v1alpha1.StrategyDetailsDeployment{
+				DeploymentSpecs: []v1alpha1.StrategyDeploymentSpec{
+					{Name: "dep", Spec: appsv1.DeploymentSpec{Selector: selector(t, "test=label")}},
+				},
+			}
+
+			issuedAt := time.Now()
+			_, err := i.installCertRequirements(strategy)
+			require.NoError(t, err)
+			// Certificates are valid from the second they are issued in.
+			require.WithinDuration(t, tt.want(issuedAt), i.CertsRotateAt(), 2*time.Second)
+		})
+	}
+}
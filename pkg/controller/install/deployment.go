@@ -3,6 +3,7 @@ package install
 import (
 	"fmt"
 	"hash/fnv"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	appsv1 "k8s.io/api/apps/v1"
@@ -14,6 +15,7 @@ import (
 
 	"github.com/operator-framework/api/pkg/operators/v1alpha1"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/wrappers"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/certs"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/operators/olm/overrides/inject"
 	hashutil "github.com/operator-framework/operator-lifecycle-manager/pkg/lib/kubernetes/pkg/util/hash"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/ownerutil"
@@ -29,10 +31,16 @@ type StrategyDeploymentInstaller struct {
 	initializers           DeploymentInitializerFuncChain
 	apiServiceDescriptions []certResource
 	webhookDescriptions    []certResource
+	certIssuerBuilder      CertIssuerBuilderFunc
+	certConfigDefaults     CertConfig
+	// certsRotateAt is when the serving certificates of the last Install are
+	// rotated, or zero if it had none.
+	certsRotateAt time.Time
 }
 
 var _ Strategy = &v1alpha1.StrategyDetailsDeployment{}
 var _ StrategyInstaller = &StrategyDeploymentInstaller{}
+var _ CertRotator = &StrategyDeploymentInstaller{}
 
 // DeploymentInitializerFunc takes a deployment object and appropriately
 // initializes it for install.
@@ -65,7 +73,11 @@ func (c DeploymentInitializerFuncChain) Apply(deployment *appsv1.Deployment) (er
 // the given context.
 type DeploymentInitializerBuilderFunc func(owner ownerutil.Owner) DeploymentInitializerFunc
 
-func NewStrategyDeploymentInstaller(strategyClient wrappers.InstallStrategyDeploymentInterface, templateAnnotations map[string]string, owner ownerutil.Owner, previousStrategy Strategy, initializers DeploymentInitializerFuncChain, apiServiceDescriptions []v1alpha1.APIServiceDescription, webhookDescriptions []v1alpha1.WebhookDescription) StrategyInstaller {
+// CertIssuerBuilderFunc returns the certs.Issuer of the serving certificates
+// of the Services of the given owner, with keys of the given algorithm.
+type CertIssuerBuilderFunc func(owner ownerutil.Owner, keyAlgorithm certs.KeyAlgorithm) (certs.Issuer, error)
+
+func NewStrategyDeploymentInstaller(strategyClient wrappers.InstallStrategyDeploymentInterface, templateAnnotations map[string]string, owner ownerutil.Owner, previousStrategy Strategy, initializers DeploymentInitializerFuncChain, apiServiceDescriptions []v1alpha1.APIServiceDescription, webhookDescriptions []v1alpha1.WebhookDescription, certIssuerBuilder CertIssuerBuilderFunc, certConfig CertConfig) StrategyInstaller {
 	apiDescs := make([]certResource, len(apiServiceDescriptions))
 	for i := range apiServiceDescriptions {
 		apiDescs[i] = &apiServiceDescriptionsWithCAPEM{apiServiceDescriptions[i], []byte{}}
@@ -84,6 +96,8 @@ func NewStrategyDeploymentInstaller(strategyClient wrappers.InstallStrategyDeplo
 		initializers:           initializers,
 		apiServiceDescriptions: apiDescs,
 		webhookDescriptions:    webhookDescs,
+		certIssuerBuilder:      certIssuerBuilder,
+		certConfigDefaults:     certConfig,
 	}
 }
 
@@ -28,7 +28,11 @@ type StrategyResolverInterface interface {
 }
 
 type StrategyResolver struct {
-	OverridesBuilderFunc DeploymentInitializerBuilderFunc
+	OverridesBuilderFunc  DeploymentInitializerBuilderFunc
+	CertIssuerBuilderFunc CertIssuerBuilderFunc
+	// CertConfig is the default config of the serving certificates of the
+	// installed operators, which CSVs can override with annotations.
+	CertConfig CertConfig
 }
 
 func (r *StrategyResolver) UnmarshalStrategy(s v1alpha1.NamedInstallStrategy) (strategy Strategy, err error) {
@@ -50,7 +54,7 @@ func (r *StrategyResolver) InstallerForStrategy(strategyName string, opClient op
 			initializers = append(initializers, r.OverridesBuilderFunc(owner))
 		}
 
-		return NewStrategyDeploymentInstaller(strategyClient, annotations, owner, previousStrategy, initializers, apiServiceDescriptions, webhookDescriptions)
+		return NewStrategyDeploymentInstaller(strategyClient, annotations, owner, previousStrategy, initializers, apiServiceDescriptions, webhookDescriptions, r.CertIssuerBuilderFunc, r.CertConfig)
 	}
 
 	// Insurance against these functions being called incorrectly (unmarshal strategy will return a valid strategy name)
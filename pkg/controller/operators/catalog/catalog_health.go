@@ -0,0 +1,134 @@
+package catalog
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	resolvercache "github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver/cache"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/metrics"
+)
+
+const (
+	// CatalogSourceHealthyCondition is the CatalogSource status condition that
+	// reports whether its content could be read for resolution recently.
+	CatalogSourceHealthyCondition = "Healthy"
+
+	// CatalogSourceSnapshotsSucceedingReason is the reason of the healthy
+	// condition when the content of the catalog was last read successfully.
+	CatalogSourceSnapshotsSucceedingReason = "SnapshotsSucceeding"
+	// CatalogSourceServingStaleSnapshotReason is the reason of the healthy
+	// condition when the content of the catalog couldn't be read, and the last
+	// good content is used for resolution instead.
+	CatalogSourceServingStaleSnapshotReason = "ServingStaleSnapshot"
+	// CatalogSourceSnapshotsFailingReason is the reason of the healthy
+	// condition when the content of the catalog couldn't be read.
+	CatalogSourceSnapshotsFailingReason = "SnapshotsFailing"
+)
+
+// catalogHealthStore holds the latest health observed for each catalog by
+// the resolver.
+type catalogHealthStore struct {
+	mu     sync.RWMutex
+	health map[resolvercache.SourceKey]resolvercache.SourceHealth
+}
+
+func newCatalogHealthStore() *catalogHealthStore {
+	return &catalogHealthStore{health: map[resolvercache.SourceKey]resolvercache.SourceHealth{}}
+}
+
+// set records the health of the given catalog, and returns the previously
+// recorded one.
+func (s *catalogHealthStore) set(key resolvercache.SourceKey, health resolvercache.SourceHealth) (resolvercache.SourceHealth, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, ok := s.health[key]
+	s.health[key] = health
+	return prev, ok
+}
+
+func (s *catalogHealthStore) get(key resolvercache.SourceKey) (resolvercache.SourceHealth, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	health, ok := s.health[key]
+	return health, ok
+}
+
+func (s *catalogHealthStore) remove(key resolvercache.SourceKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.health, key)
+}
+
+// catalogHealthCondition returns the healthy condition reporting the given
+// health. Its message only holds what changes with the counts of reads and
+// errors, so that it isn't rewritten on every read: the duration of the last
+// read, and the time of the last good one while reads succeed, are exported
+// as metrics instead.
+func catalogHealthCondition(health resolvercache.SourceHealth) metav1.Condition {
+	stats := fmt.Sprintf("error rate %.0f%% over the last %d reads", health.ErrorRate*100, health.Attempts)
+	if health.LastError == nil {
+		return metav1.Condition{
+			Type:    CatalogSourceHealthyCondition,
+			Status:  metav1.ConditionTrue,
+			Reason:  CatalogSourceSnapshotsSucceedingReason,
+			Message: stats,
+		}
+	}
+	if !health.LastGoodSnapshot.IsZero() {
+		stats += fmt.Sprintf(", last good read at %s", health.LastGoodSnapshot.UTC().Format(time.RFC3339))
+	}
+	switch {
+	case health.Stale:
+		return metav1.Condition{
+			Type:    CatalogSourceHealthyCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  CatalogSourceServingStaleSnapshotReason,
+			Message: fmt.Sprintf("%v: resolving with the last good content (%s)", health.LastError, stats),
+		}
+	default:
+		return metav1.Condition{
+			Type:    CatalogSourceHealthyCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  CatalogSourceSnapshotsFailingReason,
+			Message: fmt.Sprintf("%v (%s)", health.LastError, stats),
+		}
+	}
+}
+
+// observeCatalogHealth is called by the resolver with the health of a catalog
+// after each attempt to read its content. The CatalogSource is requeued to
+// report a change of its healthy condition.
+func (o *Operator) observeCatalogHealth(key resolvercache.SourceKey, health resolvercache.SourceHealth) {
+	metrics.RegisterCatalogSourceHealth(key.Name, key.Namespace, health.Score(), health.LastSnapshotDuration, health.LastGoodSnapshot)
+
+	prev, ok := o.catalogHealth.set(key, health)
+	if ok && sameCatalogHealthCondition(catalogHealthCondition(prev), catalogHealthCondition(health)) {
+		return
+	}
+	if err := o.catsrcQueueSet.Requeue(key.Namespace, key.Name); err != nil {
+		o.logger.WithError(err).Debug("couldn't requeue catalogsource from catalog health change")
+	}
+}
+
+// syncCatalogHealth reports the latest health observed for the given
+// CatalogSource in its healthy condition.
+func (o *Operator) syncCatalogHealth(out *v1alpha1.CatalogSource) {
+	health, ok := o.catalogHealth.get(resolvercache.SourceKey{Name: out.GetName(), Namespace: out.GetNamespace()})
+	if !ok {
+		return
+	}
+	condition := catalogHealthCondition(health)
+	if existing := meta.FindStatusCondition(out.Status.Conditions, condition.Type); existing != nil && sameCatalogHealthCondition(*existing, condition) {
+		return
+	}
+	meta.SetStatusCondition(&out.Status.Conditions, condition)
+}
+
+func sameCatalogHealthCondition(a, b metav1.Condition) bool {
+	return a.Status == b.Status && a.Reason == b.Reason && a.Message == b.Message
+}
@@ -0,0 +1,107 @@
+package catalog
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	resolvercache "github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver/cache"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/queueinformer"
+)
+
+func TestCatalogHealthCondition(t *testing.T) {
+	lastGood := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		health     resolvercache.SourceHealth
+		wantStatus metav1.ConditionStatus
+		wantReason string
+		wantMsg    string
+	}{
+		{
+			name:       "Succeeding",
+			health:     resolvercache.SourceHealth{Attempts: 4, ErrorRate: 0.25, LastSnapshotDuration: 1500 * time.Millisecond, LastGoodSnapshot: lastGood},
+			wantStatus: metav1.ConditionTrue,
+			wantReason: CatalogSourceSnapshotsSucceedingReason,
+			wantMsg:    "error rate 25% over the last 4 reads",
+		},
+		{
+			name:       "Stale",
+			health:     resolvercache.SourceHealth{Attempts: 2, ErrorRate: 0.5, LastSnapshotDuration: time.Second, LastGoodSnapshot: lastGood, LastError: errors.New("unavailable"), Stale: true},
+			wantStatus: metav1.ConditionFalse,
+			wantReason: CatalogSourceServingStaleSnapshotReason,
+			wantMsg:    "unavailable: resolving with the last good content (error rate 50% over the last 2 reads, last good read at 2021-03-01T12:00:00Z)",
+		},
+		{
+			name:       "Failing",
+			health:     resolvercache.SourceHealth{Attempts: 1, ErrorRate: 1, LastSnapshotDuration: time.Second, LastError: errors.New("unavailable")},
+			wantStatus: metav1.ConditionFalse,
+			wantReason: CatalogSourceSnapshotsFailingReason,
+			wantMsg:    "unavailable (error rate 100% over the last 1 reads)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition := catalogHealthCondition(tt.health)
+			require.Equal(t, CatalogSourceHealthyCondition, condition.Type)
+			require.Equal(t, tt.wantStatus, condition.Status)
+			require.Equal(t, tt.wantReason, condition.Reason)
+			require.Equal(t, tt.wantMsg, condition.Message)
+		})
+	}
+}
+
+func TestSyncCatalogHealth(t *testing.T) {
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer queue.ShutDown()
+	o := &Operator{
+		logger:         logrus.New(),
+		catalogHealth:  newCatalogHealthStore(),
+		catsrcQueueSet: queueinformer.NewEmptyResourceQueueSet(),
+	}
+	o.catsrcQueueSet.Set(metav1.NamespaceAll, queue)
+
+	catsrc := &v1alpha1.CatalogSource{ObjectMeta: metav1.ObjectMeta{Name: "catalog", Namespace: "ns"}}
+	key := resolvercache.SourceKey{Name: "catalog", Namespace: "ns"}
+
+	// nothing is reported before the catalog was read
+	o.syncCatalogHealth(catsrc)
+	require.Empty(t, catsrc.Status.Conditions)
+
+	// the first observation requeues the catalog
+	o.observeCatalogHealth(key, resolvercache.SourceHealth{Attempts: 1})
+	require.Equal(t, 1, queue.Len())
+	o.syncCatalogHealth(catsrc)
+	require.True(t, meta.IsStatusConditionTrue(catsrc.Status.Conditions, CatalogSourceHealthyCondition))
+
+	item, _ := queue.Get()
+	queue.Done(item)
+	queue.Forget(item)
+
+	// changed timings alone neither requeue nor update the status
+	message := meta.FindStatusCondition(catsrc.Status.Conditions, CatalogSourceHealthyCondition).Message
+	o.observeCatalogHealth(key, resolvercache.SourceHealth{Attempts: 1, LastSnapshotDuration: time.Second, LastGoodSnapshot: time.Now()})
+	require.Equal(t, 0, queue.Len())
+	o.syncCatalogHealth(catsrc)
+	require.Equal(t, message, meta.FindStatusCondition(catsrc.Status.Conditions, CatalogSourceHealthyCondition).Message)
+
+	// changed counts are reported
+	o.observeCatalogHealth(key, resolvercache.SourceHealth{Attempts: 2, LastSnapshotDuration: time.Second})
+	require.Equal(t, 1, queue.Len())
+	o.syncCatalogHealth(catsrc)
+	require.Equal(t, "error rate 0% over the last 2 reads", meta.FindStatusCondition(catsrc.Status.Conditions, CatalogSourceHealthyCondition).Message)
+
+	// a failure is reported
+	o.observeCatalogHealth(key, resolvercache.SourceHealth{Attempts: 3, ErrorRate: 1.0 / 3, LastError: errors.New("unavailable")})
+	o.syncCatalogHealth(catsrc)
+	condition := meta.FindStatusCondition(catsrc.Status.Conditions, CatalogSourceHealthyCondition)
+	require.Equal(t, metav1.ConditionFalse, condition.Status)
+	require.Equal(t, CatalogSourceSnapshotsFailingReason, condition.Reason)
+}
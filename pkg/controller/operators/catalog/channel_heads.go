@@ -0,0 +1,68 @@
+package catalog
+
+import (
+	"sync"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/operator-framework/operator-registry/pkg/api"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry"
+)
+
+type channelHeadKey struct {
+	source           registry.CatalogKey
+	pkgName, channel string
+}
+
+// channelHeadCache holds the heads of the channels that Subscriptions are
+// subscribed to, so that they are looked up once per change of their catalog
+// rather than on every sync of a namespace.
+type channelHeadCache struct {
+	mu    sync.RWMutex
+	heads map[channelHeadKey]*api.Bundle
+}
+
+func newChannelHeadCache() *channelHeadCache {
+	return &channelHeadCache{heads: map[channelHeadKey]*api.Bundle{}}
+}
+
+// get returns the head of the channel of the given Subscription, looking it up
+// with the given querier if it isn't cached.
+func (c *channelHeadCache) get(querier SourceQuerier, sub *v1alpha1.Subscription) (*api.Bundle, error) {
+	key := channelHeadKey{
+		source:  registry.CatalogKey{Name: sub.Spec.CatalogSource, Namespace: sub.Spec.CatalogSourceNamespace},
+		pkgName: sub.Spec.Package,
+		channel: sub.Spec.Channel,
+	}
+
+	c.mu.RLock()
+	head, ok := c.heads[key]
+	c.mu.RUnlock()
+	if ok {
+		return head, nil
+	}
+
+	head, err := querier.FindChannelHead(key.pkgName, key.channel, key.source)
+	if err != nil {
+		return nil, err
+	}
+	if head == nil {
+		return nil, ChannelHeadNotFoundError{Package: key.pkgName, Channel: key.channel, Source: key.source, Empty: true}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.heads[key] = head
+	return head, nil
+}
+
+// expire drops the cached channel heads of the given catalog.
+func (c *channelHeadCache) expire(source registry.CatalogKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.heads {
+		if key.source == source {
+			delete(c.heads, key)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package catalog
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilclock "k8s.io/apimachinery/pkg/util/clock"
+	k8sscheme "k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/queueinformer"
+)
+
+type OperatorOption func(*operatorConfig)
+
+type operatorConfig struct {
+	kubeconfigPath              string
+	resyncPeriod                func() time.Duration
+	operatorNamespace           string
+	clock                       utilclock.Clock
+	logger                      *logrus.Logger
+	scheme                      *runtime.Scheme
+	configMapServerImage        string
+	opmImage                    string
+	utilImage                   string
+	installPlanTimeout          time.Duration
+	bundleUnpackTimeout         time.Duration
+	resolutionPreference        resolver.ResolutionPreference
+	resolutionTimeout           time.Duration
+	additionalStepKinds         StepKinds
+	installPlanRollbackTimeout  time.Duration
+	staleCatalogTolerance       time.Duration
+	ignoreUnneededCatalogErrors bool
+}
+
+func (o *operatorConfig) apply(options []OperatorOption) {
+	for _, option := range options {
+		option(o)
+	}
+}
+
+func newInvalidConfigError(name, msg string) error {
+	return errors.Errorf("%s config invalid: %s", name, msg)
+}
+
+func (o *operatorConfig) validate() (err error) {
+	switch {
+	case o.resyncPeriod == nil:
+		err = newInvalidConfigError("resync period", "must not be nil")
+	case o.operatorNamespace == metav1.NamespaceAll:
+		err = newInvalidConfigError("operator namespace", "must be a single namespace")
+	case o.clock == nil:
+		err = newInvalidConfigError("clock", "must not be nil")
+	case o.logger == nil:
+		err = newInvalidConfigError("logger", "must not be nil")
+	case o.scheme == nil:
+		err = newInvalidConfigError("scheme", "must not be nil")
+	case o.configMapServerImage == "":
+		err = newInvalidConfigError("configmap server image", "must not be empty")
+	case o.opmImage == "":
+		err = newInvalidConfigError("opm image", "must not be empty")
+	case o.utilImage == "":
+		err = newInvalidConfigError("util image", "must not be empty")
+	case o.installPlanTimeout < 0, o.bundleUnpackTimeout < 0, o.resolutionTimeout < 0, o.installPlanRollbackTimeout < 0, o.staleCatalogTolerance < 0:
+		err = newInvalidConfigError("timeouts", "must not be negative")
+	}
+
+	return
+}
+
+func defaultOperatorConfig() *operatorConfig {
+	return &operatorConfig{
+		resyncPeriod:               queueinformer.ResyncWithJitter(15*time.Minute, 0.2),
+		operatorNamespace:          "default",
+		clock:                      utilclock.RealClock{},
+		logger:                     logrus.New(),
+		scheme:                     k8sscheme.Scheme,
+		installPlanTimeout:         time.Minute,
+		bundleUnpackTimeout:        10 * time.Minute,
+		resolutionPreference:       resolver.PreferChannelHead,
+		resolutionTimeout:          5 * time.Minute,
+		installPlanRollbackTimeout: 10 * time.Minute,
+	}
+}
+
+// WithKubeconfigPath sets the kubeconfig file to connect to the cluster with.
+// The in-cluster configuration is used if it is empty.
+func WithKubeconfigPath(kubeconfigPath string) OperatorOption {
+	return func(config *operatorConfig) {
+		config.kubeconfigPath = kubeconfigPath
+	}
+}
+
+func WithResyncPeriod(resyncPeriod func() time.Duration) OperatorOption {
+	return func(config *operatorConfig) {
+		config.resyncPeriod = resyncPeriod
+	}
+}
+
+func WithOperatorNamespace(namespace string) OperatorOption {
+	return func(config *operatorConfig) {
+		config.operatorNamespace = namespace
+	}
+}
+
+func WithClock(clock utilclock.Clock) OperatorOption {
+	return func(config *operatorConfig) {
+		config.clock = clock
+	}
+}
+
+func WithLogger(logger *logrus.Logger) OperatorOption {
+	return func(config *operatorConfig) {
+		config.logger = logger
+	}
+}
+
+func WithScheme(scheme *runtime.Scheme) OperatorOption {
+	return func(config *operatorConfig) {
+		config.scheme = scheme
+	}
+}
+
+// WithConfigMapServerImage sets the image serving the registry API of
+// ConfigMap CatalogSources.
+func WithConfigMapServerImage(image string) OperatorOption {
+	return func(config *operatorConfig) {
+		config.configMapServerImage = image
+	}
+}
+
+// WithOPMImage sets the image used to unpack bundles with opm.
+func WithOPMImage(image string) OperatorOption {
+	return func(config *operatorConfig) {
+		config.opmImage = image
+	}
+}
+
+// WithUtilImage sets the image containing the OLM utilities.
+func WithUtilImage(image string) OperatorOption {
+	return func(config *operatorConfig) {
+		config.utilImage = image
+	}
+}
+
+// WithInstallPlanTimeout sets the time since the first attempt at executing an
+// InstallPlan after which its errors are considered fatal.
+func WithInstallPlanTimeout(timeout time.Duration) OperatorOption {
+	return func(config *operatorConfig) {
+		config.installPlanTimeout = timeout
+	}
+}
+
+// WithBundleUnpackTimeout sets the time limit for unpacking bundles. Zero
+// disables the limit.
+func WithBundleUnpackTimeout(timeout time.Duration) OperatorOption {
+	return func(config *operatorConfig) {
+		config.bundleUnpackTimeout = timeout
+	}
+}
+
+// WithResolutionPreference sets how the resolver chooses among valid
+// resolutions.
+func WithResolutionPreference(preference resolver.ResolutionPreference) OperatorOption {
+	return func(config *operatorConfig) {
+		config.resolutionPreference = preference
+	}
+}
+
+// WithResolutionTimeout sets the time limit for a single dependency
+// resolution. Zero disables the limit.
+func WithResolutionTimeout(timeout time.Duration) OperatorOption {
+	return func(config *operatorConfig) {
+		config.resolutionTimeout = timeout
+	}
+}
+
+// WithAdditionalStepKinds sets the resource kinds InstallPlans may create in
+// addition to those supported by default.
+func WithAdditionalStepKinds(kinds StepKinds) OperatorOption {
+	return func(config *operatorConfig) {
+		config.additionalStepKinds = kinds
+	}
+}
+
+// WithInstallPlanRollbackTimeout sets the time after completion of an
+// InstallPlan opted into rollbacks within which its ClusterServiceVersions
+// must succeed.
+func WithInstallPlanRollbackTimeout(timeout time.Duration) OperatorOption {
+	return func(config *operatorConfig) {
+		config.installPlanRollbackTimeout = timeout
+	}
+}
+
+// WithStaleCatalogTolerance sets how long the last good content of a catalog
+// is resolved against when reading it fails. Zero disables the fallback.
+func WithStaleCatalogTolerance(tolerance time.Duration) OperatorOption {
+	return func(config *operatorConfig) {
+		config.staleCatalogTolerance = tolerance
+	}
+}
+
+// WithUnneededCatalogErrorsIgnored resolves without the content of catalogs
+// that can't be read, unless a Subscription being resolved names them.
+func WithUnneededCatalogErrorsIgnored(ignored bool) OperatorOption {
+	return func(config *operatorConfig) {
+		config.ignoreUnneededCatalogErrors = ignored
+	}
+}
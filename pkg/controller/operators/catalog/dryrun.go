@@ -0,0 +1,323 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	crdlib "github.com/operator-framework/operator-lifecycle-manager/pkg/lib/crd"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/ownerutil"
+)
+
+const (
+	// DryRunAnnotationKey requests, when set to "true", that the steps of an
+	// InstallPlan awaiting approval be dry-run against the cluster. InstallPlans
+	// created for a Subscription carrying the annotation inherit it.
+	DryRunAnnotationKey = "operatorframework.io/dry-run"
+
+	// InstallPlanDryRun is the type of the condition that reports the outcome
+	// of an InstallPlan dry-run.
+	InstallPlanDryRun v1alpha1.InstallPlanConditionType = "DryRun"
+
+	// InstallPlanReasonDryRunCompleted is the reason of a successful dry-run.
+	InstallPlanReasonDryRunCompleted v1alpha1.InstallPlanConditionReason = "DryRunCompleted"
+	// InstallPlanReasonDryRunFailed is the reason of a dry-run in which at
+	// least one step could not be applied or would break existing resources.
+	InstallPlanReasonDryRunFailed v1alpha1.InstallPlanConditionReason = "DryRunFailed"
+
+	dryRunResultsSuffix   = "-dry-run"
+	dryRunResultKeyPrefix = "step-"
+	dryRunFieldManager    = "olm-dry-run"
+	maxDryRunDiffPaths    = 20
+)
+
+// StepDryRunAction describes what applying a step would do to its target.
+type StepDryRunAction string
+
+const (
+	StepDryRunActionCreate StepDryRunAction = "Create"
+	StepDryRunActionUpdate StepDryRunAction = "Update"
+	StepDryRunActionNoOp   StepDryRunAction = "NoOp"
+)
+
+// StepDryRunResult is the outcome of dry-running a single InstallPlan step.
+type StepDryRunResult struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Action is empty for steps that are not applied from a manifest.
+	Action StepDryRunAction `json:"action,omitempty"`
+	// Diff lists the paths of the fields an update would change.
+	Diff []string `json:"diff,omitempty"`
+	// Error is set if the step could not be dry-run, or if applying it would
+	// be rejected or, for CRDs, invalidate existing custom resources.
+	Error string `json:"error,omitempty"`
+}
+
+func dryRunEnabled(obj metav1.Object) bool {
+	return obj.GetAnnotations()[DryRunAnnotationKey] == "true"
+}
+
+func dryRunResultsName(plan *v1alpha1.InstallPlan) string {
+	return plan.GetName() + dryRunResultsSuffix
+}
+
+// dryRunInstallPlan dry-runs every step of the plan, stores the results in a
+// ConfigMap owned by the plan, and summarizes them in the plan's DryRun
+// condition.
+func (o *Operator) dryRunInstallPlan(plan *v1alpha1.InstallPlan, logger logrus.FieldLogger) error {
+	r := newManifestResolver(plan.GetNamespace(), o.lister.CoreV1().ConfigMapLister(), o.logger)
+
+	results := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dryRunResultsName(plan),
+			Namespace: plan.GetNamespace(),
+		},
+		Data: map[string]string{},
+	}
+	ownerutil.AddNonBlockingOwner(results, plan)
+
+	clients, err := o.stepTargetClientsFor(plan)
+	if err != nil {
+		return err
+	}
+
+	counts := map[StepDryRunAction]int{}
+	var failed []string
+	for i, step := range plan.Status.Plan {
+		result := o.dryRunStep(plan.GetNamespace(), r, step, clients)
+		if result.Error != "" {
+			failed = append(failed, fmt.Sprintf("%s %s: %s", result.Kind, result.Name, result.Error))
+		} else if result.Action != "" {
+			counts[result.Action]++
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		results.Data[dryRunResultKeyPrefix+strconv.Itoa(i)] = string(data)
+	}
+
+	client := o.opClient.KubernetesInterface().CoreV1().ConfigMaps(plan.GetNamespace())
+	if _, err := client.Create(context.TODO(), results, metav1.CreateOptions{}); k8serrors.IsAlreadyExists(err) {
+		if _, err := client.Update(context.TODO(), results, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("error updating dry-run results: %v", err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("error creating dry-run results: %v", err)
+	}
+
+	now := o.now()
+	summary := fmt.Sprintf("%d step(s) would be created, %d updated and %d unchanged; see configmap %s", counts[StepDryRunActionCreate], counts[StepDryRunActionUpdate], counts[StepDryRunActionNoOp], results.GetName())
+	cond := v1alpha1.InstallPlanCondition{
+		Type:               InstallPlanDryRun,
+		Status:             corev1.ConditionTrue,
+		Reason:             InstallPlanReasonDryRunCompleted,
+		Message:            summary,
+		LastUpdateTime:     &now,
+		LastTransitionTime: &now,
+	}
+	if len(failed) > 0 {
+		cond.Status = corev1.ConditionFalse
+		cond.Reason = InstallPlanReasonDryRunFailed
+		cond.Message = fmt.Sprintf("%d step(s) failed: %s", len(failed), strings.Join(failed, "; "))
+	}
+	plan.Status.SetCondition(cond)
+
+	logger.WithField("result", cond.Message).Info("dry-run InstallPlan")
+	return nil
+}
+
+// dryRunStep applies the step's manifest with server-side apply in dry-run
+// mode and compares the result with the current state of its target.
+func (o *Operator) dryRunStep(namespace string, r *manifestResolver, step *v1alpha1.Step, clients stepTargetClients) StepDryRunResult {
+	result := StepDryRunResult{
+		Kind: step.Resource.Kind,
+		Name: step.Resource.Name,
+	}
+
+	manifest, err := r.ManifestForStep(step)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if manifest == "" {
+		// Steps such as copied Secrets are not applied from a manifest.
+		return result
+	}
+	obj := &unstructured.Unstructured{}
+	if err := yaml.NewYAMLOrJSONDecoder(strings.NewReader(manifest), 10).Decode(obj); err != nil {
+		result.Error = fmt.Sprintf("error decoding step manifest: %v", err)
+		return result
+	}
+
+	client, namespaced, err := o.stepTargetClient(clients, namespace, obj.GroupVersionKind())
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if namespaced {
+		obj.SetNamespace(namespace)
+	}
+	obj.SetResourceVersion("")
+
+	current, err := client.Get(context.TODO(), obj.GetName(), metav1.GetOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		result.Error = err.Error()
+		return result
+	}
+	if k8serrors.IsNotFound(err) {
+		current = nil
+	}
+
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	force := true
+	applied, err := client.Patch(context.TODO(), obj.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{
+		DryRun:       []string{metav1.DryRunAll},
+		Force:        &force,
+		FieldManager: dryRunFieldManager,
+	})
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	if current == nil {
+		result.Action = StepDryRunActionCreate
+		return result
+	}
+
+	result.Diff = diffPaths(current.Object, applied.Object)
+	result.Action = StepDryRunActionNoOp
+	if len(result.Diff) > 0 {
+		result.Action = StepDryRunActionUpdate
+	}
+
+	if step.Resource.Kind == crdKind {
+		if err := o.validateCRDCompatibility(manifest); err != nil {
+			result.Error = err.Error()
+		}
+	}
+	return result
+}
+
+// crdsForManifest returns the CRD described by the given manifest and the
+// on-cluster CRD of the same name, which is nil if it does not exist yet.
+func (o *Operator) crdsForManifest(manifest string) (desired, existing runtime.Object, err error) {
+	version, err := crdlib.Version(&manifest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch version {
+	case crdlib.V1Version:
+		crd, err := crdlib.UnmarshalV1(manifest)
+		if err != nil {
+			return nil, nil, err
+		}
+		current, err := o.opClient.ApiextensionsInterface().ApiextensionsV1().CustomResourceDefinitions().Get(context.TODO(), crd.GetName(), metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			return crd, nil, nil
+		}
+		return crd, current, err
+	case crdlib.V1Beta1Version:
+		crd, err := crdlib.UnmarshalV1Beta1(manifest)
+		if err != nil {
+			return nil, nil, err
+		}
+		current, err := o.opClient.ApiextensionsInterface().ApiextensionsV1beta1().CustomResourceDefinitions().Get(context.TODO(), crd.GetName(), metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			return crd, nil, nil
+		}
+		return crd, current, err
+	}
+	return nil, nil, fmt.Errorf("unsupported CustomResourceDefinition version %s", version)
+}
+
+// validateCRDCompatibility checks whether the existing custom resources of a
+// CRD are valid against the schema of the given CRD manifest.
+func (o *Operator) validateCRDCompatibility(manifest string) error {
+	desired, existing, err := o.crdsForManifest(manifest)
+	if err != nil || existing == nil {
+		return err
+	}
+
+	switch crd := desired.(type) {
+	case *apiextensionsv1.CustomResourceDefinition:
+		return validateV1CRDCompatibility(o.dynamicClient, existing.(*apiextensionsv1.CustomResourceDefinition), crd)
+	case *apiextensionsv1beta1.CustomResourceDefinition:
+		return validateV1Beta1CRDCompatibility(o.dynamicClient, existing.(*apiextensionsv1beta1.CustomResourceDefinition), crd)
+	}
+	return nil
+}
+
+// ignoredDiffPaths are fields that the API server manages and that are not
+// part of what a step applies.
+var ignoredDiffPaths = map[string]struct{}{
+	".metadata.creationTimestamp": {},
+	".metadata.generation":        {},
+	".metadata.managedFields":     {},
+	".metadata.resourceVersion":   {},
+	".metadata.selfLink":          {},
+	".metadata.uid":               {},
+	".status":                     {},
+}
+
+// diffPaths returns the sorted paths of the fields whose values differ
+// between before and after. At most maxDryRunDiffPaths paths are returned,
+// followed by a count of the omitted ones.
+func diffPaths(before, after map[string]interface{}) []string {
+	var paths []string
+	var walk func(path string, a, b interface{})
+	walk = func(path string, a, b interface{}) {
+		if _, ok := ignoredDiffPaths[path]; ok {
+			return
+		}
+		am, aok := a.(map[string]interface{})
+		bm, bok := b.(map[string]interface{})
+		if !aok || !bok {
+			if !reflect.DeepEqual(a, b) {
+				paths = append(paths, path)
+			}
+			return
+		}
+		keys := map[string]struct{}{}
+		for k := range am {
+			keys[k] = struct{}{}
+		}
+		for k := range bm {
+			keys[k] = struct{}{}
+		}
+		for k := range keys {
+			walk(path+"."+k, am[k], bm[k])
+		}
+	}
+	walk("", before, after)
+
+	sort.Strings(paths)
+	if len(paths) > maxDryRunDiffPaths {
+		omitted := len(paths) - maxDryRunDiffPaths
+		paths = append(paths[:maxDryRunDiffPaths], fmt.Sprintf("... and %d more", omitted))
+	}
+	return paths
+}
@@ -0,0 +1,143 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+)
+
+func TestDiffPaths(t *testing.T) {
+	tests := []struct {
+		testName string
+		before   map[string]interface{}
+		after    map[string]interface{}
+		expected []string
+	}{
+		{
+			testName: "Equal",
+			before:   map[string]interface{}{"spec": map[string]interface{}{"a": "b"}},
+			after:    map[string]interface{}{"spec": map[string]interface{}{"a": "b"}},
+		},
+		{
+			testName: "ChangedAddedRemoved",
+			before:   map[string]interface{}{"spec": map[string]interface{}{"a": "b", "c": "d", "l": []interface{}{"x"}}},
+			after:    map[string]interface{}{"spec": map[string]interface{}{"a": "B", "e": "f", "l": []interface{}{"x", "y"}}},
+			expected: []string{".spec.a", ".spec.c", ".spec.e", ".spec.l"},
+		},
+		{
+			testName: "IgnoresServerManagedFields",
+			before: map[string]interface{}{
+				"metadata": map[string]interface{}{"resourceVersion": "1", "generation": int64(1)},
+				"status":   map[string]interface{}{"phase": "Old"},
+			},
+			after: map[string]interface{}{
+				"metadata": map[string]interface{}{"resourceVersion": "2", "generation": int64(2), "labels": map[string]interface{}{"a": "b"}},
+				"status":   map[string]interface{}{"phase": "New"},
+			},
+			expected: []string{".metadata.labels"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			require.Equal(t, tt.expected, diffPaths(tt.before, tt.after))
+		})
+	}
+}
+
+func TestDiffPathsTruncates(t *testing.T) {
+	after := map[string]interface{}{}
+	for i := 0; i < maxDryRunDiffPaths+5; i++ {
+		after[string(rune('a'+i))] = i
+	}
+
+	paths := diffPaths(map[string]interface{}{}, after)
+	require.Len(t, paths, maxDryRunDiffPaths+1)
+	require.Equal(t, "... and 5 more", paths[maxDryRunDiffPaths])
+}
+
+func TestDryRunInstallPlan(t *testing.T) {
+	namespace := "ns"
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	op := newRollbackTestOperator(t, ctx, namespace)
+	_, err := op.dynamicClient.Resource(configMapGVR).Namespace(namespace).Create(context.TODO(), unstructuredConfigMap(namespace, "same", "1"), metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	// Server-side apply is not supported by the fake dynamic client, so
+	// answer dry-run applies with the applied object.
+	var patchTypes []types.PatchType
+	op.dynamicClient.(*fakedynamic.FakeDynamicClient).PrependReactor("patch", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		patch := action.(clienttesting.PatchAction)
+		patchTypes = append(patchTypes, patch.GetPatchType())
+		obj := &unstructured.Unstructured{}
+		return true, obj, obj.UnmarshalJSON(patch.GetPatch())
+	})
+
+	manifest := func(obj *unstructured.Unstructured) string {
+		obj.SetNamespace("")
+		data, err := obj.MarshalJSON()
+		require.NoError(t, err)
+		return string(data)
+	}
+	plan := withSteps(installPlan("p", namespace, v1alpha1.InstallPlanPhaseRequiresApproval, "csv"), []*v1alpha1.Step{
+		{
+			Resource: v1alpha1.StepResource{Version: "v1", Kind: "ConfigMap", Name: "cfg", Manifest: manifest(unstructuredConfigMap(namespace, "cfg", "2"))},
+			Status:   v1alpha1.StepStatusUnknown,
+		},
+		{
+			Resource: v1alpha1.StepResource{Version: "v1", Kind: "ConfigMap", Name: "same", Manifest: manifest(unstructuredConfigMap(namespace, "same", "1"))},
+			Status:   v1alpha1.StepStatusUnknown,
+		},
+		{
+			Resource: v1alpha1.StepResource{Version: "v1", Kind: "ServiceAccount", Name: "sa", Manifest: manifest(unstructuredServiceAccount(namespace, "sa"))},
+			Status:   v1alpha1.StepStatusUnknown,
+		},
+		{
+			Resource: v1alpha1.StepResource{Version: "v1", Kind: "Secret", Name: "pull-secret"},
+			Status:   v1alpha1.StepStatusUnknown,
+		},
+		{
+			Resource: v1alpha1.StepResource{Group: "some.unsupported.group", Version: "v1", Kind: "UnsupportedKind", Name: "my-unsupported-kind", Manifest: yamlFromFilePath(t, "testdata/unsupportedkind.cr.yaml")},
+			Status:   v1alpha1.StepStatusUnknown,
+		},
+	})
+
+	require.NoError(t, op.dryRunInstallPlan(plan, op.logger))
+	require.Equal(t, []types.PatchType{types.ApplyPatchType, types.ApplyPatchType, types.ApplyPatchType}, patchTypes)
+
+	results, err := op.opClient.KubernetesInterface().CoreV1().ConfigMaps(namespace).Get(context.TODO(), dryRunResultsName(plan), metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, results.Data, 5)
+
+	expected := []StepDryRunResult{
+		{Kind: "ConfigMap", Name: "cfg", Action: StepDryRunActionUpdate, Diff: []string{".data.key"}},
+		{Kind: "ConfigMap", Name: "same", Action: StepDryRunActionNoOp},
+		{Kind: "ServiceAccount", Name: "sa", Action: StepDryRunActionCreate},
+		{Kind: "Secret", Name: "pull-secret"},
+		{Kind: "UnsupportedKind", Name: "my-unsupported-kind", Error: `GroupVersion "some.unsupported.group/v1" not found`},
+	}
+	for i, e := range expected {
+		var result StepDryRunResult
+		require.NoError(t, json.Unmarshal([]byte(results.Data[dryRunResultKeyPrefix+strconv.Itoa(i)]), &result))
+		require.Equal(t, e, result)
+	}
+
+	cond := plan.Status.GetCondition(InstallPlanDryRun)
+	require.Equal(t, corev1.ConditionFalse, cond.Status)
+	require.Equal(t, InstallPlanReasonDryRunFailed, cond.Reason)
+	require.Equal(t, `1 step(s) failed: UnsupportedKind my-unsupported-kind: GroupVersion "some.unsupported.group/v1" not found`, cond.Message)
+}
@@ -0,0 +1,209 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+)
+
+const (
+	// InstallPlanRetentionCountAnnotationKey sets the number of InstallPlans
+	// to keep in a namespace.
+	InstallPlanRetentionCountAnnotationKey = "operatorframework.io/installplan-retention-count"
+	// InstallPlanRetentionMaxAgeAnnotationKey sets the age, as a duration,
+	// after which InstallPlans are deleted regardless of their number.
+	InstallPlanRetentionMaxAgeAnnotationKey = "operatorframework.io/installplan-retention-max-age"
+	// InstallPlanRetentionKeepFailedAnnotationKey, when "true", keeps failed
+	// InstallPlans in addition to the retained count.
+	InstallPlanRetentionKeepFailedAnnotationKey = "operatorframework.io/installplan-retention-keep-failed"
+	// InstallPlanArchiveAnnotationKey, when "true", records a summary of each
+	// garbage collected InstallPlan in the installplan-history ConfigMap.
+	InstallPlanArchiveAnnotationKey = "operatorframework.io/installplan-archive"
+
+	installPlanArchiveName  = "installplan-history"
+	maxArchivedInstallPlans = 1000
+	// maxInstallPlanArchiveSize bounds the total size of the keys and values
+	// of the archive, leaving room for its metadata within the 1 MiB limit
+	// on ConfigMaps.
+	maxInstallPlanArchiveSize = 768 * 1024
+)
+
+// installPlanRetentionPolicy determines which InstallPlans of a namespace are
+// garbage collected.
+type installPlanRetentionPolicy struct {
+	count      int
+	maxAge     time.Duration
+	keepFailed bool
+	archive    bool
+}
+
+// installPlanRetentionPolicy returns the retention policy for the given
+// namespace. Annotations on the namespace override the defaults, and
+// annotations on its OperatorGroup override those of the namespace. Invalid
+// annotations are ignored.
+func (o *Operator) installPlanRetentionPolicy(log logrus.FieldLogger, ns *corev1.Namespace) installPlanRetentionPolicy {
+	policy := installPlanRetentionPolicy{count: maxInstallPlanCount}
+	policy.apply(log, ns.GetAnnotations())
+
+	ogs, err := o.lister.OperatorsV1().OperatorGroupLister().OperatorGroups(ns.GetName()).List(labels.Everything())
+	if err != nil {
+		log.WithError(err).Debug("unable to list operatorgroups for installplan retention policy")
+		return policy
+	}
+	if len(ogs) == 1 {
+		policy.apply(log.WithField("operatorgroup", ogs[0].GetName()), ogs[0].GetAnnotations())
+	}
+	return policy
+}
+
+func (p *installPlanRetentionPolicy) apply(log logrus.FieldLogger, annotations map[string]string) {
+	if value, ok := annotations[InstallPlanRetentionCountAnnotationKey]; ok {
+		if count, err := strconv.Atoi(value); err != nil || count < 0 {
+			log.WithField(InstallPlanRetentionCountAnnotationKey, value).Warn("ignoring invalid installplan retention count")
+		} else {
+			p.count = count
+		}
+	}
+	if value, ok := annotations[InstallPlanRetentionMaxAgeAnnotationKey]; ok {
+		if maxAge, err := time.ParseDuration(value); err != nil || maxAge < 0 {
+			log.WithField(InstallPlanRetentionMaxAgeAnnotationKey, value).Warn("ignoring invalid installplan retention max age")
+		} else {
+			p.maxAge = maxAge
+		}
+	}
+	if value, ok := annotations[InstallPlanRetentionKeepFailedAnnotationKey]; ok {
+		p.keepFailed = value == "true"
+	}
+	if value, ok := annotations[InstallPlanArchiveAnnotationKey]; ok {
+		p.archive = value == "true"
+	}
+}
+
+// referencedInstallPlans returns the names of the InstallPlans referenced by
+// the Subscriptions in the given namespace.
+func (o *Operator) referencedInstallPlans(namespace string) (map[string]struct{}, error) {
+	subs, err := o.lister.OperatorsV1alpha1().SubscriptionLister().Subscriptions(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	referenced := map[string]struct{}{}
+	for _, sub := range subs {
+		if ref := sub.Status.InstallPlanRef; ref != nil {
+			referenced[ref.Name] = struct{}{}
+		}
+		if ref := sub.Status.Install; ref != nil {
+			referenced[ref.Name] = struct{}{}
+		}
+	}
+	return referenced, nil
+}
+
+// installPlanSummary is the archived record of a garbage collected
+// InstallPlan.
+type installPlanSummary struct {
+	Generation                 int                       `json:"generation"`
+	Phase                      v1alpha1.InstallPlanPhase `json:"phase,omitempty"`
+	Approval                   v1alpha1.Approval         `json:"approval,omitempty"`
+	Approved                   bool                      `json:"approved"`
+	ClusterServiceVersionNames []string                  `json:"clusterServiceVersionNames,omitempty"`
+	Created                    metav1.Time               `json:"created"`
+	Message                    string                    `json:"message,omitempty"`
+}
+
+// archiveInstallPlans records a summary of each of the given InstallPlans in
+// the installplan-history ConfigMap of their namespace. Older summaries are
+// pruned to keep the ConfigMap within its size limit.
+func (o *Operator) archiveInstallPlans(namespace string, ips []*v1alpha1.InstallPlan) error {
+	client := o.opClient.KubernetesInterface().CoreV1().ConfigMaps(namespace)
+	archive, err := client.Get(context.TODO(), installPlanArchiveName, metav1.GetOptions{})
+	exists := err == nil
+	if k8serrors.IsNotFound(err) {
+		archive = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      installPlanArchiveName,
+				Namespace: namespace,
+			},
+		}
+	} else if err != nil {
+		return err
+	}
+	if archive.Data == nil {
+		archive.Data = map[string]string{}
+	}
+
+	for _, ip := range ips {
+		data, err := json.Marshal(installPlanSummary{
+			Generation:                 ip.Spec.Generation,
+			Phase:                      ip.Status.Phase,
+			Approval:                   ip.Spec.Approval,
+			Approved:                   ip.Spec.Approved,
+			ClusterServiceVersionNames: ip.Spec.ClusterServiceVersionNames,
+			Created:                    ip.GetCreationTimestamp(),
+			Message:                    ip.Status.Message,
+		})
+		if err != nil {
+			return err
+		}
+		archive.Data[ip.GetName()] = string(data)
+	}
+
+	pruneInstallPlanArchive(archive.Data)
+
+	if exists {
+		_, err = client.Update(context.TODO(), archive, metav1.UpdateOptions{})
+	} else {
+		_, err = client.Create(context.TODO(), archive, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("error updating installplan archive: %v", err)
+	}
+	return nil
+}
+
+// pruneInstallPlanArchive drops the summaries of the least recently created
+// InstallPlans until at most maxArchivedInstallPlans remain and their total
+// size is at most maxInstallPlanArchiveSize.
+func pruneInstallPlanArchive(data map[string]string) {
+	size := 0
+	for name, summary := range data {
+		size += len(name) + len(summary)
+	}
+	if len(data) <= maxArchivedInstallPlans && size <= maxInstallPlanArchiveSize {
+		return
+	}
+
+	created := make(map[string]time.Time, len(data))
+	names := make([]string, 0, len(data))
+	for name, value := range data {
+		var summary installPlanSummary
+		// Entries that cannot be decoded sort first, and are dropped first.
+		_ = json.Unmarshal([]byte(value), &summary)
+		created[name] = summary.Created.Time
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if !created[names[i]].Equal(created[names[j]]) {
+			return created[names[i]].Before(created[names[j]])
+		}
+		return names[i] < names[j]
+	})
+	for _, name := range names {
+		if len(data) <= maxArchivedInstallPlans && size <= maxInstallPlanArchiveSize {
+			return
+		}
+		size -= len(name) + len(data[name])
+		delete(data, name)
+	}
+}
@@ -0,0 +1,257 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilclock "k8s.io/apimachinery/pkg/util/clock"
+
+	operatorsv1 "github.com/operator-framework/api/pkg/operators/v1"
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+)
+
+func TestGCInstallPlansRetentionPolicy(t *testing.T) {
+	namespace := "ns"
+	clockFake := utilclock.NewFakeClock(time.Date(2018, time.January, 26, 20, 40, 0, 0, time.UTC))
+
+	// plan returns an InstallPlan of the given generation, created
+	// 10-generation minutes ago.
+	plan := func(generation int, phase v1alpha1.InstallPlanPhase) *v1alpha1.InstallPlan {
+		return &v1alpha1.InstallPlan{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:         namespace,
+				Name:              fmt.Sprintf("install-%d", generation),
+				CreationTimestamp: metav1.NewTime(clockFake.Now().Add(-time.Duration(10-generation) * time.Minute)),
+			},
+			Spec: v1alpha1.InstallPlanSpec{
+				Generation:                 generation,
+				ClusterServiceVersionNames: []string{fmt.Sprintf("csv.v%d", generation)},
+			},
+			Status: v1alpha1.InstallPlanStatus{Phase: phase},
+		}
+	}
+	plans := func(generations ...int) []runtime.Object {
+		var objs []runtime.Object
+		for _, g := range generations {
+			objs = append(objs, plan(g, v1alpha1.InstallPlanPhaseComplete))
+		}
+		return objs
+	}
+	operatorGroup := func(annotations map[string]string) *operatorsv1.OperatorGroup {
+		return &operatorsv1.OperatorGroup{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "og", Annotations: annotations},
+		}
+	}
+
+	tests := []struct {
+		testName             string
+		namespaceAnnotations map[string]string
+		objs                 []runtime.Object
+		remaining            []string
+		archived             []string
+	}{
+		{
+			testName:  "Default",
+			objs:      plans(1, 2, 3, 4, 5, 6, 7),
+			remaining: []string{"install-3", "install-4", "install-5", "install-6", "install-7"},
+		},
+		{
+			testName:             "NamespaceCount",
+			namespaceAnnotations: map[string]string{InstallPlanRetentionCountAnnotationKey: "2"},
+			objs:                 plans(1, 2, 3, 4),
+			remaining:            []string{"install-3", "install-4"},
+		},
+		{
+			testName:             "OperatorGroupOverridesNamespace",
+			namespaceAnnotations: map[string]string{InstallPlanRetentionCountAnnotationKey: "1"},
+			objs:                 append(plans(1, 2, 3, 4), operatorGroup(map[string]string{InstallPlanRetentionCountAnnotationKey: "3"})),
+			remaining:            []string{"install-2", "install-3", "install-4"},
+		},
+		{
+			testName:             "InvalidCountIgnored",
+			namespaceAnnotations: map[string]string{InstallPlanRetentionCountAnnotationKey: "-1"},
+			objs:                 plans(1, 2, 3, 4, 5, 6),
+			remaining:            []string{"install-2", "install-3", "install-4", "install-5", "install-6"},
+		},
+		{
+			testName:             "ReferencedAndAwaitingApprovalKept",
+			namespaceAnnotations: map[string]string{InstallPlanRetentionCountAnnotationKey: "3"},
+			objs: append(plans(2, 4, 5),
+				plan(1, v1alpha1.InstallPlanPhaseRequiresApproval),
+				plan(3, v1alpha1.InstallPlanPhaseComplete),
+				&v1alpha1.Subscription{
+					ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "sub"},
+					Status: v1alpha1.SubscriptionStatus{
+						InstallPlanRef: &corev1.ObjectReference{Namespace: namespace, Name: "install-3"},
+					},
+				},
+			),
+			remaining: []string{"install-1", "install-3", "install-5"},
+		},
+		{
+			testName: "KeepFailed",
+			namespaceAnnotations: map[string]string{
+				InstallPlanRetentionCountAnnotationKey:      "1",
+				InstallPlanRetentionKeepFailedAnnotationKey: "true",
+			},
+			objs:      append(plans(1, 3), plan(2, v1alpha1.InstallPlanPhaseFailed)),
+			remaining: []string{"install-2", "install-3"},
+		},
+		{
+			testName:             "MaxAge",
+			namespaceAnnotations: map[string]string{InstallPlanRetentionMaxAgeAnnotationKey: "7m30s"},
+			objs:                 plans(1, 2, 3, 4),
+			remaining:            []string{"install-3", "install-4"},
+		},
+		{
+			testName: "Archive",
+			namespaceAnnotations: map[string]string{
+				InstallPlanRetentionCountAnnotationKey: "1",
+				InstallPlanArchiveAnnotationKey:        "true",
+			},
+			objs:      plans(1, 2, 3),
+			remaining: []string{"install-3"},
+			archived:  []string{"install-1", "install-2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+
+			op, err := NewFakeOperator(ctx, namespace, []string{namespace}, withClock(clockFake), withClientObjs(tt.objs...))
+			require.NoError(t, err)
+
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace, Annotations: tt.namespaceAnnotations}}
+			op.gcInstallPlans(logrus.New(), ns)
+
+			out, err := op.client.OperatorsV1alpha1().InstallPlans(namespace).List(ctx, metav1.ListOptions{})
+			require.NoError(t, err)
+			var remaining []string
+			for _, ip := range out.Items {
+				remaining = append(remaining, ip.GetName())
+			}
+			sort.Strings(remaining)
+			require.Equal(t, tt.remaining, remaining)
+
+			archive, err := op.opClient.KubernetesInterface().CoreV1().ConfigMaps(namespace).Get(ctx, installPlanArchiveName, metav1.GetOptions{})
+			if len(tt.archived) == 0 {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, archive.Data, len(tt.archived))
+			for _, name := range tt.archived {
+				var summary installPlanSummary
+				require.NoError(t, json.Unmarshal([]byte(archive.Data[name]), &summary))
+				require.Equal(t, v1alpha1.InstallPlanPhaseComplete, summary.Phase)
+				require.Len(t, summary.ClusterServiceVersionNames, 1)
+			}
+		})
+	}
+}
+
+func TestPruneInstallPlanArchive(t *testing.T) {
+	created := time.Date(2018, time.January, 26, 20, 40, 0, 0, time.UTC)
+	// archive returns n summaries with messages of the given length. The
+	// summary of install-i is created i minutes after that of install-0.
+	archive := func(n, messageLength int) map[string]string {
+		data := map[string]string{}
+		for i := 0; i < n; i++ {
+			summary, err := json.Marshal(installPlanSummary{
+				Generation: i,
+				Created:    metav1.NewTime(created.Add(time.Duration(i) * time.Minute)),
+				Message:    strings.Repeat("x", messageLength),
+			})
+			require.NoError(t, err)
+			data[fmt.Sprintf("install-%04d", i)] = string(summary)
+		}
+		return data
+	}
+	entrySize := func(data map[string]string, name string) int {
+		return len(name) + len(data[name])
+	}
+
+	tests := []struct {
+		testName string
+		data     map[string]string
+		pruned   bool
+	}{
+		{
+			testName: "WithinLimits",
+			data:     archive(10, 10),
+		},
+		{
+			testName: "TooMany",
+			data:     archive(maxArchivedInstallPlans+5, 10),
+			pruned:   true,
+		},
+		{
+			testName: "TooLarge",
+			data:     archive(200, 8*1024),
+			pruned:   true,
+		},
+		{
+			testName: "UndecodableEntriesFirst",
+			data: func() map[string]string {
+				data := archive(maxArchivedInstallPlans, 10)
+				data["zz-invalid"] = "{"
+				return data
+			}(),
+			pruned: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			before := map[string]string{}
+			for name, summary := range tt.data {
+				before[name] = summary
+			}
+
+			pruneInstallPlanArchive(tt.data)
+
+			if !tt.pruned {
+				require.Equal(t, before, tt.data)
+				return
+			}
+			require.Less(t, len(tt.data), len(before))
+			require.LessOrEqual(t, len(tt.data), maxArchivedInstallPlans)
+			size := 0
+			for name := range tt.data {
+				size += entrySize(tt.data, name)
+			}
+			require.LessOrEqual(t, size, maxInstallPlanArchiveSize)
+
+			// Only the most recently created summaries are kept, and no more
+			// than necessary are dropped.
+			var names []string
+			for name := range before {
+				if name != "zz-invalid" {
+					names = append(names, name)
+				}
+			}
+			sort.Strings(names)
+			require.NotContains(t, tt.data, "zz-invalid")
+			kept := names[len(names)-len(tt.data):]
+			for _, name := range kept {
+				require.Contains(t, tt.data, name)
+			}
+			if len(kept) < len(names) {
+				next := names[len(names)-len(tt.data)-1]
+				require.True(t, len(tt.data) == maxArchivedInstallPlans || size+entrySize(before, next) > maxInstallPlanArchiveSize,
+					"%s was dropped unnecessarily", next)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/maintenancewindow"
+)
+
+const (
+	// MaintenanceWindowAnnotationKey sets the cron schedule of the
+	// maintenance windows in which InstallPlans with automatic approval are
+	// approved. InstallPlans created for a Subscription or an OperatorGroup
+	// carrying the annotation inherit it.
+	MaintenanceWindowAnnotationKey = "operatorframework.io/maintenance-window"
+	// MaintenanceWindowDurationAnnotationKey sets how long each maintenance
+	// window lasts, as a duration. Defaults to one hour.
+	MaintenanceWindowDurationAnnotationKey = "operatorframework.io/maintenance-window-duration"
+	// MaintenanceWindowTimeZoneAnnotationKey sets the time zone of the
+	// maintenance window schedule, e.g. "Europe/Berlin". Defaults to UTC.
+	MaintenanceWindowTimeZoneAnnotationKey = "operatorframework.io/maintenance-window-time-zone"
+
+	defaultMaintenanceWindowDuration = time.Hour
+)
+
+var maintenanceWindowAnnotationKeys = []string{
+	MaintenanceWindowAnnotationKey,
+	MaintenanceWindowDurationAnnotationKey,
+	MaintenanceWindowTimeZoneAnnotationKey,
+}
+
+// maintenanceWindow returns the maintenance window set by the annotations of
+// the given object, or nil if it doesn't set one.
+func maintenanceWindow(obj metav1.Object) (*maintenancewindow.Window, error) {
+	annotations := obj.GetAnnotations()
+	schedule, ok := annotations[MaintenanceWindowAnnotationKey]
+	if !ok {
+		return nil, nil
+	}
+	duration := defaultMaintenanceWindowDuration
+	if value, ok := annotations[MaintenanceWindowDurationAnnotationKey]; ok {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maintenance window duration %q: %v", value, err)
+		}
+		duration = d
+	}
+	window, err := maintenancewindow.New(schedule, duration, annotations[MaintenanceWindowTimeZoneAnnotationKey])
+	if err != nil {
+		return nil, fmt.Errorf("invalid maintenance window: %v", err)
+	}
+	return window, nil
+}
+
+// maintenanceWindowAnnotations returns the maintenance window annotations of
+// an InstallPlan generated for the given Subscriptions: those of the first
+// Subscription, by name, that sets a window, or else those of the
+// OperatorGroup of the namespace.
+func (o *Operator) maintenanceWindowAnnotations(log logrus.FieldLogger, namespace string, subs []*v1alpha1.Subscription) map[string]string {
+	sorted := make([]*v1alpha1.Subscription, len(subs))
+	copy(sorted, subs)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].GetName() < sorted[j].GetName()
+	})
+
+	var source metav1.Object
+	for _, sub := range sorted {
+		if _, ok := sub.GetAnnotations()[MaintenanceWindowAnnotationKey]; !ok {
+			continue
+		}
+		if source != nil {
+			log.WithField("subscription", sub.GetName()).Warnf("ignoring maintenance window of subscription, the window of %s applies to the whole namespace", source.GetName())
+			continue
+		}
+		source = sub
+	}
+
+	if source == nil {
+		ogs, err := o.lister.OperatorsV1().OperatorGroupLister().OperatorGroups(namespace).List(labels.Everything())
+		if err != nil {
+			log.WithError(err).Debug("unable to list operatorgroups for maintenance window")
+			return nil
+		}
+		if len(ogs) != 1 {
+			return nil
+		}
+		source = ogs[0]
+	}
+
+	annotations := map[string]string{}
+	for _, key := range maintenanceWindowAnnotationKeys {
+		if value, ok := source.GetAnnotations()[key]; ok {
+			annotations[key] = value
+		}
+	}
+	if _, ok := annotations[MaintenanceWindowAnnotationKey]; !ok {
+		return nil
+	}
+	return annotations
+}
+
+// upgradesInstalledOperator returns true if the given steps or bundle lookups
+// replace a ClusterServiceVersion that exists in the namespace. Plans that
+// only install new operators aren't held for a maintenance window.
+func (o *Operator) upgradesInstalledOperator(namespace string, steps []*v1alpha1.Step, bundleLookups []v1alpha1.BundleLookup) bool {
+	var replaces []string
+	for _, step := range steps {
+		if step.Resource.Kind != v1alpha1.ClusterServiceVersionKind {
+			continue
+		}
+		var csv v1alpha1.ClusterServiceVersion
+		if err := json.Unmarshal([]byte(step.Resource.Manifest), &csv); err != nil {
+			continue
+		}
+		replaces = append(replaces, csv.Spec.Replaces)
+	}
+	for _, lookup := range bundleLookups {
+		replaces = append(replaces, lookup.Replaces)
+	}
+
+	csvs := o.lister.OperatorsV1alpha1().ClusterServiceVersionLister().ClusterServiceVersions(namespace)
+	for _, name := range replaces {
+		if name == "" {
+			continue
+		}
+		if _, err := csvs.Get(name); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// syncInstallPlanMaintenanceWindow approves the given InstallPlan, held for
+// its maintenance window, once the window is open. It returns false if the
+// InstallPlan isn't held for a maintenance window.
+func (o *Operator) syncInstallPlanMaintenanceWindow(plan *v1alpha1.InstallPlan, logger *logrus.Entry) (bool, error) {
+	if plan.Status.Phase != v1alpha1.InstallPlanPhaseRequiresApproval || plan.Spec.Approved || plan.Spec.Approval != v1alpha1.ApprovalAutomatic {
+		return false, nil
+	}
+	window, err := maintenanceWindow(plan)
+	if err != nil {
+		// The plan waits for a valid window, or for manual approval.
+		logger.WithError(err).Warn("not approving installplan")
+		return true, nil
+	}
+	if window == nil {
+		return false, nil
+	}
+
+	now := o.now().Time
+	if !window.Contains(now) {
+		next := window.Next(now)
+		logger.WithField("window", next.Format(time.RFC3339)).Debug("waiting for maintenance window")
+		return true, o.ipQueueSet.RequeueAfter(plan.GetNamespace(), plan.GetName(), next.Sub(now))
+	}
+
+	out := plan.DeepCopy()
+	out.Spec.Approved = true
+	if _, err := o.client.OperatorsV1alpha1().InstallPlans(out.GetNamespace()).Update(context.TODO(), out, metav1.UpdateOptions{}); err != nil {
+		return true, fmt.Errorf("error approving InstallPlan in maintenance window: %v", err)
+	}
+	logger.Info("approved installplan in maintenance window")
+	return true, nil
+}
+
+func (o *Operator) ensureSubscriptionMaintenanceWindowState(logger *logrus.Entry, sub *v1alpha1.Subscription) (*v1alpha1.Subscription, bool, error) {
+	out := sub.DeepCopy()
+
+	var reason, message string
+	if ref := sub.Status.InstallPlanRef; ref != nil {
+		ip, err := o.lister.OperatorsV1alpha1().InstallPlanLister().InstallPlans(ref.Namespace).Get(ref.Name)
+		if err != nil && !k8serrors.IsNotFound(err) {
+			logger.WithError(err).Debug("unable to get installplan")
+			return sub, false, nil
+		}
+		if err == nil && ip.Status.Phase == v1alpha1.InstallPlanPhaseRequiresApproval && !ip.Spec.Approved && ip.Spec.Approval == v1alpha1.ApprovalAutomatic {
+			window, err := maintenanceWindow(ip)
+			switch {
+			case err != nil:
+				reason = "InvalidMaintenanceWindow"
+				message = fmt.Sprintf("installplan %s will not be approved automatically: %v", ip.GetName(), err)
+			case window != nil:
+				reason = "WaitingForMaintenanceWindow"
+				message = fmt.Sprintf("installplan %s will be approved in the maintenance window starting at %s", ip.GetName(), window.Next(o.now().Time).Format(time.RFC3339))
+			}
+		}
+	}
+
+	if reason == "" {
+		out.Status.RemoveConditions(SubscriptionMaintenanceWindowPending)
+	} else {
+		cond := out.Status.GetCondition(SubscriptionMaintenanceWindowPending)
+		if cond.Status != corev1.ConditionTrue {
+			now := o.now()
+			cond.LastTransitionTime = &now
+		}
+		cond.Status = corev1.ConditionTrue
+		cond.Reason = reason
+		cond.Message = message
+		out.Status.SetCondition(cond)
+	}
+
+	if reflect.DeepEqual(sub.Status.Conditions, out.Status.Conditions) {
+		return sub, false, nil
+	}
+	out.Status.LastUpdated = o.now()
+
+	updatedSub, err := o.client.OperatorsV1alpha1().Subscriptions(out.GetNamespace()).UpdateStatus(context.TODO(), out, metav1.UpdateOptions{})
+	if err != nil {
+		logger.WithError(err).Info("error updating subscription status")
+		return nil, false, fmt.Errorf("error updating Subscription status: " + err.Error())
+	}
+
+	return updatedSub, true, nil
+}
@@ -0,0 +1,238 @@
+package catalog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilclock "k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/util/workqueue"
+
+	operatorsv1 "github.com/operator-framework/api/pkg/operators/v1"
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/clientfake"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/queueinformer"
+)
+
+// saturdayWindow opens on Saturdays at 02:00 UTC, for four hours.
+var saturdayWindow = map[string]string{
+	MaintenanceWindowAnnotationKey:         "0 2 * * 6",
+	MaintenanceWindowDurationAnnotationKey: "4h",
+}
+
+func TestCreateInstallPlanMaintenanceWindow(t *testing.T) {
+	namespace := "ns"
+
+	sub := func(name string, approval v1alpha1.Approval, annotations map[string]string) *v1alpha1.Subscription {
+		return &v1alpha1.Subscription{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Annotations: annotations},
+			Spec:       &v1alpha1.SubscriptionSpec{InstallPlanApproval: approval},
+		}
+	}
+	operatorGroup := &operatorsv1.OperatorGroup{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "og", Annotations: map[string]string{
+			MaintenanceWindowAnnotationKey:         "0 22 * * *",
+			MaintenanceWindowTimeZoneAnnotationKey: "Europe/Berlin",
+		}},
+	}
+
+	installed := &v1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "csv.v1"},
+	}
+	upgrade := []*v1alpha1.Step{{Resource: v1alpha1.StepResource{
+		Kind:     v1alpha1.ClusterServiceVersionKind,
+		Name:     "csv.v2",
+		Manifest: `{"kind":"ClusterServiceVersion","metadata":{"name":"csv.v2"},"spec":{"replaces":"csv.v1"}}`,
+	}}}
+
+	tests := []struct {
+		testName      string
+		subs          []*v1alpha1.Subscription
+		objs          []runtime.Object
+		steps         []*v1alpha1.Step
+		bundleLookups []v1alpha1.BundleLookup
+		approval      v1alpha1.Approval
+		phase         v1alpha1.InstallPlanPhase
+		approved      bool
+		annotations   map[string]string
+	}{
+		{
+			testName: "NoWindow",
+			subs:     []*v1alpha1.Subscription{sub("a", v1alpha1.ApprovalAutomatic, nil)},
+			objs:     []runtime.Object{installed},
+			steps:    upgrade,
+			approval: v1alpha1.ApprovalAutomatic,
+			phase:    v1alpha1.InstallPlanPhaseInstalling,
+			approved: true,
+		},
+		{
+			testName:    "SubscriptionWindow",
+			subs:        []*v1alpha1.Subscription{sub("a", v1alpha1.ApprovalAutomatic, nil), sub("b", v1alpha1.ApprovalAutomatic, saturdayWindow)},
+			objs:        []runtime.Object{installed, operatorGroup},
+			steps:       upgrade,
+			approval:    v1alpha1.ApprovalAutomatic,
+			phase:       v1alpha1.InstallPlanPhaseRequiresApproval,
+			annotations: saturdayWindow,
+		},
+		{
+			testName:    "OperatorGroupWindow",
+			subs:        []*v1alpha1.Subscription{sub("a", v1alpha1.ApprovalAutomatic, nil)},
+			objs:        []runtime.Object{installed, operatorGroup},
+			steps:       upgrade,
+			approval:    v1alpha1.ApprovalAutomatic,
+			phase:       v1alpha1.InstallPlanPhaseRequiresApproval,
+			annotations: operatorGroup.GetAnnotations(),
+		},
+		{
+			testName: "BundleLookupUpgrade",
+			subs:     []*v1alpha1.Subscription{sub("a", v1alpha1.ApprovalAutomatic, saturdayWindow)},
+			objs:     []runtime.Object{installed},
+			bundleLookups: []v1alpha1.BundleLookup{
+				{Path: "quay.io/example/bundle:v2", Identifier: "csv.v2", Replaces: "csv.v1"},
+			},
+			approval:    v1alpha1.ApprovalAutomatic,
+			phase:       v1alpha1.InstallPlanPhaseRequiresApproval,
+			annotations: saturdayWindow,
+		},
+		{
+			// the replaced CSV isn't installed, so this is a first install
+			testName: "FirstInstall",
+			subs:     []*v1alpha1.Subscription{sub("a", v1alpha1.ApprovalAutomatic, saturdayWindow)},
+			objs:     []runtime.Object{operatorGroup},
+			steps:    upgrade,
+			approval: v1alpha1.ApprovalAutomatic,
+			phase:    v1alpha1.InstallPlanPhaseInstalling,
+			approved: true,
+		},
+		{
+			testName: "ManualApproval",
+			subs:     []*v1alpha1.Subscription{sub("a", v1alpha1.ApprovalManual, saturdayWindow)},
+			objs:     []runtime.Object{installed},
+			steps:    upgrade,
+			approval: v1alpha1.ApprovalManual,
+			phase:    v1alpha1.InstallPlanPhaseRequiresApproval,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+
+			o, err := NewFakeOperator(ctx, namespace, []string{namespace}, withClientObjs(tt.objs...), withFakeClientOptions(clientfake.WithNameGeneration(t)))
+			require.NoError(t, err)
+
+			ref, err := o.createInstallPlan(ctx, namespace, 1, tt.subs, tt.approval, tt.steps, tt.bundleLookups)
+			require.NoError(t, err)
+
+			ip, err := o.client.OperatorsV1alpha1().InstallPlans(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, tt.approval, ip.Spec.Approval)
+			require.Equal(t, tt.approved, ip.Spec.Approved)
+			require.Equal(t, tt.phase, ip.Status.Phase)
+			for _, key := range maintenanceWindowAnnotationKeys {
+				require.Equal(t, tt.annotations[key], ip.GetAnnotations()[key], key)
+			}
+		})
+	}
+}
+
+func TestSyncInstallPlanMaintenanceWindow(t *testing.T) {
+	namespace := "ns"
+	// a Friday
+	clockFake := utilclock.NewFakeClock(time.Date(2018, time.January, 26, 20, 40, 0, 0, time.UTC))
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	plan := &v1alpha1.InstallPlan{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "install-1", Annotations: saturdayWindow},
+		Spec:       v1alpha1.InstallPlanSpec{Approval: v1alpha1.ApprovalAutomatic},
+		Status:     v1alpha1.InstallPlanStatus{Phase: v1alpha1.InstallPlanPhaseRequiresApproval},
+	}
+	sub := &v1alpha1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "sub"},
+		Spec:       &v1alpha1.SubscriptionSpec{},
+		Status: v1alpha1.SubscriptionStatus{
+			InstallPlanRef: &corev1.ObjectReference{Namespace: namespace, Name: plan.GetName()},
+		},
+	}
+	o, err := NewFakeOperator(ctx, namespace, []string{namespace}, withClock(clockFake), withClientObjs(plan, sub))
+	require.NoError(t, err)
+	logger := logrus.NewEntry(o.logger)
+	ipQueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "ips")
+	defer ipQueue.ShutDown()
+	o.ipQueueSet = queueinformer.NewEmptyResourceQueueSet()
+	o.ipQueueSet.Set(metav1.NamespaceAll, ipQueue)
+
+	// The plan waits for the window, as reported on the subscription.
+	held, err := o.syncInstallPlanMaintenanceWindow(plan, logger)
+	require.NoError(t, err)
+	require.True(t, held)
+	fetched, err := o.client.OperatorsV1alpha1().InstallPlans(namespace).Get(ctx, plan.GetName(), metav1.GetOptions{})
+	require.NoError(t, err)
+	require.False(t, fetched.Spec.Approved)
+
+	out, changed, err := o.ensureSubscriptionMaintenanceWindowState(logger, sub)
+	require.NoError(t, err)
+	require.True(t, changed)
+	cond := out.Status.GetCondition(SubscriptionMaintenanceWindowPending)
+	require.Equal(t, corev1.ConditionTrue, cond.Status)
+	require.Equal(t, "WaitingForMaintenanceWindow", cond.Reason)
+	require.Equal(t, "installplan install-1 will be approved in the maintenance window starting at 2018-01-27T02:00:00Z", cond.Message)
+
+	// The plan is approved once the window opens.
+	clockFake.SetTime(time.Date(2018, time.January, 27, 3, 0, 0, 0, time.UTC))
+	held, err = o.syncInstallPlanMaintenanceWindow(plan, logger)
+	require.NoError(t, err)
+	require.True(t, held)
+	fetched, err = o.client.OperatorsV1alpha1().InstallPlans(namespace).Get(ctx, plan.GetName(), metav1.GetOptions{})
+	require.NoError(t, err)
+	require.True(t, fetched.Spec.Approved)
+
+	// Approved plans are no longer held.
+	held, err = o.syncInstallPlanMaintenanceWindow(fetched, logger)
+	require.NoError(t, err)
+	require.False(t, held)
+}
+
+func TestEnsureSubscriptionMaintenanceWindowStateInvalid(t *testing.T) {
+	namespace := "ns"
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	plan := &v1alpha1.InstallPlan{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "install-1", Annotations: map[string]string{
+			MaintenanceWindowAnnotationKey: "0 2 * *",
+		}},
+		Spec:   v1alpha1.InstallPlanSpec{Approval: v1alpha1.ApprovalAutomatic},
+		Status: v1alpha1.InstallPlanStatus{Phase: v1alpha1.InstallPlanPhaseRequiresApproval},
+	}
+	sub := &v1alpha1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "sub"},
+		Spec:       &v1alpha1.SubscriptionSpec{},
+		Status: v1alpha1.SubscriptionStatus{
+			InstallPlanRef: &corev1.ObjectReference{Namespace: namespace, Name: plan.GetName()},
+		},
+	}
+	o, err := NewFakeOperator(ctx, namespace, []string{namespace}, withClientObjs(plan, sub))
+	require.NoError(t, err)
+	logger := logrus.NewEntry(o.logger)
+
+	// An invalid window is never opened.
+	held, err := o.syncInstallPlanMaintenanceWindow(plan, logger)
+	require.NoError(t, err)
+	require.True(t, held)
+
+	out, changed, err := o.ensureSubscriptionMaintenanceWindowState(logger, sub)
+	require.NoError(t, err)
+	require.True(t, changed)
+	cond := out.Status.GetCondition(SubscriptionMaintenanceWindowPending)
+	require.Equal(t, "InvalidMaintenanceWindow", cond.Reason)
+	require.Equal(t, `installplan install-1 will not be approved automatically: invalid maintenance window: invalid schedule "0 2 * *": expected 5 fields, found 4`, cond.Message)
+}
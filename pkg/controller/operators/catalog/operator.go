@@ -5,17 +5,18 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"reflect"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
-	errorwrap "github.com/pkg/errors"
+	"github.com/blang/semver/v4"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/grpc/connectivity"
 	corev1 "k8s.io/api/core/v1"
-	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
@@ -23,7 +24,6 @@ import (
 	extinf "k8s.io/apiextensions-apiserver/pkg/client/informers/externalversions"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -32,7 +32,6 @@ import (
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
-	"k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/tools/cache"
@@ -43,6 +42,7 @@ import (
 
 	"github.com/operator-framework/api/pkg/operators/reference"
 	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	operatorsv2 "github.com/operator-framework/api/pkg/operators/v2"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/client/clientset/versioned"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/client/informers/externalversions"
 	operatorsv1alpha1listers "github.com/operator-framework/operator-lifecycle-manager/pkg/api/client/listers/operators/v1alpha1"
@@ -63,11 +63,13 @@ import (
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/event"
 	index "github.com/operator-framework/operator-lifecycle-manager/pkg/lib/index"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/operatorclient"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/operatorcondition"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/operatorlister"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/ownerutil"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/queueinformer"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/scoped"
 	sharedtime "github.com/operator-framework/operator-lifecycle-manager/pkg/lib/time"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/tracing"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/metrics"
 )
 
@@ -88,60 +90,96 @@ const (
 	RegistryFieldManager   = "olm.registry"
 )
 
+// SubscriptionChannelHeadOutOfRange indicates that the head of the channel of
+// a Subscription is outside of the version range the Subscription is pinned
+// to, so the Subscription will not be upgraded to it.
+const SubscriptionChannelHeadOutOfRange v1alpha1.SubscriptionConditionType = "ChannelHeadOutOfRange"
+
+// SubscriptionResolutionTimedOut indicates that dependency resolution in the
+// namespace of a Subscription did not complete within the resolution timeout.
+const SubscriptionResolutionTimedOut v1alpha1.SubscriptionConditionType = "ResolutionTimedOut"
+
+// SubscriptionDeprecated indicates that the catalog of a Subscription
+// deprecates its package, its channel or the bundle it has installed.
+const SubscriptionDeprecated v1alpha1.SubscriptionConditionType = "Deprecated"
+
+// SubscriptionUpgradeHeld indicates that the OperatorCondition of the operator
+// installed by a Subscription holds its upgrade to the head of its channel.
+const SubscriptionUpgradeHeld v1alpha1.SubscriptionConditionType = "UpgradeHeld"
+
+// SubscriptionMaintenanceWindowPending indicates that the InstallPlan of a
+// Subscription waits for its maintenance window to be approved.
+const SubscriptionMaintenanceWindowPending v1alpha1.SubscriptionConditionType = "MaintenanceWindowPending"
+
 // Operator represents a Kubernetes operator that executes InstallPlans by
 // resolving dependencies in a catalog.
 type Operator struct {
 	queueinformer.Operator
 
-	logger                   *logrus.Logger
-	clock                    utilclock.Clock
-	opClient                 operatorclient.ClientInterface
-	client                   versioned.Interface
-	dynamicClient            dynamic.Interface
-	lister                   operatorlister.OperatorLister
-	catsrcQueueSet           *queueinformer.ResourceQueueSet
-	subQueueSet              *queueinformer.ResourceQueueSet
-	ipQueueSet               *queueinformer.ResourceQueueSet
-	nsResolveQueue           workqueue.RateLimitingInterface
-	namespace                string
-	recorder                 record.EventRecorder
-	sources                  *grpc.SourceStore
-	sourcesLastUpdate        sharedtime.SharedTime
-	resolver                 resolver.StepResolver
-	reconciler               reconciler.RegistryReconcilerFactory
-	catalogSubscriberIndexer map[string]cache.Indexer
-	clientAttenuator         *scoped.ClientAttenuator
-	serviceAccountQuerier    *scoped.UserDefinedServiceAccountQuerier
-	bundleUnpacker           bundle.Unpacker
-	installPlanTimeout       time.Duration
-	bundleUnpackTimeout      time.Duration
-	clientFactory            clients.Factory
+	logger                     *logrus.Logger
+	clock                      utilclock.Clock
+	opClient                   operatorclient.ClientInterface
+	client                     versioned.Interface
+	dynamicClient              dynamic.Interface
+	lister                     operatorlister.OperatorLister
+	catsrcQueueSet             *queueinformer.ResourceQueueSet
+	subQueueSet                *queueinformer.ResourceQueueSet
+	ipQueueSet                 *queueinformer.ResourceQueueSet
+	nsResolveQueue             workqueue.RateLimitingInterface
+	namespace                  string
+	recorder                   record.EventRecorder
+	sources                    *grpc.SourceStore
+	sourcesLastUpdate          sharedtime.SharedTime
+	resolver                   resolver.StepResolver
+	reconciler                 reconciler.RegistryReconcilerFactory
+	catalogSubscriberIndexer   map[string]cache.Indexer
+	clientAttenuator           *scoped.ClientAttenuator
+	serviceAccountQuerier      *scoped.UserDefinedServiceAccountQuerier
+	bundleUnpacker             bundle.Unpacker
+	installPlanTimeout         time.Duration
+	bundleUnpackTimeout        time.Duration
+	resolutionTimeout          time.Duration
+	additionalStepKinds        StepKinds
+	installPlanRollbackTimeout time.Duration
+	clientFactory              clients.Factory
+	catalogRefresher           *reconciler.CatalogRefresher
+	fileBasedSources           *resolver.FileBasedSourceStore
+	catalogHealth              *catalogHealthStore
+	channelHeads               *channelHeadCache
 }
 
 type CatalogSourceSyncFunc func(logger *logrus.Entry, in *v1alpha1.CatalogSource) (out *v1alpha1.CatalogSource, continueSync bool, syncError error)
 
 // NewOperator creates a new Catalog Operator.
-func NewOperator(ctx context.Context, kubeconfigPath string, clock utilclock.Clock, logger *logrus.Logger, resync time.Duration, configmapRegistryImage, opmImage, utilImage string, operatorNamespace string, scheme *runtime.Scheme, installPlanTimeout time.Duration, bundleUnpackTimeout time.Duration) (*Operator, error) {
-	resyncPeriod := queueinformer.ResyncWithJitter(resync, 0.2)
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+func NewOperator(ctx context.Context, options ...OperatorOption) (*Operator, error) {
+	config := defaultOperatorConfig()
+	config.apply(options)
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	logger := config.logger
+	resyncPeriod := config.resyncPeriod
+	operatorNamespace := config.operatorNamespace
+
+	restConfig, err := clientcmd.BuildConfigFromFlags("", config.kubeconfigPath)
 	if err != nil {
 		return nil, err
 	}
 
 	// Create a new client for OLM types (CRs)
-	crClient, err := versioned.NewForConfig(config)
+	crClient, err := versioned.NewForConfig(restConfig)
 	if err != nil {
 		return nil, err
 	}
 
 	// Create a new client for dynamic types (CRs)
-	dynamicClient, err := dynamic.NewForConfig(config)
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
 	if err != nil {
 		return nil, err
 	}
 
 	// Create a new queueinformer-based operator.
-	opClient, err := operatorclient.NewClientFromRestConfig(config)
+	opClient, err := operatorclient.NewClientFromRestConfig(restConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -160,35 +198,52 @@ func NewOperator(ctx context.Context, kubeconfigPath string, clock utilclock.Clo
 		return nil, err
 	}
 
-	ssaClient, err := controllerclient.NewForConfig(config, scheme, RegistryFieldManager)
+	ssaClient, err := controllerclient.NewForConfig(restConfig, config.scheme, RegistryFieldManager)
 	if err != nil {
 		return nil, err
 	}
 
 	// Allocate the new instance of an Operator.
 	op := &Operator{
-		Operator:                 queueOperator,
-		logger:                   logger,
-		clock:                    clock,
-		opClient:                 opClient,
-		dynamicClient:            dynamicClient,
-		client:                   crClient,
-		lister:                   lister,
-		namespace:                operatorNamespace,
-		recorder:                 eventRecorder,
-		catsrcQueueSet:           queueinformer.NewEmptyResourceQueueSet(),
-		subQueueSet:              queueinformer.NewEmptyResourceQueueSet(),
-		ipQueueSet:               queueinformer.NewEmptyResourceQueueSet(),
-		catalogSubscriberIndexer: map[string]cache.Indexer{},
-		serviceAccountQuerier:    scoped.NewUserDefinedServiceAccountQuerier(logger, crClient),
-		clientAttenuator:         scoped.NewClientAttenuator(logger, config, opClient),
-		installPlanTimeout:       installPlanTimeout,
-		bundleUnpackTimeout:      bundleUnpackTimeout,
-		clientFactory:            clients.NewFactory(config),
+		Operator:                   queueOperator,
+		logger:                     logger,
+		clock:                      config.clock,
+		opClient:                   opClient,
+		dynamicClient:              dynamicClient,
+		client:                     crClient,
+		lister:                     lister,
+		namespace:                  operatorNamespace,
+		recorder:                   eventRecorder,
+		catsrcQueueSet:             queueinformer.NewEmptyResourceQueueSet(),
+		subQueueSet:                queueinformer.NewEmptyResourceQueueSet(),
+		ipQueueSet:                 queueinformer.NewEmptyResourceQueueSet(),
+		catalogSubscriberIndexer:   map[string]cache.Indexer{},
+		serviceAccountQuerier:      scoped.NewUserDefinedServiceAccountQuerier(logger, crClient),
+		clientAttenuator:           scoped.NewClientAttenuator(logger, restConfig, opClient),
+		installPlanTimeout:         config.installPlanTimeout,
+		bundleUnpackTimeout:        config.bundleUnpackTimeout,
+		resolutionTimeout:          config.resolutionTimeout,
+		additionalStepKinds:        config.additionalStepKinds,
+		installPlanRollbackTimeout: config.installPlanRollbackTimeout,
+		clientFactory:              clients.NewFactory(restConfig),
+		catalogRefresher:           reconciler.NewCatalogRefresher(),
+		catalogHealth:              newCatalogHealthStore(),
+		channelHeads:               newChannelHeadCache(),
 	}
 	op.sources = grpc.NewSourceStore(logger, 10*time.Second, 10*time.Minute, op.syncSourceState)
-	op.reconciler = reconciler.NewRegistryReconcilerFactory(lister, opClient, configmapRegistryImage, op.now, ssaClient)
-	res := resolver.NewOperatorStepResolver(lister, crClient, opClient.KubernetesInterface(), operatorNamespace, op.sources, logger)
+	op.fileBasedSources = resolver.NewFileBasedSourceStore(op.syncFileBasedSource)
+	op.reconciler = reconciler.NewRegistryReconcilerFactory(lister, opClient, config.configMapServerImage, op.now, ssaClient, reconciler.NewRegistryDigestResolver(http.DefaultClient), op.catalogRefresher, reconciler.NewRegistryCatalogFetcher(http.DefaultClient), op.fileBasedSources)
+	resolverOptions := []resolver.SatResolverOption{
+		resolver.WithResolutionPreference(config.resolutionPreference),
+		resolver.WithSourceProvider(op.fileBasedSources),
+		resolver.WithCacheOptions(resolvercache.WithHealthObserver(op.observeCatalogHealth), resolvercache.WithStaleSnapshotTolerance(config.staleCatalogTolerance)),
+	}
+	// the OperatorCondition lister is registered below, along with its informer
+	resolverOptions = append(resolverOptions, resolver.WithOperatorConditionLister(lister.OperatorsV2().OperatorConditionLister()))
+	if config.ignoreUnneededCatalogErrors {
+		resolverOptions = append(resolverOptions, resolver.WithUnneededCatalogErrorsIgnored())
+	}
+	res := resolver.NewOperatorStepResolver(lister, crClient, opClient.KubernetesInterface(), operatorNamespace, op.sources, logger, resolverOptions...)
 	op.resolver = resolver.NewInstrumentedResolver(res, metrics.RegisterDependencyResolutionSuccess, metrics.RegisterDependencyResolutionFailure)
 
 	// Wire OLM CR sharedIndexInformers
@@ -314,6 +369,18 @@ func NewOperator(ctx context.Context, kubeconfigPath string, clock utilclock.Clo
 		return nil, err
 	}
 
+	// Wire OperatorConditions
+	opConditionInformer := crInformerFactory.Operators().V2().OperatorConditions()
+	op.lister.OperatorsV2().RegisterOperatorConditionLister(metav1.NamespaceAll, opConditionInformer.Lister())
+	opConditionInformer.Informer().AddEventHandler(&cache.ResourceEventHandlerFuncs{
+		AddFunc:    op.requeueOperatorConditionNamespace,
+		UpdateFunc: op.operatorConditionUpdated,
+		DeleteFunc: op.requeueOperatorConditionNamespace,
+	})
+	if err := op.RegisterInformer(opConditionInformer.Informer()); err != nil {
+		return nil, err
+	}
+
 	// Wire k8s sharedIndexInformers
 	k8sInformerFactory := informers.NewSharedInformerFactoryWithOptions(op.opClient.KubernetesInterface(), resyncPeriod())
 	sharedIndexInformers := []cache.SharedIndexInformer{}
@@ -333,6 +400,11 @@ func NewOperator(ctx context.Context, kubeconfigPath string, clock utilclock.Clo
 	op.lister.CoreV1().RegisterServiceAccountLister(metav1.NamespaceAll, serviceAccountInformer.Lister())
 	sharedIndexInformers = append(sharedIndexInformers, serviceAccountInformer.Informer())
 
+	// Wire OperatorGroups
+	operatorGroupInformer := crInformerFactory.Operators().V1().OperatorGroups()
+	op.lister.OperatorsV1().RegisterOperatorGroupLister(metav1.NamespaceAll, operatorGroupInformer.Lister())
+	sharedIndexInformers = append(sharedIndexInformers, operatorGroupInformer.Informer())
+
 	// Wire Services
 	serviceInformer := k8sInformerFactory.Core().V1().Services()
 	op.lister.CoreV1().RegisterServiceLister(metav1.NamespaceAll, serviceInformer.Lister())
@@ -404,8 +476,8 @@ func NewOperator(ctx context.Context, kubeconfigPath string, clock utilclock.Clo
 		bundle.WithPodLister(buPodInformer.Lister()),
 		bundle.WithRoleLister(roleInformer.Lister()),
 		bundle.WithRoleBindingLister(roleBindingInformer.Lister()),
-		bundle.WithOPMImage(opmImage),
-		bundle.WithUtilImage(utilImage),
+		bundle.WithOPMImage(config.opmImage),
+		bundle.WithUtilImage(config.utilImage),
 		bundle.WithNow(op.now),
 		bundle.WithUnpackTimeout(op.bundleUnpackTimeout),
 	)
@@ -464,25 +536,40 @@ func (o *Operator) syncSourceState(state grpc.SourceState) {
 
 	switch state.State {
 	case connectivity.Ready:
-		o.resolver.Expire(resolvercache.SourceKey(state.Key))
-		if o.namespace == state.Key.Namespace {
-			namespaces, err := index.CatalogSubscriberNamespaces(o.catalogSubscriberIndexer,
-				state.Key.Name, state.Key.Namespace)
-
-			if err == nil {
-				for ns := range namespaces {
-					o.nsResolveQueue.Add(ns)
-				}
-			}
-		}
-
-		o.nsResolveQueue.Add(state.Key.Namespace)
+		o.catalogContentChanged(resolvercache.SourceKey(state.Key))
 	}
 	if err := o.catsrcQueueSet.Requeue(state.Key.Namespace, state.Key.Name); err != nil {
 		o.logger.WithError(err).Info("couldn't requeue catalogsource from catalog status change")
 	}
 }
 
+// syncFileBasedSource is called when the file-based catalog served for a
+// CatalogSource is loaded, changes or is removed.
+func (o *Operator) syncFileBasedSource(key resolvercache.SourceKey) {
+	o.sourcesLastUpdate.Set(o.now().Time)
+	o.logger.Infof("file-based catalog changed: key.Namespace=%s key.Name=%s", key.Namespace, key.Name)
+	o.catalogContentChanged(key)
+}
+
+// catalogContentChanged expires the cached content of the given catalog and
+// resolves the namespaces that subscribe to it again.
+func (o *Operator) catalogContentChanged(key resolvercache.SourceKey) {
+	o.resolver.Expire(key)
+	o.channelHeads.expire(registry.CatalogKey(key))
+	if o.namespace == key.Namespace {
+		namespaces, err := index.CatalogSubscriberNamespaces(o.catalogSubscriberIndexer,
+			key.Name, key.Namespace)
+
+		if err == nil {
+			for ns := range namespaces {
+				o.nsResolveQueue.Add(ns)
+			}
+		}
+	}
+
+	o.nsResolveQueue.Add(key.Namespace)
+}
+
 func (o *Operator) requeueOwners(obj metav1.Object) {
 	namespace := obj.GetNamespace()
 	logger := o.logger.WithFields(logrus.Fields{
@@ -578,8 +665,16 @@ func (o *Operator) handleCatSrcDeletion(obj interface{}) {
 		o.logger.WithError(err).Warn("error closing client")
 	}
 	o.logger.WithField("source", sourceKey).Info("removed client for deleted catalogsource")
+	o.channelHeads.expire(sourceKey)
+	if o.fileBasedSources != nil {
+		o.fileBasedSources.Remove(resolvercache.SourceKey(sourceKey))
+	}
 
 	metrics.DeleteCatalogSourceStateMetric(catsrc.GetName(), catsrc.GetNamespace())
+	if o.catalogHealth != nil {
+		o.catalogHealth.remove(resolvercache.SourceKey(sourceKey))
+	}
+	metrics.DeleteCatalogSourceHealthMetric(catsrc.GetName(), catsrc.GetNamespace())
 }
 
 func validateSourceType(logger *logrus.Entry, in *v1alpha1.CatalogSource) (out *v1alpha1.CatalogSource, continueSync bool, _ error) {
@@ -594,6 +689,8 @@ func validateSourceType(logger *logrus.Entry, in *v1alpha1.CatalogSource) (out *
 		if out.Spec.Image == "" && out.Spec.Address == "" {
 			err = fmt.Errorf("image and address unset: at least one must be set for sourcetype: %s", sourceType)
 		}
+	case reconciler.SourceTypeFileBased:
+		err = reconciler.ValidateFileBasedCatalogSource(out)
 	default:
 		err = fmt.Errorf("unknown sourcetype: %s", sourceType)
 	}
@@ -698,6 +795,14 @@ func (o *Operator) syncRegistryServer(logger *logrus.Entry, in *v1alpha1.Catalog
 
 	logger.Debugf("check registry server healthy: %t", healthy)
 
+	if recorder, ok := srcReconciler.(reconciler.ImageHistoryRecorder); ok {
+		if recorded, err := recorder.RecordImageHistory(out); err != nil {
+			logger.WithError(err).Warn("error recording catalog image history")
+		} else if recorded {
+			logger.Debug("recorded newly served catalog image digest")
+		}
+	}
+
 	if healthy && in.Status.RegistryServiceStatus != nil {
 		logger.Debug("registry state good")
 		continueSync = true
@@ -860,6 +965,7 @@ func (o *Operator) syncCatalogSources(obj interface{}) (syncError error) {
 	if out == nil {
 		return
 	}
+	o.syncCatalogHealth(out)
 
 	if equalFunc(&catsrc.Status, &out.Status) {
 		return
@@ -873,7 +979,7 @@ func (o *Operator) syncCatalogSources(obj interface{}) (syncError error) {
 	return
 }
 
-func (o *Operator) syncResolvingNamespace(obj interface{}) error {
+func (o *Operator) syncResolvingNamespace(obj interface{}) (syncError error) {
 	ns, ok := obj.(*corev1.Namespace)
 	if !ok {
 		o.logger.Debugf("wrong type: %#v", obj)
@@ -881,12 +987,17 @@ func (o *Operator) syncResolvingNamespace(obj interface{}) error {
 	}
 	namespace := ns.GetName()
 
+	ctx, span := tracing.Start(context.Background(), "syncResolvingNamespace", attribute.String("namespace", namespace))
+	defer func() {
+		tracing.End(span, syncError)
+	}()
+
 	logger := o.logger.WithFields(logrus.Fields{
 		"namespace": namespace,
 		"id":        queueinformer.NewLoopID(),
 	})
 
-	o.gcInstallPlans(logger, namespace)
+	o.gcInstallPlans(logger, ns)
 
 	// get the set of sources that should be used for resolution and best-effort get their connections working
 	logger.Debug("resolving sources")
@@ -932,6 +1043,42 @@ func (o *Operator) syncResolvingNamespace(obj interface{}) error {
 		}
 
 		subscriptionUpdated = subscriptionUpdated || changedCSV
+
+		// report whether the version range the subscription is pinned to holds it back from the channel head
+		sub, changedRange, err := o.ensureSubscriptionVersionRangeState(logger, sub, querier)
+		if err != nil {
+			logger.Debugf("error recording version range state in status: %v", err)
+			return err
+		}
+
+		subscriptionUpdated = subscriptionUpdated || changedRange
+
+		// report whether the catalog deprecates what the subscription installs
+		sub, changedDeprecation, err := o.ensureSubscriptionDeprecationState(logger, sub)
+		if err != nil {
+			logger.Debugf("error recording deprecation state in status: %v", err)
+			return err
+		}
+
+		subscriptionUpdated = subscriptionUpdated || changedDeprecation
+
+		// report whether the operatorcondition of the installed operator holds its upgrade
+		sub, changedUpgradeHeld, err := o.ensureSubscriptionUpgradeHeldState(logger, sub, querier)
+		if err != nil {
+			logger.Debugf("error recording upgrade held state in status: %v", err)
+			return err
+		}
+
+		subscriptionUpdated = subscriptionUpdated || changedUpgradeHeld
+
+		// report whether the installplan of the subscription waits for a maintenance window
+		sub, changedMaintenanceWindow, err := o.ensureSubscriptionMaintenanceWindowState(logger, sub)
+		if err != nil {
+			logger.Debugf("error recording maintenance window state in status: %v", err)
+			return err
+		}
+
+		subscriptionUpdated = subscriptionUpdated || changedMaintenanceWindow
 		subs[i] = sub
 	}
 	if subscriptionUpdated {
@@ -950,8 +1097,32 @@ func (o *Operator) syncResolvingNamespace(obj interface{}) error {
 
 	logger.Debug("resolving subscriptions in namespace")
 
+	if o.resolutionTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.resolutionTimeout)
+		defer cancel()
+	}
+
 	// resolve a set of steps to apply to a cluster, a set of subscriptions to create/update, and any errors
-	steps, bundleLookups, updatedSubs, err := o.resolver.ResolveSteps(namespace)
+	steps, bundleLookups, updatedSubs, err := o.resolver.ResolveSteps(ctx, namespace)
+	if err == solver.Incomplete && ctx.Err() == context.DeadlineExceeded {
+		// Resolution may succeed once catalog content changes, so
+		// the error is returned to retry with backoff rather than
+		// holding up the namespace queue now.
+		logger.WithError(err).Warn("resolution timed out")
+		go o.recorder.Event(ns, corev1.EventTypeWarning, "ResolutionTimedOut", err.Error())
+		metrics.RegisterDependencyResolutionTimeout(namespace)
+		subs = o.setSubsCond(subs, SubscriptionResolutionTimedOut, "ResolutionTimedOut", fmt.Sprintf("dependency resolution did not complete within %s", o.resolutionTimeout), true)
+		_, updateErr := o.updateSubscriptionStatuses(subs)
+		if updateErr != nil {
+			logger.WithError(updateErr).Debug("failed to update subs conditions")
+			return updateErr
+		}
+		return err
+	}
+	for _, sub := range subs {
+		sub.Status.RemoveConditions(SubscriptionResolutionTimedOut)
+	}
 	if err != nil {
 		go o.recorder.Event(ns, corev1.EventTypeWarning, "ResolutionFailed", err.Error())
 		// If the error is constraints not satisfiable, then simply project the
@@ -960,9 +1131,10 @@ func (o *Operator) syncResolvingNamespace(obj interface{}) error {
 		// given not-satisfiable error is terminal and most likely require intervention
 		// from users/admins. Resyncing the namespace again is unlikely to resolve
 		// not-satisfiable error
-		if _, ok := err.(solver.NotSatisfiable); ok {
+		if conflicts, ok := err.(solver.NotSatisfiable); ok {
 			logger.WithError(err).Debug("resolution failed")
-			subs = o.setSubsCond(subs, v1alpha1.SubscriptionResolutionFailed, "ConstraintsNotSatisfiable", err.Error(), true)
+			explanation := resolver.Explain(conflicts, subs)
+			subs = o.setSubsCond(subs, v1alpha1.SubscriptionResolutionFailed, "ConstraintsNotSatisfiable", fmt.Sprintf("constraints not satisfiable:\n%s", explanation), true)
 			_, updateErr := o.updateSubscriptionStatuses(subs)
 			if updateErr != nil {
 				logger.WithError(updateErr).Debug("failed to update subs conditions")
@@ -1011,7 +1183,7 @@ func (o *Operator) syncResolvingNamespace(obj interface{}) error {
 			}
 		}
 
-		installPlanReference, err := o.ensureInstallPlan(logger, namespace, maxGeneration+1, subs, installPlanApproval, steps, bundleLookups)
+		installPlanReference, err := o.ensureInstallPlan(ctx, logger, namespace, maxGeneration+1, subs, installPlanApproval, steps, bundleLookups)
 		if err != nil {
 			logger.WithError(err).Debug("error ensuring installplan")
 			return err
@@ -1043,6 +1215,53 @@ func (o *Operator) syncSubscriptions(obj interface{}) error {
 	return nil
 }
 
+// requeueOperatorConditionNamespace resolves the namespace of an added or
+// deleted OperatorCondition again, since it may hold or release upgrades.
+func (o *Operator) requeueOperatorConditionNamespace(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		o.logger.WithError(err).Debugf("couldn't get key of %#v", obj)
+		return
+	}
+	namespace, _, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		o.logger.WithError(err).Debugf("couldn't split key %q", key)
+		return
+	}
+
+	o.nsResolveQueue.Add(namespace)
+}
+
+// operatorConditionUpdated resolves the namespace of an updated
+// OperatorCondition again if its conditions or the upgrade gates it puts on
+// the operator changed. Other updates, e.g. of conditions reported in its
+// status that don't change the gates, don't affect resolution.
+func (o *Operator) operatorConditionUpdated(oldObj, newObj interface{}) {
+	old, ok := oldObj.(*operatorsv2.OperatorCondition)
+	if !ok {
+		o.logger.Debugf("wrong type: %#v", oldObj)
+		return
+	}
+	cond, ok := newObj.(*operatorsv2.OperatorCondition)
+	if !ok {
+		o.logger.Debugf("wrong type: %#v", newObj)
+		return
+	}
+
+	if !operatorConditionChanged(old, cond) {
+		return
+	}
+
+	o.nsResolveQueue.Add(cond.GetNamespace())
+}
+
+// operatorConditionChanged returns true if the update of an OperatorCondition
+// changed its spec conditions or its upgrade gates.
+func operatorConditionChanged(old, cond *operatorsv2.OperatorCondition) bool {
+	return !reflect.DeepEqual(old.Spec.Conditions, cond.Spec.Conditions) ||
+		!operatorcondition.GatesFrom(old).Equal(operatorcondition.GatesFrom(cond))
+}
+
 func (o *Operator) nothingToUpdate(logger *logrus.Entry, sub *v1alpha1.Subscription) bool {
 	if sub.Status.InstallPlanRef != nil && sub.Status.State == v1alpha1.SubscriptionStateUpgradePending {
 		logger.Debugf("skipping update: installplan already created")
@@ -1128,6 +1347,193 @@ func (o *Operator) ensureSubscriptionCSVState(logger *logrus.Entry, sub *v1alpha
 	return updatedSub, true, nil
 }
 
+func (o *Operator) ensureSubscriptionVersionRangeState(logger *logrus.Entry, sub *v1alpha1.Subscription, querier SourceQuerier) (*v1alpha1.Subscription, bool, error) {
+	out := sub.DeepCopy()
+
+	// An invalid range is reported as a resolution failure.
+	versionRange, versionRangeStr, _ := resolver.SubscriptionVersionRange(sub)
+	if versionRange == nil {
+		out.Status.RemoveConditions(SubscriptionChannelHeadOutOfRange)
+	} else {
+		head, err := o.channelHeads.get(querier, sub)
+		var notFound ChannelHeadNotFoundError
+		if err != nil && !errors.As(err, &notFound) {
+			logger.WithError(err).Debug("unable to determine channel head")
+			return sub, false, nil
+		}
+		var version semver.Version
+		if head != nil {
+			version, err = semver.Parse(head.GetVersion())
+			if err != nil {
+				logger.WithError(err).WithField("bundle", head.GetCsvName()).Debug("unable to determine version of channel head")
+				return sub, false, nil
+			}
+		}
+
+		switch {
+		case head == nil:
+			// Without a channel head, whether it is in range is unknown.
+			cond := out.Status.GetCondition(SubscriptionChannelHeadOutOfRange)
+			if cond.Status != corev1.ConditionUnknown || cond.Reason == "" {
+				now := o.now()
+				cond.LastTransitionTime = &now
+			}
+			cond.Status = corev1.ConditionUnknown
+			cond.Reason = "ChannelNotFound"
+			if notFound.Empty {
+				cond.Reason = "ChannelEmpty"
+			}
+			cond.Message = notFound.Error()
+			out.Status.SetCondition(cond)
+		case versionRange(version):
+			out.Status.RemoveConditions(SubscriptionChannelHeadOutOfRange)
+		default:
+			cond := out.Status.GetCondition(SubscriptionChannelHeadOutOfRange)
+			if cond.Status != corev1.ConditionTrue {
+				now := o.now()
+				cond.LastTransitionTime = &now
+			}
+			cond.Status = corev1.ConditionTrue
+			cond.Reason = "VersionRangeExcludesChannelHead"
+			cond.Message = fmt.Sprintf("channel head %s (version %s) is outside of version range %s, upgrades are limited to versions within the range", head.GetCsvName(), version, versionRangeStr)
+			out.Status.SetCondition(cond)
+		}
+	}
+
+	if reflect.DeepEqual(sub.Status.Conditions, out.Status.Conditions) {
+		return sub, false, nil
+	}
+	out.Status.LastUpdated = o.now()
+
+	updatedSub, err := o.client.OperatorsV1alpha1().Subscriptions(out.GetNamespace()).UpdateStatus(context.TODO(), out, metav1.UpdateOptions{})
+	if err != nil {
+		logger.WithError(err).Info("error updating subscription status")
+		return nil, false, fmt.Errorf("error updating Subscription status: " + err.Error())
+	}
+
+	return updatedSub, true, nil
+}
+
+func (o *Operator) ensureSubscriptionDeprecationState(logger *logrus.Entry, sub *v1alpha1.Subscription) (*v1alpha1.Subscription, bool, error) {
+	deprecations, err := o.resolver.Deprecations(sub)
+	if err != nil {
+		// The condition is left as is until the catalog can be read again.
+		logger.WithError(err).Debug("unable to determine deprecations")
+		return sub, false, nil
+	}
+
+	out := sub.DeepCopy()
+	if !deprecations.Deprecated() {
+		out.Status.RemoveConditions(SubscriptionDeprecated)
+	} else {
+		var reason string
+		var messages []string
+		for _, d := range []struct {
+			reason, message string
+		}{
+			{"PackageDeprecated", deprecations.Package},
+			{"ChannelDeprecated", deprecations.Channel},
+			{"BundleDeprecated", deprecations.Bundle},
+		} {
+			if d.message == "" {
+				continue
+			}
+			// the broadest deprecation gives the reason
+			if reason == "" {
+				reason = d.reason
+			}
+			messages = append(messages, d.message)
+		}
+
+		cond := out.Status.GetCondition(SubscriptionDeprecated)
+		if cond.Status != corev1.ConditionTrue {
+			now := o.now()
+			cond.LastTransitionTime = &now
+		}
+		cond.Status = corev1.ConditionTrue
+		cond.Reason = reason
+		cond.Message = strings.Join(messages, "\n")
+		out.Status.SetCondition(cond)
+	}
+
+	if reflect.DeepEqual(sub.Status.Conditions, out.Status.Conditions) {
+		return sub, false, nil
+	}
+	out.Status.LastUpdated = o.now()
+
+	updatedSub, err := o.client.OperatorsV1alpha1().Subscriptions(out.GetNamespace()).UpdateStatus(context.TODO(), out, metav1.UpdateOptions{})
+	if err != nil {
+		logger.WithError(err).Info("error updating subscription status")
+		return nil, false, fmt.Errorf("error updating Subscription status: " + err.Error())
+	}
+
+	return updatedSub, true, nil
+}
+
+func (o *Operator) ensureSubscriptionUpgradeHeldState(logger *logrus.Entry, sub *v1alpha1.Subscription, querier SourceQuerier) (*v1alpha1.Subscription, bool, error) {
+	out := sub.DeepCopy()
+
+	if sub.Status.InstalledCSV == "" {
+		out.Status.RemoveConditions(SubscriptionUpgradeHeld)
+	} else {
+		gates, err := operatorcondition.GatesFor(o.lister.OperatorsV2().OperatorConditionLister(), sub.GetNamespace(), sub.Status.InstalledCSV)
+		if err != nil {
+			logger.WithError(err).Debug("unable to determine upgrade gates")
+			return sub, false, nil
+		}
+		head, err := o.channelHeads.get(querier, sub)
+		if err != nil {
+			logger.WithError(err).Debug("unable to determine channel head")
+			return sub, false, nil
+		}
+		version, err := semver.Parse(head.GetVersion())
+		if err != nil {
+			logger.WithError(err).WithField("bundle", head.GetCsvName()).Debug("unable to determine version of channel head")
+			return sub, false, nil
+		}
+
+		// nothing is held once the channel head is installed
+		var explanation string
+		if head.GetCsvName() != sub.Status.InstalledCSV {
+			explanation = gates.Explain(version)
+		}
+		if explanation == "" {
+			out.Status.RemoveConditions(SubscriptionUpgradeHeld)
+		} else {
+			reason := "VersionRangeExcludesChannelHead"
+			switch {
+			case gates.NotUpgradeable != "":
+				reason = "NotUpgradeable"
+			case len(gates.PendingMigrations) > 0:
+				reason = "MigrationPending"
+			}
+
+			cond := out.Status.GetCondition(SubscriptionUpgradeHeld)
+			if cond.Status != corev1.ConditionTrue {
+				now := o.now()
+				cond.LastTransitionTime = &now
+			}
+			cond.Status = corev1.ConditionTrue
+			cond.Reason = reason
+			cond.Message = fmt.Sprintf("upgrade from %s to channel head %s (version %s) is held: %s", sub.Status.InstalledCSV, head.GetCsvName(), version, explanation)
+			out.Status.SetCondition(cond)
+		}
+	}
+
+	if reflect.DeepEqual(sub.Status.Conditions, out.Status.Conditions) {
+		return sub, false, nil
+	}
+	out.Status.LastUpdated = o.now()
+
+	updatedSub, err := o.client.OperatorsV1alpha1().Subscriptions(out.GetNamespace()).UpdateStatus(context.TODO(), out, metav1.UpdateOptions{})
+	if err != nil {
+		logger.WithError(err).Info("error updating subscription status")
+		return nil, false, fmt.Errorf("error updating Subscription status: " + err.Error())
+	}
+
+	return updatedSub, true, nil
+}
+
 func (o *Operator) setIPReference(subs []*v1alpha1.Subscription, gen int, installPlanRef *corev1.ObjectReference) []*v1alpha1.Subscription {
 	var (
 		lastUpdated = o.now()
@@ -1144,7 +1550,7 @@ func (o *Operator) setIPReference(subs []*v1alpha1.Subscription, gen int, instal
 	return subs
 }
 
-func (o *Operator) ensureInstallPlan(logger *logrus.Entry, namespace string, gen int, subs []*v1alpha1.Subscription, installPlanApproval v1alpha1.Approval, steps []*v1alpha1.Step, bundleLookups []v1alpha1.BundleLookup) (*corev1.ObjectReference, error) {
+func (o *Operator) ensureInstallPlan(ctx context.Context, logger *logrus.Entry, namespace string, gen int, subs []*v1alpha1.Subscription, installPlanApproval v1alpha1.Approval, steps []*v1alpha1.Step, bundleLookups []v1alpha1.BundleLookup) (*corev1.ObjectReference, error) {
 	if len(steps) == 0 && len(bundleLookups) == 0 {
 		return nil, nil
 	}
@@ -1162,10 +1568,10 @@ func (o *Operator) ensureInstallPlan(logger *logrus.Entry, namespace string, gen
 	}
 	logger.Warn("no installplan found with matching generation, creating new one")
 
-	return o.createInstallPlan(namespace, gen, subs, installPlanApproval, steps, bundleLookups)
+	return o.createInstallPlan(ctx, namespace, gen, subs, installPlanApproval, steps, bundleLookups)
 }
 
-func (o *Operator) createInstallPlan(namespace string, gen int, subs []*v1alpha1.Subscription, installPlanApproval v1alpha1.Approval, steps []*v1alpha1.Step, bundleLookups []v1alpha1.BundleLookup) (*corev1.ObjectReference, error) {
+func (o *Operator) createInstallPlan(ctx context.Context, namespace string, gen int, subs []*v1alpha1.Subscription, installPlanApproval v1alpha1.Approval, steps []*v1alpha1.Step, bundleLookups []v1alpha1.BundleLookup) (*corev1.ObjectReference, error) {
 	if len(steps) == 0 && len(bundleLookups) == 0 {
 		return nil, nil
 	}
@@ -1187,9 +1593,19 @@ func (o *Operator) createInstallPlan(namespace string, gen int, subs []*v1alpha1
 	}
 
 	phase := v1alpha1.InstallPlanPhaseInstalling
+	approved := installPlanApproval == v1alpha1.ApprovalAutomatic
 	if installPlanApproval == v1alpha1.ApprovalManual {
 		phase = v1alpha1.InstallPlanPhaseRequiresApproval
 	}
+	// automatic approval of upgrades waits for the maintenance window, if any
+	var window map[string]string
+	if approved && o.upgradesInstalledOperator(namespace, steps, bundleLookups) {
+		window = o.maintenanceWindowAnnotations(o.logger.WithField("namespace", namespace), namespace, subs)
+		if window != nil {
+			phase = v1alpha1.InstallPlanPhaseRequiresApproval
+			approved = false
+		}
+	}
 	ip := &v1alpha1.InstallPlan{
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: "install-",
@@ -1198,13 +1614,24 @@ func (o *Operator) createInstallPlan(namespace string, gen int, subs []*v1alpha1
 		Spec: v1alpha1.InstallPlanSpec{
 			ClusterServiceVersionNames: csvNames,
 			Approval:                   installPlanApproval,
-			Approved:                   installPlanApproval == v1alpha1.ApprovalAutomatic,
+			Approved:                   approved,
 			Generation:                 gen,
 		},
 	}
 	for _, sub := range subs {
 		ownerutil.AddNonBlockingOwner(ip, sub)
+		if rollbackEnabled(sub) {
+			metav1.SetMetaDataAnnotation(&ip.ObjectMeta, RollbackOnFailureAnnotationKey, "true")
+		}
+		if dryRunEnabled(sub) {
+			metav1.SetMetaDataAnnotation(&ip.ObjectMeta, DryRunAnnotationKey, "true")
+		}
+	}
+	for key, value := range window {
+		metav1.SetMetaDataAnnotation(&ip.ObjectMeta, key, value)
 	}
+	// work on the plan, in this operator and in olm operator, joins the trace of the resolution
+	tracing.Inject(ctx, &ip.ObjectMeta)
 
 	res, err := o.client.OperatorsV1alpha1().InstallPlans(namespace).Create(context.TODO(), ip, metav1.CreateOptions{})
 	if err != nil {
@@ -1291,9 +1718,19 @@ type UnpackedBundleReference struct {
 }
 
 // unpackBundles makes one walk through the bundlelookups and attempts to progress them
-func (o *Operator) unpackBundles(plan *v1alpha1.InstallPlan) (bool, *v1alpha1.InstallPlan, error) {
-	out := plan.DeepCopy()
-	unpacked := true
+func (o *Operator) unpackBundles(plan *v1alpha1.InstallPlan) (unpacked bool, out *v1alpha1.InstallPlan, err error) {
+	_, span := tracing.Start(tracing.Extract(context.Background(), plan), "unpackBundles",
+		attribute.String("namespace", plan.GetNamespace()),
+		attribute.String("installplan", plan.GetName()),
+		attribute.Int("bundles", len(plan.Status.BundleLookups)),
+	)
+	defer func() {
+		span.SetAttributes(attribute.Bool("unpacked", unpacked))
+		tracing.End(span, err)
+	}()
+
+	out = plan.DeepCopy()
+	unpacked = true
 
 	// The bundle timeout annotation if specified overrides the --bundle-unpack-timeout flag value
 	// If the timeout cannot be parsed it's set to < 0 and subsequently ignored
@@ -1379,68 +1816,77 @@ func (o *Operator) unpackBundles(plan *v1alpha1.InstallPlan) (bool, *v1alpha1.In
 	return unpacked, out, nil
 }
 
-// gcInstallPlans garbage collects installplans that are too old
+// gcInstallPlans garbage collects installplans according to the namespace's retention policy.
 // installplans are ownerrefd to all subscription inputs, so they will not otherwise
 // be GCd unless all inputs have been deleted.
-func (o *Operator) gcInstallPlans(log logrus.FieldLogger, namespace string) {
+// installplans that are referenced by a subscription or awaiting approval are never deleted.
+func (o *Operator) gcInstallPlans(log logrus.FieldLogger, ns *corev1.Namespace) {
+	namespace := ns.GetName()
 	allIps, err := o.lister.OperatorsV1alpha1().InstallPlanLister().InstallPlans(namespace).List(labels.Everything())
 	if err != nil {
 		log.Warn("unable to list installplans for GC")
+		return
 	}
 
-	if len(allIps) <= maxInstallPlanCount {
+	policy := o.installPlanRetentionPolicy(log, ns)
+	if policy.maxAge == 0 && len(allIps) <= policy.count {
 		return
 	}
 
-	// we only consider maxDeletesPerSweep more than the allowed number of installplans for delete at one time
-	ips := allIps
-	if len(ips) > maxInstallPlanCount+maxDeletesPerSweep {
-		ips = allIps[:maxInstallPlanCount+maxDeletesPerSweep]
+	referenced, err := o.referencedInstallPlans(namespace)
+	if err != nil {
+		log.WithError(err).Warn("unable to list subscriptions for installplan GC")
+		return
 	}
 
-	byGen := map[int][]*v1alpha1.InstallPlan{}
-	for _, ip := range ips {
-		gen, ok := byGen[ip.Spec.Generation]
-		if !ok {
-			gen = make([]*v1alpha1.InstallPlan, 0)
+	// retained installplans count towards the maximum, but are never deleted
+	retained := 0
+	candidates := make([]*v1alpha1.InstallPlan, 0)
+	for _, ip := range allIps {
+		if policy.keepFailed && ip.Status.Phase == v1alpha1.InstallPlanPhaseFailed {
+			continue
 		}
-		byGen[ip.Spec.Generation] = append(gen, ip)
-	}
-
-	gens := make([]int, 0)
-	for i := range byGen {
-		gens = append(gens, i)
+		if _, ok := referenced[ip.GetName()]; ok || ip.Status.Phase == v1alpha1.InstallPlanPhaseRequiresApproval {
+			retained++
+			continue
+		}
+		candidates = append(candidates, ip)
 	}
 
-	sort.Ints(gens)
+	// sort newest first, by generation and then by creation time
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Spec.Generation != b.Spec.Generation {
+			return a.Spec.Generation > b.Spec.Generation
+		}
+		// CreationTimestamp sorting shouldn't ever be hit unless there is a bug that causes installplans to be
+		// generated without bumping the generation. It is here as a safeguard only.
+		if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+			return b.CreationTimestamp.Before(&a.CreationTimestamp)
+		}
+		// final fallback to lexicographic sort, in case many installplans are created with the same timestamp
+		return a.GetName() > b.GetName()
+	})
 
+	keep := policy.count - retained
+	now := o.now()
 	toDelete := make([]*v1alpha1.InstallPlan, 0)
-
-	for _, i := range gens {
-		g := byGen[i]
-
-		if len(ips)-len(toDelete) <= maxInstallPlanCount {
-			break
+	for i := len(candidates) - 1; i >= 0 && len(toDelete) < maxDeletesPerSweep; i-- {
+		ip := candidates[i]
+		expired := policy.maxAge > 0 && !ip.CreationTimestamp.IsZero() && now.Sub(ip.CreationTimestamp.Time) > policy.maxAge
+		if i >= keep || expired {
+			toDelete = append(toDelete, ip)
 		}
+	}
+	if len(toDelete) == 0 {
+		return
+	}
 
-		// if removing all installplans at this generation doesn't dip below the max, safe to delete all of them
-		if len(ips)-len(toDelete)-len(g) >= maxInstallPlanCount {
-			toDelete = append(toDelete, g...)
-			continue
+	if policy.archive {
+		if err := o.archiveInstallPlans(namespace, toDelete); err != nil {
+			log.WithError(err).Warn("unable to archive installplans, skipping GC")
+			return
 		}
-
-		// CreationTimestamp sorting shouldn't ever be hit unless there is a bug that causes installplans to be
-		// generated without bumping the generation. It is here as a safeguard only.
-
-		// sort by creation time
-		sort.Slice(g, func(i, j int) bool {
-			if !g[i].CreationTimestamp.Equal(&g[j].CreationTimestamp) {
-				return g[i].CreationTimestamp.Before(&g[j].CreationTimestamp)
-			}
-			// final fallback to lexicographic sort, in case many installplans are created with the same timestamp
-			return g[i].GetName() < g[j].GetName()
-		})
-		toDelete = append(toDelete, g[:len(ips)-len(toDelete)-maxInstallPlanCount]...)
 	}
 
 	for _, i := range toDelete {
@@ -1471,6 +1917,12 @@ func (o *Operator) syncInstallPlans(obj interface{}) (syncError error) {
 		return
 	}
 
+	// A completed plan that opted into rollback is reverted if its CSVs do not succeed
+	if plan.Status.Phase == v1alpha1.InstallPlanPhaseComplete && rollbackEnabled(plan) {
+		syncError = o.syncInstallPlanRollback(plan, logger)
+		return
+	}
+
 	// Complete and Failed are terminal phases
 	if plan.Status.Phase == v1alpha1.InstallPlanPhaseFailed || plan.Status.Phase == v1alpha1.InstallPlanPhaseComplete {
 		return
@@ -1576,12 +2028,49 @@ func (o *Operator) syncInstallPlans(obj interface{}) (syncError error) {
 		}
 	}
 
+	// Check and dry-run plans awaiting approval, so that their results can be reviewed before approving them
+	if plan.Status.Phase == v1alpha1.InstallPlanPhaseRequiresApproval && !plan.Spec.Approved {
+		preflight := needsPreflight(plan)
+		dryRun := dryRunEnabled(plan) && plan.Status.GetCondition(InstallPlanDryRun).Status == corev1.ConditionUnknown
+		if preflight || dryRun {
+			out := plan.DeepCopy()
+			var errs []error
+			if preflight {
+				if err := o.preflightInstallPlan(out, logger); err != nil {
+					errs = append(errs, fmt.Errorf("InstallPlan preflight checks incomplete: %v", err))
+				}
+			}
+			if dryRun {
+				if err := o.dryRunInstallPlan(out, logger); err != nil {
+					errs = append(errs, fmt.Errorf("InstallPlan dry-run failed: %v", err))
+					syncError = utilerrors.NewAggregate(errs)
+					return
+				}
+			}
+			if _, err := o.client.OperatorsV1alpha1().InstallPlans(out.GetNamespace()).UpdateStatus(context.TODO(), out, metav1.UpdateOptions{}); err != nil {
+				errs = append(errs, fmt.Errorf("error updating InstallPlan status: %v", err))
+			}
+			syncError = utilerrors.NewAggregate(errs)
+			return
+		}
+	}
+
+	// Approve plans held for their maintenance window once it is open
+	if held, err := o.syncInstallPlanMaintenanceWindow(plan, logger); held {
+		syncError = err
+		return
+	}
+
 	outInstallPlan, syncError := transitionInstallPlanState(logger.Logger, o, *plan, o.now(), o.installPlanTimeout)
 
 	if syncError != nil {
 		logger = logger.WithField("syncError", syncError)
 	}
 
+	if outInstallPlan.Status.Phase == v1alpha1.InstallPlanPhaseFailed && plan.Status.Phase == v1alpha1.InstallPlanPhaseInstalling && rollbackEnabled(plan) {
+		o.transitionInstallPlanToRolledBack(outInstallPlan, logger, outInstallPlan.Status.Message)
+	}
+
 	if outInstallPlan.Status.Phase == v1alpha1.InstallPlanPhaseInstalling {
 		defer o.ipQueueSet.RequeueAfter(outInstallPlan.GetNamespace(), outInstallPlan.GetName(), time.Second*5)
 	}
@@ -1820,12 +2309,20 @@ func (wr *warningRecorder) PopWarnings() []string {
 
 // ExecutePlan applies a planned InstallPlan to a namespace.
 func (o *Operator) ExecutePlan(plan *v1alpha1.InstallPlan) error {
+	ctx, span := tracing.Start(tracing.Extract(context.Background(), plan), "ExecutePlan",
+		attribute.String("namespace", plan.GetNamespace()),
+		attribute.String("installplan", plan.GetName()),
+	)
+	err := o.executePlan(ctx, plan)
+	tracing.End(span, err)
+	return err
+}
+
+func (o *Operator) executePlan(ctx context.Context, plan *v1alpha1.InstallPlan) error {
 	if plan.Status.Phase != v1alpha1.InstallPlanPhaseInstalling {
 		panic("attempted to install a plan that wasn't in the installing phase")
 	}
 
-	namespace := plan.GetNamespace()
-
 	// Get the set of initial installplan csv names
 	initialCSVNames := getCSVNameSet(plan)
 	// Get pre-existing CRD owners to make decisions about applying resolved CSVs
@@ -1862,14 +2359,11 @@ func (o *Operator) ExecutePlan(plan *v1alpha1.InstallPlan) error {
 		return err
 	}
 
-	ensurer := newStepEnsurer(kubeclient, crclient, dynamicClient)
 	r := newManifestResolver(plan.GetNamespace(), o.lister.CoreV1().ConfigMapLister(), o.logger)
 
 	discoveryQuerier := newDiscoveryQuerier(o.opClient.KubernetesInterface().Discovery())
 
 	// CRDs should be installed via the default OLM (cluster-admin) client and not the scoped client specified by the AttenuatedServiceAccount
-	// the StepBuilder is currently only implemented for CRD types
-	// TODO give the StepBuilder both OLM and scoped clients when it supports new scoped types
 	builderKubeClient, err := factory.NewOperatorClient()
 	if err != nil {
 		o.logger.Errorf("failed to get a client for plan execution- %v", err)
@@ -1881,9 +2375,27 @@ func (o *Operator) ExecutePlan(plan *v1alpha1.InstallPlan) error {
 		return err
 	}
 	b := newBuilder(plan, o.lister.OperatorsV1alpha1().ClusterServiceVersionLister(), builderKubeClient, builderDynamicClient, r, o.logger)
+	targets := stepTargetClients{operator: builderDynamicClient, scoped: dynamicClient}
+	b.execution = &planExecution{
+		operator:          o,
+		ensurer:           newStepEnsurer(kubeclient, crclient, dynamicClient),
+		dynamicClient:     dynamicClient,
+		initialCSVNames:   initialCSVNames,
+		existingCRDOwners: existingCRDOwners,
+	}
 
 	for i, step := range plan.Status.Plan {
-		if err := func(i int, step *v1alpha1.Step) error {
+		if err := func(i int, step *v1alpha1.Step) (stepErr error) {
+			ctx, span := tracing.Start(ctx, "ExecutePlan step",
+				attribute.String("kind", step.Resource.Kind),
+				attribute.String("name", step.Resource.Name),
+				attribute.String("resolving", step.Resolving),
+			)
+			defer func() {
+				span.SetAttributes(attribute.String("status", string(plan.Status.Plan[i].Status)))
+				tracing.End(span, stepErr)
+			}()
+
 			wr.PopWarnings()
 			defer func() {
 				warnings := wr.PopWarnings()
@@ -1906,365 +2418,22 @@ func (o *Operator) ExecutePlan(plan *v1alpha1.InstallPlan) error {
 				metrics.EmitInstallPlanWarning()
 			}()
 
-			doStep := true
-			s, err := b.create(*step)
-			if err != nil {
-				if _, ok := err.(notSupportedStepperErr); ok {
-					// stepper not implemented for this type yet
-					// stepper currently only implemented for CRD types
-					doStep = false
-				} else {
+			if rollbackEnabled(plan) && (step.Status == v1alpha1.StepStatusUnknown || step.Status == v1alpha1.StepStatusNotPresent) {
+				if err := o.recordStepTarget(plan, i, step, targets); err != nil {
 					return err
 				}
 			}
-			if doStep {
-				status, err := s.Status()
-				if err != nil {
-					return err
-				}
-				plan.Status.Plan[i].Status = status
-				return nil
-			}
 
-			switch step.Status {
-			case v1alpha1.StepStatusPresent, v1alpha1.StepStatusCreated, v1alpha1.StepStatusWaitingForAPI:
-				return nil
-			case v1alpha1.StepStatusUnknown, v1alpha1.StepStatusNotPresent:
-				manifest, err := r.ManifestForStep(step)
-				if err != nil {
-					return err
-				}
-				o.logger.WithFields(logrus.Fields{"kind": step.Resource.Kind, "name": step.Resource.Name}).Debug("execute resource")
-				switch step.Resource.Kind {
-				case v1alpha1.ClusterServiceVersionKind:
-					// Marshal the manifest into a CSV instance.
-					var csv v1alpha1.ClusterServiceVersion
-					err := json.Unmarshal([]byte(manifest), &csv)
-					if err != nil {
-						return errorwrap.Wrapf(err, "error parsing step manifest: %s", step.Resource.Name)
-					}
-
-					// Check if the resolved CSV is in the initial set
-					if _, ok := initialCSVNames[csv.GetName()]; !ok {
-						// Check for pre-existing CSVs that own the same CRDs
-						competingOwners, err := competingCRDOwnersExist(plan.GetNamespace(), &csv, existingCRDOwners)
-						if err != nil {
-							return errorwrap.Wrapf(err, "error checking crd owners for: %s", csv.GetName())
-						}
-
-						// TODO: decide on fail/continue logic for pre-existing dependent CSVs that own the same CRD(s)
-						if competingOwners {
-							// For now, error out
-							return fmt.Errorf("pre-existing CRD owners found for owned CRD(s) of dependent CSV %s", csv.GetName())
-						}
-					}
-
-					// Attempt to create the CSV.
-					csv.SetNamespace(namespace)
-
-					status, err := ensurer.EnsureClusterServiceVersion(&csv)
-					if err != nil {
-						return err
-					}
-
-					plan.Status.Plan[i].Status = status
-
-				case v1alpha1.SubscriptionKind:
-					// Marshal the manifest into a subscription instance.
-					var sub v1alpha1.Subscription
-					err := json.Unmarshal([]byte(manifest), &sub)
-					if err != nil {
-						return errorwrap.Wrapf(err, "error parsing step manifest: %s", step.Resource.Name)
-					}
-
-					// Add the InstallPlan's name as an annotation
-					if annotations := sub.GetAnnotations(); annotations != nil {
-						annotations[generatedByKey] = plan.GetName()
-					} else {
-						sub.SetAnnotations(map[string]string{generatedByKey: plan.GetName()})
-					}
-
-					// Attempt to create the Subscription
-					sub.SetNamespace(namespace)
-
-					status, err := ensurer.EnsureSubscription(&sub)
-					if err != nil {
-						return err
-					}
-
-					plan.Status.Plan[i].Status = status
-
-				case resolver.BundleSecretKind:
-					var s corev1.Secret
-					err := json.Unmarshal([]byte(manifest), &s)
-					if err != nil {
-						return errorwrap.Wrapf(err, "error parsing step manifest: %s", step.Resource.Name)
-					}
-
-					// add ownerrefs on the secret that point to the CSV in the bundle
-					if step.Resolving != "" {
-						owner := &v1alpha1.ClusterServiceVersion{}
-						owner.SetNamespace(plan.GetNamespace())
-						owner.SetName(step.Resolving)
-						ownerutil.AddNonBlockingOwner(&s, owner)
-					}
-
-					// Update UIDs on all CSV OwnerReferences
-					updated, err := o.getUpdatedOwnerReferences(s.OwnerReferences, plan.Namespace)
-					if err != nil {
-						return errorwrap.Wrapf(err, "error generating ownerrefs for secret %s", s.GetName())
-					}
-					s.SetOwnerReferences(updated)
-					s.SetNamespace(namespace)
-
-					status, err := ensurer.EnsureBundleSecret(plan.Namespace, &s)
-					if err != nil {
-						return err
-					}
-
-					plan.Status.Plan[i].Status = status
-
-				case secretKind:
-					status, err := ensurer.EnsureSecret(o.namespace, plan.GetNamespace(), step.Resource.Name)
-					if err != nil {
-						return err
-					}
-
-					plan.Status.Plan[i].Status = status
-
-				case clusterRoleKind:
-					// Marshal the manifest into a ClusterRole instance.
-					var cr rbacv1.ClusterRole
-					err := json.Unmarshal([]byte(manifest), &cr)
-					if err != nil {
-						return errorwrap.Wrapf(err, "error parsing step manifest: %s", step.Resource.Name)
-					}
-
-					status, err := ensurer.EnsureClusterRole(&cr, step)
-					if err != nil {
-						return err
-					}
-
-					plan.Status.Plan[i].Status = status
-
-				case clusterRoleBindingKind:
-					// Marshal the manifest into a RoleBinding instance.
-					var rb rbacv1.ClusterRoleBinding
-					err := json.Unmarshal([]byte(manifest), &rb)
-					if err != nil {
-						return errorwrap.Wrapf(err, "error parsing step manifest: %s", step.Resource.Name)
-					}
-
-					status, err := ensurer.EnsureClusterRoleBinding(&rb, step)
-					if err != nil {
-						return err
-					}
-
-					plan.Status.Plan[i].Status = status
-
-				case roleKind:
-					// Marshal the manifest into a Role instance.
-					var r rbacv1.Role
-					err := json.Unmarshal([]byte(manifest), &r)
-					if err != nil {
-						return errorwrap.Wrapf(err, "error parsing step manifest: %s", step.Resource.Name)
-					}
-
-					// Update UIDs on all CSV OwnerReferences
-					updated, err := o.getUpdatedOwnerReferences(r.OwnerReferences, plan.Namespace)
-					if err != nil {
-						return errorwrap.Wrapf(err, "error generating ownerrefs for role %s", r.GetName())
-					}
-					r.SetOwnerReferences(updated)
-					r.SetNamespace(namespace)
-
-					status, err := ensurer.EnsureRole(plan.Namespace, &r)
-					if err != nil {
-						return err
-					}
-
-					plan.Status.Plan[i].Status = status
-
-				case roleBindingKind:
-					// Marshal the manifest into a RoleBinding instance.
-					var rb rbacv1.RoleBinding
-					err := json.Unmarshal([]byte(manifest), &rb)
-					if err != nil {
-						return errorwrap.Wrapf(err, "error parsing step manifest: %s", step.Resource.Name)
-					}
-
-					// Update UIDs on all CSV OwnerReferences
-					updated, err := o.getUpdatedOwnerReferences(rb.OwnerReferences, plan.Namespace)
-					if err != nil {
-						return errorwrap.Wrapf(err, "error generating ownerrefs for rolebinding %s", rb.GetName())
-					}
-					rb.SetOwnerReferences(updated)
-					rb.SetNamespace(namespace)
-
-					status, err := ensurer.EnsureRoleBinding(plan.Namespace, &rb)
-					if err != nil {
-						return err
-					}
-
-					plan.Status.Plan[i].Status = status
-
-				case serviceAccountKind:
-					// Marshal the manifest into a ServiceAccount instance.
-					var sa corev1.ServiceAccount
-					err := json.Unmarshal([]byte(manifest), &sa)
-					if err != nil {
-						return errorwrap.Wrapf(err, "error parsing step manifest: %s", step.Resource.Name)
-					}
-
-					// Update UIDs on all CSV OwnerReferences
-					updated, err := o.getUpdatedOwnerReferences(sa.OwnerReferences, plan.Namespace)
-					if err != nil {
-						return errorwrap.Wrapf(err, "error generating ownerrefs for service account: %s", sa.GetName())
-					}
-					sa.SetOwnerReferences(updated)
-					sa.SetNamespace(namespace)
-
-					status, err := ensurer.EnsureServiceAccount(namespace, &sa)
-					if err != nil {
-						return err
-					}
-
-					plan.Status.Plan[i].Status = status
-
-				case serviceKind:
-					// Marshal the manifest into a Service instance
-					var s corev1.Service
-					err := json.Unmarshal([]byte(manifest), &s)
-					if err != nil {
-						return errorwrap.Wrapf(err, "error parsing step manifest: %s", step.Resource.Name)
-					}
-
-					// add ownerrefs on the service that point to the CSV in the bundle
-					if step.Resolving != "" {
-						owner := &v1alpha1.ClusterServiceVersion{}
-						owner.SetNamespace(plan.GetNamespace())
-						owner.SetName(step.Resolving)
-						ownerutil.AddNonBlockingOwner(&s, owner)
-					}
-
-					// Update UIDs on all CSV OwnerReferences
-					updated, err := o.getUpdatedOwnerReferences(s.OwnerReferences, plan.Namespace)
-					if err != nil {
-						return errorwrap.Wrapf(err, "error generating ownerrefs for service: %s", s.GetName())
-					}
-					s.SetOwnerReferences(updated)
-					s.SetNamespace(namespace)
-
-					status, err := ensurer.EnsureService(namespace, &s)
-					if err != nil {
-						return err
-					}
-
-					plan.Status.Plan[i].Status = status
-
-				case configMapKind:
-					var cfg corev1.ConfigMap
-					err := json.Unmarshal([]byte(manifest), &cfg)
-					if err != nil {
-						return errorwrap.Wrapf(err, "error parsing step manifest: %s", step.Resource.Name)
-					}
-
-					// add ownerrefs on the configmap that point to the CSV in the bundle
-					if step.Resolving != "" {
-						owner := &v1alpha1.ClusterServiceVersion{}
-						owner.SetNamespace(plan.GetNamespace())
-						owner.SetName(step.Resolving)
-						ownerutil.AddNonBlockingOwner(&cfg, owner)
-					}
-
-					// Update UIDs on all CSV OwnerReferences
-					updated, err := o.getUpdatedOwnerReferences(cfg.OwnerReferences, plan.Namespace)
-					if err != nil {
-						return errorwrap.Wrapf(err, "error generating ownerrefs for configmap: %s", cfg.GetName())
-					}
-					cfg.SetOwnerReferences(updated)
-					cfg.SetNamespace(namespace)
-
-					status, err := ensurer.EnsureConfigMap(plan.Namespace, &cfg)
-					if err != nil {
-						return err
-					}
-
-					plan.Status.Plan[i].Status = status
-
-				default:
-					if !isSupported(step.Resource.Kind) {
-						// Not a supported resource
-						plan.Status.Plan[i].Status = v1alpha1.StepStatusUnsupportedResource
-						return v1alpha1.ErrInvalidInstallPlan
-					}
-
-					// Marshal the manifest into an unstructured object
-					dec := yaml.NewYAMLOrJSONDecoder(strings.NewReader(manifest), 10)
-					unstructuredObject := &unstructured.Unstructured{}
-					if err := dec.Decode(unstructuredObject); err != nil {
-						return errorwrap.Wrapf(err, "error decoding %s object to an unstructured object", step.Resource.Name)
-					}
-
-					// Get the resource from the GVK.
-					gvk := unstructuredObject.GroupVersionKind()
-					r, err := o.apiresourceFromGVK(gvk)
-					if err != nil {
-						return err
-					}
-
-					// Create the GVR
-					gvr := schema.GroupVersionResource{
-						Group:    gvk.Group,
-						Version:  gvk.Version,
-						Resource: r.Name,
-					}
-
-					if step.Resolving != "" {
-						owner := &v1alpha1.ClusterServiceVersion{}
-						owner.SetNamespace(plan.GetNamespace())
-						owner.SetName(step.Resolving)
-
-						if r.Namespaced {
-							// Set OwnerReferences for namespace-scoped resource
-							ownerutil.AddNonBlockingOwner(unstructuredObject, owner)
-
-							// Update UIDs on all CSV OwnerReferences
-							updated, err := o.getUpdatedOwnerReferences(unstructuredObject.GetOwnerReferences(), plan.Namespace)
-							if err != nil {
-								return errorwrap.Wrapf(err, "error generating ownerrefs for unstructured object: %s", unstructuredObject.GetName())
-							}
-
-							unstructuredObject.SetOwnerReferences(updated)
-						} else {
-							// Add owner labels to cluster-scoped resource
-							if err := ownerutil.AddOwnerLabels(unstructuredObject, owner); err != nil {
-								return err
-							}
-						}
-					}
-
-					// Set up the dynamic client ResourceInterface and set ownerrefs
-					var resourceInterface dynamic.ResourceInterface
-					if r.Namespaced {
-						unstructuredObject.SetNamespace(namespace)
-						resourceInterface = dynamicClient.Resource(gvr).Namespace(namespace)
-					} else {
-						resourceInterface = dynamicClient.Resource(gvr)
-					}
-
-					// Ensure Unstructured Object
-					status, err := ensurer.EnsureUnstructuredObject(resourceInterface, unstructuredObject)
-					if err != nil {
-						return err
-					}
-
-					plan.Status.Plan[i].Status = status
-				}
-			default:
-				return v1alpha1.ErrInvalidInstallPlan
+			s, err := b.create(ctx, *step)
+			if err != nil {
+				return err
 			}
-			return nil
+			status, err := s.Status()
+			if err != nil && status != v1alpha1.StepStatusUnsupportedResource {
+				return err
+			}
+			plan.Status.Plan[i].Status = status
+			return err
 		}(i, step); err != nil {
 			if k8serrors.IsNotFound(err) {
 				// Check for APIVersions present in the installplan steps that are not available on the server.
@@ -2420,20 +2589,50 @@ const (
 	ConsoleLinkKind           = "ConsoleLink"
 )
 
-var supportedKinds = map[string]struct{}{
-	PrometheusRuleKind:        {},
-	ServiceMonitorKind:        {},
-	PodDisruptionBudgetKind:   {},
-	PriorityClassKind:         {},
-	VerticalPodAutoscalerKind: {},
-	ConsoleYAMLSampleKind:     {},
-	ConsoleQuickStartKind:     {},
-	ConsoleCLIDownloadKind:    {},
-	ConsoleLinkKind:           {},
+// isSupported returns true if OLM supports this type of CustomResource, i.e. it
+// was registered with registerUnstructuredKind.
+func isSupported(kind string) bool {
+	_, ok := unstructuredKinds[kind]
+	return ok
 }
 
-// isSupported returns true if OLM supports this type of CustomResource.
-func isSupported(kind string) bool {
-	_, ok := supportedKinds[kind]
+// supportsStepKind returns true if InstallPlan steps may create resources of the
+// given group and kind, either because OLM supports the kind natively or
+// because it has been allowlisted for this Operator.
+func (o *Operator) supportsStepKind(group, kind string) bool {
+	return isSupported(kind) || o.additionalStepKinds.Allows(group, kind)
+}
+
+// StepKinds is an allowlist of resource kinds that InstallPlan steps may create
+// in addition to the kinds OLM supports natively. Each entry is either a bare
+// Kind, which matches that Kind in any API group, or a Kind qualified by its
+// group, e.g. "NetworkPolicy.networking.k8s.io". Whether an allowlisted kind
+// is actually served by the cluster is checked via discovery when a step is
+// executed.
+type StepKinds map[string]struct{}
+
+// ParseStepKinds parses a comma-separated list of StepKinds entries.
+func ParseStepKinds(value string) (StepKinds, error) {
+	kinds := StepKinds{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		gk := schema.ParseGroupKind(entry)
+		if gk.Kind == "" || strings.HasSuffix(entry, ".") {
+			return nil, fmt.Errorf("invalid step kind %q: must be of the form Kind or Kind.group", entry)
+		}
+		kinds[gk.String()] = struct{}{}
+	}
+	return kinds, nil
+}
+
+// Allows returns true if the given group and kind match an entry of the allowlist.
+func (k StepKinds) Allows(group, kind string) bool {
+	if _, ok := k[kind]; ok {
+		return true
+	}
+	_, ok := k[schema.GroupKind{Group: group, Kind: kind}.String()]
 	return ok
 }
@@ -25,6 +25,7 @@ import (
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -47,21 +48,26 @@ import (
 
 	operatorsv1 "github.com/operator-framework/api/pkg/operators/v1"
 	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	operatorsv2 "github.com/operator-framework/api/pkg/operators/v2"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/client/clientset/versioned/fake"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/client/informers/externalversions"
 	olmerrors "github.com/operator-framework/operator-lifecycle-manager/pkg/controller/errors"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/grpc"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/reconciler"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver"
+	resolvercache "github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver/cache"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver/solver"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/fakes"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/clientfake"
 	controllerclient "github.com/operator-framework/operator-lifecycle-manager/pkg/lib/controller-runtime/client"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/operatorclient"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/operatorcondition"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/operatorlister"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/ownerutil"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/queueinformer"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/scoped"
+	"github.com/operator-framework/operator-registry/pkg/api"
 )
 
 type mockTransitioner struct {
@@ -298,7 +304,7 @@ func TestGCInstallPlans(t *testing.T) {
 
 		out := make([]v1alpha1.InstallPlan, 0)
 		for {
-			op.gcInstallPlans(logrus.New(), "ns")
+			op.gcInstallPlans(logrus.New(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}})
 			require.NoError(t, err)
 
 			outList, err := op.client.OperatorsV1alpha1().InstallPlans("ns").List(ctx, metav1.ListOptions{})
@@ -718,11 +724,41 @@ func TestExecutePlanDynamicResources(t *testing.T) {
 	namespace := "ns"
 	unsupportedYaml := yamlFromFilePath(t, "testdata/unsupportedkind.cr.yaml")
 
+	unsupportedStep := func() []*v1alpha1.Step {
+		return []*v1alpha1.Step{
+			{
+				Resource: v1alpha1.StepResource{
+					CatalogSource:          "catalog",
+					CatalogSourceNamespace: namespace,
+					Group:                  "some.unsupported.group",
+					Version:                "v1",
+					Kind:                   "UnsupportedKind",
+					Name:                   "unsupportedkind",
+					Manifest:               unsupportedYaml,
+				},
+				Status: v1alpha1.StepStatusUnknown,
+			},
+		}
+	}
+
 	tests := []struct {
-		testName string
-		in       *v1alpha1.InstallPlan
-		err      error
+		testName  string
+		in        *v1alpha1.InstallPlan
+		stepKinds StepKinds
+		err       error
 	}{
+		{
+			testName:  "AllowlistedKindInOtherGroup",
+			in:        withSteps(installPlan("p", namespace, v1alpha1.InstallPlanPhaseInstalling, "csv"), unsupportedStep()),
+			stepKinds: StepKinds{"UnsupportedKind.other.group": {}},
+			err:       v1alpha1.ErrInvalidInstallPlan,
+		},
+		{
+			testName:  "AllowlistedKindNotInDiscovery",
+			in:        withSteps(installPlan("p", namespace, v1alpha1.InstallPlanPhaseInstalling, "csv"), unsupportedStep()),
+			stepKinds: StepKinds{"UnsupportedKind.some.unsupported.group": {}},
+			err:       fmt.Errorf("GroupVersion %q not found", "some.unsupported.group/v1"),
+		},
 		{
 			testName: "UnsupportedObject",
 			in: withSteps(installPlan("p", namespace, v1alpha1.InstallPlanPhaseInstalling, "csv"),
@@ -752,6 +788,7 @@ func TestExecutePlanDynamicResources(t *testing.T) {
 
 			op, err := NewFakeOperator(ctx, namespace, []string{namespace}, withClientObjs(tt.in))
 			require.NoError(t, err)
+			op.additionalStepKinds = tt.stepKinds
 
 			err = op.ExecutePlan(tt.in)
 			require.Equal(t, tt.err, err)
@@ -759,6 +796,125 @@ func TestExecutePlanDynamicResources(t *testing.T) {
 	}
 }
 
+func TestExecutePlanAdditionalStepKinds(t *testing.T) {
+	namespace := "ns"
+	networkPolicyGVR := schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"}
+	networkPolicy := func() []*v1alpha1.Step {
+		manifest := `{"apiVersion":"networking.k8s.io/v1","kind":"NetworkPolicy","metadata":{"name":"deny-all"},"spec":{"podSelector":{}}}`
+		return []*v1alpha1.Step{{
+			Resource: v1alpha1.StepResource{Group: "networking.k8s.io", Version: "v1", Kind: "NetworkPolicy", Name: "deny-all", Manifest: manifest},
+			Status:   v1alpha1.StepStatusUnknown,
+		}}
+	}
+
+	tests := []struct {
+		testName  string
+		stepKinds StepKinds
+		status    v1alpha1.StepStatus
+		err       error
+	}{
+		{
+			testName: "NotAllowlisted",
+			status:   v1alpha1.StepStatusUnsupportedResource,
+			err:      v1alpha1.ErrInvalidInstallPlan,
+		},
+		{
+			testName:  "Allowlisted",
+			stepKinds: StepKinds{"NetworkPolicy.networking.k8s.io": {}},
+			status:    v1alpha1.StepStatusCreated,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+
+			plan := withSteps(installPlan("p", namespace, v1alpha1.InstallPlanPhaseInstalling, "csv"), networkPolicy())
+			op, err := NewFakeOperator(ctx, namespace, []string{namespace}, withClientObjs(plan))
+			require.NoError(t, err)
+			op.additionalStepKinds = tt.stepKinds
+			op.opClient.KubernetesInterface().(*k8sfake.Clientset).Resources = []*metav1.APIResourceList{{
+				GroupVersion: networkPolicyGVR.GroupVersion().String(),
+				APIResources: []metav1.APIResource{{Name: networkPolicyGVR.Resource, Namespaced: true, Kind: "NetworkPolicy"}},
+			}}
+
+			require.Equal(t, tt.err, op.ExecutePlan(plan))
+			require.Equal(t, tt.status, plan.Status.Plan[0].Status)
+
+			_, err = op.dynamicClient.Resource(networkPolicyGVR).Namespace(namespace).Get(context.TODO(), "deny-all", metav1.GetOptions{})
+			if tt.err != nil {
+				require.True(t, k8serrors.IsNotFound(err), "unexpected error %v", err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestParseStepKinds(t *testing.T) {
+	tests := []struct {
+		testName string
+		value    string
+		allowed  []schema.GroupKind
+		denied   []schema.GroupKind
+		err      string
+	}{
+		{
+			testName: "Empty",
+			value:    "",
+			denied:   []schema.GroupKind{{Kind: "NetworkPolicy"}},
+		},
+		{
+			testName: "BareKindMatchesAnyGroup",
+			value:    "NetworkPolicy",
+			allowed: []schema.GroupKind{
+				{Kind: "NetworkPolicy"},
+				{Group: "networking.k8s.io", Kind: "NetworkPolicy"},
+			},
+			denied: []schema.GroupKind{{Kind: "Ingress"}},
+		},
+		{
+			testName: "QualifiedKindMatchesGroup",
+			value:    "NetworkPolicy.networking.k8s.io, Ingress.networking.k8s.io,",
+			allowed: []schema.GroupKind{
+				{Group: "networking.k8s.io", Kind: "NetworkPolicy"},
+				{Group: "networking.k8s.io", Kind: "Ingress"},
+			},
+			denied: []schema.GroupKind{
+				{Kind: "NetworkPolicy"},
+				{Group: "extensions", Kind: "Ingress"},
+			},
+		},
+		{
+			testName: "MissingKind",
+			value:    ".networking.k8s.io",
+			err:      `invalid step kind ".networking.k8s.io": must be of the form Kind or Kind.group`,
+		},
+		{
+			testName: "MissingGroup",
+			value:    "NetworkPolicy.",
+			err:      `invalid step kind "NetworkPolicy.": must be of the form Kind or Kind.group`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			kinds, err := ParseStepKinds(tt.value)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			for _, gk := range tt.allowed {
+				require.True(t, kinds.Allows(gk.Group, gk.Kind), "expected %s to be allowed", gk)
+			}
+			for _, gk := range tt.denied {
+				require.False(t, kinds.Allows(gk.Group, gk.Kind), "expected %s to be denied", gk)
+			}
+		})
+	}
+}
+
 func TestSyncCatalogSources(t *testing.T) {
 	clockFake := utilclock.NewFakeClock(time.Date(2018, time.January, 26, 20, 40, 0, 0, time.UTC))
 	now := metav1.NewTime(clockFake.Now())
@@ -1014,6 +1170,26 @@ func TestSyncCatalogSources(t *testing.T) {
 			},
 			expectedError: nil,
 		},
+		{
+			testName:  "CatalogSourceWithFileBasedType/EnsuresImageOrConfigMapIsSet",
+			namespace: "cool-namespace",
+			catalogSource: &v1alpha1.CatalogSource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "invalid-spec-catalog",
+					Namespace: "cool-namespace",
+					UID:       types.UID("catalog-uid"),
+					Labels:    map[string]string{"olm.catalogSource": "invalid-spec-catalog"},
+				},
+				Spec: v1alpha1.CatalogSourceSpec{
+					SourceType: reconciler.SourceTypeFileBased,
+				},
+			},
+			expectedStatus: &v1alpha1.CatalogSourceStatus{
+				Message: fmt.Sprintf("image and configmaps unset: one must be set for sourcetype: %s", reconciler.SourceTypeFileBased),
+				Reason:  v1alpha1.CatalogSourceSpecInvalidError,
+			},
+			expectedError: nil,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.testName, func(t *testing.T) {
@@ -1204,7 +1380,7 @@ func TestSyncResolvingNamespace(t *testing.T) {
 
 			o.sourcesLastUpdate.Set(tt.fields.sourcesLastUpdate.Time)
 			o.resolver = &fakes.FakeStepResolver{
-				ResolveStepsStub: func(string) ([]*v1alpha1.Step, []v1alpha1.BundleLookup, []*v1alpha1.Subscription, error) {
+				ResolveStepsStub: func(context.Context, string) ([]*v1alpha1.Step, []v1alpha1.BundleLookup, []*v1alpha1.Subscription, error) {
 					return nil, nil, nil, tt.fields.resolveErr
 				},
 			}
@@ -1225,6 +1401,358 @@ func TestSyncResolvingNamespace(t *testing.T) {
 	}
 }
 
+func TestSyncResolvingNamespaceResolutionTimeout(t *testing.T) {
+	clockFake := utilclock.NewFakeClock(time.Date(2018, time.January, 26, 20, 40, 0, 0, time.UTC))
+	testNamespace := "testNamespace"
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	sub := &v1alpha1.Subscription{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       v1alpha1.SubscriptionKind,
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sub",
+			Namespace: testNamespace,
+		},
+		Spec: &v1alpha1.SubscriptionSpec{
+			CatalogSource:          "src",
+			CatalogSourceNamespace: testNamespace,
+		},
+	}
+	o, err := NewFakeOperator(ctx, testNamespace, []string{testNamespace}, withClock(clockFake), withClientObjs(sub), withFakeClientOptions(clientfake.WithSelfLinks(t)))
+	require.NoError(t, err)
+
+	o.reconciler = &fakes.FakeRegistryReconcilerFactory{
+		ReconcilerForSourceStub: func(source *v1alpha1.CatalogSource) reconciler.RegistryReconciler {
+			return &fakes.FakeRegistryReconciler{
+				EnsureRegistryServerStub: func(source *v1alpha1.CatalogSource) error {
+					return nil
+				},
+			}
+		},
+	}
+
+	o.resolutionTimeout = time.Millisecond
+	timeout := true
+	o.resolver = &fakes.FakeStepResolver{
+		ResolveStepsStub: func(ctx context.Context, _ string) ([]*v1alpha1.Step, []v1alpha1.BundleLookup, []*v1alpha1.Subscription, error) {
+			if !timeout {
+				return nil, nil, nil, nil
+			}
+			<-ctx.Done()
+			return nil, nil, nil, solver.Incomplete
+		},
+	}
+
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: testNamespace,
+		},
+	}
+
+	require.Equal(t, solver.Incomplete, o.syncResolvingNamespace(namespace))
+
+	fetched, err := o.client.OperatorsV1alpha1().Subscriptions(testNamespace).Get(context.TODO(), sub.GetName(), metav1.GetOptions{})
+	require.NoError(t, err)
+	cond := fetched.Status.GetCondition(SubscriptionResolutionTimedOut)
+	require.Equal(t, corev1.ConditionTrue, cond.Status)
+	require.Equal(t, "ResolutionTimedOut", cond.Reason)
+	require.Equal(t, "dependency resolution did not complete within 1ms", cond.Message)
+
+	// A later resolution that completes removes the condition.
+	timeout = false
+	require.NoError(t, o.syncResolvingNamespace(namespace))
+
+	fetched, err = o.client.OperatorsV1alpha1().Subscriptions(testNamespace).Get(context.TODO(), sub.GetName(), metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, corev1.ConditionUnknown, fetched.Status.GetCondition(SubscriptionResolutionTimedOut).Status)
+}
+
+type fakeChannelHeadQuerier struct {
+	SourceQuerier
+	head  *api.Bundle
+	err   error
+	calls int
+}
+
+func (q *fakeChannelHeadQuerier) FindChannelHead(pkgName, channelName string, source registry.CatalogKey) (*api.Bundle, error) {
+	q.calls++
+	return q.head, q.err
+}
+
+func TestEnsureSubscriptionVersionRangeState(t *testing.T) {
+	clockFake := utilclock.NewFakeClock(time.Date(2018, time.January, 26, 20, 40, 0, 0, time.UTC))
+	testNamespace := "testNamespace"
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	sub := &v1alpha1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "sub",
+			Namespace:   testNamespace,
+			Annotations: map[string]string{resolver.VersionRangeAnnotationKey: ">=1.0.0 <2.0.0"},
+		},
+		Spec: &v1alpha1.SubscriptionSpec{
+			CatalogSource:          "src",
+			CatalogSourceNamespace: testNamespace,
+			Package:                "pkg",
+			Channel:                "stable",
+		},
+	}
+	o, err := NewFakeOperator(ctx, testNamespace, []string{testNamespace}, withClock(clockFake), withClientObjs(sub))
+	require.NoError(t, err)
+	logger := logrus.NewEntry(o.logger)
+
+	querier := &fakeChannelHeadQuerier{head: &api.Bundle{CsvName: "pkg.v2.0.0", Version: "2.0.0"}}
+	out, changed, err := o.ensureSubscriptionVersionRangeState(logger, sub, querier)
+	require.NoError(t, err)
+	require.True(t, changed)
+	cond := out.Status.GetCondition(SubscriptionChannelHeadOutOfRange)
+	require.Equal(t, corev1.ConditionTrue, cond.Status)
+	require.Equal(t, "VersionRangeExcludesChannelHead", cond.Reason)
+	require.Equal(t, "channel head pkg.v2.0.0 (version 2.0.0) is outside of version range >=1.0.0 <2.0.0, upgrades are limited to versions within the range", cond.Message)
+
+	// Nothing changes while the channel head stays out of range, and the
+	// cached channel head is used.
+	out, changed, err = o.ensureSubscriptionVersionRangeState(logger, out, querier)
+	require.NoError(t, err)
+	require.False(t, changed)
+	require.Equal(t, 1, querier.calls)
+
+	// The channel head is looked up again once the catalog changes.
+	source := registry.CatalogKey{Name: "src", Namespace: testNamespace}
+	querier.head = &api.Bundle{CsvName: "pkg.v1.5.0", Version: "1.5.0"}
+	o.channelHeads.expire(source)
+	out, changed, err = o.ensureSubscriptionVersionRangeState(logger, out, querier)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, corev1.ConditionUnknown, out.Status.GetCondition(SubscriptionChannelHeadOutOfRange).Status)
+	require.Equal(t, 2, querier.calls)
+
+	// A missing channel is reported.
+	querier.head = nil
+	querier.err = ChannelHeadNotFoundError{Package: "pkg", Channel: "stable", Source: source}
+	o.channelHeads.expire(source)
+	out, changed, err = o.ensureSubscriptionVersionRangeState(logger, out, querier)
+	require.NoError(t, err)
+	require.True(t, changed)
+	cond = out.Status.GetCondition(SubscriptionChannelHeadOutOfRange)
+	require.Equal(t, corev1.ConditionUnknown, cond.Status)
+	require.Equal(t, "ChannelNotFound", cond.Reason)
+	require.Equal(t, "channel stable not found in package pkg of CatalogSource src", cond.Message)
+
+	// So is an empty one.
+	querier.err = nil
+	out, changed, err = o.ensureSubscriptionVersionRangeState(logger, out, querier)
+	require.NoError(t, err)
+	require.True(t, changed)
+	cond = out.Status.GetCondition(SubscriptionChannelHeadOutOfRange)
+	require.Equal(t, "ChannelEmpty", cond.Reason)
+	require.Equal(t, "channel stable of package pkg in CatalogSource src has no head", cond.Message)
+}
+
+func TestEnsureSubscriptionDeprecationState(t *testing.T) {
+	clockFake := utilclock.NewFakeClock(time.Date(2018, time.January, 26, 20, 40, 0, 0, time.UTC))
+	testNamespace := "testNamespace"
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	sub := &v1alpha1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sub",
+			Namespace: testNamespace,
+		},
+		Spec: &v1alpha1.SubscriptionSpec{
+			CatalogSource:          "src",
+			CatalogSourceNamespace: testNamespace,
+			Package:                "pkg",
+			Channel:                "stable",
+		},
+	}
+	o, err := NewFakeOperator(ctx, testNamespace, []string{testNamespace}, withClock(clockFake), withClientObjs(sub))
+	require.NoError(t, err)
+	logger := logrus.NewEntry(o.logger)
+
+	fakeResolver := &fakes.FakeStepResolver{}
+	o.resolver = fakeResolver
+	fakeResolver.DeprecationsReturns(resolvercache.Deprecations{Channel: "stable is no longer updated, use fast", Bundle: "pkg.v1 is broken"}, nil)
+	out, changed, err := o.ensureSubscriptionDeprecationState(logger, sub)
+	require.NoError(t, err)
+	require.True(t, changed)
+	cond := out.Status.GetCondition(SubscriptionDeprecated)
+	require.Equal(t, corev1.ConditionTrue, cond.Status)
+	require.Equal(t, "ChannelDeprecated", cond.Reason)
+	require.Equal(t, "stable is no longer updated, use fast\npkg.v1 is broken", cond.Message)
+
+	// Nothing changes while the catalog can't be read.
+	fakeResolver.DeprecationsReturns(resolvercache.Deprecations{}, errors.New("unavailable"))
+	out, changed, err = o.ensureSubscriptionDeprecationState(logger, out)
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	fakeResolver.DeprecationsReturns(resolvercache.Deprecations{}, nil)
+	out, changed, err = o.ensureSubscriptionDeprecationState(logger, out)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, corev1.ConditionUnknown, out.Status.GetCondition(SubscriptionDeprecated).Status)
+}
+
+func TestEnsureSubscriptionUpgradeHeldState(t *testing.T) {
+	clockFake := utilclock.NewFakeClock(time.Date(2018, time.January, 26, 20, 40, 0, 0, time.UTC))
+	testNamespace := "testNamespace"
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	sub := &v1alpha1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sub",
+			Namespace: testNamespace,
+		},
+		Spec: &v1alpha1.SubscriptionSpec{
+			CatalogSource:          "src",
+			CatalogSourceNamespace: testNamespace,
+			Package:                "pkg",
+			Channel:                "stable",
+		},
+		Status: v1alpha1.SubscriptionStatus{
+			InstalledCSV: "pkg.v1.0.0",
+		},
+	}
+	cond := &operatorsv2.OperatorCondition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pkg.v1.0.0",
+			Namespace: testNamespace,
+		},
+		Status: operatorsv2.OperatorConditionStatus{
+			Conditions: []metav1.Condition{
+				{
+					Type:    operatorcondition.MigrationConditionPrefix + "storage",
+					Status:  metav1.ConditionFalse,
+					Message: "copying data",
+				},
+				{
+					Type:    operatorcondition.UpgradeableVersionRange,
+					Status:  metav1.ConditionTrue,
+					Message: "<2.0.0",
+				},
+			},
+		},
+	}
+	o, err := NewFakeOperator(ctx, testNamespace, []string{testNamespace}, withClock(clockFake), withClientObjs(sub, cond))
+	require.NoError(t, err)
+	logger := logrus.NewEntry(o.logger)
+
+	querier := &fakeChannelHeadQuerier{head: &api.Bundle{CsvName: "pkg.v2.0.0", Version: "2.0.0"}}
+	out, changed, err := o.ensureSubscriptionUpgradeHeldState(logger, sub, querier)
+	require.NoError(t, err)
+	require.True(t, changed)
+	held := out.Status.GetCondition(SubscriptionUpgradeHeld)
+	require.Equal(t, corev1.ConditionTrue, held.Status)
+	require.Equal(t, "MigrationPending", held.Reason)
+	require.Equal(t, "upgrade from pkg.v1.0.0 to channel head pkg.v2.0.0 (version 2.0.0) is held: migration storage has not completed: copying data, version 2.0.0 is outside of the upgradeable version range <2.0.0", held.Message)
+
+	// Nothing changes while the upgrade stays held.
+	out, changed, err = o.ensureSubscriptionUpgradeHeldState(logger, out, querier)
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	// Nothing is held once the channel head is installed.
+	querier.head = &api.Bundle{CsvName: "pkg.v1.0.0", Version: "1.0.0"}
+	o.channelHeads.expire(registry.CatalogKey{Name: sub.Spec.CatalogSource, Namespace: sub.Spec.CatalogSourceNamespace})
+	out, changed, err = o.ensureSubscriptionUpgradeHeldState(logger, out, querier)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, corev1.ConditionUnknown, out.Status.GetCondition(SubscriptionUpgradeHeld).Status)
+}
+
+func TestOperatorConditionUpdated(t *testing.T) {
+	base := &operatorsv2.OperatorCondition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "pkg.v1.0.0",
+			Namespace:  "ns",
+			Generation: 1,
+		},
+		Spec: operatorsv2.OperatorConditionSpec{
+			Conditions: []metav1.Condition{
+				{Type: operatorsv2.Upgradeable, Status: metav1.ConditionTrue, ObservedGeneration: 1},
+			},
+		},
+		Status: operatorsv2.OperatorConditionStatus{
+			Conditions: []metav1.Condition{
+				{Type: operatorsv2.Upgradeable, Status: metav1.ConditionTrue, ObservedGeneration: 1},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		update  func(cond *operatorsv2.OperatorCondition)
+		resolve bool
+	}{
+		{
+			name:   "Resync",
+			update: func(cond *operatorsv2.OperatorCondition) {},
+		},
+		{
+			name: "StatusOnly",
+			update: func(cond *operatorsv2.OperatorCondition) {
+				cond.Status.Conditions[0].Message = "still upgradeable"
+				cond.Status.Conditions[0].LastTransitionTime = metav1.Now()
+			},
+		},
+		{
+			name: "SpecConditions",
+			update: func(cond *operatorsv2.OperatorCondition) {
+				cond.Spec.Conditions[0].Status = metav1.ConditionFalse
+			},
+			resolve: true,
+		},
+		{
+			name: "StatusGate",
+			update: func(cond *operatorsv2.OperatorCondition) {
+				cond.Status.Conditions[0].Status = metav1.ConditionFalse
+			},
+			resolve: true,
+		},
+		{
+			name: "Override",
+			update: func(cond *operatorsv2.OperatorCondition) {
+				cond.Spec.Overrides = []metav1.Condition{
+					{Type: operatorcondition.UpgradeableVersionRange, Status: metav1.ConditionTrue, Message: "<2.0.0"},
+				}
+			},
+			resolve: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &Operator{
+				logger:         logrus.New(),
+				nsResolveQueue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "resolver"),
+			}
+			defer o.nsResolveQueue.ShutDown()
+
+			cond := base.DeepCopy()
+			tt.update(cond)
+			o.operatorConditionUpdated(base, cond)
+
+			if !tt.resolve {
+				require.Zero(t, o.nsResolveQueue.Len())
+				return
+			}
+			require.Equal(t, 1, o.nsResolveQueue.Len())
+			ns, _ := o.nsResolveQueue.Get()
+			require.Equal(t, "ns", ns)
+		})
+	}
+}
+
 func TestCompetingCRDOwnersExist(t *testing.T) {
 
 	testNamespace := "default"
@@ -1478,12 +2006,16 @@ func NewFakeOperator(ctx context.Context, namespace string, namespaces []string,
 	subInformer := operatorsFactory.Operators().V1alpha1().Subscriptions()
 	ipInformer := operatorsFactory.Operators().V1alpha1().InstallPlans()
 	csvInformer := operatorsFactory.Operators().V1alpha1().ClusterServiceVersions()
-	sharedInformers = append(sharedInformers, catsrcInformer.Informer(), subInformer.Informer(), ipInformer.Informer(), csvInformer.Informer())
+	ogInformer := operatorsFactory.Operators().V1().OperatorGroups()
+	opConditionInformer := operatorsFactory.Operators().V2().OperatorConditions()
+	sharedInformers = append(sharedInformers, catsrcInformer.Informer(), subInformer.Informer(), ipInformer.Informer(), csvInformer.Informer(), ogInformer.Informer(), opConditionInformer.Informer())
 
 	lister.OperatorsV1alpha1().RegisterCatalogSourceLister(metav1.NamespaceAll, catsrcInformer.Lister())
 	lister.OperatorsV1alpha1().RegisterSubscriptionLister(metav1.NamespaceAll, subInformer.Lister())
 	lister.OperatorsV1alpha1().RegisterInstallPlanLister(metav1.NamespaceAll, ipInformer.Lister())
 	lister.OperatorsV1alpha1().RegisterClusterServiceVersionLister(metav1.NamespaceAll, csvInformer.Lister())
+	lister.OperatorsV1().RegisterOperatorGroupLister(metav1.NamespaceAll, ogInformer.Lister())
+	lister.OperatorsV2().RegisterOperatorConditionLister(metav1.NamespaceAll, opConditionInformer.Lister())
 
 	factory := informers.NewSharedInformerFactoryWithOptions(opClientFake.KubernetesInterface(), wakeupInterval, informers.WithNamespace(metav1.NamespaceAll))
 	roleInformer := factory.Rbac().V1().Roles()
@@ -1529,6 +2061,9 @@ func NewFakeOperator(ctx context.Context, namespace string, namespaces []string,
 		clientAttenuator:      scoped.NewClientAttenuator(logger, &rest.Config{}, opClientFake),
 		serviceAccountQuerier: scoped.NewUserDefinedServiceAccountQuerier(logger, clientFake),
 		catsrcQueueSet:        queueinformer.NewEmptyResourceQueueSet(),
+		catalogRefresher:      reconciler.NewCatalogRefresher(),
+		catalogHealth:         newCatalogHealthStore(),
+		channelHeads:          newChannelHeadCache(),
 		clientFactory: &stubClientFactory{
 			operatorClient:   opClientFake,
 			kubernetesClient: clientFake,
@@ -1536,6 +2071,7 @@ func NewFakeOperator(ctx context.Context, namespace string, namespaces []string,
 		},
 	}
 	op.sources = grpc.NewSourceStore(config.logger, 1*time.Second, 5*time.Second, op.syncSourceState)
+	op.fileBasedSources = resolver.NewFileBasedSourceStore(op.syncFileBasedSource)
 	if op.reconciler == nil {
 		s := runtime.NewScheme()
 		err := k8sfake.AddToScheme(s)
@@ -1544,7 +2080,7 @@ func NewFakeOperator(ctx context.Context, namespace string, namespaces []string,
 		}
 		applier := controllerclient.NewFakeApplier(s, "testowner")
 
-		op.reconciler = reconciler.NewRegistryReconcilerFactory(lister, op.opClient, "test:pod", op.now, applier)
+		op.reconciler = reconciler.NewRegistryReconcilerFactory(lister, op.opClient, "test:pod", op.now, applier, nil, op.catalogRefresher, nil, op.fileBasedSources)
 	}
 
 	op.RunInformers(ctx)
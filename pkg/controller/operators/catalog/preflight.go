@@ -0,0 +1,384 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"github.com/sirupsen/logrus"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	crdlib "github.com/operator-framework/operator-lifecycle-manager/pkg/lib/crd"
+)
+
+// Preflight checks are run against InstallPlans that await manual approval,
+// and their results are recorded as one condition per check. A condition is
+// True if the plan passed the check, False if executing the plan is known to
+// fail it, and Unknown if the check could not be performed.
+const (
+	// InstallPlanPreflightStorageVersions reports whether the plan's CRDs keep
+	// serving every version that is stored for their existing counterparts.
+	InstallPlanPreflightStorageVersions v1alpha1.InstallPlanConditionType = "PreflightStorageVersions"
+	// InstallPlanPreflightExistingResources reports whether existing custom
+	// resources are valid against the schemas of the plan's CRDs.
+	InstallPlanPreflightExistingResources v1alpha1.InstallPlanConditionType = "PreflightExistingResources"
+	// InstallPlanPreflightPermissions reports whether the OperatorGroup's
+	// service account holds every permission granted by the plan's Roles and
+	// ClusterRoles, which it needs to create them without escalating.
+	InstallPlanPreflightPermissions v1alpha1.InstallPlanConditionType = "PreflightPermissions"
+	// InstallPlanPreflightKubeVersion reports whether the cluster meets the
+	// minimum Kubernetes version of the plan's ClusterServiceVersions.
+	InstallPlanPreflightKubeVersion v1alpha1.InstallPlanConditionType = "PreflightKubeVersion"
+
+	InstallPlanReasonPreflightPassed v1alpha1.InstallPlanConditionReason = "PreflightPassed"
+	InstallPlanReasonPreflightFailed v1alpha1.InstallPlanConditionReason = "PreflightFailed"
+	InstallPlanReasonPreflightError  v1alpha1.InstallPlanConditionReason = "PreflightError"
+)
+
+var preflightConditionTypes = []v1alpha1.InstallPlanConditionType{
+	InstallPlanPreflightStorageVersions,
+	InstallPlanPreflightExistingResources,
+	InstallPlanPreflightPermissions,
+	InstallPlanPreflightKubeVersion,
+}
+
+// needsPreflight returns true if the plan awaits manual approval and any of
+// its preflight checks has not been performed successfully yet.
+func needsPreflight(plan *v1alpha1.InstallPlan) bool {
+	if plan.Spec.Approval != v1alpha1.ApprovalManual || plan.Spec.Approved || plan.Status.Phase != v1alpha1.InstallPlanPhaseRequiresApproval {
+		return false
+	}
+	for _, t := range preflightConditionTypes {
+		if plan.Status.GetCondition(t).Status == corev1.ConditionUnknown {
+			return true
+		}
+	}
+	return false
+}
+
+// preflightResult collects the findings of a single check. Failures are
+// reasons the plan would fail, errors prevented the check from completing.
+type preflightResult struct {
+	failures []string
+	errs     []error
+}
+
+func (r *preflightResult) fail(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *preflightResult) condition(t v1alpha1.InstallPlanConditionType, now *metav1.Time) v1alpha1.InstallPlanCondition {
+	cond := v1alpha1.InstallPlanCondition{
+		Type:               t,
+		Status:             corev1.ConditionTrue,
+		Reason:             InstallPlanReasonPreflightPassed,
+		LastUpdateTime:     now,
+		LastTransitionTime: now,
+	}
+	switch {
+	case len(r.failures) > 0:
+		cond.Status = corev1.ConditionFalse
+		cond.Reason = InstallPlanReasonPreflightFailed
+		cond.Message = strings.Join(r.failures, "; ")
+	case len(r.errs) > 0:
+		cond.Status = corev1.ConditionUnknown
+		cond.Reason = InstallPlanReasonPreflightError
+		cond.Message = utilerrors.NewAggregate(r.errs).Error()
+	}
+	return cond
+}
+
+// preflightInstallPlan runs all preflight checks against the plan and sets
+// their conditions. It returns an error if any check could not be performed,
+// so that the checks are retried.
+func (o *Operator) preflightInstallPlan(plan *v1alpha1.InstallPlan, logger logrus.FieldLogger) error {
+	r := newManifestResolver(plan.GetNamespace(), o.lister.CoreV1().ConfigMapLister(), o.logger)
+
+	var storage, existing, permissions, kubeVersion preflightResult
+	var reviewer *accessReviewer
+	if sa := plan.Status.AttenuatedServiceAccountRef; sa != nil {
+		reviewer = &accessReviewer{o: o, sa: sa, allowed: map[string]bool{}}
+	}
+	for _, step := range plan.Status.Plan {
+		manifest, err := r.ManifestForStep(step)
+		if err != nil {
+			err = fmt.Errorf("error resolving manifest of %s %s: %v", step.Resource.Kind, step.Resource.Name, err)
+			storage.errs = append(storage.errs, err)
+			existing.errs = append(existing.errs, err)
+			permissions.errs = append(permissions.errs, err)
+			kubeVersion.errs = append(kubeVersion.errs, err)
+			continue
+		}
+
+		switch step.Resource.Kind {
+		case crdKind:
+			o.preflightCRD(step.Resource.Name, manifest, &storage, &existing)
+		case roleKind, clusterRoleKind:
+			o.preflightPermissions(plan, reviewer, step.Resource.Kind, step.Resource.Name, manifest, &permissions)
+		case v1alpha1.ClusterServiceVersionKind:
+			o.preflightKubeVersion(step.Resource.Name, manifest, &kubeVersion)
+		}
+	}
+
+	now := o.now()
+	var errs []error
+	results := map[v1alpha1.InstallPlanConditionType]*preflightResult{
+		InstallPlanPreflightStorageVersions:   &storage,
+		InstallPlanPreflightExistingResources: &existing,
+		InstallPlanPreflightPermissions:       &permissions,
+		InstallPlanPreflightKubeVersion:       &kubeVersion,
+	}
+	for _, t := range preflightConditionTypes {
+		result := results[t]
+		cond := result.condition(t, &now)
+		plan.Status.SetCondition(cond)
+		if cond.Status == corev1.ConditionFalse {
+			logger.WithField("check", t).Info(cond.Message)
+		}
+		errs = append(errs, result.errs...)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// preflightCRD checks that a CRD upgrade neither drops a stored version nor
+// invalidates existing custom resources.
+func (o *Operator) preflightCRD(name, manifest string, storage, existing *preflightResult) {
+	desired, current, err := o.crdsForManifest(manifest)
+	if err != nil {
+		err = fmt.Errorf("error getting CustomResourceDefinition %s: %v", name, err)
+		storage.errs = append(storage.errs, err)
+		existing.errs = append(existing.errs, err)
+		return
+	}
+	if current == nil {
+		return
+	}
+
+	if safe, err := crdlib.SafeStorageVersionUpgrade(current, desired); !safe {
+		storage.fail("CustomResourceDefinition %s: %v", name, err)
+	} else if err != nil {
+		storage.errs = append(storage.errs, fmt.Errorf("CustomResourceDefinition %s: %v", name, err))
+	}
+
+	if err := o.validateCRDCompatibility(manifest); err != nil {
+		existing.fail("CustomResourceDefinition %s: %v", name, err)
+	}
+}
+
+// preflightPermissions checks that the plan's service account, if any, may
+// create the given Role or ClusterRole: it must either be allowed to escalate
+// or already hold every permission the role grants.
+func (o *Operator) preflightPermissions(plan *v1alpha1.InstallPlan, reviewer *accessReviewer, kind, name, manifest string, result *preflightResult) {
+	if reviewer == nil {
+		// The plan is executed with OLM's own permissions.
+		return
+	}
+
+	var rules []rbacv1.PolicyRule
+	var namespace, resource string
+	switch kind {
+	case roleKind:
+		var role rbacv1.Role
+		if err := json.Unmarshal([]byte(manifest), &role); err != nil {
+			result.errs = append(result.errs, fmt.Errorf("error parsing %s %s: %v", kind, name, err))
+			return
+		}
+		rules, namespace, resource = role.Rules, plan.GetNamespace(), "roles"
+	case clusterRoleKind:
+		var role rbacv1.ClusterRole
+		if err := json.Unmarshal([]byte(manifest), &role); err != nil {
+			result.errs = append(result.errs, fmt.Errorf("error parsing %s %s: %v", kind, name, err))
+			return
+		}
+		rules, resource = role.Rules, "clusterroles"
+	}
+
+	escalate, err := reviewer.Allowed(accessAttributes{resource: &authorizationv1.ResourceAttributes{
+		Namespace: namespace,
+		Verb:      "escalate",
+		Group:     rbacv1.GroupName,
+		Resource:  resource,
+	}})
+	if err != nil {
+		result.errs = append(result.errs, err)
+		return
+	}
+	if escalate {
+		return
+	}
+
+	var missing []string
+	seen := map[string]struct{}{}
+	for _, rule := range rules {
+		for _, attributes := range ruleAttributes(namespace, rule) {
+			allowed, err := reviewer.Allowed(attributes)
+			if err != nil {
+				result.errs = append(result.errs, err)
+				return
+			}
+			if _, ok := seen[attributes.String()]; !allowed && !ok {
+				seen[attributes.String()] = struct{}{}
+				missing = append(missing, attributes.String())
+			}
+		}
+	}
+	if len(missing) > 0 {
+		result.fail("service account %s/%s cannot grant %s %s permissions it does not hold: %s", reviewer.sa.Namespace, reviewer.sa.Name, kind, name, strings.Join(missing, ", "))
+	}
+}
+
+// accessReviewer reviews the accesses of a service account, at most once
+// each per preflight run: the rules of a plan's roles commonly repeat the
+// same verbs and resources.
+type accessReviewer struct {
+	o       *Operator
+	sa      *corev1.ObjectReference
+	allowed map[string]bool
+}
+
+// Allowed returns true if the service account may perform the given access.
+// Accesses to named resources are only reviewed individually if the service
+// account may not perform them on all resources of their kind.
+func (r *accessReviewer) Allowed(attributes accessAttributes) (bool, error) {
+	if attributes.resource != nil && attributes.resource.Name != "" {
+		all := *attributes.resource
+		all.Name = ""
+		allowed, err := r.Allowed(accessAttributes{resource: &all})
+		if err != nil || allowed {
+			return allowed, err
+		}
+	}
+
+	key := attributes.key()
+	if allowed, ok := r.allowed[key]; ok {
+		return allowed, nil
+	}
+	var resource authorizationv1.ResourceAttributes
+	if attributes.resource != nil {
+		resource = *attributes.resource
+	}
+	allowed, err := r.o.serviceAccountAllowed(r.sa, resource, attributes.nonResource)
+	if err != nil {
+		return false, err
+	}
+	r.allowed[key] = allowed
+	return allowed, nil
+}
+
+type accessAttributes struct {
+	resource    *authorizationv1.ResourceAttributes
+	nonResource *authorizationv1.NonResourceAttributes
+}
+
+func (a accessAttributes) String() string {
+	if a.nonResource != nil {
+		return fmt.Sprintf("%s %s", a.nonResource.Verb, a.nonResource.Path)
+	}
+	resource := a.resource.Resource
+	if a.resource.Group != "" {
+		resource = resource + "." + a.resource.Group
+	}
+	if a.resource.Name != "" {
+		resource = resource + "/" + a.resource.Name
+	}
+	return fmt.Sprintf("%s %s", a.resource.Verb, resource)
+}
+
+// key identifies the access, including its namespace.
+func (a accessAttributes) key() string {
+	if a.nonResource != nil {
+		return a.String()
+	}
+	return a.resource.Namespace + ":" + a.String()
+}
+
+// ruleAttributes expands a PolicyRule into the individual accesses it grants.
+func ruleAttributes(namespace string, rule rbacv1.PolicyRule) []accessAttributes {
+	var attributes []accessAttributes
+	for _, verb := range rule.Verbs {
+		for _, path := range rule.NonResourceURLs {
+			attributes = append(attributes, accessAttributes{nonResource: &authorizationv1.NonResourceAttributes{Verb: verb, Path: path}})
+		}
+		for _, group := range rule.APIGroups {
+			for _, resource := range rule.Resources {
+				names := rule.ResourceNames
+				if len(names) == 0 {
+					names = []string{""}
+				}
+				for _, name := range names {
+					attributes = append(attributes, accessAttributes{resource: &authorizationv1.ResourceAttributes{
+						Namespace: namespace,
+						Verb:      verb,
+						Group:     group,
+						Resource:  resource,
+						Name:      name,
+					}})
+				}
+			}
+		}
+	}
+	return attributes
+}
+
+// serviceAccountAllowed asks the API server whether the given service account
+// may perform the described access.
+func (o *Operator) serviceAccountAllowed(sa *corev1.ObjectReference, resource authorizationv1.ResourceAttributes, nonResource *authorizationv1.NonResourceAttributes) (bool, error) {
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   fmt.Sprintf("system:serviceaccount:%s:%s", sa.Namespace, sa.Name),
+			Groups: []string{"system:serviceaccounts", "system:serviceaccounts:" + sa.Namespace, "system:authenticated"},
+		},
+	}
+	if nonResource != nil {
+		review.Spec.NonResourceAttributes = nonResource
+	} else {
+		review.Spec.ResourceAttributes = &resource
+	}
+
+	out, err := o.opClient.KubernetesInterface().AuthorizationV1().SubjectAccessReviews().Create(context.TODO(), review, metav1.CreateOptions{})
+	if err != nil {
+		return false, fmt.Errorf("error reviewing access of service account %s/%s: %v", sa.Namespace, sa.Name, err)
+	}
+	return out.Status.Allowed, nil
+}
+
+// preflightKubeVersion checks that the cluster meets the minimum Kubernetes
+// version of a ClusterServiceVersion.
+func (o *Operator) preflightKubeVersion(name, manifest string, result *preflightResult) {
+	var csv v1alpha1.ClusterServiceVersion
+	if err := json.Unmarshal([]byte(manifest), &csv); err != nil {
+		result.errs = append(result.errs, fmt.Errorf("error parsing ClusterServiceVersion %s: %v", name, err))
+		return
+	}
+	if csv.Spec.MinKubeVersion == "" {
+		return
+	}
+
+	minVersion, err := semver.ParseTolerant(csv.Spec.MinKubeVersion)
+	if err != nil {
+		result.fail("ClusterServiceVersion %s has an invalid minKubeVersion %q: %v", name, csv.Spec.MinKubeVersion, err)
+		return
+	}
+
+	info, err := o.opClient.KubernetesInterface().Discovery().ServerVersion()
+	if err != nil {
+		result.errs = append(result.errs, fmt.Errorf("error discovering server version: %v", err))
+		return
+	}
+	serverVersion, err := semver.ParseTolerant(strings.Split(info.GitVersion, "-")[0])
+	if err != nil {
+		result.errs = append(result.errs, fmt.Errorf("error parsing server version %q: %v", info.GitVersion, err))
+		return
+	}
+
+	if minVersion.GT(serverVersion) {
+		result.fail("ClusterServiceVersion %s requires Kubernetes %s or later, but the server runs %s", name, minVersion, serverVersion)
+	}
+}
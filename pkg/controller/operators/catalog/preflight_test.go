@@ -0,0 +1,211 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/client/clientset/versioned/fake"
+)
+
+func TestPreflightInstallPlan(t *testing.T) {
+	namespace := "ns"
+
+	manifest := func(obj runtime.Object) string {
+		data, err := json.Marshal(obj)
+		require.NoError(t, err)
+		return string(data)
+	}
+	crd := func(versions ...string) *apiextensionsv1.CustomResourceDefinition {
+		crd := &apiextensionsv1.CustomResourceDefinition{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apiextensions.k8s.io/v1", Kind: crdKind},
+			ObjectMeta: metav1.ObjectMeta{Name: "widgets.example.com"},
+			Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+				Group: "example.com",
+				Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: "widgets", Kind: "Widget"},
+				Scope: apiextensionsv1.NamespaceScoped,
+			},
+		}
+		for _, v := range versions {
+			crd.Spec.Versions = append(crd.Spec.Versions, apiextensionsv1.CustomResourceDefinitionVersion{Name: v, Served: true, Storage: true})
+		}
+		return crd
+	}
+	existingCRD := crd("v1alpha1")
+	existingCRD.Status.StoredVersions = []string{"v1alpha1"}
+
+	csv := func(minKubeVersion string) *v1alpha1.ClusterServiceVersion {
+		return &v1alpha1.ClusterServiceVersion{
+			TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.SchemeGroupVersion.String(), Kind: v1alpha1.ClusterServiceVersionKind},
+			ObjectMeta: metav1.ObjectMeta{Name: "csv"},
+			Spec:       v1alpha1.ClusterServiceVersionSpec{MinKubeVersion: minKubeVersion},
+		}
+	}
+	role := &rbacv1.Role{
+		TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: roleKind},
+		ObjectMeta: metav1.ObjectMeta{Name: "role"},
+		Rules: []rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "list"}},
+		},
+	}
+	namedRole := &rbacv1.Role{
+		TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: roleKind},
+		ObjectMeta: metav1.ObjectMeta{Name: "named"},
+		Rules: []rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "list"}, ResourceNames: []string{"a", "b"}},
+			{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}},
+		},
+	}
+	step := func(kind, name string, obj runtime.Object) *v1alpha1.Step {
+		return &v1alpha1.Step{
+			Resource: v1alpha1.StepResource{Kind: kind, Name: name, Manifest: manifest(obj)},
+			Status:   v1alpha1.StepStatusUnknown,
+		}
+	}
+	sa := &corev1.ObjectReference{Namespace: namespace, Name: "scoped"}
+
+	tests := []struct {
+		testName string
+		steps    []*v1alpha1.Step
+		saRef    *corev1.ObjectReference
+		escalate bool
+		failed   map[v1alpha1.InstallPlanConditionType]string
+		reviews  int
+	}{
+		{
+			testName: "Passed",
+			steps: []*v1alpha1.Step{
+				step(crdKind, existingCRD.GetName(), crd("v1alpha1", "v1")),
+				step(v1alpha1.ClusterServiceVersionKind, "csv", csv("0.0.0")),
+				step(roleKind, "role", role),
+			},
+		},
+		{
+			testName: "StorageVersionRemoved",
+			steps:    []*v1alpha1.Step{step(crdKind, existingCRD.GetName(), crd("v1"))},
+			failed: map[v1alpha1.InstallPlanConditionType]string{
+				InstallPlanPreflightStorageVersions: "CustomResourceDefinition widgets.example.com: new CRD removes version v1alpha1 that is listed as a stored version on the existing CRD",
+			},
+		},
+		{
+			testName: "KubeVersionTooOld",
+			steps:    []*v1alpha1.Step{step(v1alpha1.ClusterServiceVersionKind, "csv", csv("v99.1.0"))},
+			failed: map[v1alpha1.InstallPlanConditionType]string{
+				InstallPlanPreflightKubeVersion: "ClusterServiceVersion csv requires Kubernetes 99.1.0 or later, but the server runs 0.0.0",
+			},
+		},
+		{
+			testName: "PermissionsEscalated",
+			steps:    []*v1alpha1.Step{step(roleKind, "role", role)},
+			saRef:    sa,
+			failed: map[v1alpha1.InstallPlanConditionType]string{
+				InstallPlanPreflightPermissions: "service account ns/scoped cannot grant Role role permissions it does not hold: list pods",
+			},
+		},
+		{
+			testName: "PermissionsEscalateAllowed",
+			steps:    []*v1alpha1.Step{step(roleKind, "role", role)},
+			saRef:    sa,
+			escalate: true,
+		},
+		{
+			// escalate, get and list pods are reviewed once for both
+			// roles, and named pods only for the denied list.
+			testName: "PermissionsReviewedOnce",
+			steps:    []*v1alpha1.Step{step(roleKind, "role", role), step(roleKind, "named", namedRole)},
+			saRef:    sa,
+			failed: map[v1alpha1.InstallPlanConditionType]string{
+				InstallPlanPreflightPermissions: "service account ns/scoped cannot grant Role role permissions it does not hold: list pods; " +
+					"service account ns/scoped cannot grant Role named permissions it does not hold: list pods/a, list pods/b",
+			},
+			reviews: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+
+			op, err := NewFakeOperator(ctx, namespace, []string{namespace}, withExtObjs(existingCRD))
+			require.NoError(t, err)
+
+			// The service account may only get pods, and escalate if the
+			// test says so.
+			var reviews []authorizationv1.SubjectAccessReviewSpec
+			op.opClient.KubernetesInterface().(*k8sfake.Clientset).PrependReactor("create", "subjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview).DeepCopy()
+				reviews = append(reviews, review.Spec)
+				attributes := review.Spec.ResourceAttributes
+				review.Status.Allowed = attributes != nil && (attributes.Verb == "get" && attributes.Resource == "pods" || attributes.Verb == "escalate" && tt.escalate)
+				return true, review, nil
+			})
+
+			plan := withSteps(installPlan("p", namespace, v1alpha1.InstallPlanPhaseRequiresApproval, "csv"), tt.steps)
+			plan.Spec.Approval = v1alpha1.ApprovalManual
+			plan.Status.AttenuatedServiceAccountRef = tt.saRef
+			require.True(t, needsPreflight(plan))
+
+			require.NoError(t, op.preflightInstallPlan(plan, op.logger))
+			require.False(t, needsPreflight(plan))
+
+			for _, ct := range preflightConditionTypes {
+				cond := plan.Status.GetCondition(ct)
+				if message, ok := tt.failed[ct]; ok {
+					require.Equal(t, corev1.ConditionFalse, cond.Status, ct)
+					require.Equal(t, InstallPlanReasonPreflightFailed, cond.Reason, ct)
+					require.Equal(t, message, cond.Message, ct)
+					continue
+				}
+				require.Equal(t, corev1.ConditionTrue, cond.Status, "%s: %s", ct, cond.Message)
+				require.Equal(t, InstallPlanReasonPreflightPassed, cond.Reason, ct)
+			}
+
+			if tt.saRef == nil {
+				require.Empty(t, reviews)
+			} else {
+				require.Equal(t, "system:serviceaccount:ns:scoped", reviews[0].User)
+			}
+			if tt.reviews > 0 {
+				require.Len(t, reviews, tt.reviews)
+			}
+		})
+	}
+}
+
+func TestSyncInstallPlansPreflightErrors(t *testing.T) {
+	namespace := "ns"
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	plan := withSteps(installPlan("p", namespace, v1alpha1.InstallPlanPhaseRequiresApproval, "csv"), []*v1alpha1.Step{
+		{
+			Resource: v1alpha1.StepResource{Kind: v1alpha1.ClusterServiceVersionKind, Name: "csv", Manifest: "{"},
+			Status:   v1alpha1.StepStatusUnknown,
+		},
+	})
+	plan.Spec.Approval = v1alpha1.ApprovalManual
+	op, err := NewFakeOperator(ctx, namespace, []string{namespace}, withClientObjs(plan, operatorGroup("og", "", namespace, nil)))
+	require.NoError(t, err)
+	op.client.(*fake.ReactionForwardingClientsetDecorator).PrependReactor("update", "installplans", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("conflict")
+	})
+
+	// Neither the preflight error nor the status update error is lost.
+	err = op.syncInstallPlans(plan)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "InstallPlan preflight checks incomplete: error parsing ClusterServiceVersion csv")
+	require.Contains(t, err.Error(), "error updating InstallPlan status: conflict")
+}
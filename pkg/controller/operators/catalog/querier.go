@@ -28,6 +28,7 @@ type SourceRef struct {
 type SourceQuerier interface {
 	// Deprecated: This FindReplacement function will be deprecated soon
 	FindReplacement(currentVersion *semver.Version, bundleName, pkgName, channelName string, initialSource registry.CatalogKey) (*api.Bundle, *registry.CatalogKey, error)
+	FindChannelHead(pkgName, channelName string, source registry.CatalogKey) (*api.Bundle, error)
 	Queryable() error
 }
 
@@ -99,6 +100,52 @@ func (q *NamespaceSourceQuerier) FindReplacement(currentVersion *semver.Version,
 	return nil, nil, errors.NewAggregate(errs)
 }
 
+// ChannelHeadNotFoundError is returned by FindChannelHead when a catalog
+// serves a package, but the channel is missing from the package or has no
+// head.
+type ChannelHeadNotFoundError struct {
+	Package string
+	Channel string
+	Source  registry.CatalogKey
+	// Empty is true if the channel exists but has no head.
+	Empty bool
+}
+
+func (e ChannelHeadNotFoundError) Error() string {
+	if e.Empty {
+		return fmt.Sprintf("channel %s of package %s in CatalogSource %s has no head", e.Channel, e.Package, e.Source.Name)
+	}
+	return fmt.Sprintf("channel %s not found in package %s of CatalogSource %s", e.Channel, e.Package, e.Source.Name)
+}
+
+// FindChannelHead returns the bundle at the head of a channel of a package
+// in the given CatalogSource. It returns a ChannelHeadNotFoundError if the
+// CatalogSource serves the package, but not the head of the channel.
+func (q *NamespaceSourceQuerier) FindChannelHead(pkgName, channelName string, source registry.CatalogKey) (*api.Bundle, error) {
+	client, ok := q.sources[source]
+	if !ok {
+		return nil, fmt.Errorf("CatalogSource %s not found", source.Name)
+	}
+	head, err := client.GetBundleInPackageChannel(context.TODO(), pkgName, channelName)
+	if err == nil && head != nil {
+		return head, nil
+	}
+
+	// Tell a missing or empty channel apart from a catalog that can't be read.
+	pkg, pkgErr := client.GetPackage(context.TODO(), pkgName)
+	if pkgErr != nil || pkg == nil {
+		return nil, err
+	}
+	notFound := ChannelHeadNotFoundError{Package: pkgName, Channel: channelName, Source: source}
+	for _, ch := range pkg.GetChannels() {
+		if ch.GetName() == channelName {
+			notFound.Empty = true
+			break
+		}
+	}
+	return nil, notFound
+}
+
 func (q *NamespaceSourceQuerier) findChannelHead(currentVersion *semver.Version, pkgName, channelName string, source client.Interface) (*api.Bundle, error) {
 	if currentVersion == nil {
 		return nil, nil
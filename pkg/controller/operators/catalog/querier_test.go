@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/operator-framework/operator-registry/pkg/api"
 	"github.com/operator-framework/operator-registry/pkg/client"
 	"github.com/stretchr/testify/require"
 
@@ -105,3 +106,61 @@ func TestNamespaceSourceQuerier_Queryable(t *testing.T) {
 		})
 	}
 }
+
+func TestNamespaceSourceQuerier_FindChannelHead(t *testing.T) {
+	source := registry.CatalogKey{Name: "test", Namespace: "ns"}
+	pkg := &api.Package{Name: "pkg", Channels: []*api.Channel{{Name: "empty"}}}
+
+	tests := []struct {
+		name    string
+		channel string
+		head    *api.Bundle
+		headErr error
+		pkg     *api.Package
+		pkgErr  error
+		want    *api.Bundle
+		err     error
+	}{
+		{
+			name:    "Head",
+			channel: "stable",
+			head:    &api.Bundle{CsvName: "pkg.v1.0.0"},
+			want:    &api.Bundle{CsvName: "pkg.v1.0.0"},
+		},
+		{
+			name:    "ChannelNotFound",
+			channel: "stable",
+			headErr: fmt.Errorf("no entry found"),
+			pkg:     pkg,
+			err:     ChannelHeadNotFoundError{Package: "pkg", Channel: "stable", Source: source},
+		},
+		{
+			name:    "ChannelEmpty",
+			channel: "empty",
+			headErr: fmt.Errorf("no entry found"),
+			pkg:     pkg,
+			err:     ChannelHeadNotFoundError{Package: "pkg", Channel: "empty", Source: source, Empty: true},
+		},
+		{
+			name:    "Unreadable",
+			channel: "stable",
+			headErr: fmt.Errorf("connection refused"),
+			pkgErr:  fmt.Errorf("connection refused"),
+			err:     fmt.Errorf("connection refused"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registryClient := &fakes.FakeRegistryClient{}
+			registryClient.GetBundleForChannelReturns(tt.head, tt.headErr)
+			registryClient.GetPackageReturns(tt.pkg, tt.pkgErr)
+			q := NewNamespaceSourceQuerier(map[registry.CatalogKey]registry.ClientInterface{
+				source: &registry.Client{Client: &client.Client{Registry: registryClient}},
+			})
+
+			head, err := q.FindChannelHead("pkg", tt.channel, source)
+			require.Equal(t, tt.err, err)
+			require.Equal(t, tt.want, head)
+		})
+	}
+}
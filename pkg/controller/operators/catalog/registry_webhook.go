@@ -0,0 +1,188 @@
+package catalog
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// RegistryWebhookPath is the path the registry push webhook handler is served at.
+const RegistryWebhookPath = "/registry-webhook"
+
+// maxRegistryWebhookBodySize bounds the size of the push events read.
+const maxRegistryWebhookBodySize = 1 << 20
+
+// registryPushEvent holds the fields of the push events of the supported
+// registries that identify the pushed image.
+type registryPushEvent struct {
+	// Image is set by generic events: {"image": "quay.io/org/catalog:latest"}.
+	Image string `json:"image"`
+
+	// DockerURL and UpdatedTags are set by Quay.
+	DockerURL   string   `json:"docker_url"`
+	UpdatedTags []string `json:"updated_tags"`
+
+	// Repository and PushData are set by Docker Hub. Quay sets Repository to
+	// a string, which is ignored.
+	Repository json.RawMessage `json:"repository"`
+	PushData   *struct {
+		Tag string `json:"tag"`
+	} `json:"push_data"`
+}
+
+// images returns the images the event reports a push of. Images without a tag
+// stand for all tags of their repository.
+func (e registryPushEvent) images() []string {
+	var images []string
+	if e.Image != "" {
+		images = append(images, e.Image)
+	}
+	if e.DockerURL != "" {
+		if len(e.UpdatedTags) == 0 {
+			images = append(images, e.DockerURL)
+		}
+		for _, tag := range e.UpdatedTags {
+			images = append(images, e.DockerURL+":"+tag)
+		}
+	}
+	var hub struct {
+		RepoName string `json:"repo_name"`
+	}
+	if len(e.Repository) > 0 && json.Unmarshal(e.Repository, &hub) == nil && hub.RepoName != "" {
+		image := hub.RepoName
+		if e.PushData != nil && e.PushData.Tag != "" {
+			image += ":" + e.PushData.Tag
+		}
+		images = append(images, image)
+	}
+	return images
+}
+
+// RegistryWebhookHandler returns a handler for registry push webhooks. It
+// requests an immediate check for updates of the CatalogSources that poll the
+// pushed image, instead of waiting for their next poll. Pushed images are given
+// as image query parameters, or by the push event in the request body. Requests
+// must carry the given token as a bearer token in their Authorization header;
+// with an empty token, all requests are rejected.
+func (o *Operator) RegistryWebhookHandler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !validRegistryWebhookToken(r, token) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		images := r.URL.Query()["image"]
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxRegistryWebhookBodySize))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("error reading request: %v", err), http.StatusBadRequest)
+			return
+		}
+		if len(strings.TrimSpace(string(body))) > 0 {
+			var event registryPushEvent
+			if err := json.Unmarshal(body, &event); err != nil {
+				http.Error(w, fmt.Sprintf("invalid push event: %v", err), http.StatusBadRequest)
+				return
+			}
+			images = append(images, event.images()...)
+		}
+		if len(images) == 0 {
+			http.Error(w, "no pushed image in request", http.StatusBadRequest)
+			return
+		}
+
+		refreshed, err := o.refreshCatalogSourcesForImages(images)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(struct {
+			CatalogSources []string `json:"catalogSources"`
+		}{CatalogSources: refreshed})
+	})
+}
+
+func validRegistryWebhookToken(r *http.Request, token string) bool {
+	auth := r.Header.Get("Authorization")
+	if token == "" || !strings.HasPrefix(auth, "Bearer ") {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), []byte(token)) == 1
+}
+
+// refreshCatalogSourcesForImages requests an update check of the polled
+// CatalogSources whose image is one of the given pushed images, and returns
+// their namespace/name keys.
+func (o *Operator) refreshCatalogSourcesForImages(images []string) ([]string, error) {
+	pushed := make([]reference.Named, 0, len(images))
+	for _, image := range images {
+		named, err := reference.ParseNormalizedNamed(image)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pushed image %q: %v", image, err)
+		}
+		pushed = append(pushed, named)
+	}
+
+	catsrcs, err := o.lister.OperatorsV1alpha1().CatalogSourceLister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	var refreshed []string
+	for _, catsrc := range catsrcs {
+		if !catsrc.Poll() {
+			continue
+		}
+		image, err := reference.ParseNormalizedNamed(catsrc.Spec.Image)
+		if err != nil {
+			continue
+		}
+		for _, p := range pushed {
+			if !pushMatches(p, image) {
+				continue
+			}
+			o.catalogRefresher.Request(catsrc.GetNamespace(), catsrc.GetName())
+			if err := o.catsrcQueueSet.Requeue(catsrc.GetNamespace(), catsrc.GetName()); err != nil {
+				o.logger.WithError(err).WithFields(logrus.Fields{"catalogsource": catsrc.GetName(), "namespace": catsrc.GetNamespace()}).Warn("error requeueing catalogsource for refresh")
+			}
+			refreshed = append(refreshed, catsrc.GetNamespace()+"/"+catsrc.GetName())
+			break
+		}
+	}
+	sort.Strings(refreshed)
+
+	return refreshed, nil
+}
+
+// pushMatches returns true if a push of the pushed image may have changed the
+// given image: they name the same repository, and the same tag unless the push
+// names none. Images pinned to a digest never change.
+func pushMatches(pushed, image reference.Named) bool {
+	if pushed.Name() != image.Name() {
+		return false
+	}
+	if _, ok := image.(reference.Digested); ok {
+		return false
+	}
+	pushedTag, ok := pushed.(reference.Tagged)
+	if !ok {
+		return true
+	}
+	imageTag, ok := reference.TagNameOnly(image).(reference.Tagged)
+	return ok && imageTag.Tag() == pushedTag.Tag()
+}
@@ -0,0 +1,233 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/distribution/reference"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+)
+
+func TestPushMatches(t *testing.T) {
+	tests := []struct {
+		pushed string
+		image  string
+		want   bool
+	}{
+		{pushed: "quay.io/org/catalog:latest", image: "quay.io/org/catalog:latest", want: true},
+		{pushed: "quay.io/org/catalog:latest", image: "quay.io/org/catalog", want: true},
+		{pushed: "quay.io/org/catalog", image: "quay.io/org/catalog:v1", want: true},
+		{pushed: "quay.io/org/catalog:v2", image: "quay.io/org/catalog:v1", want: false},
+		{pushed: "quay.io/org/catalog:latest", image: "quay.io/org/other:latest", want: false},
+		{pushed: "org/catalog:latest", image: "docker.io/org/catalog:latest", want: true},
+		{pushed: "quay.io/org/catalog", image: "quay.io/org/catalog@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pushed+"/"+tt.image, func(t *testing.T) {
+			pushed, err := reference.ParseNormalizedNamed(tt.pushed)
+			require.NoError(t, err)
+			image, err := reference.ParseNormalizedNamed(tt.image)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, pushMatches(pushed, image))
+		})
+	}
+}
+
+func TestRegistryPushEventImages(t *testing.T) {
+	tests := []struct {
+		name  string
+		event string
+		want  []string
+	}{
+		{
+			name:  "Generic",
+			event: `{"image": "quay.io/org/catalog:latest"}`,
+			want:  []string{"quay.io/org/catalog:latest"},
+		},
+		{
+			name:  "Quay",
+			event: `{"repository": "org/catalog", "docker_url": "quay.io/org/catalog", "updated_tags": ["latest", "v1"]}`,
+			want:  []string{"quay.io/org/catalog:latest", "quay.io/org/catalog:v1"},
+		},
+		{
+			name:  "QuayWithoutTags",
+			event: `{"repository": "org/catalog", "docker_url": "quay.io/org/catalog"}`,
+			want:  []string{"quay.io/org/catalog"},
+		},
+		{
+			name:  "DockerHub",
+			event: `{"push_data": {"tag": "latest"}, "repository": {"repo_name": "org/catalog"}}`,
+			want:  []string{"org/catalog:latest"},
+		},
+		{
+			name:  "Unknown",
+			event: `{"something": "else"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var event registryPushEvent
+			require.NoError(t, json.Unmarshal([]byte(tt.event), &event))
+			require.Equal(t, tt.want, event.images())
+		})
+	}
+}
+
+func TestRegistryWebhookHandler(t *testing.T) {
+	polled := func(namespace, name, image string) *v1alpha1.CatalogSource {
+		return &v1alpha1.CatalogSource{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, UID: types.UID(namespace + "/" + name)},
+			Spec: v1alpha1.CatalogSourceSpec{
+				SourceType: v1alpha1.SourceTypeGrpc,
+				Image:      image,
+				UpdateStrategy: &v1alpha1.UpdateStrategy{
+					RegistryPoll: &v1alpha1.RegistryPoll{Interval: &metav1.Duration{Duration: time.Hour}},
+				},
+			},
+		}
+	}
+	notPolled := polled("ns-a", "not-polled", "quay.io/org/catalog:latest")
+	notPolled.Spec.UpdateStrategy = nil
+	bearer := http.Header{"Authorization": []string{"Bearer secret"}}
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		header     http.Header
+		body       string
+		wantStatus int
+		wantKeys   []string
+	}{
+		{
+			name:       "WrongMethod",
+			method:     http.MethodGet,
+			target:     RegistryWebhookPath + "?image=quay.io/org/catalog:latest",
+			header:     bearer,
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "MissingToken",
+			method:     http.MethodPost,
+			target:     RegistryWebhookPath + "?image=quay.io/org/catalog:latest",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "TokenQueryParameter",
+			method:     http.MethodPost,
+			target:     RegistryWebhookPath + "?token=secret&image=quay.io/org/catalog:latest",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "WrongToken",
+			method:     http.MethodPost,
+			target:     RegistryWebhookPath + "?image=quay.io/org/catalog:latest",
+			header:     http.Header{"Authorization": []string{"Bearer wrong"}},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "NoImage",
+			method:     http.MethodPost,
+			target:     RegistryWebhookPath,
+			header:     bearer,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "InvalidImage",
+			method:     http.MethodPost,
+			target:     RegistryWebhookPath + "?image=Quay.io/Org",
+			header:     bearer,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "InvalidEvent",
+			method:     http.MethodPost,
+			target:     RegistryWebhookPath,
+			header:     bearer,
+			body:       `{`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "ImageQueryParameter",
+			method:     http.MethodPost,
+			target:     RegistryWebhookPath + "?image=quay.io/org/catalog:latest",
+			header:     bearer,
+			wantStatus: http.StatusAccepted,
+			wantKeys:   []string{"ns-a/latest", "ns-b/default-tag"},
+		},
+		{
+			name:       "QuayEvent",
+			method:     http.MethodPost,
+			target:     RegistryWebhookPath,
+			header:     bearer,
+			body:       `{"docker_url": "quay.io/org/catalog", "updated_tags": ["v1"]}`,
+			wantStatus: http.StatusAccepted,
+			wantKeys:   []string{"ns-a/v1"},
+		},
+		{
+			name:       "NoMatch",
+			method:     http.MethodPost,
+			target:     RegistryWebhookPath + "?image=quay.io/org/other",
+			header:     bearer,
+			wantStatus: http.StatusAccepted,
+			wantKeys:   []string{},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	op, err := NewFakeOperator(ctx, "ns-a", []string{"ns-a", "ns-b"}, withClientObjs(
+		polled("ns-a", "latest", "quay.io/org/catalog:latest"),
+		polled("ns-a", "v1", "quay.io/org/catalog:v1"),
+		polled("ns-b", "default-tag", "quay.io/org/catalog"),
+		polled("ns-b", "other", "quay.io/org/other-catalog:latest"),
+		notPolled,
+	))
+	require.NoError(t, err)
+	handler := op.RegistryWebhookHandler("secret")
+
+	// Without a token, all requests are rejected.
+	req := httptest.NewRequest(http.MethodPost, RegistryWebhookPath+"?image=quay.io/org/catalog:latest", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	rec := httptest.NewRecorder()
+	op.RegistryWebhookHandler("").ServeHTTP(rec, req)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			require.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+			if tt.wantStatus != http.StatusAccepted {
+				return
+			}
+
+			var resp struct {
+				CatalogSources []string `json:"catalogSources"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			if len(tt.wantKeys) == 0 {
+				require.Empty(t, resp.CatalogSources)
+				return
+			}
+			require.Equal(t, tt.wantKeys, resp.CatalogSources)
+			for _, key := range tt.wantKeys {
+				parts := strings.SplitN(key, "/", 2)
+				require.True(t, op.catalogRefresher.Requested(parts[0], parts[1]), key)
+			}
+		})
+	}
+}
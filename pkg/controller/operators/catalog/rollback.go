@@ -0,0 +1,306 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	olmerrors "github.com/operator-framework/operator-lifecycle-manager/pkg/controller/errors"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/ownerutil"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/scoped"
+)
+
+const (
+	// RollbackOnFailureAnnotationKey opts an InstallPlan into transactional
+	// execution when set to "true". InstallPlans created for a Subscription
+	// carrying the annotation inherit it.
+	RollbackOnFailureAnnotationKey = "operatorframework.io/rollback-on-failure"
+
+	// InstallPlanReasonRolledBack is the reason of the Installed condition of
+	// a failed InstallPlan whose steps have been reverted.
+	InstallPlanReasonRolledBack v1alpha1.InstallPlanConditionReason = "RolledBack"
+
+	rollbackJournalSnapshotKey = "snapshot"
+)
+
+// stepSnapshot is the state of a step's target object before the step was
+// first applied. A nil Object means that the target did not exist.
+type stepSnapshot struct {
+	Group   string                     `json:"group"`
+	Version string                     `json:"version"`
+	Kind    string                     `json:"kind"`
+	Name    string                     `json:"name"`
+	Object  *unstructured.Unstructured `json:"object,omitempty"`
+}
+
+func (s stepSnapshot) GroupVersionKind() schema.GroupVersionKind {
+	return schema.GroupVersionKind{Group: s.Group, Version: s.Version, Kind: s.Kind}
+}
+
+// rollbackEnabled returns true if the given InstallPlan or Subscription has
+// opted into transactional InstallPlan execution.
+func rollbackEnabled(obj metav1.Object) bool {
+	return obj.GetAnnotations()[RollbackOnFailureAnnotationKey] == "true"
+}
+
+// rollbackJournalName returns the name of the Secret that holds the snapshot
+// recorded for the i-th step of the given InstallPlan.
+func rollbackJournalName(plan *v1alpha1.InstallPlan, i int) string {
+	return fmt.Sprintf("%s-rollback-%d", plan.GetName(), i)
+}
+
+// stepTargetClients are the dynamic clients that the targets of an
+// InstallPlan's steps are applied with: CRDs with the catalog operator's own
+// client, and all other kinds with the client scoped to the service account
+// of the plan's OperatorGroup, if it specifies one.
+type stepTargetClients struct {
+	operator dynamic.Interface
+	scoped   dynamic.Interface
+}
+
+// stepTargetClientsFor returns the clients that the steps of the given plan
+// are executed with.
+func (o *Operator) stepTargetClientsFor(plan *v1alpha1.InstallPlan) (stepTargetClients, error) {
+	attenuate, err := o.clientAttenuator.AttenuateToServiceAccount(scoped.StaticQuerier(plan.Status.AttenuatedServiceAccountRef))
+	if err != nil {
+		return stepTargetClients{}, err
+	}
+	scopedClient, err := o.clientFactory.WithConfigTransformer(attenuate).NewDynamicClient()
+	if err != nil {
+		return stepTargetClients{}, err
+	}
+	operatorClient, err := o.clientFactory.NewDynamicClient()
+	if err != nil {
+		return stepTargetClients{}, err
+	}
+	return stepTargetClients{operator: operatorClient, scoped: scopedClient}, nil
+}
+
+// stepTargetClient returns a client for the resource of the given kind, as
+// determined via discovery, and whether the resource is namespaced. Clients
+// for namespaced resources are scoped to the given namespace.
+func (o *Operator) stepTargetClient(clients stepTargetClients, namespace string, gvk schema.GroupVersionKind) (dynamic.ResourceInterface, bool, error) {
+	r, err := o.apiresourceFromGVK(gvk)
+	if err != nil {
+		return nil, false, err
+	}
+
+	client := clients.scoped
+	if gvk.Kind == crdKind {
+		client = clients.operator
+	}
+	gvr := gvk.GroupVersion().WithResource(r.Name)
+	if r.Namespaced {
+		return client.Resource(gvr).Namespace(namespace), true, nil
+	}
+	return client.Resource(gvr), false, nil
+}
+
+// recordStepTarget saves the current state of the object targeted by the i-th
+// step of the plan, unless a snapshot for that step has already been recorded
+// by an earlier execution attempt. Each snapshot is kept in a Secret of its
+// own, since the targets may be Secrets, and are limited in size only by the
+// limit of a single object. The Secrets are owned by the InstallPlan so that
+// they are garbage collected along with it.
+func (o *Operator) recordStepTarget(plan *v1alpha1.InstallPlan, i int, step *v1alpha1.Step, clients stepTargetClients) error {
+	secrets := o.opClient.KubernetesInterface().CoreV1().Secrets(plan.GetNamespace())
+	if _, err := secrets.Get(context.TODO(), rollbackJournalName(plan, i), metav1.GetOptions{}); err == nil {
+		return nil
+	} else if !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("error getting rollback journal: %v", err)
+	}
+
+	snapshot := stepSnapshot{
+		Group:   step.Resource.Group,
+		Version: step.Resource.Version,
+		Kind:    step.Resource.Kind,
+		Name:    step.Resource.Name,
+	}
+	client, _, err := o.stepTargetClient(clients, plan.GetNamespace(), snapshot.GroupVersionKind())
+	if _, ok := err.(olmerrors.GroupVersionKindNotFoundError); ok || k8serrors.IsNotFound(err) {
+		// The API is not served (yet), e.g. because it is defined by a CRD
+		// that is part of this plan, so no object of this kind can exist.
+	} else if err != nil {
+		return err
+	} else {
+		current, err := client.Get(context.TODO(), step.Resource.Name, metav1.GetOptions{})
+		if err == nil {
+			snapshot.Object = current
+		} else if !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("error recording prior state of %s %s: %v", step.Resource.Kind, step.Resource.Name, err)
+		}
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	entry := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rollbackJournalName(plan, i),
+			Namespace: plan.GetNamespace(),
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{rollbackJournalSnapshotKey: data},
+	}
+	ownerutil.AddNonBlockingOwner(entry, plan)
+	if _, err := secrets.Create(context.TODO(), entry, metav1.CreateOptions{}); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return fmt.Errorf("error recording rollback journal: %v", err)
+	}
+	return nil
+}
+
+// rollbackJournal returns the recorded snapshots of the plan's steps, by step
+// index.
+func (o *Operator) rollbackJournal(plan *v1alpha1.InstallPlan) (map[int]stepSnapshot, error) {
+	journal := map[int]stepSnapshot{}
+	for i := range plan.Status.Plan {
+		entry, err := o.opClient.KubernetesInterface().CoreV1().Secrets(plan.GetNamespace()).Get(context.TODO(), rollbackJournalName(plan, i), metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var snapshot stepSnapshot
+		if err := json.Unmarshal(entry.Data[rollbackJournalSnapshotKey], &snapshot); err != nil {
+			return nil, fmt.Errorf("error decoding rollback journal %s: %v", entry.GetName(), err)
+		}
+		journal[i] = snapshot
+	}
+	return journal, nil
+}
+
+// discardRollbackJournal deletes the recorded snapshots of the given steps of
+// the plan.
+func (o *Operator) discardRollbackJournal(plan *v1alpha1.InstallPlan, journal map[int]stepSnapshot) error {
+	for i := range journal {
+		err := o.opClient.KubernetesInterface().CoreV1().Secrets(plan.GetNamespace()).Delete(context.TODO(), rollbackJournalName(plan, i), metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// rollbackInstallPlan reverts the targets of all recorded steps of the plan to
+// their prior state, in reverse step order, and marks the reverted steps as
+// not present. The journal is deleted once all steps have been reverted.
+func (o *Operator) rollbackInstallPlan(plan *v1alpha1.InstallPlan) error {
+	journal, err := o.rollbackJournal(plan)
+	if err != nil {
+		return err
+	}
+	if len(journal) == 0 {
+		// Nothing has been applied.
+		return nil
+	}
+
+	clients, err := o.stepTargetClientsFor(plan)
+	if err != nil {
+		return err
+	}
+
+	var indices []int
+	for i := range journal {
+		indices = append(indices, i)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(indices)))
+
+	ensurer := newStepEnsurer(o.opClient, o.client, clients.scoped)
+	for _, i := range indices {
+		snapshot := journal[i]
+		client, _, err := o.stepTargetClient(clients, plan.GetNamespace(), snapshot.GroupVersionKind())
+		if _, ok := err.(olmerrors.GroupVersionKindNotFoundError); ok || k8serrors.IsNotFound(err) {
+			// The API is gone, and with it any object the step created.
+		} else if err != nil {
+			return err
+		} else if err := ensurer.RevertUnstructuredObject(client, snapshot.Name, snapshot.Object); err != nil {
+			return err
+		}
+
+		plan.Status.Plan[i].Status = v1alpha1.StepStatusNotPresent
+	}
+
+	return o.discardRollbackJournal(plan, journal)
+}
+
+// transitionInstallPlanToRolledBack reverts a failed plan and records the
+// outcome on its Installed condition.
+func (o *Operator) transitionInstallPlanToRolledBack(plan *v1alpha1.InstallPlan, logger logrus.FieldLogger, message string) {
+	now := o.now()
+	plan.Status.Phase = v1alpha1.InstallPlanPhaseFailed
+	if err := o.rollbackInstallPlan(plan); err != nil {
+		logger.WithError(err).Warn("failed to roll back InstallPlan")
+		message = fmt.Sprintf("%s; rollback failed: %v", message, err)
+		plan.Status.SetCondition(v1alpha1.ConditionFailed(v1alpha1.InstallPlanInstalled, v1alpha1.InstallPlanReasonComponentFailed, message, &now))
+		plan.Status.Message = message
+		return
+	}
+
+	logger.Info("rolled back InstallPlan")
+	message = fmt.Sprintf("%s; rolled back", message)
+	plan.Status.SetCondition(v1alpha1.ConditionFailed(v1alpha1.InstallPlanInstalled, InstallPlanReasonRolledBack, message, &now))
+	plan.Status.Message = message
+}
+
+// syncInstallPlanRollback watches over a completed plan that still has a
+// rollback journal until the plan's ClusterServiceVersions succeed, at which
+// point the journal is discarded. If they do not succeed within the rollback
+// timeout, the plan is rolled back and marked as failed.
+func (o *Operator) syncInstallPlanRollback(plan *v1alpha1.InstallPlan, logger *logrus.Entry) error {
+	journal, err := o.rollbackJournal(plan)
+	if err != nil {
+		return err
+	}
+	if len(journal) == 0 {
+		return nil
+	}
+
+	var pending []string
+	for _, name := range plan.Spec.ClusterServiceVersionNames {
+		csv, err := o.client.OperatorsV1alpha1().ClusterServiceVersions(plan.GetNamespace()).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+		if err != nil || csv.Status.Phase != v1alpha1.CSVPhaseSucceeded {
+			pending = append(pending, name)
+		}
+	}
+
+	if len(pending) == 0 {
+		logger.Info("InstallPlan ClusterServiceVersions succeeded, discarding rollback journal")
+		return o.discardRollbackJournal(plan, journal)
+	}
+
+	completed := plan.Status.GetCondition(v1alpha1.InstallPlanInstalled).LastTransitionTime
+	if completed != nil {
+		if remaining := o.installPlanRollbackTimeout - o.now().Sub(completed.Time); remaining > 0 {
+			if remaining > 30*time.Second {
+				remaining = 30 * time.Second
+			}
+			return o.ipQueueSet.RequeueAfter(plan.GetNamespace(), plan.GetName(), remaining)
+		}
+	}
+
+	out := plan.DeepCopy()
+	o.transitionInstallPlanToRolledBack(out, logger, fmt.Sprintf("clusterserviceversions %s did not reach phase %s within %s", strings.Join(pending, ", "), v1alpha1.CSVPhaseSucceeded, o.installPlanRollbackTimeout))
+	if _, err := o.client.OperatorsV1alpha1().InstallPlans(out.GetNamespace()).UpdateStatus(context.TODO(), out, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("error updating InstallPlan status: %v", err)
+	}
+	o.requeueSubscriptionForInstallPlan(plan, logger)
+	return nil
+}
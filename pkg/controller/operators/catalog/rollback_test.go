@@ -0,0 +1,230 @@
+package catalog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilclock "k8s.io/apimachinery/pkg/util/clock"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+)
+
+var (
+	configMapGVR      = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	serviceAccountGVR = schema.GroupVersionResource{Version: "v1", Resource: "serviceaccounts"}
+)
+
+// newRollbackTestOperator returns a fake Operator whose discovery serves
+// ConfigMaps and ServiceAccounts, with an existing ConfigMap "cfg".
+func newRollbackTestOperator(t *testing.T, ctx context.Context, namespace string, fakeOptions ...fakeOperatorOption) *Operator {
+	op, err := NewFakeOperator(ctx, namespace, []string{namespace}, fakeOptions...)
+	require.NoError(t, err)
+
+	op.opClient.KubernetesInterface().(*k8sfake.Clientset).Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Name: "configmaps", Namespaced: true, Kind: "ConfigMap"},
+				{Name: "serviceaccounts", Namespaced: true, Kind: "ServiceAccount"},
+			},
+		},
+	}
+
+	_, err = op.dynamicClient.Resource(configMapGVR).Namespace(namespace).Create(context.TODO(), unstructuredConfigMap(namespace, "cfg", "1"), metav1.CreateOptions{})
+	require.NoError(t, err)
+	return op
+}
+
+func unstructuredConfigMap(namespace, name, value string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": namespace,
+		},
+		"data": map[string]interface{}{
+			"key": value,
+		},
+	}}
+}
+
+func unstructuredServiceAccount(namespace, name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ServiceAccount",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": namespace,
+		},
+	}}
+}
+
+func rollbackTestPlan(namespace string, phase v1alpha1.InstallPlanPhase) *v1alpha1.InstallPlan {
+	plan := withSteps(installPlan("p", namespace, phase, "csv"), []*v1alpha1.Step{
+		{
+			Resource: v1alpha1.StepResource{Version: "v1", Kind: "ConfigMap", Name: "cfg"},
+			Status:   v1alpha1.StepStatusUnknown,
+		},
+		{
+			Resource: v1alpha1.StepResource{Version: "v1", Kind: "ServiceAccount", Name: "sa"},
+			Status:   v1alpha1.StepStatusUnknown,
+		},
+	})
+	plan.SetAnnotations(map[string]string{RollbackOnFailureAnnotationKey: "true"})
+	return plan
+}
+
+// applyRollbackTestPlan records and applies the steps of a rollbackTestPlan:
+// "cfg" is updated and "sa" is created.
+func applyRollbackTestPlan(t *testing.T, op *Operator, plan *v1alpha1.InstallPlan) {
+	clients, err := op.stepTargetClientsFor(plan)
+	require.NoError(t, err)
+	for i, step := range plan.Status.Plan {
+		require.NoError(t, op.recordStepTarget(plan, i, step, clients))
+	}
+
+	namespace := plan.GetNamespace()
+	configMaps := op.dynamicClient.Resource(configMapGVR).Namespace(namespace)
+	_, err = newStepEnsurer(op.opClient, op.client, op.dynamicClient).EnsureUnstructuredObject(configMaps, unstructuredConfigMap(namespace, "cfg", "2"))
+	require.NoError(t, err)
+	_, err = op.dynamicClient.Resource(serviceAccountGVR).Namespace(namespace).Create(context.TODO(), unstructuredServiceAccount(namespace, "sa"), metav1.CreateOptions{})
+	require.NoError(t, err)
+	for _, step := range plan.Status.Plan {
+		step.Status = v1alpha1.StepStatusCreated
+	}
+
+	// Recording again, e.g. on a retry, must not replace the prior state.
+	require.NoError(t, op.recordStepTarget(plan, 0, plan.Status.Plan[0], clients))
+}
+
+func requireRolledBack(t *testing.T, op *Operator, namespace string) {
+	cfg, err := op.dynamicClient.Resource(configMapGVR).Namespace(namespace).Get(context.TODO(), "cfg", metav1.GetOptions{})
+	require.NoError(t, err)
+	value, _, err := unstructured.NestedString(cfg.Object, "data", "key")
+	require.NoError(t, err)
+	require.Equal(t, "1", value)
+
+	_, err = op.dynamicClient.Resource(serviceAccountGVR).Namespace(namespace).Get(context.TODO(), "sa", metav1.GetOptions{})
+	require.True(t, k8serrors.IsNotFound(err), "expected sa to be deleted, got %v", err)
+}
+
+func requireNoRollbackJournal(t *testing.T, op *Operator, plan *v1alpha1.InstallPlan) {
+	for i := range plan.Status.Plan {
+		_, err := op.opClient.KubernetesInterface().CoreV1().Secrets(plan.GetNamespace()).Get(context.TODO(), rollbackJournalName(plan, i), metav1.GetOptions{})
+		require.True(t, k8serrors.IsNotFound(err), "expected rollback journal to be deleted, got %v", err)
+	}
+}
+
+func TestRollbackInstallPlan(t *testing.T) {
+	namespace := "ns"
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	op := newRollbackTestOperator(t, ctx, namespace)
+	plan := rollbackTestPlan(namespace, v1alpha1.InstallPlanPhaseInstalling)
+	applyRollbackTestPlan(t, op, plan)
+
+	// Each step is journaled in a Secret of its own.
+	for i := range plan.Status.Plan {
+		entry, err := op.opClient.KubernetesInterface().CoreV1().Secrets(namespace).Get(context.TODO(), rollbackJournalName(plan, i), metav1.GetOptions{})
+		require.NoError(t, err)
+		require.Contains(t, entry.Data, rollbackJournalSnapshotKey)
+		require.Len(t, entry.GetOwnerReferences(), 1)
+		require.Equal(t, plan.GetName(), entry.GetOwnerReferences()[0].Name)
+	}
+
+	require.NoError(t, op.rollbackInstallPlan(plan))
+	requireRolledBack(t, op, namespace)
+	requireNoRollbackJournal(t, op, plan)
+	for _, step := range plan.Status.Plan {
+		require.Equal(t, v1alpha1.StepStatusNotPresent, step.Status)
+	}
+}
+
+func TestSyncInstallPlanRollback(t *testing.T) {
+	namespace := "ns"
+	clockFake := utilclock.NewFakeClock(time.Date(2018, time.January, 26, 20, 40, 0, 0, time.UTC))
+	completed := metav1.NewTime(clockFake.Now().Add(-time.Hour))
+
+	tests := []struct {
+		testName     string
+		csvPhase     v1alpha1.ClusterServiceVersionPhase
+		expectedPlan v1alpha1.InstallPlanPhase
+		rolledBack   bool
+	}{
+		{
+			testName:     "CSVSucceeded",
+			csvPhase:     v1alpha1.CSVPhaseSucceeded,
+			expectedPlan: v1alpha1.InstallPlanPhaseComplete,
+		},
+		{
+			testName:     "CSVDidNotSucceed",
+			csvPhase:     v1alpha1.CSVPhaseFailed,
+			expectedPlan: v1alpha1.InstallPlanPhaseFailed,
+			rolledBack:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+
+			plan := rollbackTestPlan(namespace, v1alpha1.InstallPlanPhaseComplete)
+			plan.Status.SetCondition(v1alpha1.ConditionMet(v1alpha1.InstallPlanInstalled, &completed))
+			csv := &v1alpha1.ClusterServiceVersion{
+				ObjectMeta: metav1.ObjectMeta{Name: "csv", Namespace: namespace},
+				Status:     v1alpha1.ClusterServiceVersionStatus{Phase: tt.csvPhase},
+			}
+
+			op := newRollbackTestOperator(t, ctx, namespace, withClock(clockFake), withClientObjs(plan, csv))
+			op.installPlanRollbackTimeout = time.Minute
+			applyRollbackTestPlan(t, op, plan)
+
+			require.NoError(t, op.syncInstallPlanRollback(plan, op.logger.WithField("test", tt.testName)))
+			requireNoRollbackJournal(t, op, plan)
+
+			out, err := op.client.OperatorsV1alpha1().InstallPlans(namespace).Get(context.TODO(), plan.GetName(), metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedPlan, out.Status.Phase)
+			if !tt.rolledBack {
+				return
+			}
+			requireRolledBack(t, op, namespace)
+			cond := out.Status.GetCondition(v1alpha1.InstallPlanInstalled)
+			require.Equal(t, InstallPlanReasonRolledBack, cond.Reason)
+			require.Equal(t, "clusterserviceversions csv did not reach phase Succeeded within 1m0s; rolled back", cond.Message)
+		})
+	}
+}
+
+func TestRecordStepTargetScopedClient(t *testing.T) {
+	namespace := "ns"
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	op := newRollbackTestOperator(t, ctx, namespace)
+	plan := rollbackTestPlan(namespace, v1alpha1.InstallPlanPhaseInstalling)
+
+	// Steps other than CRDs are recorded with the client that executes them.
+	scopedClient := fakedynamic.NewSimpleDynamicClient(runtime.NewScheme(), unstructuredConfigMap(namespace, "cfg", "scoped"))
+	clients := stepTargetClients{operator: op.dynamicClient, scoped: scopedClient}
+	require.NoError(t, op.recordStepTarget(plan, 0, plan.Status.Plan[0], clients))
+
+	journal, err := op.rollbackJournal(plan)
+	require.NoError(t, err)
+	require.Len(t, journal, 1)
+	value, _, err := unstructured.NestedString(journal[0].Object.Object, "data", "key")
+	require.NoError(t, err)
+	require.Equal(t, "scoped", value)
+}
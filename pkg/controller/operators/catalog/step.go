@@ -45,6 +45,22 @@ type builder struct {
 	logger           logrus.FieldLogger
 
 	annotator alongside.Annotator
+
+	// execution is set when the plan is executed, and holds what the Steppers
+	// of kinds other than CRDs need.
+	execution *planExecution
+}
+
+// planExecution holds the state shared by the Steppers of one execution of an
+// InstallPlan. CRDs are installed with the OLM clients of the builder, while
+// the objects of all other kinds are ensured with the clients scoped to the
+// service account of the plan's OperatorGroup, if it specifies one.
+type planExecution struct {
+	operator          *Operator
+	ensurer           *StepEnsurer
+	dynamicClient     dynamic.Interface
+	initialCSVNames   map[string]struct{}
+	existingCRDOwners map[string][]string
 }
 
 func newBuilder(plan *v1alpha1.InstallPlan, csvLister listersv1alpha1.ClusterServiceVersionLister, opclient operatorclient.ClientInterface, dynamicClient dynamic.Interface, manifestResolver ManifestResolver, logger logrus.FieldLogger) *builder {
@@ -58,23 +74,37 @@ func newBuilder(plan *v1alpha1.InstallPlan, csvLister listersv1alpha1.ClusterSer
 	}
 }
 
-type notSupportedStepperErr struct {
-	message string
-}
+// stepperBuilderFunc returns a Stepper that ensures the resource described by
+// the given step and its resolved manifest. The context is the one of the
+// step's execution.
+type stepperBuilderFunc func(ctx context.Context, b *builder, step *v1alpha1.Step, manifest string) (Stepper, error)
 
-func (n notSupportedStepperErr) Error() string {
-	return n.message
-}
+// stepperBuilders maps a step's resource Kind to the builder for its Stepper.
+// Steps whose Kind has no registered builder are applied as unstructured
+// objects, if their Kind has been allowlisted.
+var stepperBuilders = map[string]stepperBuilderFunc{}
 
-// step is a factory that creates StepperFuncs based on the install plan step Kind.
-func (b *builder) create(step v1alpha1.Step) (Stepper, error) {
-	manifest, err := b.manifestResolver.ManifestForStep(&step)
-	if err != nil {
-		return nil, err
+// unstructuredKinds are the Kinds registered with registerUnstructuredKind.
+var unstructuredKinds = map[string]struct{}{}
+
+// registerStepperBuilder makes steps of the given Kind be executed by the
+// Steppers returned from fn. Registering the same Kind twice panics.
+func registerStepperBuilder(kind string, fn stepperBuilderFunc) {
+	if _, ok := stepperBuilders[kind]; ok {
+		panic(fmt.Sprintf("stepper builder for %s already registered", kind))
 	}
+	stepperBuilders[kind] = fn
+}
 
-	switch step.Resource.Kind {
-	case crdKind:
+// registerUnstructuredKind makes steps of the given Kind be applied as
+// unstructured objects with the dynamic client, without allowlisting.
+func registerUnstructuredKind(kind string) {
+	registerStepperBuilder(kind, newUnstructuredStepper)
+	unstructuredKinds[kind] = struct{}{}
+}
+
+func init() {
+	registerStepperBuilder(crdKind, func(_ context.Context, b *builder, step *v1alpha1.Step, manifest string) (Stepper, error) {
 		version, err := crdlib.Version(&manifest)
 		if err != nil {
 			return nil, err
@@ -82,12 +112,44 @@ func (b *builder) create(step v1alpha1.Step) (Stepper, error) {
 
 		switch version {
 		case crdlib.V1Version:
-			return b.NewCRDV1Step(b.opclient.ApiextensionsInterface().ApiextensionsV1(), &step, manifest), nil
+			return b.NewCRDV1Step(b.opclient.ApiextensionsInterface().ApiextensionsV1(), step, manifest), nil
 		case crdlib.V1Beta1Version:
-			return b.NewCRDV1Beta1Step(b.opclient.ApiextensionsInterface().ApiextensionsV1beta1(), &step, manifest), nil
+			return b.NewCRDV1Beta1Step(b.opclient.ApiextensionsInterface().ApiextensionsV1beta1(), step, manifest), nil
+		}
+		return StepperFunc(func() (v1alpha1.StepStatus, error) {
+			return v1alpha1.StepStatusUnsupportedResource, v1alpha1.ErrInvalidInstallPlan
+		}), nil
+	})
+}
+
+// step is a factory that creates StepperFuncs based on the install plan step Kind.
+func (b *builder) create(ctx context.Context, step v1alpha1.Step) (Stepper, error) {
+	build, ok := stepperBuilders[step.Resource.Kind]
+	if !ok {
+		build = newUnstructuredStepper
+	}
+
+	manifest, err := b.manifestResolver.ManifestForStep(&step)
+	if err != nil {
+		return nil, err
+	}
+
+	return build(ctx, b, &step, manifest)
+}
+
+// ensureOnce returns a Stepper that ensures the object of the given step with
+// ensure until the step is present or created.
+func (b *builder) ensureOnce(step *v1alpha1.Step, ensure func() (v1alpha1.StepStatus, error)) StepperFunc {
+	return func() (v1alpha1.StepStatus, error) {
+		switch step.Status {
+		case v1alpha1.StepStatusPresent, v1alpha1.StepStatusCreated, v1alpha1.StepStatusWaitingForAPI:
+			return step.Status, nil
+		case v1alpha1.StepStatusUnknown, v1alpha1.StepStatusNotPresent:
+			b.logger.WithFields(logrus.Fields{"kind": step.Resource.Kind, "name": step.Resource.Name}).Debug("execute resource")
+			return ensure()
 		}
+		return step.Status, v1alpha1.ErrInvalidInstallPlan
 	}
-	return nil, notSupportedStepperErr{fmt.Sprintf("stepper interface does not support %s", step.Resource.Kind)}
 }
 
 func (b *builder) NewCRDV1Step(client apiextensionsv1client.ApiextensionsV1Interface, step *v1alpha1.Step, manifest string) StepperFunc {
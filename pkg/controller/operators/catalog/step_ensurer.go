@@ -328,6 +328,37 @@ func (o *StepEnsurer) EnsureUnstructuredObject(client dynamic.ResourceInterface,
 	return
 }
 
+// RevertUnstructuredObject restores the named object to its prior state. A nil
+// prior state means that the object did not exist, in which case it is deleted.
+func (o *StepEnsurer) RevertUnstructuredObject(client dynamic.ResourceInterface, name string, prior *unstructured.Unstructured) error {
+	if prior == nil {
+		if err := client.Delete(context.TODO(), name, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+			return errorwrap.Wrapf(err, "error deleting unstructured object %s", name)
+		}
+		return nil
+	}
+
+	obj := prior.DeepCopy()
+	current, err := client.Get(context.TODO(), name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		obj.SetResourceVersion("")
+		obj.SetUID("")
+		if _, err := client.Create(context.TODO(), obj, metav1.CreateOptions{}); err != nil {
+			return errorwrap.Wrapf(err, "error recreating unstructured object %s", name)
+		}
+		return nil
+	}
+	if err != nil {
+		return errorwrap.Wrapf(err, "error getting unstructured object %s", name)
+	}
+
+	obj.SetResourceVersion(current.GetResourceVersion())
+	if _, err := client.Update(context.TODO(), obj, metav1.UpdateOptions{}); err != nil {
+		return errorwrap.Wrapf(err, "error reverting unstructured object %s", name)
+	}
+	return nil
+}
+
 // EnsureConfigMap writes the specified ConfigMap object to the cluster.
 func (o *StepEnsurer) EnsureConfigMap(namespace string, configmap *corev1.ConfigMap) (status v1alpha1.StepStatus, err error) {
 	_, createErr := o.kubeClient.KubernetesInterface().CoreV1().ConfigMaps(namespace).Create(context.TODO(), configmap, metav1.CreateOptions{})
@@ -0,0 +1,360 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	errorwrap "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/ownerutil"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/tracing"
+)
+
+func init() {
+	registerStepperBuilder(v1alpha1.ClusterServiceVersionKind, newClusterServiceVersionStepper)
+	registerStepperBuilder(v1alpha1.SubscriptionKind, newSubscriptionStepper)
+	registerStepperBuilder(resolver.BundleSecretKind, newBundleSecretStepper)
+	registerStepperBuilder(secretKind, newSecretStepper)
+	registerStepperBuilder(clusterRoleKind, newClusterRoleStepper)
+	registerStepperBuilder(clusterRoleBindingKind, newClusterRoleBindingStepper)
+	registerStepperBuilder(roleKind, newRoleStepper)
+	registerStepperBuilder(roleBindingKind, newRoleBindingStepper)
+	registerStepperBuilder(serviceAccountKind, newServiceAccountStepper)
+	registerStepperBuilder(serviceKind, newServiceStepper)
+	registerStepperBuilder(configMapKind, newConfigMapStepper)
+
+	registerUnstructuredKind(PrometheusRuleKind)
+	registerUnstructuredKind(ServiceMonitorKind)
+	registerUnstructuredKind(PodDisruptionBudgetKind)
+	registerUnstructuredKind(PriorityClassKind)
+	registerUnstructuredKind(VerticalPodAutoscalerKind)
+	registerUnstructuredKind(ConsoleYAMLSampleKind)
+	registerUnstructuredKind(ConsoleQuickStartKind)
+	registerUnstructuredKind(ConsoleCLIDownloadKind)
+	registerUnstructuredKind(ConsoleLinkKind)
+}
+
+// resolvingOwner returns the CSV that the given step installs an object for,
+// or nil if the step isn't part of a bundle.
+func resolvingOwner(plan *v1alpha1.InstallPlan, step *v1alpha1.Step) *v1alpha1.ClusterServiceVersion {
+	if step.Resolving == "" {
+		return nil
+	}
+	owner := &v1alpha1.ClusterServiceVersion{}
+	owner.SetNamespace(plan.GetNamespace())
+	owner.SetName(step.Resolving)
+	return owner
+}
+
+func newClusterServiceVersionStepper(ctx context.Context, b *builder, step *v1alpha1.Step, manifest string) (Stepper, error) {
+	return b.ensureOnce(step, func() (v1alpha1.StepStatus, error) {
+		e := b.execution
+
+		// Marshal the manifest into a CSV instance.
+		var csv v1alpha1.ClusterServiceVersion
+		err := json.Unmarshal([]byte(manifest), &csv)
+		if err != nil {
+			return step.Status, errorwrap.Wrapf(err, "error parsing step manifest: %s", step.Resource.Name)
+		}
+
+		// Check if the resolved CSV is in the initial set
+		if _, ok := e.initialCSVNames[csv.GetName()]; !ok {
+			// Check for pre-existing CSVs that own the same CRDs
+			competingOwners, err := competingCRDOwnersExist(b.plan.GetNamespace(), &csv, e.existingCRDOwners)
+			if err != nil {
+				return step.Status, errorwrap.Wrapf(err, "error checking crd owners for: %s", csv.GetName())
+			}
+
+			// TODO: decide on fail/continue logic for pre-existing dependent CSVs that own the same CRD(s)
+			if competingOwners {
+				// For now, error out
+				return step.Status, fmt.Errorf("pre-existing CRD owners found for owned CRD(s) of dependent CSV %s", csv.GetName())
+			}
+		}
+
+		// Attempt to create the CSV.
+		csv.SetNamespace(b.plan.GetNamespace())
+		// the transitions of the CSV in olm operator join the trace of the plan
+		tracing.Inject(ctx, &csv.ObjectMeta)
+
+		return e.ensurer.EnsureClusterServiceVersion(&csv)
+	}), nil
+}
+
+func newSubscriptionStepper(_ context.Context, b *builder, step *v1alpha1.Step, manifest string) (Stepper, error) {
+	return b.ensureOnce(step, func() (v1alpha1.StepStatus, error) {
+		// Marshal the manifest into a subscription instance.
+		var sub v1alpha1.Subscription
+		err := json.Unmarshal([]byte(manifest), &sub)
+		if err != nil {
+			return step.Status, errorwrap.Wrapf(err, "error parsing step manifest: %s", step.Resource.Name)
+		}
+
+		// Add the InstallPlan's name as an annotation
+		if annotations := sub.GetAnnotations(); annotations != nil {
+			annotations[generatedByKey] = b.plan.GetName()
+		} else {
+			sub.SetAnnotations(map[string]string{generatedByKey: b.plan.GetName()})
+		}
+
+		// Attempt to create the Subscription
+		sub.SetNamespace(b.plan.GetNamespace())
+
+		return b.execution.ensurer.EnsureSubscription(&sub)
+	}), nil
+}
+
+func newBundleSecretStepper(_ context.Context, b *builder, step *v1alpha1.Step, manifest string) (Stepper, error) {
+	return b.ensureOnce(step, func() (v1alpha1.StepStatus, error) {
+		var s corev1.Secret
+		err := json.Unmarshal([]byte(manifest), &s)
+		if err != nil {
+			return step.Status, errorwrap.Wrapf(err, "error parsing step manifest: %s", step.Resource.Name)
+		}
+
+		// add ownerrefs on the secret that point to the CSV in the bundle
+		if owner := resolvingOwner(b.plan, step); owner != nil {
+			ownerutil.AddNonBlockingOwner(&s, owner)
+		}
+
+		// Update UIDs on all CSV OwnerReferences
+		updated, err := b.execution.operator.getUpdatedOwnerReferences(s.OwnerReferences, b.plan.GetNamespace())
+		if err != nil {
+			return step.Status, errorwrap.Wrapf(err, "error generating ownerrefs for secret %s", s.GetName())
+		}
+		s.SetOwnerReferences(updated)
+		s.SetNamespace(b.plan.GetNamespace())
+
+		return b.execution.ensurer.EnsureBundleSecret(b.plan.GetNamespace(), &s)
+	}), nil
+}
+
+func newSecretStepper(_ context.Context, b *builder, step *v1alpha1.Step, _ string) (Stepper, error) {
+	return b.ensureOnce(step, func() (v1alpha1.StepStatus, error) {
+		return b.execution.ensurer.EnsureSecret(b.execution.operator.namespace, b.plan.GetNamespace(), step.Resource.Name)
+	}), nil
+}
+
+func newClusterRoleStepper(_ context.Context, b *builder, step *v1alpha1.Step, manifest string) (Stepper, error) {
+	return b.ensureOnce(step, func() (v1alpha1.StepStatus, error) {
+		// Marshal the manifest into a ClusterRole instance.
+		var cr rbacv1.ClusterRole
+		err := json.Unmarshal([]byte(manifest), &cr)
+		if err != nil {
+			return step.Status, errorwrap.Wrapf(err, "error parsing step manifest: %s", step.Resource.Name)
+		}
+
+		return b.execution.ensurer.EnsureClusterRole(&cr, step)
+	}), nil
+}
+
+func newClusterRoleBindingStepper(_ context.Context, b *builder, step *v1alpha1.Step, manifest string) (Stepper, error) {
+	return b.ensureOnce(step, func() (v1alpha1.StepStatus, error) {
+		// Marshal the manifest into a RoleBinding instance.
+		var rb rbacv1.ClusterRoleBinding
+		err := json.Unmarshal([]byte(manifest), &rb)
+		if err != nil {
+			return step.Status, errorwrap.Wrapf(err, "error parsing step manifest: %s", step.Resource.Name)
+		}
+
+		return b.execution.ensurer.EnsureClusterRoleBinding(&rb, step)
+	}), nil
+}
+
+func newRoleStepper(_ context.Context, b *builder, step *v1alpha1.Step, manifest string) (Stepper, error) {
+	return b.ensureOnce(step, func() (v1alpha1.StepStatus, error) {
+		// Marshal the manifest into a Role instance.
+		var r rbacv1.Role
+		err := json.Unmarshal([]byte(manifest), &r)
+		if err != nil {
+			return step.Status, errorwrap.Wrapf(err, "error parsing step manifest: %s", step.Resource.Name)
+		}
+
+		// Update UIDs on all CSV OwnerReferences
+		updated, err := b.execution.operator.getUpdatedOwnerReferences(r.OwnerReferences, b.plan.GetNamespace())
+		if err != nil {
+			return step.Status, errorwrap.Wrapf(err, "error generating ownerrefs for role %s", r.GetName())
+		}
+		r.SetOwnerReferences(updated)
+		r.SetNamespace(b.plan.GetNamespace())
+
+		return b.execution.ensurer.EnsureRole(b.plan.GetNamespace(), &r)
+	}), nil
+}
+
+func newRoleBindingStepper(_ context.Context, b *builder, step *v1alpha1.Step, manifest string) (Stepper, error) {
+	return b.ensureOnce(step, func() (v1alpha1.StepStatus, error) {
+		// Marshal the manifest into a RoleBinding instance.
+		var rb rbacv1.RoleBinding
+		err := json.Unmarshal([]byte(manifest), &rb)
+		if err != nil {
+			return step.Status, errorwrap.Wrapf(err, "error parsing step manifest: %s", step.Resource.Name)
+		}
+
+		// Update UIDs on all CSV OwnerReferences
+		updated, err := b.execution.operator.getUpdatedOwnerReferences(rb.OwnerReferences, b.plan.GetNamespace())
+		if err != nil {
+			return step.Status, errorwrap.Wrapf(err, "error generating ownerrefs for rolebinding %s", rb.GetName())
+		}
+		rb.SetOwnerReferences(updated)
+		rb.SetNamespace(b.plan.GetNamespace())
+
+		return b.execution.ensurer.EnsureRoleBinding(b.plan.GetNamespace(), &rb)
+	}), nil
+}
+
+func newServiceAccountStepper(_ context.Context, b *builder, step *v1alpha1.Step, manifest string) (Stepper, error) {
+	return b.ensureOnce(step, func() (v1alpha1.StepStatus, error) {
+		// Marshal the manifest into a ServiceAccount instance.
+		var sa corev1.ServiceAccount
+		err := json.Unmarshal([]byte(manifest), &sa)
+		if err != nil {
+			return step.Status, errorwrap.Wrapf(err, "error parsing step manifest: %s", step.Resource.Name)
+		}
+
+		// Update UIDs on all CSV OwnerReferences
+		updated, err := b.execution.operator.getUpdatedOwnerReferences(sa.OwnerReferences, b.plan.GetNamespace())
+		if err != nil {
+			return step.Status, errorwrap.Wrapf(err, "error generating ownerrefs for service account: %s", sa.GetName())
+		}
+		sa.SetOwnerReferences(updated)
+		sa.SetNamespace(b.plan.GetNamespace())
+
+		return b.execution.ensurer.EnsureServiceAccount(b.plan.GetNamespace(), &sa)
+	}), nil
+}
+
+func newServiceStepper(_ context.Context, b *builder, step *v1alpha1.Step, manifest string) (Stepper, error) {
+	return b.ensureOnce(step, func() (v1alpha1.StepStatus, error) {
+		// Marshal the manifest into a Service instance
+		var s corev1.Service
+		err := json.Unmarshal([]byte(manifest), &s)
+		if err != nil {
+			return step.Status, errorwrap.Wrapf(err, "error parsing step manifest: %s", step.Resource.Name)
+		}
+
+		// add ownerrefs on the service that point to the CSV in the bundle
+		if owner := resolvingOwner(b.plan, step); owner != nil {
+			ownerutil.AddNonBlockingOwner(&s, owner)
+		}
+
+		// Update UIDs on all CSV OwnerReferences
+		updated, err := b.execution.operator.getUpdatedOwnerReferences(s.OwnerReferences, b.plan.GetNamespace())
+		if err != nil {
+			return step.Status, errorwrap.Wrapf(err, "error generating ownerrefs for service: %s", s.GetName())
+		}
+		s.SetOwnerReferences(updated)
+		s.SetNamespace(b.plan.GetNamespace())
+
+		return b.execution.ensurer.EnsureService(b.plan.GetNamespace(), &s)
+	}), nil
+}
+
+func newConfigMapStepper(_ context.Context, b *builder, step *v1alpha1.Step, manifest string) (Stepper, error) {
+	return b.ensureOnce(step, func() (v1alpha1.StepStatus, error) {
+		var cfg corev1.ConfigMap
+		err := json.Unmarshal([]byte(manifest), &cfg)
+		if err != nil {
+			return step.Status, errorwrap.Wrapf(err, "error parsing step manifest: %s", step.Resource.Name)
+		}
+
+		// add ownerrefs on the configmap that point to the CSV in the bundle
+		if owner := resolvingOwner(b.plan, step); owner != nil {
+			ownerutil.AddNonBlockingOwner(&cfg, owner)
+		}
+
+		// Update UIDs on all CSV OwnerReferences
+		updated, err := b.execution.operator.getUpdatedOwnerReferences(cfg.OwnerReferences, b.plan.GetNamespace())
+		if err != nil {
+			return step.Status, errorwrap.Wrapf(err, "error generating ownerrefs for configmap: %s", cfg.GetName())
+		}
+		cfg.SetOwnerReferences(updated)
+		cfg.SetNamespace(b.plan.GetNamespace())
+
+		return b.execution.ensurer.EnsureConfigMap(b.plan.GetNamespace(), &cfg)
+	}), nil
+}
+
+// newUnstructuredStepper returns the Stepper of steps whose Kind was registered
+// with registerUnstructuredKind or has no registered builder. The object is
+// applied with the dynamic client if OLM supports its Kind, or if the Kind has
+// been allowlisted.
+func newUnstructuredStepper(_ context.Context, b *builder, step *v1alpha1.Step, manifest string) (Stepper, error) {
+	return b.ensureOnce(step, func() (v1alpha1.StepStatus, error) {
+		o := b.execution.operator
+		namespace := b.plan.GetNamespace()
+
+		if !o.supportsStepKind(step.Resource.Group, step.Resource.Kind) {
+			// Not a supported resource
+			return v1alpha1.StepStatusUnsupportedResource, v1alpha1.ErrInvalidInstallPlan
+		}
+
+		// Marshal the manifest into an unstructured object
+		dec := yaml.NewYAMLOrJSONDecoder(strings.NewReader(manifest), 10)
+		unstructuredObject := &unstructured.Unstructured{}
+		if err := dec.Decode(unstructuredObject); err != nil {
+			return step.Status, errorwrap.Wrapf(err, "error decoding %s object to an unstructured object", step.Resource.Name)
+		}
+
+		// The manifest must be of a supported kind as well, not only the step that references it.
+		gvk := unstructuredObject.GroupVersionKind()
+		if !o.supportsStepKind(gvk.Group, gvk.Kind) {
+			return v1alpha1.StepStatusUnsupportedResource, v1alpha1.ErrInvalidInstallPlan
+		}
+
+		// Get the resource from the GVK.
+		r, err := o.apiresourceFromGVK(gvk)
+		if err != nil {
+			return step.Status, err
+		}
+
+		// Create the GVR
+		gvr := schema.GroupVersionResource{
+			Group:    gvk.Group,
+			Version:  gvk.Version,
+			Resource: r.Name,
+		}
+
+		if owner := resolvingOwner(b.plan, step); owner != nil {
+			if r.Namespaced {
+				// Set OwnerReferences for namespace-scoped resource
+				ownerutil.AddNonBlockingOwner(unstructuredObject, owner)
+
+				// Update UIDs on all CSV OwnerReferences
+				updated, err := o.getUpdatedOwnerReferences(unstructuredObject.GetOwnerReferences(), namespace)
+				if err != nil {
+					return step.Status, errorwrap.Wrapf(err, "error generating ownerrefs for unstructured object: %s", unstructuredObject.GetName())
+				}
+
+				unstructuredObject.SetOwnerReferences(updated)
+			} else {
+				// Add owner labels to cluster-scoped resource
+				if err := ownerutil.AddOwnerLabels(unstructuredObject, owner); err != nil {
+					return step.Status, err
+				}
+			}
+		}
+
+		// Set up the dynamic client ResourceInterface and set ownerrefs
+		var resourceInterface dynamic.ResourceInterface
+		if r.Namespaced {
+			unstructuredObject.SetNamespace(namespace)
+			resourceInterface = b.execution.dynamicClient.Resource(gvr).Namespace(namespace)
+		} else {
+			resourceInterface = b.execution.dynamicClient.Resource(gvr)
+		}
+
+		// Ensure Unstructured Object
+		return b.execution.ensurer.EnsureUnstructuredObject(resourceInterface, unstructuredObject)
+	}), nil
+}
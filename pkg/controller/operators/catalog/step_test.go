@@ -12,6 +12,7 @@ import (
 	"github.com/operator-framework/api/pkg/operators/v1alpha1"
 	v1alpha1listers "github.com/operator-framework/operator-lifecycle-manager/pkg/api/client/listers/operators/v1alpha1"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/operators/internal/alongside"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/operatorlister/operatorlisterfakes"
 )
 
@@ -170,3 +171,31 @@ func TestSetInstalledAlongsideAnnotation(t *testing.T) {
 		})
 	}
 }
+
+func TestStepperBuildersRegistered(t *testing.T) {
+	for _, kind := range []string{
+		crdKind,
+		v1alpha1.ClusterServiceVersionKind,
+		v1alpha1.SubscriptionKind,
+		resolver.BundleSecretKind,
+		secretKind,
+		clusterRoleKind,
+		clusterRoleBindingKind,
+		roleKind,
+		roleBindingKind,
+		serviceAccountKind,
+		serviceKind,
+		configMapKind,
+		PrometheusRuleKind,
+		ConsoleLinkKind,
+	} {
+		assert.Contains(t, stepperBuilders, kind)
+	}
+
+	// kinds applied as unstructured objects are the supported ones
+	for kind := range unstructuredKinds {
+		assert.Contains(t, stepperBuilders, kind)
+		assert.True(t, isSupported(kind))
+	}
+	assert.False(t, isSupported(crdKind))
+}
@@ -0,0 +1,98 @@
+package catalog
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/reconciler"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/fakes"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/clientfake"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/tracing"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/tracing/tracingtest"
+)
+
+func TestTraceResolutionAndPlanExecution(t *testing.T) {
+	recorder := tracingtest.InstallRecorder("catalog-operator")
+	defer recorder.Uninstall()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	namespace := "ns"
+	sub := &v1alpha1.Subscription{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       v1alpha1.SubscriptionKind,
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sub",
+			Namespace: namespace,
+		},
+		Spec: &v1alpha1.SubscriptionSpec{
+			CatalogSource:          "src",
+			CatalogSourceNamespace: namespace,
+		},
+	}
+	o, err := NewFakeOperator(ctx, namespace, []string{namespace}, withClientObjs(sub), withFakeClientOptions(clientfake.WithSelfLinks(t), clientfake.WithNameGeneration(t)))
+	require.NoError(t, err)
+	o.reconciler = &fakes.FakeRegistryReconcilerFactory{
+		ReconcilerForSourceStub: func(source *v1alpha1.CatalogSource) reconciler.RegistryReconciler {
+			return &fakes.FakeRegistryReconciler{}
+		},
+	}
+
+	steps := []*v1alpha1.Step{
+		{
+			Resolving: "csv",
+			Resource: v1alpha1.StepResource{
+				CatalogSource:          "src",
+				CatalogSourceNamespace: namespace,
+				Group:                  v1alpha1.GroupName,
+				Version:                v1alpha1.GroupVersion,
+				Kind:                   v1alpha1.ClusterServiceVersionKind,
+				Name:                   "csv",
+				Manifest:               toManifest(t, csv("csv", namespace, nil, nil)),
+			},
+			Status: v1alpha1.StepStatusUnknown,
+		},
+	}
+	o.resolver = &fakes.FakeStepResolver{
+		ResolveStepsStub: func(context.Context, string) ([]*v1alpha1.Step, []v1alpha1.BundleLookup, []*v1alpha1.Subscription, error) {
+			return steps, nil, []*v1alpha1.Subscription{sub.DeepCopy()}, nil
+		},
+	}
+
+	// the generated plan carries the trace of the resolution
+	require.NoError(t, o.syncResolvingNamespace(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}))
+	resolution := recorder.Span("syncResolvingNamespace")
+	require.NotNil(t, resolution)
+
+	plans, err := o.client.OperatorsV1alpha1().InstallPlans(namespace).List(context.TODO(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, plans.Items, 1)
+	plan := &plans.Items[0]
+	require.Contains(t, plan.GetAnnotations(), tracing.TraceParentAnnotationKey)
+
+	// executing the plan joins the trace, and passes it on to the CSV
+	plan.Status.Phase = v1alpha1.InstallPlanPhaseInstalling
+	require.NoError(t, o.ExecutePlan(plan))
+
+	execution, step := recorder.Span("ExecutePlan"), recorder.Span("ExecutePlan step")
+	require.NotNil(t, execution)
+	require.NotNil(t, step)
+	require.Equal(t, resolution.SpanContext.TraceID(), execution.SpanContext.TraceID())
+	require.Equal(t, execution.SpanContext.SpanID(), step.Parent.SpanID())
+
+	created, err := o.client.OperatorsV1alpha1().ClusterServiceVersions(namespace).Get(context.TODO(), "csv", metav1.GetOptions{})
+	require.NoError(t, err)
+	traced := tracing.Extract(context.Background(), created)
+	_, span := tracing.Start(traced, "olm")
+	span.End()
+	require.Equal(t, resolution.SpanContext.TraceID(), recorder.Span("olm").SpanContext.TraceID())
+	require.Equal(t, step.SpanContext.SpanID(), recorder.Span("olm").Parent.SpanID())
+}
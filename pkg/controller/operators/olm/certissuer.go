@@ -0,0 +1,64 @@
+package olm
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/certs"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/install"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/ownerutil"
+)
+
+// CertIssuerAnnotationKey is the OperatorGroup annotation that selects the
+// issuer of the serving certificates of the operators in its namespace, in
+// the format accepted by certs.ParseIssuerConfig.
+const CertIssuerAnnotationKey = "operatorframework.io/cert-issuer"
+
+// certIssuer returns the issuer of the serving certificates of the given
+// owner's Services: the one selected by its OperatorGroup, or the cluster-wide
+// default otherwise. The certificates have keys of the given algorithm.
+func (a *Operator) certIssuer(owner ownerutil.Owner, keyAlgorithm certs.KeyAlgorithm) (certs.Issuer, error) {
+	namespace := owner.GetNamespace()
+	config := a.certIssuerConfig
+
+	ogs, err := a.lister.OperatorsV1().OperatorGroupLister().OperatorGroups(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	if len(ogs) == 1 {
+		if value, ok := ogs[0].GetAnnotations()[CertIssuerAnnotationKey]; ok {
+			if config, err = certs.ParseIssuerConfig(value); err != nil {
+				return nil, fmt.Errorf("invalid %s annotation on operatorgroup %s/%s: %v", CertIssuerAnnotationKey, namespace, ogs[0].GetName(), err)
+			}
+			// OperatorGroups may only use the CA keys of their own namespace.
+			if config.Type == certs.CASecretIssuerType && config.Namespace != "" && config.Namespace != namespace {
+				return nil, fmt.Errorf("invalid %s annotation on operatorgroup %s/%s: CA secret must be in namespace %s", CertIssuerAnnotationKey, namespace, ogs[0].GetName(), namespace)
+			}
+		}
+	}
+
+	switch config.Type {
+	case certs.CASecretIssuerType:
+		if config.Namespace != "" {
+			namespace = config.Namespace
+		}
+		return certs.NewCASecretIssuer(a.opClient.KubernetesInterface(), namespace, config.Name, certs.CertGeneratorFunc(certs.CreateSignedServingPair), keyAlgorithm), nil
+	case certs.CertManagerIssuerType:
+		return certs.NewCertManagerIssuer(a.opClient.KubernetesInterface(), a.dynamicClient, config.Kind, config.Name, keyAlgorithm), nil
+	}
+	return certs.NewSelfSignedIssuer(certs.CertGeneratorFunc(certs.CreateSignedServingPair), keyAlgorithm), nil
+}
+
+// certConfigFor returns the config of the serving certificates of the given CSV,
+// falling back to the cluster-wide defaults if its annotations are invalid.
+// Installs fail with the error in that case.
+func (a *Operator) certConfigFor(csv *v1alpha1.ClusterServiceVersion) install.CertConfig {
+	config, err := install.CertConfigFor(csv, a.certConfig)
+	if err != nil {
+		a.logger.WithError(err).Debugf("using default certificate config for csv %s/%s", csv.GetNamespace(), csv.GetName())
+		return a.certConfig
+	}
+	return config
+}
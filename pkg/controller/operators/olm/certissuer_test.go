@@ -0,0 +1,104 @@
+package olm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	operatorsv1 "github.com/operator-framework/api/pkg/operators/v1"
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/certs"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/install"
+)
+
+func TestCertIssuer(t *testing.T) {
+	namespace := "ns"
+	notAfter := time.Now().Add(time.Hour)
+
+	// A CA the ca-secret issuers can sign with.
+	ca, err := certs.GenerateCA(notAfter, install.Organization, certs.DefaultKeyAlgorithm)
+	require.NoError(t, err)
+	caPEM, caKeyPEM, err := ca.ToPEM()
+	require.NoError(t, err)
+	caSecret := func(namespace string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "ca", Namespace: namespace},
+			Data:       map[string][]byte{corev1.TLSCertKey: caPEM, corev1.TLSPrivateKeyKey: caKeyPEM},
+		}
+	}
+	operatorGroup := func(annotations map[string]string) *operatorsv1.OperatorGroup {
+		return &operatorsv1.OperatorGroup{
+			ObjectMeta: metav1.ObjectMeta{Name: "og", Namespace: namespace, Annotations: annotations},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		defaultConfig certs.IssuerConfig
+		clientObjs    []runtime.Object
+		// wantCA is true if the issuer is expected to sign with the CA held
+		// in the ca secrets, and false if it is expected to generate one.
+		wantCA  bool
+		wantErr string
+	}{
+		{
+			name: "Default",
+		},
+		{
+			name:          "ClusterDefault",
+			defaultConfig: certs.IssuerConfig{Type: certs.CASecretIssuerType, Namespace: "pki", Name: "ca"},
+			wantCA:        true,
+		},
+		{
+			name:          "OperatorGroupOverride",
+			defaultConfig: certs.IssuerConfig{Type: certs.CASecretIssuerType, Namespace: "pki", Name: "ca"},
+			clientObjs:    []runtime.Object{operatorGroup(map[string]string{CertIssuerAnnotationKey: "self-signed"})},
+		},
+		{
+			name:       "OperatorGroupCASecret",
+			clientObjs: []runtime.Object{operatorGroup(map[string]string{CertIssuerAnnotationKey: "ca-secret:ca"})},
+			wantCA:     true,
+		},
+		{
+			name:       "OperatorGroupForeignCASecret",
+			clientObjs: []runtime.Object{operatorGroup(map[string]string{CertIssuerAnnotationKey: "ca-secret:pki/ca"})},
+			wantErr:    "invalid operatorframework.io/cert-issuer annotation on operatorgroup ns/og: CA secret must be in namespace ns",
+		},
+		{
+			name:       "OperatorGroupInvalid",
+			clientObjs: []runtime.Object{operatorGroup(map[string]string{CertIssuerAnnotationKey: "vault"})},
+			wantErr:    `invalid operatorframework.io/cert-issuer annotation on operatorgroup ns/og: unknown issuer type "vault"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+
+			op, err := NewFakeOperator(ctx,
+				withNamespaces(namespace, "pki"),
+				withClientObjs(tt.clientObjs...),
+				withK8sObjs(caSecret(namespace), caSecret("pki")),
+			)
+			require.NoError(t, err)
+			op.certIssuerConfig = tt.defaultConfig
+
+			csv := &v1alpha1.ClusterServiceVersion{ObjectMeta: metav1.ObjectMeta{Name: "csv", Namespace: namespace}}
+			issuer, err := op.certIssuer(csv, certs.DefaultKeyAlgorithm)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			got, err := issuer.CA(notAfter, install.Organization)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantCA, got.Cert.Equal(ca.Cert))
+		})
+	}
+}
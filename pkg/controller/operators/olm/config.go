@@ -13,6 +13,7 @@ import (
 
 	configv1client "github.com/openshift/client-go/config/clientset/versioned"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/client/clientset/versioned"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/certs"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/install"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/labeler"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/operatorclient"
@@ -33,6 +34,8 @@ type operatorConfig struct {
 	apiLabeler        labeler.Labeler
 	restConfig        *rest.Config
 	configClient      configv1client.Interface
+	certIssuerConfig  certs.IssuerConfig
+	certConfig        install.CertConfig
 }
 
 func (o *operatorConfig) apply(options []OperatorOption) {
@@ -70,6 +73,8 @@ func (o *operatorConfig) validate() (err error) {
 		err = newInvalidConfigError("api labeler", "must not be nil")
 	case o.restConfig == nil:
 		err = newInvalidConfigError("rest config", "must not be nil")
+	case o.certConfig.Validate() != nil:
+		err = newInvalidConfigError("cert config", o.certConfig.Validate().Error())
 	}
 
 	return
@@ -85,6 +90,8 @@ func defaultOperatorConfig() *operatorConfig {
 		strategyResolver:  &install.StrategyResolver{},
 		apiReconciler:     APIIntersectionReconcileFunc(ReconcileAPIIntersection),
 		apiLabeler:        labeler.Func(LabelSetsFor),
+		certIssuerConfig:  certs.IssuerConfig{Type: certs.SelfSignedIssuerType},
+		certConfig:        install.DefaultCertConfig(),
 	}
 }
 
@@ -159,3 +166,20 @@ func WithConfigClient(configClient configv1client.Interface) OperatorOption {
 		config.configClient = configClient
 	}
 }
+
+// WithCertIssuer sets the cluster-wide default issuer of the serving
+// certificates of operator APIServices and webhooks.
+func WithCertIssuer(certIssuerConfig certs.IssuerConfig) OperatorOption {
+	return func(config *operatorConfig) {
+		config.certIssuerConfig = certIssuerConfig
+	}
+}
+
+// WithCertConfig sets the cluster-wide default lifetime, rotation window and
+// key algorithm of the serving certificates of operator APIServices and
+// webhooks.
+func WithCertConfig(certConfig install.CertConfig) OperatorOption {
+	return func(config *operatorConfig) {
+		config.certConfig = certConfig
+	}
+}
@@ -10,6 +10,7 @@ import (
 	v1 "github.com/operator-framework/api/pkg/operators/v1"
 	"github.com/sirupsen/logrus"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
@@ -21,6 +22,7 @@ import (
 	utilclock "k8s.io/apimachinery/pkg/util/clock"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/informers"
 	k8sscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/cache"
@@ -49,6 +51,7 @@ import (
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/proxy"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/queueinformer"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/scoped"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/tracing"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/metrics"
 )
 
@@ -86,6 +89,9 @@ type Operator struct {
 	clientAttenuator      *scoped.ClientAttenuator
 	serviceAccountQuerier *scoped.UserDefinedServiceAccountQuerier
 	clientFactory         clients.Factory
+	dynamicClient         dynamic.Interface
+	certIssuerConfig      certs.IssuerConfig
+	certConfig            install.CertConfig
 }
 
 func NewOperator(ctx context.Context, options ...OperatorOption) (*Operator, error) {
@@ -112,6 +118,11 @@ func newOperatorWithConfig(ctx context.Context, config *operatorConfig) (*Operat
 
 	lister := operatorlister.NewLister()
 
+	dynamicClient, err := dynamic.NewForConfig(config.restConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	scheme := runtime.NewScheme()
 	if err := k8sscheme.AddToScheme(scheme); err != nil {
 		return nil, err
@@ -141,10 +152,14 @@ func newOperatorWithConfig(ctx context.Context, config *operatorConfig) (*Operat
 		clientAttenuator:      scoped.NewClientAttenuator(config.logger, config.restConfig, config.operatorClient),
 		serviceAccountQuerier: scoped.NewUserDefinedServiceAccountQuerier(config.logger, config.externalClient),
 		clientFactory:         clients.NewFactory(config.restConfig),
+		dynamicClient:         dynamicClient,
+		certIssuerConfig:      config.certIssuerConfig,
+		certConfig:            config.certConfig,
 	}
 
 	// Set up syncing for namespace-scoped resources
 	k8sSyncer := queueinformer.LegacySyncHandler(op.syncObject).ToSyncerWithDelete(op.handleDeletion)
+	certExpiry := metrics.NewCertExpiry()
 	for _, namespace := range config.watchedNamespaces {
 		// Wire CSVs
 		csvInformer := externalversions.NewSharedInformerFactoryWithOptions(
@@ -365,6 +380,7 @@ func newOperatorWithConfig(ctx context.Context, config *operatorConfig) (*Operat
 		if err != nil {
 			return nil, err
 		}
+		secretInformer.Informer().AddEventHandler(certExpiry)
 		if err := op.RegisterQueueInformer(secretQueueInformer); err != nil {
 			return nil, err
 		}
@@ -559,7 +575,9 @@ func newOperatorWithConfig(ctx context.Context, config *operatorConfig) (*Operat
 
 	overridesBuilderFunc := overrides.NewDeploymentInitializer(op.logger, proxyQuerierInUse, op.lister)
 	op.resolver = &install.StrategyResolver{
-		OverridesBuilderFunc: overridesBuilderFunc.GetDeploymentInitializer,
+		OverridesBuilderFunc:  overridesBuilderFunc.GetDeploymentInitializer,
+		CertIssuerBuilderFunc: op.certIssuer,
+		CertConfig:            op.certConfig,
 	}
 
 	return op, nil
@@ -1371,6 +1389,25 @@ func (a *Operator) transitionCSVState(in v1alpha1.ClusterServiceVersion) (out *v
 		"phase":     in.Status.Phase,
 	})
 
+	// Only transitions towards Succeeded are traced, not the resyncs of
+	// installed operators.
+	if in.Status.Phase != v1alpha1.CSVPhaseSucceeded {
+		_, span := tracing.Start(tracing.Extract(context.Background(), &in), "transitionCSVState",
+			attribute.String("namespace", in.GetNamespace()),
+			attribute.String("csv", in.GetName()),
+			attribute.String("phase", string(in.Status.Phase)),
+		)
+		defer func() {
+			if out != nil {
+				span.SetAttributes(
+					attribute.String("phase.next", string(out.Status.Phase)),
+					attribute.String("reason", string(out.Status.Reason)),
+				)
+			}
+			tracing.End(span, syncError)
+		}()
+	}
+
 	if in.Status.Reason == v1alpha1.CSVReasonComponentFailedNoRetry {
 		// will change phase out of failed in the event of an intentional requeue
 		logger.Debugf("skipping sync for CSV in failed-no-retry state")
@@ -1510,10 +1547,10 @@ func (a *Operator) transitionCSVState(in v1alpha1.ClusterServiceVersion) (out *v
 		logger.Info("scheduling ClusterServiceVersion for requirement verification")
 		out.SetPhaseWithEvent(v1alpha1.CSVPhasePending, v1alpha1.CSVReasonRequirementsUnknown, "requirements not yet checked", now, a.recorder)
 	case v1alpha1.CSVPhasePending:
-		// Check previous version's Upgradeable condition
+		// Check whether the previous version's OperatorCondition allows upgrading to this version
 		replacedCSV := a.isReplacing(out)
 		if replacedCSV != nil {
-			operatorUpgradeable, condErr := a.isOperatorUpgradeable(replacedCSV)
+			operatorUpgradeable, condErr := a.isOperatorUpgradeable(replacedCSV, out.Spec.Version.Version)
 			if !operatorUpgradeable {
 				out.SetPhaseWithEventIfChanged(v1alpha1.CSVPhasePending, v1alpha1.CSVReasonOperatorConditionNotUpgradeable, fmt.Sprintf("operator is not upgradeable: %s", condErr), now, a.recorder)
 				return
@@ -1601,14 +1638,25 @@ func (a *Operator) transitionCSVState(in v1alpha1.ClusterServiceVersion) (out *v
 				out.SetPhaseWithEvent(v1alpha1.CSVPhaseFailed, v1alpha1.CSVReasonComponentFailedNoRetry, fmt.Sprintf("install strategy failed: %s", syncError), now, a.recorder)
 				return
 			}
+			if install.ReasonForError(syncError) == install.StrategyErrReasonWaiting {
+				// The install is retried with backoff, e.g. until the
+				// serving certificates are issued.
+				out.SetPhaseWithEventIfChanged(v1alpha1.CSVPhaseInstallReady, v1alpha1.CSVReasonWaiting, fmt.Sprintf("install strategy waiting: %s", syncError), now, a.recorder)
+				return
+			}
 			out.SetPhaseWithEvent(v1alpha1.CSVPhaseFailed, v1alpha1.CSVReasonComponentFailed, fmt.Sprintf("install strategy failed: %s", syncError), now, a.recorder)
 			return
 		}
 
 		if out.HasCAResources() {
 			now := metav1.Now()
-			expiration := now.Add(install.DefaultCertValidFor)
-			rotateAt := expiration.Add(-1 * install.DefaultCertMinFresh)
+			rotateAt := a.certConfigFor(out).RotateAt(now.Time)
+			// the issued certificates may expire sooner than configured
+			if rotator, ok := installer.(install.CertRotator); ok {
+				if issued := rotator.CertsRotateAt(); !issued.IsZero() && issued.Before(rotateAt) {
+					rotateAt = issued
+				}
+			}
 			rotateTime := metav1.NewTime(rotateAt)
 			out.Status.CertsLastUpdated = &now
 			out.Status.CertsRotateAt = &rotateTime
@@ -1669,7 +1717,7 @@ func (a *Operator) transitionCSVState(in v1alpha1.ClusterServiceVersion) (out *v
 		}
 
 		// Check if it's time to refresh owned APIService certs
-		if install.ShouldRotateCerts(out) {
+		if install.ShouldRotateCerts(out, a.certConfigFor(out)) {
 			logger.Debug("CSV owns resources that require a cert refresh")
 			out.SetPhaseWithEvent(v1alpha1.CSVPhasePending, v1alpha1.CSVReasonNeedsCertRotation, "CSV owns resources that require a cert refresh", now, a.recorder)
 			return
@@ -1770,7 +1818,7 @@ func (a *Operator) transitionCSVState(in v1alpha1.ClusterServiceVersion) (out *v
 		}
 
 		// Check if it's time to refresh owned APIService certs
-		if install.ShouldRotateCerts(out) {
+		if install.ShouldRotateCerts(out, a.certConfigFor(out)) {
 			logger.Debug("CSV owns resources that require a cert refresh")
 			out.SetPhaseWithEvent(v1alpha1.CSVPhasePending, v1alpha1.CSVReasonNeedsCertRotation, "owned APIServices need cert refresh", now, a.recorder)
 			return
@@ -1961,8 +2009,20 @@ func (a *Operator) parseStrategiesAndUpdateStatus(csv *v1alpha1.ClusterServiceVe
 	}
 	kubeclient, err := a.clientFactory.WithConfigTransformer(attenuate).NewOperatorClient()
 
+	// The trace the CSV was installed in isn't carried over to the pods of
+	// the operator, whose templates get the annotations of the CSV.
+	annotations := csv.GetAnnotations()
+	if _, ok := annotations[tracing.TraceParentAnnotationKey]; ok {
+		annotations = make(map[string]string, len(annotations))
+		for k, v := range csv.GetAnnotations() {
+			if k != tracing.TraceParentAnnotationKey {
+				annotations[k] = v
+			}
+		}
+	}
+
 	strName := strategy.GetStrategyName()
-	installer := a.resolver.InstallerForStrategy(strName, kubeclient, a.lister, csv, csv.GetAnnotations(), csv.GetAllAPIServiceDescriptions(), csv.Spec.WebhookDefinitions, previousStrategy)
+	installer := a.resolver.InstallerForStrategy(strName, kubeclient, a.lister, csv, annotations, csv.GetAllAPIServiceDescriptions(), csv.Spec.WebhookDefinitions, previousStrategy)
 	return installer, strategy
 }
 
@@ -269,6 +269,7 @@ func NewFakeOperator(ctx context.Context, options ...fakeOperatorOption) (*Opera
 			apiReconciler:     APIIntersectionReconcileFunc(ReconcileAPIIntersection),
 			apiLabeler:        labeler.Func(LabelSetsFor),
 			restConfig:        &rest.Config{},
+			certConfig:        install.DefaultCertConfig(),
 		},
 		recorder: &record.FakeRecorder{},
 		// default expected namespaces
@@ -509,7 +510,7 @@ func keyPairToTLSSecret(name, namespace string, kp *certs.KeyPair) *corev1.Secre
 }
 
 func signedServingPair(notAfter time.Time, ca *certs.KeyPair, hosts []string) *certs.KeyPair {
-	servingPair, err := certs.CreateSignedServingPair(notAfter, install.Organization, ca, hosts)
+	servingPair, err := certs.CreateSignedServingPair(notAfter, install.Organization, ca, hosts, certs.DefaultKeyAlgorithm)
 	if err != nil {
 		panic(err)
 	}
@@ -1,6 +1,7 @@
 package overrides
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/operator-framework/api/pkg/operators/v1alpha1"
@@ -11,12 +12,37 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 )
 
+// PodConfigAnnotationKey is the key of an optional annotation on a
+// Subscription whose value is a JSON encoded PodConfig.
+const PodConfigAnnotationKey = "operatorframework.io/pod-config"
+
+// PodConfig holds the overrides for an operator's pods that can not be
+// expressed in a SubscriptionConfig.
+type PodConfig struct {
+	// Affinity replaces the affinity of the operator's pods.
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// PriorityClassName replaces the priority class of the operator's pods.
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// TopologySpreadConstraints are appended to those of the operator's
+	// pods, if not already present.
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// SecurityContext replaces the security context of the operator's pods.
+	SecurityContext *corev1.PodSecurityContext `json:"securityContext,omitempty"`
+
+	// Annotations are added to the operator's pods, overwriting existing
+	// annotations with the same keys.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
 type operatorConfig struct {
 	lister operatorlister.OperatorLister
 	logger *logrus.Logger
 }
 
-func (o *operatorConfig) GetConfigOverrides(ownerCSV ownerutil.Owner) (envVarOverrides []corev1.EnvVar, volumeOverrides []corev1.Volume, volumeMountOverrides []corev1.VolumeMount, tolerationOverrides []corev1.Toleration, resourcesOverride *corev1.ResourceRequirements, nodeSelectorOverride map[string]string, err error) {
+func (o *operatorConfig) GetConfigOverrides(ownerCSV ownerutil.Owner) (config *v1alpha1.SubscriptionConfig, podConfig *PodConfig, err error) {
 	list, listErr := o.lister.OperatorsV1alpha1().SubscriptionLister().Subscriptions(ownerCSV.GetNamespace()).List(labels.Everything())
 	if listErr != nil {
 		err = fmt.Errorf("failed to list subscription namespace=%s - %v", ownerCSV.GetNamespace(), listErr)
@@ -29,17 +55,16 @@ func (o *operatorConfig) GetConfigOverrides(ownerCSV ownerutil.Owner) (envVarOve
 		return
 	}
 
-	if owner.Spec.Config == nil {
-		// No overrides
-		return
-	}
+	config = owner.Spec.Config
 
-	envVarOverrides = owner.Spec.Config.Env
-	volumeOverrides = owner.Spec.Config.Volumes
-	volumeMountOverrides = owner.Spec.Config.VolumeMounts
-	tolerationOverrides = owner.Spec.Config.Tolerations
-	resourcesOverride = owner.Spec.Config.Resources
-	nodeSelectorOverride = owner.Spec.Config.NodeSelector
+	if value := owner.GetAnnotations()[PodConfigAnnotationKey]; value != "" {
+		podConfig = &PodConfig{}
+		if jsonErr := json.Unmarshal([]byte(value), podConfig); jsonErr != nil {
+			podConfig = nil
+			err = fmt.Errorf("failed to parse %s annotation of subscription name=%s - %v", PodConfigAnnotationKey, owner.GetName(), jsonErr)
+			return
+		}
+	}
 
 	return
 }
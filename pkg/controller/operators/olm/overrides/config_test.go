@@ -0,0 +1,135 @@
+package overrides
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	listersv1alpha1 "github.com/operator-framework/operator-lifecycle-manager/pkg/api/client/listers/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/operatorlister"
+)
+
+const (
+	testNamespace = "ns"
+	testCSVName   = "operator.v1.0.0"
+)
+
+func newTestCSV() *v1alpha1.ClusterServiceVersion {
+	return &v1alpha1.ClusterServiceVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: testCSVName, Namespace: testNamespace},
+	}
+}
+
+func newTestSubscription(config *v1alpha1.SubscriptionConfig, annotations map[string]string) *v1alpha1.Subscription {
+	return &v1alpha1.Subscription{
+		ObjectMeta: metav1.ObjectMeta{Name: "operator", Namespace: testNamespace, Annotations: annotations},
+		Spec:       &v1alpha1.SubscriptionSpec{Config: config},
+		Status:     v1alpha1.SubscriptionStatus{InstalledCSV: testCSVName},
+	}
+}
+
+func newTestLister(t *testing.T, subs ...*v1alpha1.Subscription) operatorlister.OperatorLister {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, sub := range subs {
+		require.NoError(t, indexer.Add(sub))
+	}
+
+	lister := operatorlister.NewLister()
+	lister.OperatorsV1alpha1().RegisterSubscriptionLister(testNamespace, listersv1alpha1.NewSubscriptionLister(indexer))
+	return lister
+}
+
+func TestGetConfigOverrides(t *testing.T) {
+	config := &v1alpha1.SubscriptionConfig{
+		Env: []corev1.EnvVar{{Name: "foo", Value: "bar"}},
+	}
+
+	tests := []struct {
+		name          string
+		subs          []*v1alpha1.Subscription
+		wantConfig    *v1alpha1.SubscriptionConfig
+		wantPodConfig *PodConfig
+		wantErr       bool
+	}{
+		{
+			name: "NoSubscription",
+		},
+		{
+			name: "SubscriptionForOtherCSV",
+			subs: []*v1alpha1.Subscription{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: testNamespace},
+					Spec:       &v1alpha1.SubscriptionSpec{Config: config},
+					Status:     v1alpha1.SubscriptionStatus{InstalledCSV: "other.v1.0.0"},
+				},
+			},
+		},
+		{
+			name:       "NoAnnotation",
+			subs:       []*v1alpha1.Subscription{newTestSubscription(config, nil)},
+			wantConfig: config,
+		},
+		{
+			name:       "EmptyAnnotation",
+			subs:       []*v1alpha1.Subscription{newTestSubscription(config, map[string]string{PodConfigAnnotationKey: ""})},
+			wantConfig: config,
+		},
+		{
+			name: "ValidAnnotation",
+			subs: []*v1alpha1.Subscription{newTestSubscription(nil, map[string]string{
+				PodConfigAnnotationKey: `{"priorityClassName":"high","annotations":{"foo":"bar"}}`,
+			})},
+			wantPodConfig: &PodConfig{
+				PriorityClassName: "high",
+				Annotations:       map[string]string{"foo": "bar"},
+			},
+		},
+		{
+			name: "EmptyObjectAnnotation",
+			subs: []*v1alpha1.Subscription{newTestSubscription(nil, map[string]string{
+				PodConfigAnnotationKey: `{}`,
+			})},
+			wantPodConfig: &PodConfig{},
+		},
+		{
+			name: "InvalidAnnotation",
+			subs: []*v1alpha1.Subscription{newTestSubscription(config, map[string]string{
+				PodConfigAnnotationKey: `{"priorityClassName":`,
+			})},
+			wantConfig: config,
+			wantErr:    true,
+		},
+		{
+			name: "MistypedAnnotation",
+			subs: []*v1alpha1.Subscription{newTestSubscription(nil, map[string]string{
+				PodConfigAnnotationKey: `{"annotations":["foo"]}`,
+			})},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &operatorConfig{
+				lister: newTestLister(t, tt.subs...),
+				logger: logrus.New(),
+			}
+
+			gotConfig, gotPodConfig, err := c.GetConfigOverrides(newTestCSV())
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), PodConfigAnnotationKey)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantConfig, gotConfig)
+			assert.Equal(t, tt.wantPodConfig, gotPodConfig)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package overrides
 import (
 	"fmt"
 
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/install"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/operators/olm/overrides/inject"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/operatorlister"
@@ -11,6 +12,8 @@ import (
 	"github.com/sirupsen/logrus"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 // NewDeploymentInitializer returns a function that accepts a Deployment object
@@ -40,18 +43,41 @@ func (d *DeploymentInitializer) GetDeploymentInitializer(ownerCSV ownerutil.Owne
 }
 
 // Initialize initializes a deployment object with appropriate global cluster
-// level proxy env variable(s).
+// level proxy env variable(s) and the overrides configured on the owning
+// Subscription.
 func (d *DeploymentInitializer) initialize(ownerCSV ownerutil.Owner, deployment *appsv1.Deployment) error {
-	var envVarOverrides, proxyEnvVar, merged []corev1.EnvVar
+	var proxyEnvVar, merged []corev1.EnvVar
 	var err error
 
-	envVarOverrides, volumeOverrides, volumeMountOverrides, tolerationOverrides, resourcesOverride, nodeSelectorOverride, err := d.config.GetConfigOverrides(ownerCSV)
+	config, podConfig, err := d.config.GetConfigOverrides(ownerCSV)
 	if err != nil {
 		err = fmt.Errorf("failed to get subscription pod configuration - %v", err)
 		return err
 	}
 
-	if !proxy.IsOverridden(envVarOverrides) {
+	if config == nil {
+		config = &v1alpha1.SubscriptionConfig{}
+	}
+	if podConfig == nil {
+		podConfig = &PodConfig{}
+	}
+
+	if config.Selector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(config.Selector)
+		if err != nil {
+			return fmt.Errorf("failed to parse subscription config selector - %v", err)
+		}
+		if !selector.Matches(labels.Set(deployment.Spec.Template.GetLabels())) {
+			// The overrides only apply to the pods selected by the
+			// Subscription, but the cluster proxy configuration
+			// still applies to every deployment.
+			d.logger.WithField("csv", ownerCSV.GetName()).WithField("deployment", deployment.GetName()).Debug("deployment not selected by subscription config")
+			config = &v1alpha1.SubscriptionConfig{}
+			podConfig = &PodConfig{}
+		}
+	}
+
+	if !proxy.IsOverridden(config.Env) {
 		proxyEnvVar, err = d.querier.QueryProxyConfig()
 		if err != nil {
 			err = fmt.Errorf("failed to query cluster proxy configuration - %v", err)
@@ -61,7 +87,7 @@ func (d *DeploymentInitializer) initialize(ownerCSV ownerutil.Owner, deployment
 		proxyEnvVar = dropEmptyProxyEnv(proxyEnvVar)
 	}
 
-	merged = append(envVarOverrides, proxyEnvVar...)
+	merged = append(config.Env, proxyEnvVar...)
 
 	if len(merged) == 0 {
 		d.logger.WithField("csv", ownerCSV.GetName()).Debug("no env var to inject into csv")
@@ -72,26 +98,50 @@ func (d *DeploymentInitializer) initialize(ownerCSV ownerutil.Owner, deployment
 		return fmt.Errorf("failed to inject proxy env variable(s) into deployment spec name=%s - %v", deployment.Name, err)
 	}
 
-	if err = inject.InjectVolumesIntoDeployment(podSpec, volumeOverrides); err != nil {
+	if err = inject.InjectEnvFromIntoDeployment(podSpec, config.EnvFrom); err != nil {
+		return fmt.Errorf("failed to inject envFrom source(s) into deployment spec name=%s - %v", deployment.Name, err)
+	}
+
+	if err = inject.InjectVolumesIntoDeployment(podSpec, config.Volumes); err != nil {
 		return fmt.Errorf("failed to inject volume(s) into deployment spec name=%s - %v", deployment.Name, err)
 	}
 
-	if err = inject.InjectVolumeMountsIntoDeployment(podSpec, volumeMountOverrides); err != nil {
+	if err = inject.InjectVolumeMountsIntoDeployment(podSpec, config.VolumeMounts); err != nil {
 		return fmt.Errorf("failed to inject volumeMounts(s) into deployment spec name=%s - %v", deployment.Name, err)
 	}
 
-	if err = inject.InjectTolerationsIntoDeployment(podSpec, tolerationOverrides); err != nil {
+	if err = inject.InjectTolerationsIntoDeployment(podSpec, config.Tolerations); err != nil {
 		return fmt.Errorf("failed to inject toleration(s) into deployment spec name=%s - %v", deployment.Name, err)
 	}
 
-	if err = inject.InjectResourcesIntoDeployment(podSpec, resourcesOverride); err != nil {
+	if err = inject.InjectResourcesIntoDeployment(podSpec, config.Resources); err != nil {
 		return fmt.Errorf("failed to inject resources into deployment spec name=%s - %v", deployment.Name, err)
 	}
 
-	if err = inject.InjectNodeSelectorIntoDeployment(podSpec, nodeSelectorOverride); err != nil {
+	if err = inject.InjectNodeSelectorIntoDeployment(podSpec, config.NodeSelector); err != nil {
 		return fmt.Errorf("failed to inject nodeSelector into deployment spec name=%s - %v", deployment.Name, err)
 	}
 
+	if err = inject.InjectAffinityIntoDeployment(podSpec, podConfig.Affinity); err != nil {
+		return fmt.Errorf("failed to inject affinity into deployment spec name=%s - %v", deployment.Name, err)
+	}
+
+	if err = inject.InjectPriorityClassNameIntoDeployment(podSpec, podConfig.PriorityClassName); err != nil {
+		return fmt.Errorf("failed to inject priorityClassName into deployment spec name=%s - %v", deployment.Name, err)
+	}
+
+	if err = inject.InjectTopologySpreadConstraintsIntoDeployment(podSpec, podConfig.TopologySpreadConstraints); err != nil {
+		return fmt.Errorf("failed to inject topologySpreadConstraint(s) into deployment spec name=%s - %v", deployment.Name, err)
+	}
+
+	if err = inject.InjectSecurityContextIntoDeployment(podSpec, podConfig.SecurityContext); err != nil {
+		return fmt.Errorf("failed to inject securityContext into deployment spec name=%s - %v", deployment.Name, err)
+	}
+
+	if err = inject.InjectAnnotationsIntoDeployment(&deployment.Spec.Template, podConfig.Annotations); err != nil {
+		return fmt.Errorf("failed to inject annotation(s) into deployment spec name=%s - %v", deployment.Name, err)
+	}
+
 	return nil
 }
 
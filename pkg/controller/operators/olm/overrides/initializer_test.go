@@ -0,0 +1,129 @@
+package overrides
+
+import (
+	"testing"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type staticQuerier []corev1.EnvVar
+
+func (q staticQuerier) QueryProxyConfig() ([]corev1.EnvVar, error) {
+	return q, nil
+}
+
+func newTestDeployment(podLabels map[string]string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "operator", Namespace: testNamespace},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: podLabels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "operator"}},
+				},
+			},
+		},
+	}
+}
+
+func TestDeploymentInitializer(t *testing.T) {
+	proxyEnv := corev1.EnvVar{Name: "HTTP_PROXY", Value: "http://proxy:8080"}
+	configEnv := corev1.EnvVar{Name: "foo", Value: "bar"}
+	podConfig := map[string]string{PodConfigAnnotationKey: `{"priorityClassName":"high"}`}
+
+	tests := []struct {
+		name              string
+		sub               *v1alpha1.Subscription
+		podLabels         map[string]string
+		wantErr           bool
+		wantEnv           []corev1.EnvVar
+		wantPriorityClass string
+	}{
+		{
+			name:    "NoSubscription",
+			wantEnv: []corev1.EnvVar{proxyEnv},
+		},
+		{
+			name: "NoSelector",
+			sub: newTestSubscription(&v1alpha1.SubscriptionConfig{
+				Env: []corev1.EnvVar{configEnv},
+			}, podConfig),
+			wantEnv:           []corev1.EnvVar{configEnv, proxyEnv},
+			wantPriorityClass: "high",
+		},
+		{
+			name: "EmptySelector",
+			sub: newTestSubscription(&v1alpha1.SubscriptionConfig{
+				Selector: &metav1.LabelSelector{},
+				Env:      []corev1.EnvVar{configEnv},
+			}, podConfig),
+			podLabels:         map[string]string{"app": "operator"},
+			wantEnv:           []corev1.EnvVar{configEnv, proxyEnv},
+			wantPriorityClass: "high",
+		},
+		{
+			name: "SelectorMatches",
+			sub: newTestSubscription(&v1alpha1.SubscriptionConfig{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "operator"}},
+				Env:      []corev1.EnvVar{configEnv},
+			}, podConfig),
+			podLabels:         map[string]string{"app": "operator"},
+			wantEnv:           []corev1.EnvVar{configEnv, proxyEnv},
+			wantPriorityClass: "high",
+		},
+		{
+			name: "SelectorDoesNotMatch",
+			sub: newTestSubscription(&v1alpha1.SubscriptionConfig{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "webhook"}},
+				Env:      []corev1.EnvVar{configEnv},
+			}, podConfig),
+			podLabels: map[string]string{"app": "operator"},
+			wantEnv:   []corev1.EnvVar{proxyEnv},
+		},
+		{
+			name: "InvalidSelector",
+			sub: newTestSubscription(&v1alpha1.SubscriptionConfig{
+				Selector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "app", Operator: "Like", Values: []string{"operator"}},
+				}},
+				Env: []corev1.EnvVar{configEnv},
+			}, nil),
+			podLabels: map[string]string{"app": "operator"},
+			wantErr:   true,
+		},
+		{
+			name:      "InvalidPodConfig",
+			sub:       newTestSubscription(nil, map[string]string{PodConfigAnnotationKey: "priorityClassName: high"}),
+			podLabels: map[string]string{"app": "operator"},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var subs []*v1alpha1.Subscription
+			if tt.sub != nil {
+				subs = append(subs, tt.sub)
+			}
+			d := NewDeploymentInitializer(logrus.New(), staticQuerier{proxyEnv}, newTestLister(t, subs...))
+
+			deployment := newTestDeployment(tt.podLabels)
+			err := d.GetDeploymentInitializer(newTestCSV())(deployment)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			podSpec := deployment.Spec.Template.Spec
+			assert.ElementsMatch(t, tt.wantEnv, podSpec.Containers[0].Env)
+			assert.Equal(t, tt.wantPriorityClass, podSpec.PriorityClassName)
+		})
+	}
+}
@@ -230,3 +230,143 @@ func InjectNodeSelectorIntoDeployment(podSpec *corev1.PodSpec, nodeSelector map[
 
 	return nil
 }
+
+// InjectEnvFromIntoDeployment injects the provided EnvFromSources
+// into the container(s) of the given PodSpec.
+//
+// EnvFromSources will be appended to the existing ones of each
+// Container if not already present.
+func InjectEnvFromIntoDeployment(podSpec *corev1.PodSpec, envFromSources []corev1.EnvFromSource) error {
+	if podSpec == nil {
+		return errors.New("no pod spec provided")
+	}
+
+	for i := range podSpec.Containers {
+		container := &podSpec.Containers[i]
+		container.EnvFrom = mergeEnvFromSources(container.EnvFrom, envFromSources)
+	}
+
+	return nil
+}
+
+func mergeEnvFromSources(containerEnvFromSources []corev1.EnvFromSource, newEnvFromSources []corev1.EnvFromSource) (merged []corev1.EnvFromSource) {
+	merged = containerEnvFromSources
+	for _, newEnvFromSource := range newEnvFromSources {
+		found := false
+		for _, existing := range containerEnvFromSources {
+			if reflect.DeepEqual(existing, newEnvFromSource) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, newEnvFromSource)
+		}
+	}
+
+	return
+}
+
+// InjectAffinityIntoDeployment injects the provided Affinity
+// into the given PodSpec.
+//
+// If the PodSpec already defines an Affinity it will be overwritten.
+func InjectAffinityIntoDeployment(podSpec *corev1.PodSpec, affinity *corev1.Affinity) error {
+	if podSpec == nil {
+		return errors.New("no pod spec provided")
+	}
+
+	if affinity != nil {
+		podSpec.Affinity = affinity
+	}
+
+	return nil
+}
+
+// InjectPriorityClassNameIntoDeployment injects the provided
+// PriorityClassName into the given PodSpec.
+//
+// If the PodSpec already defines a PriorityClassName it will be
+// overwritten.
+func InjectPriorityClassNameIntoDeployment(podSpec *corev1.PodSpec, priorityClassName string) error {
+	if podSpec == nil {
+		return errors.New("no pod spec provided")
+	}
+
+	if priorityClassName != "" {
+		podSpec.PriorityClassName = priorityClassName
+	}
+
+	return nil
+}
+
+// InjectTopologySpreadConstraintsIntoDeployment injects the provided
+// TopologySpreadConstraints into the given PodSpec.
+//
+// TopologySpreadConstraints will be appended to the existing ones if
+// not already present.
+func InjectTopologySpreadConstraintsIntoDeployment(podSpec *corev1.PodSpec, constraints []corev1.TopologySpreadConstraint) error {
+	if podSpec == nil {
+		return errors.New("no pod spec provided")
+	}
+
+	merged := podSpec.TopologySpreadConstraints
+	for _, constraint := range constraints {
+		found := false
+		for _, existing := range podSpec.TopologySpreadConstraints {
+			if reflect.DeepEqual(existing, constraint) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, constraint)
+		}
+	}
+	podSpec.TopologySpreadConstraints = merged
+
+	return nil
+}
+
+// InjectSecurityContextIntoDeployment injects the provided
+// PodSecurityContext into the given PodSpec.
+//
+// If the PodSpec already defines a SecurityContext it will be
+// overwritten.
+func InjectSecurityContextIntoDeployment(podSpec *corev1.PodSpec, securityContext *corev1.PodSecurityContext) error {
+	if podSpec == nil {
+		return errors.New("no pod spec provided")
+	}
+
+	if securityContext != nil {
+		podSpec.SecurityContext = securityContext
+	}
+
+	return nil
+}
+
+// InjectAnnotationsIntoDeployment injects the provided annotations
+// into the given PodTemplateSpec.
+//
+// If the PodTemplateSpec already defines an annotation with the same
+// key as any of the provided annotations then it will be overwritten.
+func InjectAnnotationsIntoDeployment(podTemplate *corev1.PodTemplateSpec, annotations map[string]string) error {
+	if podTemplate == nil {
+		return errors.New("no pod template provided")
+	}
+
+	if len(annotations) == 0 {
+		return nil
+	}
+
+	merged := podTemplate.GetAnnotations()
+	if merged == nil {
+		merged = make(map[string]string, len(annotations))
+	}
+	for key, value := range annotations {
+		merged[key] = value
+	}
+	podTemplate.SetAnnotations(merged)
+
+	return nil
+}
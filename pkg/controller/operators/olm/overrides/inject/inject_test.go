@@ -6,6 +6,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/operators/olm/overrides/inject"
 )
@@ -792,3 +793,417 @@ func TestInjectNodeSelectorIntoDeployment(t *testing.T) {
 		})
 	}
 }
+
+func TestInjectEnvFromIntoDeployment(t *testing.T) {
+	secretRef := corev1.EnvFromSource{
+		SecretRef: &corev1.SecretEnvSource{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "license-secret"},
+		},
+	}
+	configMapRef := corev1.EnvFromSource{
+		ConfigMapRef: &corev1.ConfigMapEnvSource{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "config"},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		podSpec        *corev1.PodSpec
+		envFromSources []corev1.EnvFromSource
+		expected       *corev1.PodSpec
+	}{
+		{
+			// Nil PodSpec is injected with an EnvFromSource
+			// Expected: PodSpec is nil
+			name:           "WithNilPodSpec",
+			podSpec:        nil,
+			envFromSources: []corev1.EnvFromSource{secretRef},
+			expected:       nil,
+		},
+		{
+			// PodSpec has containers without EnvFromSources and one EnvFromSource config given
+			// Expected: EnvFromSource will be appended to every container
+			name: "WithContainerHasNoEnvFrom",
+			podSpec: &corev1.PodSpec{
+				Containers: []corev1.Container{
+					corev1.Container{},
+					corev1.Container{},
+				},
+			},
+			envFromSources: []corev1.EnvFromSource{secretRef},
+			expected: &corev1.PodSpec{
+				Containers: []corev1.Container{
+					corev1.Container{
+						EnvFrom: []corev1.EnvFromSource{secretRef},
+					},
+					corev1.Container{
+						EnvFrom: []corev1.EnvFromSource{secretRef},
+					},
+				},
+			},
+		},
+		{
+			// PodSpec has a container with one EnvFromSource and 2 EnvFromSource config given with 1 overlapping
+			// Expected: Non overlapping EnvFromSource will be appended
+			name: "WithContainerHasOverlappingEnvFrom",
+			podSpec: &corev1.PodSpec{
+				Containers: []corev1.Container{
+					corev1.Container{
+						EnvFrom: []corev1.EnvFromSource{configMapRef},
+					},
+				},
+			},
+			envFromSources: []corev1.EnvFromSource{configMapRef, secretRef},
+			expected: &corev1.PodSpec{
+				Containers: []corev1.Container{
+					corev1.Container{
+						EnvFrom: []corev1.EnvFromSource{configMapRef, secretRef},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inject.InjectEnvFromIntoDeployment(tt.podSpec, tt.envFromSources)
+
+			podSpecWant := tt.expected
+			podSpecGot := tt.podSpec
+
+			assert.Equal(t, podSpecWant, podSpecGot)
+		})
+	}
+}
+
+func TestInjectAffinityIntoDeployment(t *testing.T) {
+	defaultAffinity := &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{
+					{
+						MatchExpressions: []corev1.NodeSelectorRequirement{
+							{
+								Key:      "kubernetes.io/os",
+								Operator: corev1.NodeSelectorOpIn,
+								Values:   []string{"linux"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	podAntiAffinity := &corev1.Affinity{
+		PodAntiAffinity: &corev1.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+				{
+					TopologyKey: "kubernetes.io/hostname",
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		podSpec  *corev1.PodSpec
+		affinity *corev1.Affinity
+		expected *corev1.PodSpec
+	}{
+		{
+			// Nil PodSpec is injected with an Affinity
+			// Expected: PodSpec is nil
+			name:     "WithNilPodSpec",
+			podSpec:  nil,
+			affinity: defaultAffinity,
+			expected: nil,
+		},
+		{
+			// PodSpec with no Affinity is injected with an Affinity
+			// Expected: Affinity is set
+			name:     "WithDeploymentHasNoAffinity",
+			podSpec:  &corev1.PodSpec{},
+			affinity: defaultAffinity,
+			expected: &corev1.PodSpec{
+				Affinity: defaultAffinity,
+			},
+		},
+		{
+			// PodSpec with an existing Affinity is injected with an Affinity
+			// Expected: Existing Affinity is overwritten
+			name: "WithDeploymentHasAffinity",
+			podSpec: &corev1.PodSpec{
+				Affinity: defaultAffinity,
+			},
+			affinity: podAntiAffinity,
+			expected: &corev1.PodSpec{
+				Affinity: podAntiAffinity,
+			},
+		},
+		{
+			// Existing PodSpec is left alone if Affinity is nil
+			// Expected: PodSpec is not changed
+			name: "WithNilAffinity",
+			podSpec: &corev1.PodSpec{
+				Affinity: defaultAffinity,
+			},
+			affinity: nil,
+			expected: &corev1.PodSpec{
+				Affinity: defaultAffinity,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inject.InjectAffinityIntoDeployment(tt.podSpec, tt.affinity)
+
+			podSpecWant := tt.expected
+			podSpecGot := tt.podSpec
+
+			assert.Equal(t, podSpecWant, podSpecGot)
+		})
+	}
+}
+
+func TestInjectPriorityClassNameIntoDeployment(t *testing.T) {
+	tests := []struct {
+		name              string
+		podSpec           *corev1.PodSpec
+		priorityClassName string
+		expected          *corev1.PodSpec
+	}{
+		{
+			// Nil PodSpec is injected with a PriorityClassName
+			// Expected: PodSpec is nil
+			name:              "WithNilPodSpec",
+			podSpec:           nil,
+			priorityClassName: "high-priority",
+			expected:          nil,
+		},
+		{
+			// PodSpec with an existing PriorityClassName is injected with a PriorityClassName
+			// Expected: Existing PriorityClassName is overwritten
+			name: "WithDeploymentHasPriorityClassName",
+			podSpec: &corev1.PodSpec{
+				PriorityClassName: "low-priority",
+			},
+			priorityClassName: "high-priority",
+			expected: &corev1.PodSpec{
+				PriorityClassName: "high-priority",
+			},
+		},
+		{
+			// Existing PodSpec is left alone if PriorityClassName is empty
+			// Expected: PodSpec is not changed
+			name: "WithEmptyPriorityClassName",
+			podSpec: &corev1.PodSpec{
+				PriorityClassName: "low-priority",
+			},
+			priorityClassName: "",
+			expected: &corev1.PodSpec{
+				PriorityClassName: "low-priority",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inject.InjectPriorityClassNameIntoDeployment(tt.podSpec, tt.priorityClassName)
+
+			podSpecWant := tt.expected
+			podSpecGot := tt.podSpec
+
+			assert.Equal(t, podSpecWant, podSpecGot)
+		})
+	}
+}
+
+func TestInjectTopologySpreadConstraintsIntoDeployment(t *testing.T) {
+	zoneConstraint := corev1.TopologySpreadConstraint{
+		MaxSkew:           1,
+		TopologyKey:       "topology.kubernetes.io/zone",
+		WhenUnsatisfiable: corev1.ScheduleAnyway,
+	}
+	hostConstraint := corev1.TopologySpreadConstraint{
+		MaxSkew:           1,
+		TopologyKey:       "kubernetes.io/hostname",
+		WhenUnsatisfiable: corev1.DoNotSchedule,
+	}
+
+	tests := []struct {
+		name        string
+		podSpec     *corev1.PodSpec
+		constraints []corev1.TopologySpreadConstraint
+		expected    *corev1.PodSpec
+	}{
+		{
+			// Nil PodSpec is injected with a TopologySpreadConstraint
+			// Expected: PodSpec is nil
+			name:        "WithNilPodSpec",
+			podSpec:     nil,
+			constraints: []corev1.TopologySpreadConstraint{zoneConstraint},
+			expected:    nil,
+		},
+		{
+			// PodSpec has no TopologySpreadConstraints and one config given
+			// Expected: TopologySpreadConstraint will be appended
+			name:        "WithDeploymentHasNoTopologySpreadConstraints",
+			podSpec:     &corev1.PodSpec{},
+			constraints: []corev1.TopologySpreadConstraint{zoneConstraint},
+			expected: &corev1.PodSpec{
+				TopologySpreadConstraints: []corev1.TopologySpreadConstraint{zoneConstraint},
+			},
+		},
+		{
+			// PodSpec has one TopologySpreadConstraint and 2 config given with 1 overlapping
+			// Expected: Non overlapping TopologySpreadConstraint will be appended
+			name: "WithDeploymentHasOverlappingTopologySpreadConstraints",
+			podSpec: &corev1.PodSpec{
+				TopologySpreadConstraints: []corev1.TopologySpreadConstraint{hostConstraint},
+			},
+			constraints: []corev1.TopologySpreadConstraint{hostConstraint, zoneConstraint},
+			expected: &corev1.PodSpec{
+				TopologySpreadConstraints: []corev1.TopologySpreadConstraint{hostConstraint, zoneConstraint},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inject.InjectTopologySpreadConstraintsIntoDeployment(tt.podSpec, tt.constraints)
+
+			podSpecWant := tt.expected
+			podSpecGot := tt.podSpec
+
+			assert.Equal(t, podSpecWant, podSpecGot)
+		})
+	}
+}
+
+func TestInjectSecurityContextIntoDeployment(t *testing.T) {
+	runAsNonRoot := true
+	defaultSecurityContext := &corev1.PodSecurityContext{
+		RunAsNonRoot: &runAsNonRoot,
+	}
+	var fsGroup int64 = 2000
+	fsGroupSecurityContext := &corev1.PodSecurityContext{
+		FSGroup: &fsGroup,
+	}
+
+	tests := []struct {
+		name            string
+		podSpec         *corev1.PodSpec
+		securityContext *corev1.PodSecurityContext
+		expected        *corev1.PodSpec
+	}{
+		{
+			// Nil PodSpec is injected with a SecurityContext
+			// Expected: PodSpec is nil
+			name:            "WithNilPodSpec",
+			podSpec:         nil,
+			securityContext: defaultSecurityContext,
+			expected:        nil,
+		},
+		{
+			// PodSpec with an existing SecurityContext is injected with a SecurityContext
+			// Expected: Existing SecurityContext is overwritten
+			name: "WithDeploymentHasSecurityContext",
+			podSpec: &corev1.PodSpec{
+				SecurityContext: defaultSecurityContext,
+			},
+			securityContext: fsGroupSecurityContext,
+			expected: &corev1.PodSpec{
+				SecurityContext: fsGroupSecurityContext,
+			},
+		},
+		{
+			// Existing PodSpec is left alone if SecurityContext is nil
+			// Expected: PodSpec is not changed
+			name: "WithNilSecurityContext",
+			podSpec: &corev1.PodSpec{
+				SecurityContext: defaultSecurityContext,
+			},
+			securityContext: nil,
+			expected: &corev1.PodSpec{
+				SecurityContext: defaultSecurityContext,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inject.InjectSecurityContextIntoDeployment(tt.podSpec, tt.securityContext)
+
+			podSpecWant := tt.expected
+			podSpecGot := tt.podSpec
+
+			assert.Equal(t, podSpecWant, podSpecGot)
+		})
+	}
+}
+
+func TestInjectAnnotationsIntoDeployment(t *testing.T) {
+	tests := []struct {
+		name        string
+		podTemplate *corev1.PodTemplateSpec
+		annotations map[string]string
+		expected    *corev1.PodTemplateSpec
+	}{
+		{
+			// Nil PodTemplateSpec is injected with annotations
+			// Expected: PodTemplateSpec is nil
+			name:        "WithNilPodTemplate",
+			podTemplate: nil,
+			annotations: map[string]string{"foo": "bar"},
+			expected:    nil,
+		},
+		{
+			// PodTemplateSpec with no annotations is injected with annotations
+			// Expected: Annotations are set
+			name:        "WithDeploymentHasNoAnnotations",
+			podTemplate: &corev1.PodTemplateSpec{},
+			annotations: map[string]string{"foo": "bar"},
+			expected: &corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{"foo": "bar"},
+				},
+			},
+		},
+		{
+			// PodTemplateSpec with existing annotations is injected with annotations
+			// Expected: Annotations are merged and existing keys are overwritten
+			name: "WithDeploymentHasAnnotations",
+			podTemplate: &corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{"foo": "baz", "olm.targetNamespaces": "ns"},
+				},
+			},
+			annotations: map[string]string{"foo": "bar"},
+			expected: &corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{"foo": "bar", "olm.targetNamespaces": "ns"},
+				},
+			},
+		},
+		{
+			// Existing PodTemplateSpec is left alone if annotations are nil
+			// Expected: PodTemplateSpec is not changed
+			name:        "WithNilAnnotations",
+			podTemplate: &corev1.PodTemplateSpec{},
+			annotations: nil,
+			expected:    &corev1.PodTemplateSpec{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inject.InjectAnnotationsIntoDeployment(tt.podTemplate, tt.annotations)
+
+			podTemplateWant := tt.expected
+			podTemplateGot := tt.podTemplate
+
+			assert.Equal(t, podTemplateWant, podTemplateGot)
+		})
+	}
+}
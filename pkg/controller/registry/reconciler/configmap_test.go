@@ -23,6 +23,7 @@ import (
 
 	"github.com/operator-framework/api/pkg/operators/v1alpha1"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/clientfake"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/operatorclient"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/operatorlister"
@@ -38,6 +39,10 @@ type fakeReconcilerConfig struct {
 	k8sObjs              []runtime.Object
 	k8sClientOptions     []clientfake.Option
 	configMapServerImage string
+	digestResolver       ImageDigestResolver
+	refresher            *CatalogRefresher
+	catalogFetcher       FileBasedCatalogFetcher
+	fileBasedSources     *resolver.FileBasedSourceStore
 }
 
 type fakeReconcilerOption func(*fakeReconcilerConfig)
@@ -60,6 +65,30 @@ func withK8sClientOptions(options ...clientfake.Option) fakeReconcilerOption {
 	}
 }
 
+func withDigestResolver(digestResolver ImageDigestResolver) fakeReconcilerOption {
+	return func(config *fakeReconcilerConfig) {
+		config.digestResolver = digestResolver
+	}
+}
+
+func withRefresher(refresher *CatalogRefresher) fakeReconcilerOption {
+	return func(config *fakeReconcilerConfig) {
+		config.refresher = refresher
+	}
+}
+
+func withCatalogFetcher(catalogFetcher FileBasedCatalogFetcher) fakeReconcilerOption {
+	return func(config *fakeReconcilerConfig) {
+		config.catalogFetcher = catalogFetcher
+	}
+}
+
+func withFileBasedSources(fileBasedSources *resolver.FileBasedSourceStore) fakeReconcilerOption {
+	return func(config *fakeReconcilerConfig) {
+		config.fileBasedSources = fileBasedSources
+	}
+}
+
 func withConfigMapServerImage(configMapServerImage string) fakeReconcilerOption {
 	return func(config *fakeReconcilerConfig) {
 		config.configMapServerImage = configMapServerImage
@@ -110,6 +139,10 @@ func fakeReconcilerFactory(t *testing.T, stopc <-chan struct{}, options ...fakeR
 		OpClient:             opClientFake,
 		Lister:               lister,
 		ConfigMapServerImage: config.configMapServerImage,
+		DigestResolver:       config.digestResolver,
+		Refresher:            config.refresher,
+		CatalogFetcher:       config.catalogFetcher,
+		FileBasedSources:     config.fileBasedSources,
 	}
 
 	var hasSyncedCheckFns []cache.InformerSynced
@@ -202,7 +235,7 @@ func objectsForCatalogSource(catsrc *v1alpha1.CatalogSource) []runtime.Object {
 		)
 	case v1alpha1.SourceTypeGrpc:
 		if catsrc.Spec.Image != "" {
-			decorated := grpcCatalogSourceDecorator{catsrc}
+			decorated := grpcCatalogSourceDecorator{CatalogSource: catsrc}
 			objs = clientfake.AddSimpleGeneratedNames(
 				decorated.Pod(catsrc.GetName()),
 				decorated.Service(),
@@ -0,0 +1,237 @@
+package reconciler
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containerd/containerd/remotes"
+	"github.com/containerd/containerd/remotes/docker"
+	"github.com/docker/distribution/reference"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/operatorclient"
+)
+
+const (
+	// UpdateStrategyAnnotationKey is the CatalogSource annotation that selects
+	// how a CatalogSource with a registry poll update strategy discovers
+	// updates of its image.
+	UpdateStrategyAnnotationKey = "operatorframework.io/update-strategy"
+	// DigestUpdateStrategy resolves the image tag to a digest through the
+	// registry API on every poll, and only rolls the catalog pod when the digest
+	// changes. By default, an update pod pulls the image on every poll instead.
+	DigestUpdateStrategy = "digest"
+
+	// CatalogImageDigestAnnotationKey is the annotation of catalog pods that
+	// records the digest their image tag resolved to when they were created.
+	CatalogImageDigestAnnotationKey = "catalogsource.operators.coreos.com/image-digest"
+
+	defaultDigestResolveTimeout = 30 * time.Second
+)
+
+// ImageDigestResolver resolves image references to the digests of the
+// manifests they currently point to.
+type ImageDigestResolver interface {
+	// Resolve returns the digest the given image reference resolves to, using
+	// the credentials in the given image pull Secrets.
+	Resolve(ctx context.Context, image string, pullSecrets []*corev1.Secret) (string, error)
+}
+
+// ImageDigestResolverFunc is a function that implements ImageDigestResolver.
+type ImageDigestResolverFunc func(ctx context.Context, image string, pullSecrets []*corev1.Secret) (string, error)
+
+// Resolve calls the function.
+func (f ImageDigestResolverFunc) Resolve(ctx context.Context, image string, pullSecrets []*corev1.Secret) (string, error) {
+	return f(ctx, image, pullSecrets)
+}
+
+// NewRegistryDigestResolver returns an ImageDigestResolver that queries the
+// image's registry through the registry API with the given client. Registries
+// on localhost are queried over plain HTTP.
+func NewRegistryDigestResolver(client *http.Client) ImageDigestResolver {
+	return &registryDigestResolver{client: client}
+}
+
+type registryDigestResolver struct {
+	client *http.Client
+}
+
+func (r *registryDigestResolver) Resolve(ctx context.Context, image string, pullSecrets []*corev1.Secret) (string, error) {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", fmt.Errorf("invalid image reference %q: %v", image, err)
+	}
+	named = reference.TagNameOnly(named)
+	if digested, ok := named.(reference.Digested); ok {
+		return digested.Digest().String(), nil
+	}
+
+	resolver, err := newRegistryResolver(r.client, pullSecrets)
+	if err != nil {
+		return "", err
+	}
+	_, desc, err := resolver.Resolve(ctx, named.String())
+	if err != nil {
+		return "", fmt.Errorf("error resolving digest of %s: %v", named.String(), err)
+	}
+	return desc.Digest.String(), nil
+}
+
+// catalogPullSecrets returns the image pull Secrets listed by the given
+// CatalogSource.
+func catalogPullSecrets(client operatorclient.ClientInterface, source *v1alpha1.CatalogSource) ([]*corev1.Secret, error) {
+	var pullSecrets []*corev1.Secret
+	for _, name := range source.Spec.Secrets {
+		if name == "" {
+			continue
+		}
+		secret, err := client.KubernetesInterface().CoreV1().Secrets(source.GetNamespace()).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting pull secret %s", name)
+		}
+		pullSecrets = append(pullSecrets, secret)
+	}
+	return pullSecrets, nil
+}
+
+// newRegistryResolver returns a resolver of image references that queries
+// registries with the given client and the credentials in the given image pull
+// Secrets. Registries on localhost are queried over plain HTTP.
+func newRegistryResolver(client *http.Client, pullSecrets []*corev1.Secret) (remotes.Resolver, error) {
+	creds, err := registryCredentials(pullSecrets)
+	if err != nil {
+		return nil, err
+	}
+	return docker.NewResolver(docker.ResolverOptions{
+		Hosts: docker.ConfigureDefaultRegistries(
+			docker.WithClient(client),
+			docker.WithPlainHTTP(docker.MatchLocalhost),
+			docker.WithAuthorizer(docker.NewDockerAuthorizer(
+				docker.WithAuthClient(client),
+				docker.WithAuthCreds(func(host string) (string, string, error) {
+					c := creds[host]
+					return c.username, c.password, nil
+				}),
+			)),
+		),
+	}), nil
+}
+
+type registryCredential struct {
+	username string
+	password string
+}
+
+type dockerConfig struct {
+	Auths map[string]dockerConfigEntry `json:"auths"`
+}
+
+type dockerConfigEntry struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Auth     string `json:"auth"`
+}
+
+// registryCredentials returns the registry credentials in the given image pull
+// Secrets, by registry host.
+func registryCredentials(pullSecrets []*corev1.Secret) (map[string]registryCredential, error) {
+	creds := map[string]registryCredential{}
+	for _, secret := range pullSecrets {
+		var auths map[string]dockerConfigEntry
+		switch secret.Type {
+		case corev1.SecretTypeDockerConfigJson:
+			var config dockerConfig
+			if err := json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &config); err != nil {
+				return nil, fmt.Errorf("invalid %s in secret %s/%s: %v", corev1.DockerConfigJsonKey, secret.GetNamespace(), secret.GetName(), err)
+			}
+			auths = config.Auths
+		case corev1.SecretTypeDockercfg:
+			if err := json.Unmarshal(secret.Data[corev1.DockerConfigKey], &auths); err != nil {
+				return nil, fmt.Errorf("invalid %s in secret %s/%s: %v", corev1.DockerConfigKey, secret.GetNamespace(), secret.GetName(), err)
+			}
+		default:
+			continue
+		}
+
+		for server, entry := range auths {
+			cred := registryCredential{username: entry.Username, password: entry.Password}
+			if entry.Auth != "" {
+				decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+				if err != nil {
+					return nil, fmt.Errorf("invalid auth for %s in secret %s/%s: %v", server, secret.GetNamespace(), secret.GetName(), err)
+				}
+				parts := strings.SplitN(string(decoded), ":", 2)
+				if len(parts) != 2 {
+					return nil, fmt.Errorf("invalid auth for %s in secret %s/%s", server, secret.GetNamespace(), secret.GetName())
+				}
+				cred.username, cred.password = parts[0], parts[1]
+			}
+			creds[registryHost(server)] = cred
+		}
+	}
+	return creds, nil
+}
+
+// registryHost returns the host of the registry API of the given docker config
+// server, which may be a URL.
+func registryHost(server string) string {
+	host := strings.TrimPrefix(strings.TrimPrefix(server, "https://"), "http://")
+	host = strings.SplitN(host, "/", 2)[0]
+	if host == "docker.io" || host == "index.docker.io" {
+		// The registry API of Docker Hub is served by registry-1.docker.io.
+		return "registry-1.docker.io"
+	}
+	return host
+}
+
+// CatalogRefresher records requests to check the images of CatalogSources for
+// updates ahead of their polling interval.
+type CatalogRefresher struct {
+	mu        sync.Mutex
+	requested map[types.NamespacedName]struct{}
+}
+
+// NewCatalogRefresher returns an empty CatalogRefresher.
+func NewCatalogRefresher() *CatalogRefresher {
+	return &CatalogRefresher{requested: map[types.NamespacedName]struct{}{}}
+}
+
+// Request records a request to check the image of the given CatalogSource for
+// updates on its next sync.
+func (r *CatalogRefresher) Request(namespace, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requested[types.NamespacedName{Namespace: namespace, Name: name}] = struct{}{}
+}
+
+// Requested returns true if a check of the image of the given CatalogSource was
+// requested and not done yet.
+func (r *CatalogRefresher) Requested(namespace, name string) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.requested[types.NamespacedName{Namespace: namespace, Name: name}]
+	return ok
+}
+
+// Done records that the image of the given CatalogSource was checked.
+func (r *CatalogRefresher) Done(namespace, name string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.requested, types.NamespacedName{Namespace: namespace, Name: name})
+}
@@ -0,0 +1,186 @@
+package reconciler
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testManifestDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+// fakeRegistry serves the manifest digest of org/catalog:latest, and requires
+// basic auth with the given credentials if they aren't empty.
+func fakeRegistry(t *testing.T, username, password string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username != "" {
+			if u, p, ok := r.BasicAuth(); !ok || u != username || p != password {
+				w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+		switch r.URL.Path {
+		case "/v2/", "/v2":
+			w.WriteHeader(http.StatusOK)
+		case "/v2/org/catalog/manifests/latest":
+			w.Header().Set("Content-Type", "application/vnd.docker.distribution.manifest.v2+json")
+			w.Header().Set("Docker-Content-Digest", testManifestDigest)
+			w.Header().Set("Content-Length", "512")
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func dockerConfigJSONSecret(server, username, password string) *corev1.Secret {
+	auth := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "pull-secret", Namespace: testNamespace},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			corev1.DockerConfigJsonKey: []byte(`{"auths":{"` + server + `":{"auth":"` + auth + `"}}}`),
+		},
+	}
+}
+
+func TestRegistryDigestResolver(t *testing.T) {
+	public := fakeRegistry(t, "", "")
+	defer public.Close()
+	private := fakeRegistry(t, "user", "pass")
+	defer private.Close()
+	publicHost := strings.TrimPrefix(public.URL, "http://")
+	privateHost := strings.TrimPrefix(private.URL, "http://")
+
+	tests := []struct {
+		testName    string
+		image       string
+		pullSecrets []*corev1.Secret
+		want        string
+		wantErr     bool
+	}{
+		{
+			testName: "TagResolved",
+			image:    publicHost + "/org/catalog:latest",
+			want:     testManifestDigest,
+		},
+		{
+			testName: "DefaultTagResolved",
+			image:    publicHost + "/org/catalog",
+			want:     testManifestDigest,
+		},
+		{
+			testName: "DigestReturnedAsIs",
+			image:    "quay.io/org/catalog@" + testManifestDigest,
+			want:     testManifestDigest,
+		},
+		{
+			testName: "UnknownTag",
+			image:    publicHost + "/org/catalog:v1",
+			wantErr:  true,
+		},
+		{
+			testName: "InvalidReference",
+			image:    "Quay.io/Org/Catalog:latest",
+			wantErr:  true,
+		},
+		{
+			testName:    "PrivateWithCredentials",
+			image:       privateHost + "/org/catalog:latest",
+			pullSecrets: []*corev1.Secret{dockerConfigJSONSecret(private.URL, "user", "pass")},
+			want:        testManifestDigest,
+		},
+		{
+			testName:    "PrivateWithWrongCredentials",
+			image:       privateHost + "/org/catalog:latest",
+			pullSecrets: []*corev1.Secret{dockerConfigJSONSecret(private.URL, "user", "wrong")},
+			wantErr:     true,
+		},
+		{
+			testName: "PrivateWithoutCredentials",
+			image:    privateHost + "/org/catalog:latest",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			resolver := NewRegistryDigestResolver(http.DefaultClient)
+			got, err := resolver.Resolve(context.Background(), tt.image, tt.pullSecrets)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRegistryCredentials(t *testing.T) {
+	dockercfg := &corev1.Secret{
+		Type: corev1.SecretTypeDockercfg,
+		Data: map[string][]byte{
+			corev1.DockerConfigKey: []byte(`{"https://index.docker.io/v1/":{"username":"hub","password":"secret"}}`),
+		},
+	}
+	opaque := &corev1.Secret{
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{"token": []byte("ignored")},
+	}
+
+	creds, err := registryCredentials([]*corev1.Secret{
+		dockerConfigJSONSecret("quay.io", "quser", "qpass:with:colons"),
+		dockercfg,
+		opaque,
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]registryCredential{
+		"quay.io":              {username: "quser", password: "qpass:with:colons"},
+		"registry-1.docker.io": {username: "hub", password: "secret"},
+	}, creds)
+
+	_, err = registryCredentials([]*corev1.Secret{{
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths":{"quay.io":{"auth":"bm9jb2xvbg=="}}}`)},
+	}})
+	require.Error(t, err, "auth without a colon must be rejected")
+
+	_, err = registryCredentials([]*corev1.Secret{{
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{corev1.DockerConfigJsonKey: []byte(`not json`)},
+	}})
+	require.Error(t, err)
+}
+
+func TestRegistryHost(t *testing.T) {
+	for server, want := range map[string]string{
+		"quay.io":                     "quay.io",
+		"https://quay.io":             "quay.io",
+		"http://localhost:5000/v2/":   "localhost:5000",
+		"docker.io":                   "registry-1.docker.io",
+		"https://index.docker.io/v1/": "registry-1.docker.io",
+	} {
+		require.Equal(t, want, registryHost(server), server)
+	}
+}
+
+func TestCatalogRefresher(t *testing.T) {
+	var unset *CatalogRefresher
+	require.False(t, unset.Requested(testNamespace, "catalog"))
+	unset.Done(testNamespace, "catalog")
+
+	refresher := NewCatalogRefresher()
+	require.False(t, refresher.Requested(testNamespace, "catalog"))
+	refresher.Request(testNamespace, "catalog")
+	require.True(t, refresher.Requested(testNamespace, "catalog"))
+	require.False(t, refresher.Requested("other", "catalog"))
+	refresher.Done(testNamespace, "catalog")
+	require.False(t, refresher.Requested(testNamespace, "catalog"))
+}
@@ -0,0 +1,341 @@
+package reconciler
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/distribution/reference"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/install"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver/cache"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/operatorclient"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/operatorlister"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/ownerutil"
+)
+
+const (
+	// SourceTypeFileBased is the type of CatalogSources whose file-based
+	// catalog is read from ConfigMaps or an OCI artifact and served by the
+	// catalog operator itself, without a registry pod.
+	SourceTypeFileBased v1alpha1.SourceType = "file-based"
+
+	// ConfigMapSelectorAnnotationKey is the annotation of file-based
+	// CatalogSources that selects the ConfigMaps in their namespace holding
+	// the catalog, as a label selector.
+	ConfigMapSelectorAnnotationKey = "operatorframework.io/catalog-configmap-selector"
+
+	// CatalogSourceFileBasedLoadedCondition is the CatalogSource status
+	// condition that reports whether its file-based catalog is served.
+	CatalogSourceFileBasedLoadedCondition = "FileBasedCatalogLoaded"
+	// CatalogSourceLoadedReason is the reason of the loaded condition when the
+	// current content of the catalog is served.
+	CatalogSourceLoadedReason = "Loaded"
+	// CatalogSourceLoadFailedReason is the reason of the loaded condition when
+	// the content of the catalog couldn't be loaded.
+	CatalogSourceLoadFailedReason = "LoadFailed"
+
+	defaultFileBasedFetchTimeout = 5 * time.Minute
+)
+
+// fileBasedCatalogSourceDecorator wraps CatalogSource to add additional methods
+type fileBasedCatalogSourceDecorator struct {
+	*v1alpha1.CatalogSource
+}
+
+func (s *fileBasedCatalogSourceDecorator) key() cache.SourceKey {
+	return cache.SourceKey{Name: s.GetName(), Namespace: s.GetNamespace()}
+}
+
+// configMapSelector returns the selector of the ConfigMaps holding the
+// catalog, nil if it isn't set.
+func (s *fileBasedCatalogSourceDecorator) configMapSelector() (*metav1.LabelSelector, error) {
+	value, ok := s.GetAnnotations()[ConfigMapSelectorAnnotationKey]
+	if !ok || value == "" {
+		return nil, nil
+	}
+	selector, err := metav1.ParseToLabelSelector(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s annotation %q: %v", ConfigMapSelectorAnnotationKey, value, err)
+	}
+	return selector, nil
+}
+
+// pollDue returns true if the image of the catalog should be checked for
+// updates: when its registry poll interval elapsed since the last check.
+func (s *fileBasedCatalogSourceDecorator) pollDue(now time.Time) bool {
+	if s.Spec.UpdateStrategy == nil || s.Spec.UpdateStrategy.RegistryPoll == nil || s.Spec.UpdateStrategy.Interval == nil {
+		return false
+	}
+	latest := s.Status.LatestImageRegistryPoll
+	if latest.IsZero() {
+		return true
+	}
+	return !latest.Add(s.Spec.UpdateStrategy.Interval.Duration).After(now)
+}
+
+// ValidateFileBasedCatalogSource returns an error if the given file-based
+// CatalogSource doesn't name exactly one of an image and ConfigMaps.
+func ValidateFileBasedCatalogSource(source *v1alpha1.CatalogSource) error {
+	s := fileBasedCatalogSourceDecorator{source}
+	selector, err := s.configMapSelector()
+	if err != nil {
+		return err
+	}
+	configMaps := source.Spec.ConfigMap != "" || selector != nil
+	switch {
+	case source.Spec.Image != "" && configMaps:
+		return fmt.Errorf("image and configmaps set: only one may be set for sourcetype: %s", SourceTypeFileBased)
+	case source.Spec.Image == "" && !configMaps:
+		return fmt.Errorf("image and configmaps unset: one must be set for sourcetype: %s", SourceTypeFileBased)
+	}
+	return nil
+}
+
+// FileBasedRegistryReconciler reconciles CatalogSources whose file-based
+// catalog is served by the catalog operator from a FileBasedSourceStore.
+type FileBasedRegistryReconciler struct {
+	now            nowFunc
+	Lister         operatorlister.OperatorLister
+	OpClient       operatorclient.ClientInterface
+	DigestResolver ImageDigestResolver
+	Fetcher        FileBasedCatalogFetcher
+	Refresher      *CatalogRefresher
+	Store          *resolver.FileBasedSourceStore
+}
+
+var _ RegistryReconciler = &FileBasedRegistryReconciler{}
+
+// EnsureRegistryServer loads the file-based catalog of the given CatalogSource
+// into the store if its content changed. If loading fails, the previously
+// loaded content is served until it succeeds.
+func (c *FileBasedRegistryReconciler) EnsureRegistryServer(catalogSource *v1alpha1.CatalogSource) error {
+	source := fileBasedCatalogSourceDecorator{catalogSource}
+	if c.Store == nil {
+		return fmt.Errorf("no file-based catalog store configured")
+	}
+	if err := ValidateFileBasedCatalogSource(catalogSource); err != nil {
+		return err
+	}
+
+	var (
+		files    map[string][]byte
+		revision string
+		err      error
+	)
+	if source.Spec.Image != "" {
+		files, revision, err = c.imageFiles(source)
+	} else {
+		files, revision, err = c.configMapFiles(source)
+	}
+	if err == nil && files != nil {
+		err = c.load(source, files, revision)
+	}
+	if err != nil {
+		meta.SetStatusCondition(&source.Status.Conditions, metav1.Condition{
+			Type:    CatalogSourceFileBasedLoadedCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  CatalogSourceLoadFailedReason,
+			Message: err.Error(),
+		})
+		return err
+	}
+	return nil
+}
+
+// load serves the catalog in the given files with the given revision.
+func (c *FileBasedRegistryReconciler) load(source fileBasedCatalogSourceDecorator, files map[string][]byte, revision string) error {
+	fbc, err := resolver.LoadFileBasedCatalogFiles(files)
+	if err != nil {
+		return err
+	}
+	src, err := resolver.NewFileBasedSource(source.key(), fbc)
+	if err != nil {
+		return err
+	}
+	c.Store.Set(source.key(), src, revision)
+
+	logger := logrus.WithField("CatalogSource", source.GetName())
+	logger.Infof("loaded file-based catalog revision %s", revision)
+	message := fmt.Sprintf("serving %d bundles of %d packages from revision %s", len(fbc.Bundles)-len(src.Skipped()), len(fbc.Packages), revision)
+	if skipped := src.Skipped(); len(skipped) > 0 {
+		for _, reason := range skipped {
+			logger.Warnf("skipping invalid %s", reason)
+		}
+		message = fmt.Sprintf("%s, skipped %d invalid bundles: %s", message, len(skipped), strings.Join(skipped, "; "))
+	}
+	meta.SetStatusCondition(&source.Status.Conditions, metav1.Condition{
+		Type:    CatalogSourceFileBasedLoadedCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  CatalogSourceLoadedReason,
+		Message: message,
+	})
+	return nil
+}
+
+// imageFiles returns the files of the catalog artifact and its digest if the
+// artifact must be checked for updates and its digest changed, and nil files
+// otherwise.
+func (c *FileBasedRegistryReconciler) imageFiles(source fileBasedCatalogSourceDecorator) (map[string][]byte, string, error) {
+	served, ok := c.Store.Revision(source.key())
+	refresh := c.Refresher.Requested(source.GetNamespace(), source.GetName())
+	if ok && !refresh && !source.pollDue(c.now().Time) {
+		return nil, "", nil
+	}
+	if c.DigestResolver == nil || c.Fetcher == nil {
+		return nil, "", fmt.Errorf("no catalog artifact fetcher configured")
+	}
+
+	pullSecrets, err := catalogPullSecrets(c.OpClient, source.CatalogSource)
+	if err != nil {
+		return nil, "", err
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), defaultFileBasedFetchTimeout)
+	defer cancel()
+	imageDigest, err := c.DigestResolver.Resolve(ctx, source.Spec.Image, pullSecrets)
+	if err != nil {
+		return nil, "", err
+	}
+	source.SetLastUpdateTime()
+	c.Refresher.Done(source.GetNamespace(), source.GetName())
+	if ok && imageDigest == served {
+		logrus.WithField("CatalogSource", source.GetName()).Info("catalog polling result: no update")
+		return nil, "", nil
+	}
+
+	named, err := reference.ParseNormalizedNamed(source.Spec.Image)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid image reference %q: %v", source.Spec.Image, err)
+	}
+	// fetch the resolved digest, the tag may have moved on in the meantime
+	files, err := c.Fetcher.Fetch(ctx, named.Name()+"@"+imageDigest, pullSecrets)
+	if err != nil {
+		return nil, "", err
+	}
+	return files, imageDigest, nil
+}
+
+// configMapFiles returns the files of the ConfigMaps holding the catalog,
+// keyed by <configmap>/<key>, and a revision of their content if it changed,
+// and nil files otherwise. ConfigMaps are adopted by the CatalogSource, so
+// that their changes trigger a sync.
+//
+// ConfigMaps are read from the informer cache, which only holds those with an
+// olm.managed label. Newly selected ConfigMaps without it are listed from the
+// API server until the catalog is first served, at every registry poll
+// interval, and when a refresh of the CatalogSource is requested.
+func (c *FileBasedRegistryReconciler) configMapFiles(source fileBasedCatalogSourceDecorator) (map[string][]byte, string, error) {
+	client := c.OpClient.KubernetesInterface().CoreV1().ConfigMaps(source.GetNamespace())
+	lister := c.Lister.CoreV1().ConfigMapLister().ConfigMaps(source.GetNamespace())
+
+	byName := map[string]*corev1.ConfigMap{}
+	if source.Spec.ConfigMap != "" {
+		cm, err := lister.Get(source.Spec.ConfigMap)
+		if apierrors.IsNotFound(err) {
+			// not adopted yet
+			cm, err = client.Get(context.TODO(), source.Spec.ConfigMap, metav1.GetOptions{})
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get catalog config map %s: %v", source.Spec.ConfigMap, err)
+		}
+		byName[cm.GetName()] = cm
+	}
+	labelSelector, err := source.configMapSelector()
+	if err != nil {
+		return nil, "", err
+	}
+	if labelSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid %s annotation: %v", ConfigMapSelectorAnnotationKey, err)
+		}
+		cached, err := lister.List(selector)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to list catalog config maps: %v", err)
+		}
+		for _, cm := range cached {
+			byName[cm.GetName()] = cm
+		}
+
+		_, served := c.Store.Revision(source.key())
+		if !served || source.pollDue(c.now().Time) || c.Refresher.Requested(source.GetNamespace(), source.GetName()) {
+			list, err := client.List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to list catalog config maps: %v", err)
+			}
+			for i := range list.Items {
+				if _, ok := byName[list.Items[i].GetName()]; !ok {
+					byName[list.Items[i].GetName()] = &list.Items[i]
+				}
+			}
+			source.SetLastUpdateTime()
+			c.Refresher.Done(source.GetNamespace(), source.GetName())
+		}
+	}
+
+	configMaps := make([]*corev1.ConfigMap, 0, len(byName))
+	for _, cm := range byName {
+		// don't mutate the cache
+		configMaps = append(configMaps, cm.DeepCopy())
+	}
+	sort.Slice(configMaps, func(i, j int) bool {
+		return configMaps[i].GetName() < configMaps[j].GetName()
+	})
+
+	hasher := fnv.New32a()
+	for _, cm := range configMaps {
+		labels := cm.GetLabels()
+		if wasOwned := ownerutil.EnsureOwner(cm, source.CatalogSource); !wasOwned || labels[install.OLMManagedLabelKey] == "" {
+			if labels == nil {
+				labels = map[string]string{}
+			}
+			if labels[install.OLMManagedLabelKey] == "" {
+				labels[install.OLMManagedLabelKey] = "false"
+			}
+			cm.SetLabels(labels)
+			updated, err := client.Update(context.TODO(), cm, metav1.UpdateOptions{})
+			if err != nil {
+				return nil, "", fmt.Errorf("unable to write owner onto catalog config map %s: %v", cm.GetName(), err)
+			}
+			*cm = *updated
+		}
+		fmt.Fprintf(hasher, "%s:%s;", cm.GetName(), cm.GetResourceVersion())
+	}
+	revision := rand.SafeEncodeString(fmt.Sprint(hasher.Sum32()))
+	if served, ok := c.Store.Revision(source.key()); ok && served == revision {
+		return nil, "", nil
+	}
+
+	files := map[string][]byte{}
+	for _, cm := range configMaps {
+		for key, value := range cm.Data {
+			files[cm.GetName()+"/"+key] = []byte(value)
+		}
+		for key, value := range cm.BinaryData {
+			files[cm.GetName()+"/"+key] = value
+		}
+	}
+	return files, revision, nil
+}
+
+// CheckRegistryServer returns true if the file-based catalog of the given
+// CatalogSource is served.
+func (c *FileBasedRegistryReconciler) CheckRegistryServer(catalogSource *v1alpha1.CatalogSource) (healthy bool, err error) {
+	if c.Store == nil {
+		return false, nil
+	}
+	source := fileBasedCatalogSourceDecorator{catalogSource}
+	_, healthy = c.Store.Revision(source.key())
+	return healthy, nil
+}
@@ -0,0 +1,282 @@
+package reconciler
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/containerd/containerd/archive/compression"
+	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/platforms"
+	"github.com/containerd/containerd/remotes"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ConfigsLocationLabel is the image label that locates the file-based
+	// catalog within the filesystem of a catalog image.
+	ConfigsLocationLabel = "operators.operatorframework.io.index.configs.v1"
+
+	defaultConfigsLocation = "/configs"
+
+	// maxFileBasedCatalogSize bounds the size of the files read from a
+	// file-based catalog artifact.
+	maxFileBasedCatalogSize = 64 << 20
+
+	// whiteoutPrefix prefixes the names of the entries of filesystem layers
+	// that delete the file of the same name from earlier layers.
+	whiteoutPrefix = ".wh."
+	// whiteoutOpaqueDir is the name of the entry of filesystem layers that
+	// deletes the contents of its directory from earlier layers.
+	whiteoutOpaqueDir = whiteoutPrefix + whiteoutPrefix + ".opq"
+)
+
+// FileBasedCatalogFetcher fetches the files of file-based catalogs
+// distributed as OCI artifacts.
+type FileBasedCatalogFetcher interface {
+	// Fetch returns the files of the file-based catalog in the given image,
+	// keyed by their path relative to the catalog, using the credentials in
+	// the given image pull Secrets.
+	Fetch(ctx context.Context, image string, pullSecrets []*corev1.Secret) (map[string][]byte, error)
+}
+
+// FileBasedCatalogFetcherFunc is a function that implements FileBasedCatalogFetcher.
+type FileBasedCatalogFetcherFunc func(ctx context.Context, image string, pullSecrets []*corev1.Secret) (map[string][]byte, error)
+
+// Fetch calls the function.
+func (f FileBasedCatalogFetcherFunc) Fetch(ctx context.Context, image string, pullSecrets []*corev1.Secret) (map[string][]byte, error) {
+	return f(ctx, image, pullSecrets)
+}
+
+// NewRegistryCatalogFetcher returns a FileBasedCatalogFetcher that pulls
+// catalog artifacts through the registry API with the given client.
+//
+// Catalog images are read from the directory named by their
+// ConfigsLocationLabel, /configs by default. Artifacts whose layers aren't
+// filesystem layers are read from the JSON and YAML layers named by their
+// org.opencontainers.image.title annotation.
+func NewRegistryCatalogFetcher(client *http.Client) FileBasedCatalogFetcher {
+	return &registryCatalogFetcher{client: client}
+}
+
+type registryCatalogFetcher struct {
+	client *http.Client
+}
+
+func (f *registryCatalogFetcher) Fetch(ctx context.Context, image string, pullSecrets []*corev1.Secret) (map[string][]byte, error) {
+	resolver, err := newRegistryResolver(f.client, pullSecrets)
+	if err != nil {
+		return nil, err
+	}
+	name, desc, err := resolver.Resolve(ctx, image)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving %s: %v", image, err)
+	}
+	fetcher, err := resolver.Fetcher(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &catalogArtifactReader{fetcher: fetcher, remaining: maxFileBasedCatalogSize}
+	manifest, err := r.manifest(ctx, desc)
+	if err != nil {
+		return nil, fmt.Errorf("error reading manifest of %s: %v", image, err)
+	}
+	files, err := r.files(ctx, manifest)
+	if err != nil {
+		return nil, fmt.Errorf("error reading catalog of %s: %v", image, err)
+	}
+	return files, nil
+}
+
+// catalogArtifactReader reads the blobs of a catalog artifact, up to a
+// remaining number of bytes.
+type catalogArtifactReader struct {
+	fetcher   remotes.Fetcher
+	remaining int64
+}
+
+func (r *catalogArtifactReader) read(rd io.Reader) ([]byte, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(rd, r.remaining+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > r.remaining {
+		return nil, fmt.Errorf("catalog exceeds %d bytes", maxFileBasedCatalogSize)
+	}
+	r.remaining -= int64(len(data))
+	return data, nil
+}
+
+func (r *catalogArtifactReader) blob(ctx context.Context, desc ocispec.Descriptor) ([]byte, error) {
+	rc, err := r.fetcher.Fetch(ctx, desc)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return r.read(rc)
+}
+
+// manifest returns the manifest of the given descriptor, selecting the one of
+// the default platform from image indexes.
+func (r *catalogArtifactReader) manifest(ctx context.Context, desc ocispec.Descriptor) (*ocispec.Manifest, error) {
+	for {
+		data, err := r.blob(ctx, desc)
+		if err != nil {
+			return nil, err
+		}
+		switch desc.MediaType {
+		case ocispec.MediaTypeImageIndex, images.MediaTypeDockerSchema2ManifestList:
+			var index ocispec.Index
+			if err := json.Unmarshal(data, &index); err != nil {
+				return nil, err
+			}
+			var found bool
+			for _, m := range index.Manifests {
+				if m.Platform == nil || platforms.Default().Match(*m.Platform) {
+					desc, found = m, true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("no manifest for platform %s", platforms.DefaultString())
+			}
+		case ocispec.MediaTypeImageManifest, images.MediaTypeDockerSchema2Manifest:
+			var manifest ocispec.Manifest
+			if err := json.Unmarshal(data, &manifest); err != nil {
+				return nil, err
+			}
+			return &manifest, nil
+		default:
+			return nil, fmt.Errorf("unsupported media type %s", desc.MediaType)
+		}
+	}
+}
+
+// files returns the catalog files in the layers of the given manifest. Later
+// layers override the files of earlier ones.
+func (r *catalogArtifactReader) files(ctx context.Context, manifest *ocispec.Manifest) (map[string][]byte, error) {
+	location := defaultConfigsLocation
+	if images.IsKnownConfig(manifest.Config.MediaType) {
+		data, err := r.blob(ctx, manifest.Config)
+		if err != nil {
+			return nil, err
+		}
+		var config ocispec.Image
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, err
+		}
+		if l, ok := config.Config.Labels[ConfigsLocationLabel]; ok {
+			location = l
+		}
+	}
+	location = strings.Trim(path.Clean("/"+location), "/")
+
+	files := map[string][]byte{}
+	for _, layer := range manifest.Layers {
+		if images.IsLayerType(layer.MediaType) {
+			if err := r.layerFiles(ctx, layer, location, files); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		title := path.Clean(layer.Annotations[ocispec.AnnotationTitle])
+		switch path.Ext(title) {
+		case ".json", ".yaml", ".yml":
+		default:
+			continue
+		}
+		data, err := r.blob(ctx, layer)
+		if err != nil {
+			return nil, err
+		}
+		files[strings.TrimPrefix(title, "/")] = data
+	}
+	return files, nil
+}
+
+// layerFiles adds the regular files below location in the given filesystem
+// layer to files, and removes the files of earlier layers its whiteouts
+// delete. Every entry of the layer counts towards the size of the catalog,
+// including the ones that aren't read.
+func (r *catalogArtifactReader) layerFiles(ctx context.Context, layer ocispec.Descriptor, location string, files map[string][]byte) error {
+	rc, err := r.fetcher.Fetch(ctx, layer)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	decompressed, err := compression.DecompressStream(rc)
+	if err != nil {
+		return err
+	}
+	defer decompressed.Close()
+
+	// whiteouts only apply to the files of earlier layers
+	added := map[string]struct{}{}
+	tr := tar.NewReader(decompressed)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		name := strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")
+		dir, base := path.Split(name)
+		dir = strings.TrimSuffix(dir, "/")
+		switch {
+		case base == whiteoutOpaqueDir:
+			whiteout(files, added, location, dir, false)
+		case strings.HasPrefix(base, whiteoutPrefix):
+			whiteout(files, added, location, path.Join(dir, strings.TrimPrefix(base, whiteoutPrefix)), true)
+		}
+
+		if hdr.Typeflag != tar.TypeReg || strings.HasPrefix(base, whiteoutPrefix) || (location != "" && !strings.HasPrefix(name, location+"/")) {
+			if err := r.skip(hdr.Size); err != nil {
+				return err
+			}
+			continue
+		}
+		relative := strings.TrimPrefix(name, location+"/")
+		data, err := r.read(tr)
+		if err != nil {
+			return err
+		}
+		files[relative] = data
+		added[relative] = struct{}{}
+	}
+}
+
+// skip counts the given number of bytes that aren't read towards the size of
+// the catalog.
+func (r *catalogArtifactReader) skip(size int64) error {
+	if size > r.remaining {
+		return fmt.Errorf("catalog exceeds %d bytes", maxFileBasedCatalogSize)
+	}
+	r.remaining -= size
+	return nil
+}
+
+// whiteout removes the files of earlier layers below the given path of the
+// layer filesystem, and the file at the path itself if inclusive is set.
+func whiteout(files map[string][]byte, added map[string]struct{}, location, target string, inclusive bool) {
+	for name := range files {
+		if _, ok := added[name]; ok {
+			continue
+		}
+		full := name
+		if location != "" {
+			full = location + "/" + name
+		}
+		if (inclusive && full == target) || target == "" || strings.HasPrefix(full, target+"/") {
+			delete(files, name)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package reconciler
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/containerd/containerd/platforms"
+	"github.com/containerd/containerd/remotes"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeArtifactRegistry serves the manifests and blobs of org/catalog, with the
+// manifest of the given tags being the given blobs.
+type fakeArtifactRegistry struct {
+	blobs map[digest.Digest][]byte
+	types map[digest.Digest]string
+	tags  map[string]digest.Digest
+}
+
+func newFakeArtifactRegistry() *fakeArtifactRegistry {
+	return &fakeArtifactRegistry{
+		blobs: map[digest.Digest][]byte{},
+		types: map[digest.Digest]string{},
+		tags:  map[string]digest.Digest{},
+	}
+}
+
+func (r *fakeArtifactRegistry) add(t *testing.T, mediaType string, content interface{}) ocispec.Descriptor {
+	data, ok := content.([]byte)
+	if !ok {
+		var err error
+		data, err = json.Marshal(content)
+		require.NoError(t, err)
+	}
+	d := digest.FromBytes(data)
+	r.blobs[d] = data
+	r.types[d] = mediaType
+	return ocispec.Descriptor{MediaType: mediaType, Digest: d, Size: int64(len(data))}
+}
+
+func (r *fakeArtifactRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var ref string
+	switch {
+	case req.URL.Path == "/v2/" || req.URL.Path == "/v2":
+		w.WriteHeader(http.StatusOK)
+		return
+	case strings.HasPrefix(req.URL.Path, "/v2/org/catalog/manifests/"):
+		ref = strings.TrimPrefix(req.URL.Path, "/v2/org/catalog/manifests/")
+		if d, ok := r.tags[ref]; ok {
+			ref = d.String()
+		}
+	case strings.HasPrefix(req.URL.Path, "/v2/org/catalog/blobs/"):
+		ref = strings.TrimPrefix(req.URL.Path, "/v2/org/catalog/blobs/")
+	}
+	data, ok := r.blobs[digest.Digest(ref)]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", r.types[digest.Digest(ref)])
+	w.Header().Set("Docker-Content-Digest", ref)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func tarGzLayer(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestRegistryCatalogFetcher(t *testing.T) {
+	registry := newFakeArtifactRegistry()
+
+	// a multi-arch catalog image with its catalog in /catalog
+	config := ocispec.Image{}
+	config.Config.Labels = map[string]string{ConfigsLocationLabel: "/catalog"}
+	base := registry.add(t, ocispec.MediaTypeImageLayerGzip, tarGzLayer(t, map[string]string{
+		"bin/opm":                     "binary",
+		"catalog/etcd/old.yaml":       "replaced",
+		"catalog/etcd/removed.yaml":   "removed",
+		"catalog/prometheus/old.yaml": "removed",
+		"catalog/redis/package.yaml":  "removed",
+	}))
+	top := registry.add(t, ocispec.MediaTypeImageLayerGzip, tarGzLayer(t, map[string]string{
+		"catalog/etcd/old.yaml":           "schema: olm.package",
+		"./catalog/etcd/new.json":         `{"schema":"olm.bundle"}`,
+		"catalog/etcd/.wh.removed.yaml":   "",
+		"catalog/.wh.redis":               "",
+		"catalog/prometheus/.wh..wh..opq": "",
+		"catalog/prometheus/package.yaml": "schema: olm.package",
+	}))
+	manifest := registry.add(t, ocispec.MediaTypeImageManifest, ocispec.Manifest{
+		Config: registry.add(t, ocispec.MediaTypeImageConfig, config),
+		Layers: []ocispec.Descriptor{base, top},
+	})
+	other := registry.add(t, ocispec.MediaTypeImageManifest, ocispec.Manifest{})
+	otherPlatform := platforms.DefaultSpec()
+	otherPlatform.Architecture = "unknown"
+	platform := platforms.DefaultSpec()
+	other.Platform = &otherPlatform
+	manifest.Platform = &platform
+	registry.tags["image"] = registry.add(t, ocispec.MediaTypeImageIndex, ocispec.Index{
+		Manifests: []ocispec.Descriptor{other, manifest},
+	}).Digest
+
+	// an artifact with a catalog file layer
+	file := registry.add(t, "application/vnd.cncf.operatorframework.catalog.v1+json", []byte(`{"schema":"olm.package"}`))
+	file.Annotations = map[string]string{ocispec.AnnotationTitle: "etcd/package.json"}
+	ignored := registry.add(t, "text/plain", []byte("ignored"))
+	ignored.Annotations = map[string]string{ocispec.AnnotationTitle: "README.md"}
+	registry.tags["artifact"] = registry.add(t, ocispec.MediaTypeImageManifest, ocispec.Manifest{
+		Config: registry.add(t, "application/vnd.cncf.operatorframework.catalog.config.v1+json", []byte("{}")),
+		Layers: []ocispec.Descriptor{file, ignored},
+	}).Digest
+
+	server := httptest.NewServer(registry)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	tests := []struct {
+		testName string
+		image    string
+		want     map[string][]byte
+		wantErr  bool
+	}{
+		{
+			testName: "Image",
+			image:    host + "/org/catalog:image",
+			want: map[string][]byte{
+				"etcd/old.yaml":           []byte("schema: olm.package"),
+				"etcd/new.json":           []byte(`{"schema":"olm.bundle"}`),
+				"prometheus/package.yaml": []byte("schema: olm.package"),
+			},
+		},
+		{
+			testName: "Artifact",
+			image:    host + "/org/catalog:artifact",
+			want: map[string][]byte{
+				"etcd/package.json": []byte(`{"schema":"olm.package"}`),
+			},
+		},
+		{
+			testName: "Missing",
+			image:    host + "/org/catalog:missing",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			files, err := NewRegistryCatalogFetcher(http.DefaultClient).Fetch(context.Background(), tt.image, nil)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, files)
+		})
+	}
+}
+
+func TestLayerFilesSize(t *testing.T) {
+	layer := tarGzLayer(t, map[string]string{
+		"bin/opm":               strings.Repeat("x", 64),
+		"configs/etcd/pkg.yaml": "schema: olm.package",
+	})
+	fetcher := remotes.FetcherFunc(func(ctx context.Context, desc ocispec.Descriptor) (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(layer)), nil
+	})
+	desc := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageLayerGzip}
+
+	// entries outside of the catalog count towards its size
+	r := &catalogArtifactReader{fetcher: fetcher, remaining: 64}
+	require.Error(t, r.layerFiles(context.Background(), desc, "configs", map[string][]byte{}))
+
+	r = &catalogArtifactReader{fetcher: fetcher, remaining: 128}
+	files := map[string][]byte{}
+	require.NoError(t, r.layerFiles(context.Background(), desc, "configs", files))
+	require.Equal(t, map[string][]byte{"etcd/pkg.yaml": []byte("schema: olm.package")}, files)
+	require.Equal(t, int64(128-64-len("schema: olm.package")), r.remaining)
+}
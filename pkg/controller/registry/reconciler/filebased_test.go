@@ -0,0 +1,253 @@
+package reconciler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver/cache"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/ownerutil"
+)
+
+const (
+	testPackageYAML = "schema: olm.package\nname: etcd\ndefaultChannel: stable\n"
+	testBundleJSON  = `{"schema":"olm.bundle","name":"etcdoperator.v0.9.4","package":"etcd","image":"quay.io/etcd/bundle:v0.9.4",` +
+		`"properties":[{"type":"olm.package","value":{"packageName":"etcd","version":"0.9.4"}},{"type":"olm.channel","value":{"name":"stable"}}]}`
+)
+
+func fileBasedCatalogSource(annotations map[string]string, spec v1alpha1.CatalogSourceSpec) *v1alpha1.CatalogSource {
+	spec.SourceType = SourceTypeFileBased
+	return &v1alpha1.CatalogSource{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "fbc",
+			Namespace:   testNamespace,
+			UID:         types.UID("catalog-uid"),
+			Annotations: annotations,
+		},
+		Spec: spec,
+	}
+}
+
+func TestValidateFileBasedCatalogSource(t *testing.T) {
+	selector := map[string]string{ConfigMapSelectorAnnotationKey: "catalog=fbc"}
+	tests := []struct {
+		testName    string
+		annotations map[string]string
+		spec        v1alpha1.CatalogSourceSpec
+		wantErr     string
+	}{
+		{
+			testName: "Image",
+			spec:     v1alpha1.CatalogSourceSpec{Image: "quay.io/org/catalog:latest"},
+		},
+		{
+			testName: "ConfigMap",
+			spec:     v1alpha1.CatalogSourceSpec{ConfigMap: "catalog"},
+		},
+		{
+			testName:    "ConfigMapSelector",
+			annotations: selector,
+		},
+		{
+			testName:    "ConfigMapAndSelector",
+			annotations: selector,
+			spec:        v1alpha1.CatalogSourceSpec{ConfigMap: "catalog"},
+		},
+		{
+			testName: "Unset",
+			wantErr:  "image and configmaps unset: one must be set for sourcetype: file-based",
+		},
+		{
+			testName:    "ImageAndConfigMaps",
+			annotations: selector,
+			spec:        v1alpha1.CatalogSourceSpec{Image: "quay.io/org/catalog:latest"},
+			wantErr:     "image and configmaps set: only one may be set for sourcetype: file-based",
+		},
+		{
+			testName:    "InvalidSelector",
+			annotations: map[string]string{ConfigMapSelectorAnnotationKey: "catalog in"},
+			wantErr:     `invalid operatorframework.io/catalog-configmap-selector annotation "catalog in"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			err := ValidateFileBasedCatalogSource(fileBasedCatalogSource(tt.annotations, tt.spec))
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFileBasedRegistryReconcilerConfigMaps(t *testing.T) {
+	stopc := make(chan struct{})
+	defer close(stopc)
+
+	configMap := func(name string, labels map[string]string, data map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace, Labels: labels, ResourceVersion: "1"},
+			Data:       data,
+		}
+	}
+	packages := configMap("packages", nil, map[string]string{"package.yaml": testPackageYAML})
+	bundles := configMap("bundles", map[string]string{"catalog": "fbc"}, map[string]string{"bundles.json": testBundleJSON})
+	unrelated := configMap("unrelated", map[string]string{"catalog": "other"}, map[string]string{"broken.json": "{"})
+
+	var changed []cache.SourceKey
+	store := resolver.NewFileBasedSourceStore(func(key cache.SourceKey) {
+		changed = append(changed, key)
+	})
+	refresher := NewCatalogRefresher()
+	factory, client := fakeReconcilerFactory(t, stopc, withK8sObjs(packages, bundles, unrelated), withRefresher(refresher), withFileBasedSources(store))
+	lister := factory.(*registryReconcilerFactory).Lister.CoreV1().ConfigMapLister().ConfigMaps(testNamespace)
+	// waitForCache waits for the informer cache to hold the given resource
+	// version of a config map
+	waitForCache := func(name, resourceVersion string) {
+		require.Eventually(t, func() bool {
+			cm, err := lister.Get(name)
+			return err == nil && cm.GetResourceVersion() == resourceVersion
+		}, 10*time.Second, 10*time.Millisecond)
+	}
+	catsrc := fileBasedCatalogSource(map[string]string{ConfigMapSelectorAnnotationKey: "catalog=fbc"}, v1alpha1.CatalogSourceSpec{ConfigMap: "packages"})
+	rec := factory.ReconcilerForSource(catsrc)
+	require.IsType(t, &FileBasedRegistryReconciler{}, rec)
+	key := cache.SourceKey{Name: catsrc.GetName(), Namespace: catsrc.GetNamespace()}
+
+	healthy, err := rec.CheckRegistryServer(catsrc)
+	require.NoError(t, err)
+	require.False(t, healthy)
+
+	require.NoError(t, rec.EnsureRegistryServer(catsrc))
+	require.Equal(t, []cache.SourceKey{key}, changed)
+	healthy, err = rec.CheckRegistryServer(catsrc)
+	require.NoError(t, err)
+	require.True(t, healthy)
+	require.True(t, meta.IsStatusConditionTrue(catsrc.Status.Conditions, CatalogSourceFileBasedLoadedCondition))
+
+	snapshot, err := store.Sources(testNamespace)[key].Snapshot(context.Background())
+	require.NoError(t, err)
+	require.Len(t, snapshot.Entries, 1)
+	require.Equal(t, "etcdoperator.v0.9.4", snapshot.Entries[0].Name)
+
+	// the selected config maps are adopted
+	for _, name := range []string{"packages", "bundles"} {
+		cm, err := client.KubernetesInterface().CoreV1().ConfigMaps(testNamespace).Get(context.TODO(), name, metav1.GetOptions{})
+		require.NoError(t, err)
+		require.True(t, ownerutil.IsOwnedBy(cm, catsrc))
+		require.Equal(t, "false", cm.GetLabels()["olm.managed"])
+	}
+	cm, err := client.KubernetesInterface().CoreV1().ConfigMaps(testNamespace).Get(context.TODO(), "unrelated", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.False(t, ownerutil.IsOwnedBy(cm, catsrc))
+
+	// unchanged config maps aren't loaded again
+	require.NoError(t, rec.EnsureRegistryServer(catsrc))
+	require.Len(t, changed, 1)
+
+	// new config maps without an olm.managed label are picked up on a requested refresh
+	_, err = client.KubernetesInterface().CoreV1().ConfigMaps(testNamespace).Create(context.TODO(), configMap("invalid", map[string]string{"catalog": "fbc"}, map[string]string{
+		"bundles.json": `{"schema":"olm.bundle","name":"etcdoperator.v0.9.5","package":"etcd","image":"quay.io/etcd/bundle:v0.9.5",` +
+			`"properties":[{"type":"olm.package","value":{"packageName":"etcd","version":"0.9.5"}},{"type":"olm.channel","value":{"name":"stable","replaces":"etcdoperator.v0.9.4"}},` +
+			`{"type":"olm.constraint","value":{"failureMessage":"no constraint"}}]}`,
+	}), metav1.CreateOptions{})
+	require.NoError(t, err)
+	require.NoError(t, rec.EnsureRegistryServer(catsrc))
+	require.Len(t, changed, 1)
+	refresher.Request(catsrc.GetNamespace(), catsrc.GetName())
+	require.NoError(t, rec.EnsureRegistryServer(catsrc))
+	require.Len(t, changed, 2)
+	require.False(t, refresher.Requested(catsrc.GetNamespace(), catsrc.GetName()))
+
+	// invalid bundles are left out and reported
+	cond := meta.FindStatusCondition(catsrc.Status.Conditions, CatalogSourceFileBasedLoadedCondition)
+	require.NotNil(t, cond)
+	require.Equal(t, metav1.ConditionTrue, cond.Status)
+	require.Contains(t, cond.Message, "serving 1 bundles of 1 packages")
+	require.Contains(t, cond.Message, `skipped 1 invalid bundles: bundle "etcdoperator.v0.9.5" in package "etcd": invalid olm.constraint property`)
+	snapshot, err = store.Sources(testNamespace)[key].Snapshot(context.Background())
+	require.NoError(t, err)
+	require.Len(t, snapshot.Entries, 1)
+	require.Equal(t, "etcdoperator.v0.9.4", snapshot.Entries[0].Name)
+
+	// broken content is reported, and the previous content stays served
+	cm, err = client.KubernetesInterface().CoreV1().ConfigMaps(testNamespace).Get(context.TODO(), "bundles", metav1.GetOptions{})
+	require.NoError(t, err)
+	cm.Data["bundles.json"] = "{"
+	cm.SetResourceVersion("3")
+	_, err = client.KubernetesInterface().CoreV1().ConfigMaps(testNamespace).Update(context.TODO(), cm, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	waitForCache("bundles", "3")
+	require.Error(t, rec.EnsureRegistryServer(catsrc))
+	require.Len(t, changed, 2)
+	require.True(t, meta.IsStatusConditionFalse(catsrc.Status.Conditions, CatalogSourceFileBasedLoadedCondition))
+	healthy, err = rec.CheckRegistryServer(catsrc)
+	require.NoError(t, err)
+	require.True(t, healthy)
+}
+
+func TestFileBasedRegistryReconcilerImage(t *testing.T) {
+	stopc := make(chan struct{})
+	defer close(stopc)
+
+	digest := digestA
+	resolved := 0
+	digestResolver := ImageDigestResolverFunc(func(ctx context.Context, image string, pullSecrets []*corev1.Secret) (string, error) {
+		resolved++
+		return digest, nil
+	})
+	var fetched []string
+	fetcher := FileBasedCatalogFetcherFunc(func(ctx context.Context, image string, pullSecrets []*corev1.Secret) (map[string][]byte, error) {
+		fetched = append(fetched, image)
+		return map[string][]byte{"etcd/package.yaml": []byte(testPackageYAML), "etcd/bundles.json": []byte(testBundleJSON)}, nil
+	})
+	refresher := NewCatalogRefresher()
+	store := resolver.NewFileBasedSourceStore(nil)
+	factory, _ := fakeReconcilerFactory(t, stopc, withDigestResolver(digestResolver), withCatalogFetcher(fetcher), withRefresher(refresher), withFileBasedSources(store))
+
+	catsrc := fileBasedCatalogSource(nil, v1alpha1.CatalogSourceSpec{
+		Image: "quay.io/org/catalog:latest",
+		UpdateStrategy: &v1alpha1.UpdateStrategy{
+			RegistryPoll: &v1alpha1.RegistryPoll{Interval: &metav1.Duration{Duration: time.Hour}},
+		},
+	})
+	rec := factory.ReconcilerForSource(catsrc)
+	key := cache.SourceKey{Name: catsrc.GetName(), Namespace: catsrc.GetNamespace()}
+
+	// the resolved digest is fetched
+	require.NoError(t, rec.EnsureRegistryServer(catsrc))
+	require.Equal(t, []string{"quay.io/org/catalog@" + digestA}, fetched)
+	require.NotNil(t, catsrc.Status.LatestImageRegistryPoll)
+	revision, ok := store.Revision(key)
+	require.True(t, ok)
+	require.Equal(t, digestA, revision)
+
+	// nothing is polled before the interval elapsed
+	require.NoError(t, rec.EnsureRegistryServer(catsrc))
+	require.Equal(t, 1, resolved)
+
+	// an unchanged digest isn't fetched again
+	refresher.Request(catsrc.GetNamespace(), catsrc.GetName())
+	require.NoError(t, rec.EnsureRegistryServer(catsrc))
+	require.Equal(t, 2, resolved)
+	require.Len(t, fetched, 1)
+	require.False(t, refresher.Requested(catsrc.GetNamespace(), catsrc.GetName()))
+
+	// a new digest is fetched once the poll is due
+	digest = digestB
+	catsrc.Status.LatestImageRegistryPoll = &metav1.Time{Time: time.Now().Add(-2 * time.Hour)}
+	require.NoError(t, rec.EnsureRegistryServer(catsrc))
+	require.Equal(t, []string{"quay.io/org/catalog@" + digestA, "quay.io/org/catalog@" + digestB}, fetched)
+	revision, _ = store.Revision(key)
+	require.Equal(t, digestB, revision)
+}
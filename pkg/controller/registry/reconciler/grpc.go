@@ -6,6 +6,8 @@ import (
 	"hash/fnv"
 	"time"
 
+	"github.com/docker/distribution/reference"
+	"github.com/opencontainers/go-digest"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
@@ -33,6 +35,8 @@ const (
 // grpcCatalogSourceDecorator wraps CatalogSource to add additional methods
 type grpcCatalogSourceDecorator struct {
 	*v1alpha1.CatalogSource
+	// images is the image history and rollback of the CatalogSource, if loaded.
+	images *imageRecord
 }
 
 type UpdateNotReadyErr struct {
@@ -123,19 +127,32 @@ func (s *grpcCatalogSourceDecorator) ServiceAccount() *corev1.ServiceAccount {
 }
 
 func (s *grpcCatalogSourceDecorator) Pod(saName string) *corev1.Pod {
-	pod := Pod(s.CatalogSource, "registry-server", s.Spec.Image, saName, s.Labels(), s.Annotations(), 5, 10)
+	return s.podForImage(s.Image(), saName)
+}
+
+func (s *grpcCatalogSourceDecorator) podForImage(image, saName string) *corev1.Pod {
+	pod := Pod(s.CatalogSource, "registry-server", image, saName, s.Labels(), s.Annotations(), 5, 10)
 	ownerutil.AddOwner(pod, s.CatalogSource, false, false)
 	return pod
 }
 
+// DigestUpdates returns true if the CatalogSource polls its registry for
+// updates with the digest update strategy.
+func (s *grpcCatalogSourceDecorator) DigestUpdates() bool {
+	return s.Poll() && s.GetAnnotations()[UpdateStrategyAnnotationKey] == DigestUpdateStrategy
+}
+
 type GrpcRegistryReconciler struct {
-	now       nowFunc
-	Lister    operatorlister.OperatorLister
-	OpClient  operatorclient.ClientInterface
-	SSAClient *controllerclient.ServerSideApplier
+	now            nowFunc
+	Lister         operatorlister.OperatorLister
+	OpClient       operatorclient.ClientInterface
+	SSAClient      *controllerclient.ServerSideApplier
+	DigestResolver ImageDigestResolver
+	Refresher      *CatalogRefresher
 }
 
 var _ RegistryReconciler = &GrpcRegistryReconciler{}
+var _ ImageHistoryRecorder = &GrpcRegistryReconciler{}
 
 func (c *GrpcRegistryReconciler) currentService(source grpcCatalogSourceDecorator) *corev1.Service {
 	serviceName := source.Service().GetName()
@@ -180,7 +197,7 @@ func (c *GrpcRegistryReconciler) currentPodsWithCorrectImageAndSpec(source grpcC
 	found := []*corev1.Pod{}
 	newPod := source.Pod(saName)
 	for _, p := range pods {
-		if p.Spec.Containers[0].Image == source.Spec.Image && podHashMatch(p, newPod) {
+		if servesImage(p, source.Image()) && podHashMatch(p, newPod) {
 			found = append(found, p)
 		}
 	}
@@ -188,12 +205,30 @@ func (c *GrpcRegistryReconciler) currentPodsWithCorrectImageAndSpec(source grpcC
 }
 
 // EnsureRegistryServer ensures that all components of registry server are up to date.
-func (c *GrpcRegistryReconciler) EnsureRegistryServer(catalogSource *v1alpha1.CatalogSource) error {
-	source := grpcCatalogSourceDecorator{catalogSource}
+func (c *GrpcRegistryReconciler) EnsureRegistryServer(catalogSource *v1alpha1.CatalogSource) (err error) {
+	source, err := c.withImageRecord(catalogSource)
+	if err != nil {
+		return err
+	}
+	if err := source.validate(); err != nil {
+		return err
+	}
+	// keep rollbacks and served digests even if the sync fails later on
+	defer func() {
+		if saveErr := c.saveImageRecord(source); saveErr != nil && err == nil {
+			err = saveErr
+		}
+	}()
 
 	// if service status is nil, we force create every object to ensure they're created the first time
 	overwrite := source.Status.RegistryServiceStatus == nil
 
+	// restore the previously served digest if the pod serving a new one fails within the grace period
+	if rollback := c.rollbackNeeded(source, c.currentPods(source)); rollback != nil {
+		logrus.WithField("CatalogSource", source.GetName()).Warnf("registry pod serving %s failed, rolling back to %s", rollback.From, rollback.To)
+		source.setRollback(rollback)
+	}
+
 	//TODO: if any of these error out, we should write a status back (possibly set RegistryServiceStatus to nil so they get recreated)
 	sa, err := c.ensureSA(source)
 	// recreate the pod if no existing pod is serving the latest image or correct spec
@@ -202,7 +237,21 @@ func (c *GrpcRegistryReconciler) EnsureRegistryServer(catalogSource *v1alpha1.Ca
 	if err != nil && !k8serror.IsAlreadyExists(err) {
 		return errors.Wrapf(err, "error ensuring service account: %s", source.GetName())
 	}
-	if err := c.ensurePod(source, sa.GetName(), overwritePod); err != nil {
+	// roll the pod if the image tag resolves to a new digest
+	imageDigest, digestChanged, err := c.checkImageDigest(source, overwritePod)
+	if err != nil {
+		if !overwritePod {
+			return errors.Wrapf(err, "error checking catalog image digest: %s", source.GetName())
+		}
+		// don't hold up the creation of the catalog pod on the registry API, the digest is resolved on the next poll
+		logrus.WithField("CatalogSource", source.GetName()).WithError(err).Warn("error checking catalog image digest")
+	}
+	if served := source.servedDigest(); served != "" {
+		imageDigest = served
+	}
+	// the check may have ended a rollback, which changes the image to serve
+	overwritePod = overwritePod || digestChanged || len(c.currentPodsWithCorrectImageAndSpec(source, sa.GetName())) == 0
+	if err := c.ensurePod(source, sa.GetName(), imageDigest, overwritePod); err != nil {
 		return errors.Wrapf(err, "error ensuring pod: %s", source.Pod(sa.Name).GetName())
 	}
 	if err := c.ensureUpdatePod(source, sa.Name); err != nil {
@@ -225,10 +274,31 @@ func (c *GrpcRegistryReconciler) EnsureRegistryServer(catalogSource *v1alpha1.Ca
 			Port:             fmt.Sprintf("%d", source.Service().Spec.Ports[0].Port),
 		}
 	}
+
+	c.recordImageHistory(source)
 	return nil
 }
 
-func (c *GrpcRegistryReconciler) ensurePod(source grpcCatalogSourceDecorator, saName string, overwrite bool) error {
+// RecordImageHistory records the digests newly served by the registry pods of
+// the given CatalogSource in its image history.
+func (c *GrpcRegistryReconciler) RecordImageHistory(catalogSource *v1alpha1.CatalogSource) (bool, error) {
+	source, err := c.withImageRecord(catalogSource)
+	if err != nil {
+		return false, err
+	}
+	changed := c.recordImageHistory(source)
+	return changed, c.saveImageRecord(source)
+}
+
+func (c *GrpcRegistryReconciler) recordImageHistory(source grpcCatalogSourceDecorator) bool {
+	history, changed := c.nextImageHistory(source, c.currentPodsWithCorrectImageAndSpec(source, source.ServiceAccount().GetName()))
+	if changed {
+		source.setImageHistory(history)
+	}
+	return changed
+}
+
+func (c *GrpcRegistryReconciler) ensurePod(source grpcCatalogSourceDecorator, saName, imageDigest string, overwrite bool) error {
 	// currentLivePods refers to the currently live instances of the catalog source
 	currentLivePods := c.currentPods(source)
 	if len(currentLivePods) > 0 {
@@ -241,7 +311,24 @@ func (c *GrpcRegistryReconciler) ensurePod(source grpcCatalogSourceDecorator, sa
 			}
 		}
 	}
-	_, err := c.OpClient.KubernetesInterface().CoreV1().Pods(source.GetNamespace()).Create(context.TODO(), source.Pod(saName), metav1.CreateOptions{})
+	pod := source.Pod(saName)
+	if imageDigest != "" {
+		// run the resolved digest, so that a later push to the tag can't
+		// change what the pod serves when its image is pulled again
+		image, err := digestImage(source.Image(), imageDigest)
+		if err != nil {
+			return errors.Wrapf(err, "error pinning catalog image to digest %s", imageDigest)
+		}
+		pod.Spec.Containers[0].Image = image
+		pod.Spec.Containers[0].ImagePullPolicy = corev1.PullIfNotPresent
+		annotations := map[string]string{}
+		for k, v := range pod.GetAnnotations() {
+			annotations[k] = v
+		}
+		annotations[CatalogImageDigestAnnotationKey] = imageDigest
+		pod.SetAnnotations(annotations)
+	}
+	_, err := c.OpClient.KubernetesInterface().CoreV1().Pods(source.GetNamespace()).Create(context.TODO(), pod, metav1.CreateOptions{})
 	if err != nil {
 		return errors.Wrapf(err, "error creating new pod: %s", source.Pod(saName).GetGenerateName())
 	}
@@ -249,22 +336,105 @@ func (c *GrpcRegistryReconciler) ensurePod(source grpcCatalogSourceDecorator, sa
 	return nil
 }
 
+// digestImage returns the repository of the given image pinned to the given
+// digest.
+func digestImage(image, imageDigest string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", err
+	}
+	canonical, err := reference.WithDigest(reference.TrimNamed(named), digest.Digest(imageDigest))
+	if err != nil {
+		return "", err
+	}
+	return canonical.String(), nil
+}
+
+// servesImage returns true if the given registry pod runs the given image,
+// either as is or pinned to the digest in its annotation.
+func servesImage(pod *corev1.Pod, image string) bool {
+	podImage := pod.Spec.Containers[0].Image
+	if podImage == image {
+		return true
+	}
+	imageDigest := pod.GetAnnotations()[CatalogImageDigestAnnotationKey]
+	if imageDigest == "" {
+		return false
+	}
+	pinned, err := digestImage(image, imageDigest)
+	return err == nil && podImage == pinned
+}
+
+// checkImageDigest resolves the image tag of CatalogSources with the digest
+// update strategy when their poll is due, and returns the digest along with
+// whether it differs from the one the serving pod was created for. Serving pods
+// created before the CatalogSource switched to the strategy are rolled once.
+func (c *GrpcRegistryReconciler) checkImageDigest(source grpcCatalogSourceDecorator, overwritePod bool) (imageDigest string, changed bool, err error) {
+	if !source.DigestUpdates() || source.pinned() {
+		return "", false, nil
+	}
+
+	var current string
+	for _, p := range c.currentPods(source) {
+		current = p.GetAnnotations()[CatalogImageDigestAnnotationKey]
+	}
+	refresh := c.Refresher.Requested(source.GetNamespace(), source.GetName())
+	if current != "" && !overwritePod && !refresh && !source.Update() {
+		return current, false, nil
+	}
+	if c.DigestResolver == nil {
+		return "", false, fmt.Errorf("no image digest resolver configured")
+	}
+
+	pullSecrets, err := catalogPullSecrets(c.OpClient, source.CatalogSource)
+	if err != nil {
+		return "", false, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), defaultDigestResolveTimeout)
+	defer cancel()
+	imageDigest, err = c.DigestResolver.Resolve(ctx, source.Spec.Image, pullSecrets)
+	if err != nil {
+		return "", false, err
+	}
+	source.SetLastUpdateTime()
+	c.Refresher.Done(source.GetNamespace(), source.GetName())
+
+	if rollback := source.rollback(); rollback != nil {
+		if imageDigest == rollback.From {
+			logrus.WithField("CatalogSource", source.GetName()).Infof("catalog polling result: no update, image still at rolled back digest %s", rollback.From)
+			return rollback.To, false, nil
+		}
+		// the tag moved on from the rolled back digest
+		source.setRollback(nil)
+	}
+
+	if imageDigest == current {
+		logrus.WithField("CatalogSource", source.GetName()).Info("catalog polling result: no update")
+		return imageDigest, false, nil
+	}
+	logrus.WithField("CatalogSource", source.GetName()).Infof("catalog image digest changed from %q to %q", current, imageDigest)
+	return imageDigest, true, nil
+}
+
 // ensureUpdatePod checks that for the same catalog source version the same container imageID is running
 func (c *GrpcRegistryReconciler) ensureUpdatePod(source grpcCatalogSourceDecorator, saName string) error {
-	if !source.Poll() {
+	if !source.Poll() || source.DigestUpdates() || source.pinned() {
 		return nil
 	}
 
 	currentLivePods := c.currentPods(source)
 	currentUpdatePods := c.currentUpdatePods(source)
 
-	if source.Update() && len(currentUpdatePods) == 0 {
+	refresh := c.Refresher.Requested(source.GetNamespace(), source.GetName())
+	if (source.Update() || refresh) && len(currentUpdatePods) == 0 {
 		logrus.WithField("CatalogSource", source.GetName()).Infof("catalog update required at %s", time.Now().String())
 		pod, err := c.createUpdatePod(source, saName)
 		if err != nil {
 			return errors.Wrapf(err, "creating update catalog source pod")
 		}
 		source.SetLastUpdateTime()
+		c.Refresher.Done(source.GetNamespace(), source.GetName())
 		return UpdateNotReadyErr{catalogName: source.GetName(), podName: pod.GetName()}
 	}
 
@@ -284,6 +454,17 @@ func (c *GrpcRegistryReconciler) ensureUpdatePod(source grpcCatalogSourceDecorat
 	}
 
 	for _, updatePod := range currentUpdatePods {
+		if rollback := source.rollback(); rollback != nil {
+			if podDigest(updatePod) == rollback.From {
+				logrus.WithField("CatalogSource", source.GetName()).Infof("catalog polling result: no update, image still at rolled back digest %s", rollback.From)
+				if err := c.removePods([]*corev1.Pod{updatePod}, source.GetNamespace()); err != nil {
+					return errors.Wrapf(err, "error deleting duplicate catalog polling pod: %s", updatePod.GetName())
+				}
+				continue
+			}
+			// the tag moved on from the rolled back digest
+			source.setRollback(nil)
+		}
 		// if container imageID IDs are different, switch the serving pods
 		if imageChanged(updatePod, currentLivePods) {
 			err := c.promoteCatalog(updatePod, source.GetName())
@@ -370,7 +551,8 @@ func HashServiceSpec(spec corev1.ServiceSpec) string {
 // createUpdatePod is an internal method that creates a pod using the latest catalog source.
 func (c *GrpcRegistryReconciler) createUpdatePod(source grpcCatalogSourceDecorator, saName string) (*corev1.Pod, error) {
 	// remove label from pod to ensure service does not accidentally route traffic to the pod
-	p := source.Pod(saName)
+	// update pods always check the image of the CatalogSource, even while it is rolled back
+	p := source.podForImage(source.Spec.Image, saName)
 	p = swapLabels(p, "", source.Name)
 
 	pod, err := c.OpClient.KubernetesInterface().CoreV1().Pods(source.GetNamespace()).Create(context.TODO(), p, metav1.CreateOptions{})
@@ -422,15 +604,28 @@ func (c *GrpcRegistryReconciler) removePods(pods []*corev1.Pod, namespace string
 
 // CheckRegistryServer returns true if the given CatalogSource is considered healthy; false otherwise.
 func (c *GrpcRegistryReconciler) CheckRegistryServer(catalogSource *v1alpha1.CatalogSource) (healthy bool, err error) {
-	source := grpcCatalogSourceDecorator{catalogSource}
+	source, err := c.withImageRecord(catalogSource)
+	if err != nil {
+		return
+	}
+	if err = source.validate(); err != nil {
+		return
+	}
 	// Check on registry resources
 	// TODO: add gRPC health check
-	if len(c.currentPodsWithCorrectImageAndSpec(source, source.ServiceAccount().GetName())) < 1 ||
+	pods := c.currentPodsWithCorrectImageAndSpec(source, source.ServiceAccount().GetName())
+	if len(pods) < 1 ||
 		c.currentService(source) == nil {
 		healthy = false
 		return
 	}
 
+	// a failing new digest must be rolled back
+	if c.rollbackNeeded(source, pods) != nil {
+		healthy = false
+		return
+	}
+
 	healthy = true
 	return
 }
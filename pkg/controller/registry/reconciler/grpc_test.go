@@ -303,7 +303,7 @@ func TestGrpcRegistryReconciler(t *testing.T) {
 			}
 
 			// Check for resource existence
-			decorated := grpcCatalogSourceDecorator{tt.in.catsrc}
+			decorated := grpcCatalogSourceDecorator{CatalogSource: tt.in.catsrc}
 			pod := decorated.Pod(tt.in.catsrc.GetName())
 			service := decorated.Service()
 			sa := decorated.ServiceAccount()
@@ -394,7 +394,7 @@ func TestRegistryPodPriorityClass(t *testing.T) {
 			require.NoError(t, err)
 
 			// Check for resource existence
-			decorated := grpcCatalogSourceDecorator{tt.in.catsrc}
+			decorated := grpcCatalogSourceDecorator{CatalogSource: tt.in.catsrc}
 			pod := decorated.Pod(tt.in.catsrc.GetName())
 			listOptions := metav1.ListOptions{LabelSelector: labels.SelectorFromSet(labels.Set{CatalogSourceLabelKey: tt.in.catsrc.GetName()}).String()}
 			outPods, podErr := client.KubernetesInterface().CoreV1().Pods(pod.GetNamespace()).List(context.TODO(), listOptions)
@@ -614,3 +614,55 @@ func TestUpdatePodByDigest(t *testing.T) {
 		require.Equal(t, tt.result, imageChanged(tt.updatePod, tt.servingPods), table[i].description)
 	}
 }
+
+func TestGrpcRegistryReconcilerDigestUpdates(t *testing.T) {
+	stopc := make(chan struct{})
+	defer close(stopc)
+
+	catsrc := validGrpcCatalogSource("quay.io/org/catalog:latest", "")
+	catsrc.SetCreationTimestamp(metav1.Now())
+	catsrc.SetAnnotations(map[string]string{UpdateStrategyAnnotationKey: DigestUpdateStrategy})
+	catsrc.Spec.UpdateStrategy = &v1alpha1.UpdateStrategy{
+		RegistryPoll: &v1alpha1.RegistryPoll{Interval: &metav1.Duration{Duration: time.Hour}},
+	}
+
+	digest, resolved := digestA, 0
+	resolver := ImageDigestResolverFunc(func(ctx context.Context, image string, pullSecrets []*corev1.Secret) (string, error) {
+		require.Equal(t, catsrc.Spec.Image, image)
+		resolved++
+		return digest, nil
+	})
+	refresher := NewCatalogRefresher()
+
+	factory, _ := fakeReconcilerFactory(t, stopc, withDigestResolver(resolver), withRefresher(refresher), withK8sClientOptions(clientfake.WithNameGeneration(t)))
+	lister := factory.(*registryReconcilerFactory).Lister
+	rec := factory.ReconcilerForSource(catsrc)
+
+	// the first pod is created for the resolved digest
+	require.NoError(t, rec.EnsureRegistryServer(catsrc))
+	require.Equal(t, 1, resolved)
+	first := servingPod(t, lister, catsrc, "quay.io/org/catalog@"+digestA, digestA)
+	require.Equal(t, corev1.PullIfNotPresent, first.Spec.Containers[0].ImagePullPolicy)
+	require.NotNil(t, catsrc.Status.LatestImageRegistryPoll)
+
+	// the digest isn't resolved again before the poll is due
+	require.NoError(t, rec.EnsureRegistryServer(catsrc))
+	require.Equal(t, 1, resolved)
+	require.Equal(t, first.GetName(), servingPod(t, lister, catsrc, "quay.io/org/catalog@"+digestA, digestA).GetName())
+
+	// a refresh resolves the digest, and keeps the pod when it didn't change
+	refresher.Request(catsrc.GetNamespace(), catsrc.GetName())
+	require.NoError(t, rec.EnsureRegistryServer(catsrc))
+	require.Equal(t, 2, resolved)
+	require.False(t, refresher.Requested(catsrc.GetNamespace(), catsrc.GetName()))
+	require.Equal(t, first.GetName(), servingPod(t, lister, catsrc, "quay.io/org/catalog@"+digestA, digestA).GetName())
+
+	// a due poll rolls the pod when the digest changed
+	digest = digestB
+	past := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	catsrc.Status.LatestImageRegistryPoll = &past
+	require.NoError(t, rec.EnsureRegistryServer(catsrc))
+	require.Equal(t, 3, resolved)
+	require.NotEqual(t, first.GetName(), servingPod(t, lister, catsrc, "quay.io/org/catalog@"+digestB, digestB).GetName())
+	require.True(t, catsrc.Status.LatestImageRegistryPoll.After(past.Time))
+}
@@ -0,0 +1,410 @@
+package reconciler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/install"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/ownerutil"
+)
+
+const (
+	// RollbackGracePeriodAnnotationKey is the CatalogSource annotation that
+	// enables automatic rollbacks: if a registry pod serving a new digest fails
+	// within the given duration, the previously served digest is restored.
+	RollbackGracePeriodAnnotationKey = "operatorframework.io/rollback-grace-period"
+	// PinnedDigestAnnotationKey is the CatalogSource annotation that pins its
+	// registry pods to the given digest of its image, for example one from its
+	// image history.
+	PinnedDigestAnnotationKey = "operatorframework.io/pinned-digest"
+
+	// CatalogSourceRolledBackCondition is the CatalogSource status condition
+	// that is true while its image is rolled back.
+	CatalogSourceRolledBackCondition = "RolledBack"
+	// CatalogSourceRolledBackReason is the reason of the rolled back condition.
+	CatalogSourceRolledBackReason = "RegistryPodFailed"
+
+	// ImageHistoryConfigMapSuffix is appended to the name of a CatalogSource
+	// to name the ConfigMap that holds its image history and rollback.
+	ImageHistoryConfigMapSuffix = "-image-history"
+	// ImageHistoryConfigMapHistoryKey is the key of the image history ConfigMap
+	// that holds the image digests most recently served by the registry pods
+	// of the CatalogSource, newest first.
+	ImageHistoryConfigMapHistoryKey = "history"
+	// ImageHistoryConfigMapRollbackKey is the key of the image history
+	// ConfigMap that holds the automatic rollback of the CatalogSource, if any.
+	ImageHistoryConfigMapRollbackKey = "rollback"
+
+	// maxImageHistory bounds the number of digests kept in the image history.
+	maxImageHistory = 5
+	// podFailureThreshold is how long a registry pod may stay not ready before
+	// it is considered failed.
+	podFailureThreshold = 2 * time.Minute
+)
+
+// ImageHistoryEntry is an image digest served by the registry pods of a
+// CatalogSource.
+type ImageHistoryEntry struct {
+	// Digest is the digest of the served image.
+	Digest string `json:"digest"`
+	// Image is the image reference the registry pods ran.
+	Image string `json:"image"`
+	// ServedAt is the time the digest was first observed being served.
+	ServedAt metav1.Time `json:"servedAt"`
+}
+
+// CatalogRollback is an automatic rollback of the image of a CatalogSource.
+type CatalogRollback struct {
+	// Image is the image of the CatalogSource that was rolled back.
+	Image string `json:"image"`
+	// From is the digest that was rolled back because its registry pod failed.
+	From string `json:"from"`
+	// To is the previously served digest that was restored.
+	To string `json:"to"`
+	// At is the time of the rollback.
+	At metav1.Time `json:"at"`
+}
+
+// imageRecord is the image history and rollback of a CatalogSource. They are
+// kept in a ConfigMap owned by the CatalogSource rather than on the
+// CatalogSource itself, whose metadata and spec belong to its author.
+type imageRecord struct {
+	configMap *corev1.ConfigMap
+	history   []ImageHistoryEntry
+	rollback  *CatalogRollback
+	changed   bool
+}
+
+// imageHistoryConfigMapName returns the name of the ConfigMap holding the
+// image history of the given CatalogSource.
+func imageHistoryConfigMapName(catalogSource *v1alpha1.CatalogSource) string {
+	return catalogSource.GetName() + ImageHistoryConfigMapSuffix
+}
+
+// ImageHistory returns the image digests recorded in the given image history
+// ConfigMap, newest first.
+func ImageHistory(configMap *corev1.ConfigMap) ([]ImageHistoryEntry, error) {
+	value, ok := configMap.Data[ImageHistoryConfigMapHistoryKey]
+	if !ok {
+		return nil, nil
+	}
+	var history []ImageHistoryEntry
+	if err := json.Unmarshal([]byte(value), &history); err != nil {
+		return nil, fmt.Errorf("invalid %s of config map %s: %v", ImageHistoryConfigMapHistoryKey, configMap.GetName(), err)
+	}
+	return history, nil
+}
+
+// newImageRecord reads the image history and rollback of the given ConfigMap.
+func newImageRecord(configMap *corev1.ConfigMap) *imageRecord {
+	record := &imageRecord{configMap: configMap}
+	if configMap == nil {
+		return record
+	}
+	// start over rather than failing on a mangled record
+	record.history, _ = ImageHistory(configMap)
+	if value, ok := configMap.Data[ImageHistoryConfigMapRollbackKey]; ok {
+		var rollback CatalogRollback
+		if err := json.Unmarshal([]byte(value), &rollback); err == nil && digest.Digest(rollback.To).Validate() == nil {
+			record.rollback = &rollback
+		}
+	}
+	return record
+}
+
+// data returns the ConfigMap data of the record.
+func (r *imageRecord) data() (map[string]string, error) {
+	data := map[string]string{}
+	history, err := json.Marshal(r.history)
+	if err != nil {
+		return nil, err
+	}
+	data[ImageHistoryConfigMapHistoryKey] = string(history)
+	if r.rollback != nil {
+		rollback, err := json.Marshal(r.rollback)
+		if err != nil {
+			return nil, err
+		}
+		data[ImageHistoryConfigMapRollbackKey] = string(rollback)
+	}
+	return data, nil
+}
+
+func (s *grpcCatalogSourceDecorator) imageHistory() []ImageHistoryEntry {
+	if s.images == nil {
+		return nil
+	}
+	return s.images.history
+}
+
+func (s *grpcCatalogSourceDecorator) setImageHistory(history []ImageHistoryEntry) {
+	if s.images == nil {
+		s.images = &imageRecord{}
+	}
+	s.images.history = history
+	s.images.changed = true
+}
+
+// rollback returns the rollback of the CatalogSource, if any. A rollback only
+// applies to the image it was made for.
+func (s *grpcCatalogSourceDecorator) rollback() *CatalogRollback {
+	if s.images == nil || s.images.rollback == nil || s.images.rollback.Image != s.Spec.Image {
+		return nil
+	}
+	return s.images.rollback
+}
+
+func (s *grpcCatalogSourceDecorator) setRollback(rollback *CatalogRollback) {
+	if s.images == nil {
+		s.images = &imageRecord{}
+	}
+	if rollback == nil {
+		if s.images.rollback != nil {
+			s.images.rollback = nil
+			s.images.changed = true
+		}
+		meta.RemoveStatusCondition(&s.Status.Conditions, CatalogSourceRolledBackCondition)
+		return
+	}
+	s.images.rollback = rollback
+	s.images.changed = true
+	meta.SetStatusCondition(&s.Status.Conditions, metav1.Condition{
+		Type:    CatalogSourceRolledBackCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  CatalogSourceRolledBackReason,
+		Message: fmt.Sprintf("registry pod serving %s failed, rolled back to %s", rollback.From, rollback.To),
+	})
+}
+
+// pinnedDigest returns the digest the CatalogSource is pinned to by its
+// annotation, if any.
+func (s *grpcCatalogSourceDecorator) pinnedDigest() (string, error) {
+	value := s.GetAnnotations()[PinnedDigestAnnotationKey]
+	if value == "" {
+		return "", nil
+	}
+	if err := digest.Digest(value).Validate(); err != nil {
+		return "", fmt.Errorf("invalid %s annotation %q: %v", PinnedDigestAnnotationKey, value, err)
+	}
+	return value, nil
+}
+
+// pinned returns true if the CatalogSource is pinned to a digest, either by
+// its annotation or by a digest reference as its image. Pinned images are
+// neither polled nor rolled back.
+func (s *grpcCatalogSourceDecorator) pinned() bool {
+	if pin, err := s.pinnedDigest(); err == nil && pin != "" {
+		return true
+	}
+	named, err := reference.ParseNormalizedNamed(s.Spec.Image)
+	if err != nil {
+		return false
+	}
+	_, ok := named.(reference.Canonical)
+	return ok
+}
+
+// servedDigest returns the digest the CatalogSource is pinned or rolled back
+// to, if any. A pin takes precedence over a rollback.
+func (s *grpcCatalogSourceDecorator) servedDigest() string {
+	if pin, err := s.pinnedDigest(); err == nil && pin != "" {
+		return pin
+	}
+	if rollback := s.rollback(); rollback != nil {
+		return rollback.To
+	}
+	return ""
+}
+
+// Image returns the image the registry pods of the CatalogSource run: its
+// image repository at the digest it is pinned or rolled back to, or its image.
+func (s *grpcCatalogSourceDecorator) Image() string {
+	served := s.servedDigest()
+	if served == "" {
+		return s.Spec.Image
+	}
+	image, err := digestImage(s.Spec.Image, served)
+	if err != nil {
+		return s.Spec.Image
+	}
+	return image
+}
+
+// rollbackGracePeriod returns the grace period of automatic rollbacks, zero if
+// they are disabled.
+func (s *grpcCatalogSourceDecorator) rollbackGracePeriod() (time.Duration, error) {
+	value, ok := s.GetAnnotations()[RollbackGracePeriodAnnotationKey]
+	if !ok || value == "" {
+		return 0, nil
+	}
+	grace, err := time.ParseDuration(value)
+	if err != nil || grace < 0 {
+		return 0, fmt.Errorf("invalid %s annotation %q: must be a non-negative duration", RollbackGracePeriodAnnotationKey, value)
+	}
+	return grace, nil
+}
+
+// validate returns an error if the rollback or pin annotation of the
+// CatalogSource is invalid.
+func (s *grpcCatalogSourceDecorator) validate() error {
+	if _, err := s.rollbackGracePeriod(); err != nil {
+		return err
+	}
+	_, err := s.pinnedDigest()
+	return err
+}
+
+// withImageRecord returns the given CatalogSource with the image history and
+// rollback of its image history ConfigMap. A rollback ends once the image of
+// the CatalogSource is changed or pinned.
+func (c *GrpcRegistryReconciler) withImageRecord(catalogSource *v1alpha1.CatalogSource) (grpcCatalogSourceDecorator, error) {
+	source := grpcCatalogSourceDecorator{CatalogSource: catalogSource}
+	cm, err := c.OpClient.KubernetesInterface().CoreV1().ConfigMaps(source.GetNamespace()).Get(context.TODO(), imageHistoryConfigMapName(catalogSource), metav1.GetOptions{})
+	if err != nil && !k8serror.IsNotFound(err) {
+		return source, errors.Wrapf(err, "error getting image history config map: %s", source.GetName())
+	}
+	if err != nil {
+		cm = nil
+	}
+	source.images = newImageRecord(cm)
+	if source.images.rollback != nil && (source.rollback() == nil || source.pinned()) {
+		source.setRollback(nil)
+	}
+	return source, nil
+}
+
+// saveImageRecord writes the image history and rollback of the CatalogSource
+// to its image history ConfigMap if they changed.
+func (c *GrpcRegistryReconciler) saveImageRecord(source grpcCatalogSourceDecorator) error {
+	record := source.images
+	if record == nil || !record.changed {
+		return nil
+	}
+	data, err := record.data()
+	if err != nil {
+		return err
+	}
+	client := c.OpClient.KubernetesInterface().CoreV1().ConfigMaps(source.GetNamespace())
+	var saved *corev1.ConfigMap
+	if record.configMap == nil {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      imageHistoryConfigMapName(source.CatalogSource),
+				Namespace: source.GetNamespace(),
+				Labels:    map[string]string{install.OLMManagedLabelKey: install.OLMManagedLabelValue},
+			},
+			Data: data,
+		}
+		ownerutil.AddOwner(cm, source.CatalogSource, false, false)
+		saved, err = client.Create(context.TODO(), cm, metav1.CreateOptions{})
+	} else {
+		cm := record.configMap.DeepCopy()
+		cm.Data = data
+		saved, err = client.Update(context.TODO(), cm, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return errors.Wrapf(err, "error saving image history config map: %s", source.GetName())
+	}
+	record.configMap = saved
+	record.changed = false
+	return nil
+}
+
+// podDigest returns the digest of the image the given registry pod runs, or
+// an empty string if it isn't known yet.
+func podDigest(pod *corev1.Pod) string {
+	if d := pod.GetAnnotations()[CatalogImageDigestAnnotationKey]; d != "" {
+		return d
+	}
+	// image IDs look like docker-pullable://quay.io/org/catalog@sha256:...
+	id := imageID(pod)
+	if i := strings.LastIndex(id, "@"); i >= 0 {
+		if d := digest.Digest(id[i+1:]); d.Validate() == nil {
+			return d.String()
+		}
+	}
+	return ""
+}
+
+// podFailing returns true if the given registry pod failed, its container is
+// crash looping, or it has not been ready for longer than podFailureThreshold.
+// A single restart is not a failure.
+func podFailing(pod *corev1.Pod, now time.Time) bool {
+	if pod.Status.Phase == corev1.PodFailed || pod.Status.Phase == corev1.PodUnknown {
+		return true
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Waiting != nil && status.State.Waiting.Reason == "CrashLoopBackOff" {
+			return true
+		}
+	}
+	// not ready since the pod started, or since it last became unready
+	var notReadySince *metav1.Time
+	for i := range pod.Status.Conditions {
+		if cond := pod.Status.Conditions[i]; cond.Type == corev1.PodReady {
+			if cond.Status == corev1.ConditionTrue {
+				return false
+			}
+			notReadySince = &pod.Status.Conditions[i].LastTransitionTime
+		}
+	}
+	if notReadySince == nil || notReadySince.IsZero() {
+		notReadySince = pod.Status.StartTime
+	}
+	return notReadySince != nil && now.Sub(notReadySince.Time) > podFailureThreshold
+}
+
+// nextImageHistory returns the image history of the CatalogSource with the
+// digests served by the given pods recorded, and whether it changed.
+func (c *GrpcRegistryReconciler) nextImageHistory(source grpcCatalogSourceDecorator, pods []*corev1.Pod) ([]ImageHistoryEntry, bool) {
+	history := source.imageHistory()
+	for _, pod := range pods {
+		d := podDigest(pod)
+		if d == "" || (len(history) > 0 && history[0].Digest == d) {
+			continue
+		}
+		next := []ImageHistoryEntry{{Digest: d, Image: pod.Spec.Containers[0].Image, ServedAt: c.now()}}
+		for _, entry := range history {
+			if entry.Digest != d && len(next) < maxImageHistory {
+				next = append(next, entry)
+			}
+		}
+		return next, true
+	}
+	return history, false
+}
+
+// rollbackNeeded returns the rollback to perform if a registry pod serving the
+// newest digest in the image history failed within the rollback grace period.
+func (c *GrpcRegistryReconciler) rollbackNeeded(source grpcCatalogSourceDecorator, pods []*corev1.Pod) *CatalogRollback {
+	grace, err := source.rollbackGracePeriod()
+	if err != nil || grace == 0 || source.pinned() || source.rollback() != nil {
+		return nil
+	}
+	history := source.imageHistory()
+	if len(history) < 2 {
+		return nil
+	}
+	now := c.now()
+	if now.Sub(history[0].ServedAt.Time) > grace {
+		return nil
+	}
+	for _, pod := range pods {
+		if podDigest(pod) == history[0].Digest && podFailing(pod, now.Time) {
+			return &CatalogRollback{Image: source.Spec.Image, From: history[0].Digest, To: history[1].Digest, At: now}
+		}
+	}
+	return nil
+}
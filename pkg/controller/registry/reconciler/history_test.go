@@ -0,0 +1,420 @@
+package reconciler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/clientfake"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/operatorlister"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/ownerutil"
+)
+
+const (
+	digestA = "sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	digestB = "sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	digestC = "sha256:cccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccc"
+)
+
+func TestCatalogSourceImage(t *testing.T) {
+	tests := []struct {
+		testName    string
+		image       string
+		annotations map[string]string
+		rollback    *CatalogRollback
+		served      string
+		pinned      bool
+		wantErr     bool
+	}{
+		{
+			testName: "Tag",
+			image:    "quay.io/org/catalog:latest",
+			served:   "quay.io/org/catalog:latest",
+		},
+		{
+			testName: "Pinned",
+			image:    "quay.io/org/catalog@" + digestA,
+			served:   "quay.io/org/catalog@" + digestA,
+			pinned:   true,
+		},
+		{
+			testName: "RolledBack",
+			image:    "quay.io/org/catalog:latest",
+			rollback: &CatalogRollback{Image: "quay.io/org/catalog:latest", From: digestB, To: digestA},
+			served:   "quay.io/org/catalog@" + digestA,
+		},
+		{
+			testName: "RolledBackFromOtherImage",
+			image:    "quay.io/org/catalog:stable",
+			rollback: &CatalogRollback{Image: "quay.io/org/catalog:latest", From: digestB, To: digestA},
+			served:   "quay.io/org/catalog:stable",
+		},
+		{
+			testName:    "PinnedByAnnotation",
+			image:       "quay.io/org/catalog:latest",
+			annotations: map[string]string{PinnedDigestAnnotationKey: digestA},
+			served:      "quay.io/org/catalog@" + digestA,
+			pinned:      true,
+		},
+		{
+			testName:    "PinnedWhileRolledBack",
+			image:       "quay.io/org/catalog:latest",
+			annotations: map[string]string{PinnedDigestAnnotationKey: digestC},
+			rollback:    &CatalogRollback{Image: "quay.io/org/catalog:latest", From: digestB, To: digestA},
+			served:      "quay.io/org/catalog@" + digestC,
+			pinned:      true,
+		},
+		{
+			testName:    "InvalidPin",
+			image:       "quay.io/org/catalog:latest",
+			annotations: map[string]string{PinnedDigestAnnotationKey: "latest"},
+			served:      "quay.io/org/catalog:latest",
+			wantErr:     true,
+		},
+		{
+			testName:    "InvalidGracePeriod",
+			image:       "quay.io/org/catalog:latest",
+			annotations: map[string]string{RollbackGracePeriodAnnotationKey: "soon"},
+			served:      "quay.io/org/catalog:latest",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			catsrc := validGrpcCatalogSource(tt.image, "")
+			catsrc.SetAnnotations(tt.annotations)
+			source := grpcCatalogSourceDecorator{CatalogSource: catsrc, images: &imageRecord{rollback: tt.rollback}}
+			require.Equal(t, tt.served, source.Image())
+			require.Equal(t, tt.served, source.Pod("sa").Spec.Containers[0].Image)
+			require.Equal(t, tt.pinned, source.pinned())
+			if tt.wantErr {
+				require.Error(t, source.validate())
+			} else {
+				require.NoError(t, source.validate())
+			}
+		})
+	}
+}
+
+func TestImageRecord(t *testing.T) {
+	// decoded times are local
+	now := metav1.Date(2021, time.March, 1, 12, 0, 0, 0, time.Local)
+	record := &imageRecord{
+		history:  []ImageHistoryEntry{{Digest: digestA, Image: "quay.io/org/catalog:latest", ServedAt: now}},
+		rollback: &CatalogRollback{Image: "quay.io/org/catalog:latest", From: digestB, To: digestA, At: now},
+	}
+	data, err := record.data()
+	require.NoError(t, err)
+
+	read := newImageRecord(&corev1.ConfigMap{Data: data})
+	require.Equal(t, record.history, read.history)
+	require.Equal(t, record.rollback, read.rollback)
+
+	// mangled records are started over
+	read = newImageRecord(&corev1.ConfigMap{Data: map[string]string{
+		ImageHistoryConfigMapHistoryKey:  "[",
+		ImageHistoryConfigMapRollbackKey: `{"to":"latest"}`,
+	}})
+	require.Empty(t, read.history)
+	require.Nil(t, read.rollback)
+}
+
+func TestPodFailing(t *testing.T) {
+	now := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+	recently := metav1.NewTime(now.Add(-time.Minute))
+	long := metav1.NewTime(now.Add(-10 * time.Minute))
+	ready := func(status corev1.ConditionStatus, since metav1.Time) []corev1.PodCondition {
+		return []corev1.PodCondition{{Type: corev1.PodReady, Status: status, LastTransitionTime: since}}
+	}
+
+	tests := []struct {
+		testName string
+		status   corev1.PodStatus
+		failing  bool
+	}{
+		{
+			testName: "Ready",
+			status:   corev1.PodStatus{Phase: corev1.PodRunning, StartTime: &long, Conditions: ready(corev1.ConditionTrue, long)},
+		},
+		{
+			testName: "ReadyAfterRestart",
+			status: corev1.PodStatus{
+				Phase:             corev1.PodRunning,
+				StartTime:         &long,
+				Conditions:        ready(corev1.ConditionTrue, recently),
+				ContainerStatuses: []corev1.ContainerStatus{{RestartCount: 1}},
+			},
+		},
+		{
+			testName: "Starting",
+			status:   corev1.PodStatus{Phase: corev1.PodRunning, StartTime: &recently, Conditions: ready(corev1.ConditionFalse, recently)},
+		},
+		{
+			testName: "NotReadyPastThreshold",
+			status:   corev1.PodStatus{Phase: corev1.PodRunning, StartTime: &long, Conditions: ready(corev1.ConditionFalse, long)},
+			failing:  true,
+		},
+		{
+			testName: "NoReadyConditionPastThreshold",
+			status:   corev1.PodStatus{Phase: corev1.PodPending, StartTime: &long},
+			failing:  true,
+		},
+		{
+			testName: "CrashLoopBackOff",
+			status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				StartTime:  &recently,
+				Conditions: ready(corev1.ConditionFalse, recently),
+				ContainerStatuses: []corev1.ContainerStatus{{
+					RestartCount: 3,
+					State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+				}},
+			},
+			failing: true,
+		},
+		{
+			testName: "Failed",
+			status:   corev1.PodStatus{Phase: corev1.PodFailed},
+			failing:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			require.Equal(t, tt.failing, podFailing(&corev1.Pod{Status: tt.status}, now))
+		})
+	}
+}
+
+func TestNextImageHistory(t *testing.T) {
+	now := metav1.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+	earlier := metav1.NewTime(now.Add(-time.Hour))
+	rec := &GrpcRegistryReconciler{now: func() metav1.Time { return now }}
+
+	pod := func(digest string) *corev1.Pod {
+		return &corev1.Pod{
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{Image: "quay.io/org/catalog:latest"}}},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+				ImageID: "docker-pullable://quay.io/org/catalog@" + digest,
+			}}},
+		}
+	}
+	source := func(history ...ImageHistoryEntry) grpcCatalogSourceDecorator {
+		s := grpcCatalogSourceDecorator{CatalogSource: validGrpcCatalogSource("quay.io/org/catalog:latest", "")}
+		if history != nil {
+			s.setImageHistory(history)
+		}
+		return s
+	}
+
+	// nothing is recorded until the digest is known
+	history, changed := rec.nextImageHistory(source(), []*corev1.Pod{{Spec: pod(digestA).Spec}})
+	require.False(t, changed)
+	require.Empty(t, history)
+
+	history, changed = rec.nextImageHistory(source(), []*corev1.Pod{pod(digestA)})
+	require.True(t, changed)
+	require.Equal(t, []ImageHistoryEntry{{Digest: digestA, Image: "quay.io/org/catalog:latest", ServedAt: now}}, history)
+
+	// the newest digest is recorded once
+	_, changed = rec.nextImageHistory(source(history...), []*corev1.Pod{pod(digestA)})
+	require.False(t, changed)
+
+	// digests served again move to the front
+	history, changed = rec.nextImageHistory(source(
+		ImageHistoryEntry{Digest: digestB, ServedAt: earlier},
+		ImageHistoryEntry{Digest: digestA, ServedAt: earlier},
+	), []*corev1.Pod{pod(digestA)})
+	require.True(t, changed)
+	require.Len(t, history, 2)
+	require.Equal(t, digestA, history[0].Digest)
+	require.Equal(t, digestB, history[1].Digest)
+
+	// the history is bounded
+	var long []ImageHistoryEntry
+	for i := 0; i < maxImageHistory; i++ {
+		long = append(long, ImageHistoryEntry{Digest: digestB, ServedAt: earlier})
+	}
+	history, changed = rec.nextImageHistory(source(long...), []*corev1.Pod{pod(digestC)})
+	require.True(t, changed)
+	require.Len(t, history, maxImageHistory)
+	require.Equal(t, digestC, history[0].Digest)
+}
+
+func TestPodDigest(t *testing.T) {
+	annotated := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{CatalogImageDigestAnnotationKey: digestA}}}
+	require.Equal(t, digestA, podDigest(annotated))
+
+	running := &corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{ImageID: "quay.io/org/catalog@" + digestB}}}}
+	require.Equal(t, digestB, podDigest(running))
+
+	byID := &corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{ImageID: "sha256:0123"}}}}
+	require.Equal(t, "", podDigest(byID))
+	require.Equal(t, "", podDigest(&corev1.Pod{}))
+}
+
+func TestGrpcRegistryReconcilerRollback(t *testing.T) {
+	stopc := make(chan struct{})
+	defer close(stopc)
+
+	catsrc := validGrpcCatalogSource("quay.io/org/catalog:latest", "")
+	catsrc.SetCreationTimestamp(metav1.Now())
+	catsrc.SetAnnotations(map[string]string{
+		UpdateStrategyAnnotationKey:      DigestUpdateStrategy,
+		RollbackGracePeriodAnnotationKey: "10m",
+	})
+	catsrc.Spec.UpdateStrategy = &v1alpha1.UpdateStrategy{
+		RegistryPoll: &v1alpha1.RegistryPoll{Interval: &metav1.Duration{Duration: time.Hour}},
+	}
+
+	digest := digestA
+	resolver := ImageDigestResolverFunc(func(ctx context.Context, image string, pullSecrets []*corev1.Secret) (string, error) {
+		return digest, nil
+	})
+	refresher := NewCatalogRefresher()
+
+	factory, client := fakeReconcilerFactory(t, stopc, withDigestResolver(resolver), withRefresher(refresher), withK8sClientOptions(clientfake.WithNameGeneration(t)))
+	lister := factory.(*registryReconcilerFactory).Lister
+	rec := factory.ReconcilerForSource(catsrc)
+
+	ensure := func() {
+		require.NoError(t, rec.EnsureRegistryServer(catsrc))
+	}
+	refresh := func(d string) {
+		digest = d
+		refresher.Request(catsrc.GetNamespace(), catsrc.GetName())
+		ensure()
+	}
+	imageHistory := func() *corev1.ConfigMap {
+		cm, err := client.KubernetesInterface().CoreV1().ConfigMaps(catsrc.GetNamespace()).Get(context.TODO(), catsrc.GetName()+ImageHistoryConfigMapSuffix, metav1.GetOptions{})
+		require.NoError(t, err)
+		return cm
+	}
+	historyDigests := func() []string {
+		history, err := ImageHistory(imageHistory())
+		require.NoError(t, err)
+		var digests []string
+		for _, entry := range history {
+			digests = append(digests, entry.Digest)
+		}
+		return digests
+	}
+
+	// serve A and record it
+	ensure()
+	servingPod(t, lister, catsrc, "quay.io/org/catalog@"+digestA, digestA)
+	recorded, err := rec.(ImageHistoryRecorder).RecordImageHistory(catsrc)
+	require.NoError(t, err)
+	require.True(t, recorded)
+	require.Equal(t, []string{digestA}, historyDigests())
+	recorded, err = rec.(ImageHistoryRecorder).RecordImageHistory(catsrc)
+	require.NoError(t, err)
+	require.False(t, recorded)
+	require.True(t, ownerutil.IsOwnedBy(imageHistory(), catsrc))
+	healthy, err := rec.CheckRegistryServer(catsrc)
+	require.NoError(t, err)
+	require.True(t, healthy)
+	annotations := catsrc.GetAnnotations()
+
+	// roll to B
+	refresh(digestB)
+	podB := servingPod(t, lister, catsrc, "quay.io/org/catalog@"+digestB, digestB)
+	ensure()
+	require.Equal(t, []string{digestB, digestA}, historyDigests())
+
+	// a single restart is no failure
+	podB.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "registry-server", RestartCount: 1}}
+	podB.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+	podB, err = client.KubernetesInterface().CoreV1().Pods(podB.GetNamespace()).UpdateStatus(context.TODO(), podB, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		pods, err := lister.CoreV1().PodLister().Pods(podB.GetNamespace()).List(labels.Everything())
+		return err == nil && len(pods) == 1 && len(pods[0].Status.ContainerStatuses) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	healthy, err = rec.CheckRegistryServer(catsrc)
+	require.NoError(t, err)
+	require.True(t, healthy)
+
+	// B crash loops within the grace period: roll back to A
+	podB.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:         "registry-server",
+		RestartCount: 3,
+		State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+	}}
+	podB.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionFalse}}
+	_, err = client.KubernetesInterface().CoreV1().Pods(podB.GetNamespace()).UpdateStatus(context.TODO(), podB, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		healthy, err := rec.CheckRegistryServer(catsrc)
+		return err == nil && !healthy
+	}, 5*time.Second, 10*time.Millisecond)
+	ensure()
+	require.NotEmpty(t, imageHistory().Data[ImageHistoryConfigMapRollbackKey])
+	require.True(t, meta.IsStatusConditionTrue(catsrc.Status.Conditions, CatalogSourceRolledBackCondition))
+	servingPod(t, lister, catsrc, "quay.io/org/catalog@"+digestA, digestA)
+	ensure()
+	require.Equal(t, []string{digestA, digestB}, historyDigests())
+	healthy, err = rec.CheckRegistryServer(catsrc)
+	require.NoError(t, err)
+	require.True(t, healthy)
+
+	// the tag still points to B: stay on A
+	refresh(digestB)
+	servingPod(t, lister, catsrc, "quay.io/org/catalog@"+digestA, digestA)
+	require.NotEmpty(t, imageHistory().Data[ImageHistoryConfigMapRollbackKey])
+
+	// the tag moved on to C: end the rollback and serve the tag again
+	refresh(digestC)
+	servingPod(t, lister, catsrc, "quay.io/org/catalog@"+digestC, digestC)
+	require.Empty(t, imageHistory().Data[ImageHistoryConfigMapRollbackKey])
+	require.Nil(t, meta.FindStatusCondition(catsrc.Status.Conditions, CatalogSourceRolledBackCondition))
+	ensure()
+	require.Equal(t, []string{digestC, digestA, digestB}, historyDigests())
+
+	// the CatalogSource itself is never rewritten
+	require.Equal(t, annotations, catsrc.GetAnnotations())
+
+	// pin A from the history: serve it without polling
+	pinned := map[string]string{PinnedDigestAnnotationKey: digestA}
+	for k, v := range annotations {
+		pinned[k] = v
+	}
+	catsrc.SetAnnotations(pinned)
+	refresh(digestC)
+	servingPod(t, lister, catsrc, "quay.io/org/catalog@"+digestA, digestA)
+	require.True(t, refresher.Requested(catsrc.GetNamespace(), catsrc.GetName()), "pinned catalogs are not polled")
+	ensure()
+	require.Equal(t, []string{digestA, digestC, digestB}, historyDigests())
+
+	// unpin: serve the tag again
+	catsrc.SetAnnotations(annotations)
+	refresh(digestC)
+	servingPod(t, lister, catsrc, "quay.io/org/catalog@"+digestC, digestC)
+
+	// pin B by its image: serve it without polling
+	catsrc.Spec.Image = "quay.io/org/catalog@" + digestB
+	refresh(digestC)
+	servingPod(t, lister, catsrc, "quay.io/org/catalog@"+digestB, "")
+	require.True(t, refresher.Requested(catsrc.GetNamespace(), catsrc.GetName()), "pinned catalogs are not polled")
+}
+
+// servingPod waits for the informer to observe a single serving pod of the
+// CatalogSource with the given image and digest.
+func servingPod(t *testing.T, lister operatorlister.OperatorLister, catsrc *v1alpha1.CatalogSource, image, digest string) *corev1.Pod {
+	var pod *corev1.Pod
+	require.Eventually(t, func() bool {
+		pods, err := lister.CoreV1().PodLister().Pods(catsrc.GetNamespace()).List(labels.SelectorFromSet(labels.Set{CatalogSourceLabelKey: catsrc.GetName()}))
+		if err != nil || len(pods) != 1 || pods[0].Spec.Containers[0].Image != image || pods[0].GetAnnotations()[CatalogImageDigestAnnotationKey] != digest {
+			return false
+		}
+		pod = pods[0].DeepCopy()
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+	return pod
+}
@@ -12,6 +12,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/rand"
 
 	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver"
 	controllerclient "github.com/operator-framework/operator-lifecycle-manager/pkg/lib/controller-runtime/client"
 	hashutil "github.com/operator-framework/operator-lifecycle-manager/pkg/lib/kubernetes/pkg/util/hash"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/operatorclient"
@@ -47,6 +48,14 @@ type RegistryReconciler interface {
 	RegistryEnsurer
 }
 
+// ImageHistoryRecorder is implemented by RegistryReconcilers that keep a
+// history of the image digests served for a CatalogSource.
+type ImageHistoryRecorder interface {
+	// RecordImageHistory records newly served digests in the image history of
+	// the given CatalogSource, and returns true if it changed.
+	RecordImageHistory(catalogSource *v1alpha1.CatalogSource) (bool, error)
+}
+
 // RegistryReconcilerFactory describes factory methods for RegistryReconcilers.
 type RegistryReconcilerFactory interface {
 	ReconcilerForSource(source *v1alpha1.CatalogSource) RegistryReconciler
@@ -59,6 +68,10 @@ type registryReconcilerFactory struct {
 	OpClient             operatorclient.ClientInterface
 	ConfigMapServerImage string
 	SSAClient            *controllerclient.ServerSideApplier
+	DigestResolver       ImageDigestResolver
+	Refresher            *CatalogRefresher
+	CatalogFetcher       FileBasedCatalogFetcher
+	FileBasedSources     *resolver.FileBasedSourceStore
 }
 
 // ReconcilerForSource returns a RegistryReconciler based on the configuration of the given CatalogSource.
@@ -75,28 +88,47 @@ func (r *registryReconcilerFactory) ReconcilerForSource(source *v1alpha1.Catalog
 	case v1alpha1.SourceTypeGrpc:
 		if source.Spec.Image != "" {
 			return &GrpcRegistryReconciler{
-				now:       r.now,
-				Lister:    r.Lister,
-				OpClient:  r.OpClient,
-				SSAClient: r.SSAClient,
+				now:            r.now,
+				Lister:         r.Lister,
+				OpClient:       r.OpClient,
+				SSAClient:      r.SSAClient,
+				DigestResolver: r.DigestResolver,
+				Refresher:      r.Refresher,
 			}
 		} else if source.Spec.Address != "" {
 			return &GrpcAddressRegistryReconciler{
 				now: r.now,
 			}
 		}
+	case SourceTypeFileBased:
+		return &FileBasedRegistryReconciler{
+			now:            r.now,
+			Lister:         r.Lister,
+			OpClient:       r.OpClient,
+			DigestResolver: r.DigestResolver,
+			Fetcher:        r.CatalogFetcher,
+			Refresher:      r.Refresher,
+			Store:          r.FileBasedSources,
+		}
 	}
 	return nil
 }
 
 // NewRegistryReconcilerFactory returns an initialized RegistryReconcilerFactory.
-func NewRegistryReconcilerFactory(lister operatorlister.OperatorLister, opClient operatorclient.ClientInterface, configMapServerImage string, now nowFunc, ssaClient *controllerclient.ServerSideApplier) RegistryReconcilerFactory {
+// The digest resolver and refresher serve CatalogSources that poll for updates.
+// File-based catalogs are fetched with the catalog fetcher and served from the
+// given store.
+func NewRegistryReconcilerFactory(lister operatorlister.OperatorLister, opClient operatorclient.ClientInterface, configMapServerImage string, now nowFunc, ssaClient *controllerclient.ServerSideApplier, digestResolver ImageDigestResolver, refresher *CatalogRefresher, catalogFetcher FileBasedCatalogFetcher, fileBasedSources *resolver.FileBasedSourceStore) RegistryReconcilerFactory {
 	return &registryReconcilerFactory{
 		now:                  now,
 		Lister:               lister,
 		OpClient:             opClient,
 		ConfigMapServerImage: configMapServerImage,
 		SSAClient:            ssaClient,
+		DigestResolver:       digestResolver,
+		Refresher:            refresher,
+		CatalogFetcher:       catalogFetcher,
+		FileBasedSources:     fileBasedSources,
 	}
 }
 
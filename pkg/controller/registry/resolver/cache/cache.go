@@ -70,6 +70,20 @@ func (p StaticSourceProvider) Sources(namespaces ...string) map[SourceKey]Source
 	return result
 }
 
+// SourceProviders is a SourceProvider of the sources of all of its
+// providers. Sources of later providers take precedence.
+type SourceProviders []SourceProvider
+
+func (p SourceProviders) Sources(namespaces ...string) map[SourceKey]Source {
+	result := make(map[SourceKey]Source)
+	for _, provider := range p {
+		for key, source := range provider.Sources(namespaces...) {
+			result[key] = source
+		}
+	}
+	return result
+}
+
 type OperatorCacheProvider interface {
 	Namespaced(namespaces ...string) MultiCatalogOperatorFinder
 	Expire(catalog SourceKey)
@@ -83,6 +97,7 @@ type Cache struct {
 	ttl          time.Duration
 	sem          chan struct{}
 	m            sync.RWMutex
+	health       *healthTracker
 }
 
 type catalogSourcePriority int
@@ -119,6 +134,7 @@ func New(sp SourceProvider, options ...Option) *Cache {
 		snapshots:    make(map[SourceKey]*snapshotHeader),
 		ttl:          5 * time.Minute,
 		sem:          make(chan struct{}, MaxConcurrentSnapshotUpdates),
+		health:       newHealthTracker(),
 	}
 
 	for _, opt := range options {
@@ -146,6 +162,25 @@ func (c *NamespacedOperatorCache) Error() error {
 	return errors.NewAggregate(errs)
 }
 
+// ErrorFor returns an error if any of the given catalogs couldn't be
+// snapshotted, ignoring the errors of all other catalogs.
+func (c *NamespacedOperatorCache) ErrorFor(keys ...SourceKey) error {
+	var errs []error
+	for _, key := range keys {
+		snapshot, ok := c.snapshots[key]
+		if !ok {
+			continue
+		}
+		snapshot.m.RLock()
+		err := snapshot.err
+		snapshot.m.RUnlock()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error using catalog %s (in namespace %s): %w", key.Name, key.Namespace, err))
+		}
+	}
+	return errors.NewAggregate(errs)
+}
+
 func (c *Cache) Expire(catalog SourceKey) {
 	c.m.Lock()
 	defer c.m.Unlock()
@@ -163,6 +198,7 @@ func (c *Cache) Namespaced(namespaces ...string) MultiCatalogOperatorFinder {
 
 	now := time.Now()
 	sources := c.sp.Sources(namespaces...)
+	c.health.forget(sources, namespaces)
 
 	result := NamespacedOperatorCache{
 		snapshots: make(map[SourceKey]*snapshotHeader),
@@ -234,6 +270,10 @@ func (c *Cache) Namespaced(namespaces ...string) MultiCatalogOperatorFinder {
 		if catsrc, _ := c.catsrcLister.CatalogSources(miss.Namespace).Get(miss.Name); catsrc != nil {
 			hdr.priority = catsrc.Spec.Priority
 		}
+		// Flaky catalogs are ordered after healthy ones of the same priority.
+		if health, ok := c.health.get(miss); ok {
+			hdr.flaky = health.Flaky()
+		}
 
 		hdr.m.Lock()
 		c.snapshots[miss] = &hdr
@@ -243,7 +283,18 @@ func (c *Cache) Namespaced(namespaces ...string) MultiCatalogOperatorFinder {
 			defer hdr.m.Unlock()
 			c.sem <- struct{}{}
 			defer func() { <-c.sem }()
-			hdr.snapshot, hdr.err = source.Snapshot(ctx)
+			start := time.Now()
+			snapshot, err := source.Snapshot(ctx)
+			var stale bool
+			hdr.snapshot, stale, hdr.err = c.health.record(hdr.key, start, time.Now(), snapshot, err)
+			if stale {
+				// retry soon rather than serving the stale snapshot for a full ttl
+				retry := c.ttl
+				if retry > staleSnapshotRetry {
+					retry = staleSnapshotRetry
+				}
+				hdr.expiry = time.Now().Add(retry)
+			}
 		}(ctx, &hdr, sources[miss])
 	}
 
@@ -314,6 +365,7 @@ type snapshotHeader struct {
 	pop      context.CancelFunc
 	err      error
 	priority int
+	flaky    bool
 }
 
 func (hdr *snapshotHeader) Cancel() {
@@ -380,11 +432,15 @@ func (s sortableSnapshots) Less(i, j int) bool {
 		return false
 	}
 
-	// the rest are sorted first on priority, namespace and then by name
+	// the rest are sorted first on priority, health, namespace and then by name
 	if s.snapshots[i].priority != s.snapshots[j].priority {
 		return s.snapshots[i].priority > s.snapshots[j].priority
 	}
 
+	if s.snapshots[i].flaky != s.snapshots[j].flaky {
+		return !s.snapshots[i].flaky
+	}
+
 	if s.snapshots[i].key.Namespace != s.snapshots[j].key.Namespace {
 		if s.snapshots[i].key.Namespace == s.preferredNamespace {
 			return true
@@ -420,6 +476,7 @@ type MultiCatalogOperatorFinder interface {
 	FindPreferred(preferred *SourceKey, preferredNamespace string, predicates ...Predicate) []*Entry
 	WithExistingOperators(snapshot *Snapshot, namespace string) MultiCatalogOperatorFinder
 	Error() error
+	ErrorFor(keys ...SourceKey) error
 	OperatorFinder
 }
 
@@ -105,6 +105,23 @@ func TestOperatorCacheReuse(t *testing.T) {
 	require.Len(t, c.Namespaced("dummynamespace").Catalog(key).Find(CSVNamePredicate("v1")), 1)
 }
 
+func TestSourceProviders(t *testing.T) {
+	a := SourceKey{Namespace: "dummynamespace", Name: "a"}
+	b := SourceKey{Namespace: "dummynamespace", Name: "b"}
+	first := StaticSourceProvider{
+		a: &Snapshot{Entries: []*Entry{{Name: "a.v1"}}},
+		b: &Snapshot{Entries: []*Entry{{Name: "b.v1"}}},
+	}
+	second := StaticSourceProvider{
+		b: &Snapshot{Entries: []*Entry{{Name: "b.v2"}}},
+	}
+	c := New(SourceProviders{first, second})
+
+	require.Len(t, c.Namespaced("dummynamespace").Catalog(a).Find(CSVNamePredicate("a.v1")), 1)
+	require.Len(t, c.Namespaced("dummynamespace").Catalog(b).Find(CSVNamePredicate("b.v1")), 0)
+	require.Len(t, c.Namespaced("dummynamespace").Catalog(b).Find(CSVNamePredicate("b.v2")), 1)
+}
+
 func TestCatalogSnapshotValid(t *testing.T) {
 	type tc struct {
 		Name     string
@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+
+	opregistry "github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+const (
+	// DeprecationType is the type of the properties carrying a deprecation
+	// notice for the package, the channel or the bundle of an entry. Unlike
+	// olm.deprecated, deprecated entries remain installable.
+	DeprecationType = "olm.deprecation"
+
+	// DeprecationScopePackage, DeprecationScopeChannel and
+	// DeprecationScopeBundle are the scopes of deprecation notices, named
+	// after the file-based catalog schemas they refer to.
+	DeprecationScopePackage = "olm.package"
+	DeprecationScopeChannel = "olm.channel"
+	DeprecationScopeBundle  = "olm.bundle"
+)
+
+// DeprecationProperty is the value of an olm.deprecation property.
+type DeprecationProperty struct {
+	// Scope is what is deprecated: the package, a channel or the bundle.
+	Scope string `json:"scope"`
+	// Channel is the deprecated channel for the channel scope. The notice
+	// applies to the channel of the entry if empty.
+	Channel string `json:"channel,omitempty"`
+	// Message is the notice of the catalog author, e.g. what to use instead.
+	Message string `json:"message,omitempty"`
+}
+
+// Deprecations are the deprecation notices of an entry, by scope. An empty
+// notice means its scope isn't deprecated.
+type Deprecations struct {
+	Package string
+	Channel string
+	Bundle  string
+}
+
+// Deprecated returns true if any scope is deprecated.
+func (d Deprecations) Deprecated() bool {
+	return d.Package != "" || d.Channel != "" || d.Bundle != ""
+}
+
+// Deprecations returns the deprecation notices of the entry, read from its
+// olm.deprecation properties. An olm.deprecated property is reported as a
+// deprecation of the bundle.
+func (o *Entry) Deprecations() (Deprecations, error) {
+	var d Deprecations
+	for _, p := range o.Properties {
+		switch p.GetType() {
+		case opregistry.DeprecatedType:
+			if d.Bundle == "" {
+				d.Bundle = fmt.Sprintf("bundle %s is deprecated", o.Name)
+			}
+		case DeprecationType:
+			var prop DeprecationProperty
+			if err := json.Unmarshal([]byte(p.GetValue()), &prop); err != nil {
+				return Deprecations{}, fmt.Errorf("failed to parse %s property of %s: %w", DeprecationType, o.Name, err)
+			}
+			switch prop.Scope {
+			case DeprecationScopePackage:
+				d.Package = deprecationMessage(prop.Message, "package %s is deprecated", o.Package())
+			case DeprecationScopeChannel:
+				if prop.Channel != "" && prop.Channel != o.Channel() {
+					continue
+				}
+				d.Channel = deprecationMessage(prop.Message, "channel %s is deprecated", o.Channel())
+			case DeprecationScopeBundle:
+				d.Bundle = deprecationMessage(prop.Message, "bundle %s is deprecated", o.Name)
+			default:
+				return Deprecations{}, fmt.Errorf("invalid %s property of %s: unknown scope %q", DeprecationType, o.Name, prop.Scope)
+			}
+		}
+	}
+	return d, nil
+}
+
+func deprecationMessage(message, format, name string) string {
+	if message != "" {
+		return message
+	}
+	return fmt.Sprintf(format, name)
+}
+
+// DeprecatedPredicate matches entries with any deprecation notice.
+func DeprecatedPredicate() Predicate {
+	return deprecatedPredicate{}
+}
+
+type deprecatedPredicate struct{}
+
+func (deprecatedPredicate) Test(o *Entry) bool {
+	d, err := o.Deprecations()
+	return err == nil && d.Deprecated()
+}
+
+func (deprecatedPredicate) String() string {
+	return "deprecated"
+}
@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+	opregistry "github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+func TestEntryDeprecations(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		properties []*api.Property
+		expected   Deprecations
+		err        string
+	}{
+		{
+			name:     "None",
+			expected: Deprecations{},
+		},
+		{
+			name: "AllScopes",
+			properties: []*api.Property{
+				{Type: DeprecationType, Value: `{"scope":"olm.package","message":"use b"}`},
+				{Type: DeprecationType, Value: `{"scope":"olm.channel"}`},
+				{Type: DeprecationType, Value: `{"scope":"olm.bundle","message":"a.v1 is broken"}`},
+			},
+			expected: Deprecations{Package: "use b", Channel: "channel alpha is deprecated", Bundle: "a.v1 is broken"},
+		},
+		{
+			name: "OtherChannel",
+			properties: []*api.Property{
+				{Type: DeprecationType, Value: `{"scope":"olm.channel","channel":"beta"}`},
+			},
+			expected: Deprecations{},
+		},
+		{
+			name: "OLMDeprecated",
+			properties: []*api.Property{
+				{Type: opregistry.DeprecatedType, Value: `{}`},
+			},
+			expected: Deprecations{Bundle: "bundle a.v1 is deprecated"},
+		},
+		{
+			name: "UnknownScope",
+			properties: []*api.Property{
+				{Type: DeprecationType, Value: `{"scope":"olm.csv"}`},
+			},
+			err: `invalid olm.deprecation property of a.v1: unknown scope "olm.csv"`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Entry{
+				Name:       "a.v1",
+				SourceInfo: &OperatorSourceInfo{Package: "a", Channel: "alpha"},
+				Properties: tt.properties,
+			}
+			d, err := e.Deprecations()
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, d)
+			assert.Equal(t, tt.expected.Deprecated(), DeprecatedPredicate().Test(e))
+		})
+	}
+}
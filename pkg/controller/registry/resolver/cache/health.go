@@ -0,0 +1,171 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// healthWindow is the number of most recent snapshot attempts the health
+	// of a source is computed from.
+	healthWindow = 10
+	// minFlakySamples is the number of snapshot attempts needed before a
+	// source can be considered flaky.
+	minFlakySamples = 3
+	// flakyErrorRate is the error rate at and above which a source is
+	// considered flaky.
+	flakyErrorRate = 0.5
+	// staleSnapshotRetry bounds how long a stale snapshot is served before
+	// a fresh one is attempted again.
+	staleSnapshotRetry = 30 * time.Second
+)
+
+// SourceHealth is the health of a source, as observed by taking snapshots of
+// its content.
+type SourceHealth struct {
+	// Attempts is the number of recent snapshot attempts the health is computed from.
+	Attempts int
+	// ErrorRate is the share of recent snapshot attempts that failed.
+	ErrorRate float64
+	// LastSnapshotDuration is the time the last snapshot attempt took.
+	LastSnapshotDuration time.Duration
+	// LastGoodSnapshot is the time of the last successful snapshot, zero if
+	// there was none.
+	LastGoodSnapshot time.Time
+	// LastError is the error of the last snapshot attempt, nil if it succeeded.
+	LastError error
+	// Stale is true if the last snapshot attempt failed and the last good
+	// snapshot is served instead.
+	Stale bool
+}
+
+// Score returns the health of the source between 0, when all recent snapshot
+// attempts failed, and 1, when they all succeeded.
+func (h SourceHealth) Score() float64 {
+	return 1 - h.ErrorRate
+}
+
+// Flaky returns true if enough recent snapshot attempts failed for the source
+// to be ordered after healthy sources of the same priority.
+func (h SourceHealth) Flaky() bool {
+	return h.Attempts >= minFlakySamples && h.ErrorRate >= flakyErrorRate
+}
+
+// HealthObserver is called with the health of a source after every snapshot
+// attempt.
+type HealthObserver func(key SourceKey, health SourceHealth)
+
+// WithHealthObserver sets a function to call with the health of a source
+// after every snapshot attempt.
+func WithHealthObserver(observer HealthObserver) Option {
+	return func(c *Cache) {
+		c.health.observer = observer
+	}
+}
+
+// WithStaleSnapshotTolerance allows serving the last good snapshot of a source
+// taken within the given duration when a fresh snapshot fails, rather than
+// failing resolutions that use the source. Zero disables it.
+func WithStaleSnapshotTolerance(tolerance time.Duration) Option {
+	return func(c *Cache) {
+		c.health.tolerance = tolerance
+	}
+}
+
+type sourceHealthRecord struct {
+	results  []bool
+	health   SourceHealth
+	snapshot *Snapshot
+}
+
+// healthTracker records the outcomes of snapshot attempts, and the last good
+// snapshot of every source.
+type healthTracker struct {
+	m         sync.Mutex
+	records   map[SourceKey]*sourceHealthRecord
+	tolerance time.Duration
+	observer  HealthObserver
+}
+
+func newHealthTracker() *healthTracker {
+	return &healthTracker{records: map[SourceKey]*sourceHealthRecord{}}
+}
+
+// record records the outcome of a snapshot attempt of the given source, and
+// returns the snapshot to serve, whether it is a stale one, and the error to
+// report.
+func (t *healthTracker) record(key SourceKey, start, end time.Time, snapshot *Snapshot, err error) (*Snapshot, bool, error) {
+	t.m.Lock()
+	r, ok := t.records[key]
+	if !ok {
+		r = &sourceHealthRecord{}
+		t.records[key] = r
+	}
+
+	r.results = append(r.results, err == nil)
+	if len(r.results) > healthWindow {
+		r.results = r.results[len(r.results)-healthWindow:]
+	}
+	var failures int
+	for _, ok := range r.results {
+		if !ok {
+			failures++
+		}
+	}
+	r.health.Attempts = len(r.results)
+	r.health.ErrorRate = float64(failures) / float64(len(r.results))
+	r.health.LastSnapshotDuration = end.Sub(start)
+	r.health.LastError = err
+	r.health.Stale = false
+
+	stale := false
+	if err == nil {
+		r.health.LastGoodSnapshot = end
+		r.snapshot = snapshot
+	} else if t.tolerance > 0 && r.snapshot != nil && end.Sub(r.health.LastGoodSnapshot) <= t.tolerance {
+		r.health.Stale = true
+		snapshot, stale, err = r.snapshot, true, nil
+	}
+	health := r.health
+	t.m.Unlock()
+
+	if t.observer != nil {
+		t.observer(key, health)
+	}
+	return snapshot, stale, err
+}
+
+// get returns the health of the given source, and whether it was recorded.
+func (t *healthTracker) get(key SourceKey) (SourceHealth, bool) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	r, ok := t.records[key]
+	if !ok {
+		return SourceHealth{}, false
+	}
+	return r.health, true
+}
+
+// forget drops the records of sources in the given namespaces that aren't in
+// the given set, such as deleted catalogs.
+func (t *healthTracker) forget(keep map[SourceKey]Source, namespaces []string) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	for key := range t.records {
+		if _, ok := keep[key]; ok {
+			continue
+		}
+		for _, namespace := range namespaces {
+			if key.Namespace == namespace {
+				delete(t.records, key)
+				break
+			}
+		}
+	}
+}
+
+// Health returns the health of the given source, and whether any snapshot of
+// it was attempted.
+func (c *Cache) Health(key SourceKey) (SourceHealth, bool) {
+	return c.health.get(key)
+}
@@ -0,0 +1,159 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// toggleSource fails its snapshots while its error is set.
+type toggleSource struct {
+	m        sync.Mutex
+	err      error
+	snapshot *Snapshot
+}
+
+func (s *toggleSource) set(err error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.err = err
+}
+
+func (s *toggleSource) Snapshot(context.Context) (*Snapshot, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.snapshot, nil
+}
+
+func TestHealthTracker(t *testing.T) {
+	key := SourceKey{Namespace: "dummynamespace", Name: "dummyname"}
+	start := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+	good := &Snapshot{Entries: []*Entry{{Name: "v1"}}}
+	errBroken := errors.New("broken")
+
+	var observed []SourceHealth
+	tracker := newHealthTracker()
+	tracker.observer = func(k SourceKey, health SourceHealth) {
+		assert.Equal(t, key, k)
+		observed = append(observed, health)
+	}
+
+	snapshot, stale, err := tracker.record(key, start, start.Add(time.Second), good, nil)
+	require.NoError(t, err)
+	assert.False(t, stale)
+	assert.Equal(t, good, snapshot)
+
+	// without tolerance, failures are reported as is
+	_, stale, err = tracker.record(key, start, start.Add(2*time.Second), nil, errBroken)
+	assert.Equal(t, errBroken, err)
+	assert.False(t, stale)
+
+	health, ok := tracker.get(key)
+	require.True(t, ok)
+	assert.Equal(t, 2, health.Attempts)
+	assert.Equal(t, 0.5, health.ErrorRate)
+	assert.Equal(t, 0.5, health.Score())
+	assert.Equal(t, 2*time.Second, health.LastSnapshotDuration)
+	assert.Equal(t, start.Add(time.Second), health.LastGoodSnapshot)
+	assert.Equal(t, errBroken, health.LastError)
+	assert.False(t, health.Flaky(), "too few attempts to be flaky")
+	assert.Len(t, observed, 2)
+
+	// within the tolerance, the last good snapshot is served
+	tracker.tolerance = time.Minute
+	snapshot, stale, err = tracker.record(key, start, start.Add(time.Minute), nil, errBroken)
+	require.NoError(t, err)
+	assert.True(t, stale)
+	assert.Equal(t, good, snapshot)
+	health, _ = tracker.get(key)
+	assert.True(t, health.Stale)
+	assert.True(t, health.Flaky())
+
+	// past the tolerance, failures are reported again
+	_, stale, err = tracker.record(key, start, start.Add(2*time.Minute), nil, errBroken)
+	assert.Equal(t, errBroken, err)
+	assert.False(t, stale)
+
+	// the error rate is computed over a window of recent attempts
+	for i := 0; i < healthWindow; i++ {
+		tracker.record(key, start, start.Add(3*time.Minute), good, nil)
+	}
+	health, _ = tracker.get(key)
+	assert.Equal(t, healthWindow, health.Attempts)
+	assert.Equal(t, 0.0, health.ErrorRate)
+	assert.False(t, health.Flaky())
+
+	// records of catalogs that went away are dropped
+	tracker.forget(map[SourceKey]Source{}, []string{"othernamespace"})
+	_, ok = tracker.get(key)
+	assert.True(t, ok)
+	tracker.forget(map[SourceKey]Source{}, []string{key.Namespace})
+	_, ok = tracker.get(key)
+	assert.False(t, ok)
+}
+
+func TestOperatorCacheStaleSnapshot(t *testing.T) {
+	key := SourceKey{Namespace: "dummynamespace", Name: "dummyname"}
+	source := &toggleSource{snapshot: &Snapshot{Entries: []*Entry{{Name: "v1"}}}}
+	var observed []SourceHealth
+	var m sync.Mutex
+	c := New(StaticSourceProvider{key: source}, WithStaleSnapshotTolerance(time.Hour), WithHealthObserver(func(k SourceKey, health SourceHealth) {
+		m.Lock()
+		defer m.Unlock()
+		observed = append(observed, health)
+	}))
+
+	require.Len(t, c.Namespaced("dummynamespace").Catalog(key).Find(CSVNamePredicate("v1")), 1)
+
+	source.set(errors.New("unavailable"))
+	c.Expire(key)
+	namespaced := c.Namespaced("dummynamespace")
+	require.Len(t, namespaced.Catalog(key).Find(CSVNamePredicate("v1")), 1)
+	require.NoError(t, namespaced.Error())
+
+	health, ok := c.Health(key)
+	require.True(t, ok)
+	assert.True(t, health.Stale)
+	assert.EqualError(t, health.LastError, "unavailable")
+	m.Lock()
+	assert.Len(t, observed, 2)
+	m.Unlock()
+}
+
+func TestNamespaceOperatorCacheErrorFor(t *testing.T) {
+	good := SourceKey{Namespace: "dummynamespace", Name: "good"}
+	bad := SourceKey{Namespace: "dummynamespace", Name: "bad"}
+	c := New(StaticSourceProvider{
+		good: &Snapshot{},
+		bad:  ErrorSource{Error: errors.New("testing")},
+	})
+
+	namespaced := c.Namespaced("dummynamespace")
+	namespaced.Find()
+	require.NoError(t, namespaced.ErrorFor(good))
+	require.NoError(t, namespaced.ErrorFor(SourceKey{Namespace: "dummynamespace", Name: "missing"}))
+	require.EqualError(t, namespaced.ErrorFor(good, bad), "error using catalog bad (in namespace dummynamespace): testing")
+}
+
+func TestSortableSnapshotsFlaky(t *testing.T) {
+	flaky := &snapshotHeader{key: SourceKey{Namespace: "ns", Name: "a"}, flaky: true}
+	healthy := &snapshotHeader{key: SourceKey{Namespace: "ns", Name: "b"}}
+	important := &snapshotHeader{key: SourceKey{Namespace: "ns", Name: "c"}, flaky: true, priority: 10}
+
+	sorted := newSortableSnapshots(nil, nil, "ns", map[SourceKey]*snapshotHeader{
+		flaky.key:     flaky,
+		healthy.key:   healthy,
+		important.key: important,
+	})
+	sort.Sort(sorted)
+	require.Equal(t, []*snapshotHeader{important, healthy, flaky}, sorted.snapshots)
+}
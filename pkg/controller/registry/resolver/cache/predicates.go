@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"reflect"
 
 	"github.com/blang/semver/v4"
 
@@ -244,7 +245,7 @@ func (p andPredicate) Test(o *Entry) bool {
 func (p andPredicate) String() string {
 	var b bytes.Buffer
 	for i, predicate := range p.predicates {
-		b.WriteString(predicate.String())
+		b.WriteString(nestedString(predicate))
 		if i != len(p.predicates)-1 {
 			b.WriteString(" and ")
 		}
@@ -274,7 +275,7 @@ func (p orPredicate) Test(o *Entry) bool {
 func (p orPredicate) String() string {
 	var b bytes.Buffer
 	for i, predicate := range p.predicates {
-		b.WriteString(predicate.String())
+		b.WriteString(nestedString(predicate))
 		if i != len(p.predicates)-1 {
 			b.WriteString(" or ")
 		}
@@ -282,6 +283,91 @@ func (p orPredicate) String() string {
 	return b.String()
 }
 
+type notPredicate struct {
+	predicate Predicate
+}
+
+func Not(p Predicate) Predicate {
+	return notPredicate{
+		predicate: p,
+	}
+}
+
+func (p notPredicate) Test(o *Entry) bool {
+	return !p.predicate.Test(o)
+}
+
+func (p notPredicate) String() string {
+	return fmt.Sprintf("not %s", nestedString(p.predicate))
+}
+
+// nestedString returns the description of a predicate that is part of a
+// compound predicate, parenthesized if it is itself compound.
+func nestedString(p Predicate) string {
+	switch c := p.(type) {
+	case andPredicate:
+		if len(c.predicates) > 1 {
+			return fmt.Sprintf("(%s)", p.String())
+		}
+	case orPredicate:
+		if len(c.predicates) > 1 {
+			return fmt.Sprintf("(%s)", p.String())
+		}
+	}
+	return p.String()
+}
+
+type propertyPredicate struct {
+	typ   string
+	value interface{}
+}
+
+// PropertyPredicate matches entries that have a property of the given type
+// whose value contains the given value: objects match if they contain every
+// field of the given object with a matching value, other values must be
+// equal.
+func PropertyPredicate(typ string, value interface{}) Predicate {
+	return propertyPredicate{typ: typ, value: value}
+}
+
+func (p propertyPredicate) Test(o *Entry) bool {
+	for _, prop := range o.Properties {
+		if prop.Type != p.typ {
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal([]byte(prop.Value), &value); err != nil {
+			continue
+		}
+		if containsValue(value, p.value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p propertyPredicate) String() string {
+	value, _ := json.Marshal(p.value)
+	return fmt.Sprintf("with property %s: %s", p.typ, value)
+}
+
+func containsValue(value, want interface{}) bool {
+	wantObj, ok := want.(map[string]interface{})
+	if !ok {
+		return reflect.DeepEqual(value, want)
+	}
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for k, v := range wantObj {
+		if field, ok := obj[k]; !ok || !containsValue(field, v) {
+			return false
+		}
+	}
+	return true
+}
+
 type booleanPredicate struct {
 	result bool
 }
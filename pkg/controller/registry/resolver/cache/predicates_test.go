@@ -3,6 +3,7 @@ package cache
 import (
 	"testing"
 
+	"github.com/operator-framework/operator-registry/pkg/api"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -61,3 +62,57 @@ func TestCountingPredicate(t *testing.T) {
 		})
 	}
 }
+
+func TestNotPredicate(t *testing.T) {
+	assert.False(t, Not(True()).Test(nil))
+	assert.True(t, Not(False()).Test(nil))
+	assert.Equal(t, "not (with package: a or with package: b)", Not(Or(PkgPredicate("a"), PkgPredicate("b"))).String())
+}
+
+func TestCompoundPredicateString(t *testing.T) {
+	p := Or(And(PkgPredicate("a"), LabelPredicate("lts")), Not(PkgPredicate("b")), And(PkgPredicate("c")))
+	assert.Equal(t, "(with package: a and with label: lts) or not with package: b or with package: c", p.String())
+}
+
+func TestPropertyPredicate(t *testing.T) {
+	entry := &Entry{Properties: []*api.Property{
+		{Type: "olm.package", Value: `{"packageName":"a","version":"1.0.0"}`},
+		{Type: "olm.maxOpenShiftVersion", Value: `"4.9"`},
+	}}
+
+	for _, tc := range []struct {
+		Name     string
+		Type     string
+		Value    interface{}
+		Expected bool
+	}{
+		{
+			Name:     "object subset",
+			Type:     "olm.package",
+			Value:    map[string]interface{}{"packageName": "a"},
+			Expected: true,
+		},
+		{
+			Name:     "object mismatch",
+			Type:     "olm.package",
+			Value:    map[string]interface{}{"packageName": "a", "version": "2.0.0"},
+			Expected: false,
+		},
+		{
+			Name:     "scalar",
+			Type:     "olm.maxOpenShiftVersion",
+			Value:    "4.9",
+			Expected: true,
+		},
+		{
+			Name:     "missing type",
+			Type:     "olm.label",
+			Value:    "4.9",
+			Expected: false,
+		},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, PropertyPredicate(tc.Type, tc.Value).Test(entry))
+		})
+	}
+}
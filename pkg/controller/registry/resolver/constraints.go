@@ -0,0 +1,183 @@
+package resolver
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver/cache"
+)
+
+// constraintPropertyType is the type of bundle properties that declare a
+// dependency as an expression over candidate bundles.
+const constraintPropertyType = "olm.constraint"
+
+// constraint is the value of an olm.constraint property. Exactly one of its
+// expression fields must be set. Compound expressions nest constraints, whose
+// FailureMessage is ignored.
+type constraint struct {
+	// FailureMessage, if set, is reported when no bundle satisfies the
+	// constraint.
+	FailureMessage string `json:"failureMessage,omitempty"`
+
+	All *compoundConstraint `json:"all,omitempty"`
+	Any *compoundConstraint `json:"any,omitempty"`
+	Not *constraint         `json:"not,omitempty"`
+
+	GVK      json.RawMessage     `json:"gvk,omitempty"`
+	Package  json.RawMessage     `json:"package,omitempty"`
+	Label    json.RawMessage     `json:"label,omitempty"`
+	Property *propertyConstraint `json:"property,omitempty"`
+}
+
+type compoundConstraint struct {
+	Constraints []constraint `json:"constraints"`
+}
+
+// propertyConstraint matches bundles that have a property of the given type
+// whose value contains Value.
+type propertyConstraint struct {
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// constraintPredicate is a dependency predicate built from an olm.constraint
+// property.
+type constraintPredicate struct {
+	cache.Predicate
+	failureMessage string
+}
+
+func (c constraintPredicate) FailureMessage() string {
+	return c.failureMessage
+}
+
+// failureMessager is implemented by dependency predicates that describe
+// their unsatisfiability themselves.
+type failureMessager interface {
+	FailureMessage() string
+}
+
+func predicateForConstraintProperty(value string) (cache.Predicate, error) {
+	var c constraint
+	if err := json.Unmarshal([]byte(value), &c); err != nil {
+		return nil, err
+	}
+	p, err := c.predicate("")
+	if err != nil {
+		return nil, err
+	}
+	if c.Not != nil {
+		// A dependency is satisfied by some operator that matches it. At the
+		// top level, not would be satisfied by any operator that doesn't
+		// match the nested constraint, and would not exclude those that do.
+		return nil, c.errorf("", "not must be nested in all or any, a dependency cannot exclude operators")
+	}
+	return constraintPredicate{Predicate: p, failureMessage: c.FailureMessage}, nil
+}
+
+// predicate returns the predicate for the constraint found at the given path
+// of an olm.constraint value. Errors name the path and the offending
+// sub-expression.
+func (c *constraint) predicate(path string) (cache.Predicate, error) {
+	var set []string
+	for field, isSet := range map[string]bool{
+		"all":      c.All != nil,
+		"any":      c.Any != nil,
+		"not":      c.Not != nil,
+		"gvk":      c.GVK != nil,
+		"package":  c.Package != nil,
+		"label":    c.Label != nil,
+		"property": c.Property != nil,
+	} {
+		if isSet {
+			set = append(set, field)
+		}
+	}
+	if len(set) != 1 {
+		return nil, c.errorf(path, "exactly one of all, any, not, gvk, package, label or property must be set")
+	}
+
+	switch {
+	case c.All != nil:
+		ps, err := c.All.predicates(join(path, "all"))
+		if err != nil {
+			return nil, err
+		}
+		return cache.And(ps...), nil
+	case c.Any != nil:
+		ps, err := c.Any.predicates(join(path, "any"))
+		if err != nil {
+			return nil, err
+		}
+		return cache.Or(ps...), nil
+	case c.Not != nil:
+		p, err := c.Not.predicate(join(path, "not"))
+		if err != nil {
+			return nil, err
+		}
+		return cache.Not(p), nil
+	case c.GVK != nil:
+		return c.leaf(path, "gvk", predicateForRequiredGVKProperty, c.GVK)
+	case c.Package != nil:
+		return c.leaf(path, "package", predicateForRequiredPackageProperty, c.Package)
+	case c.Label != nil:
+		return c.leaf(path, "label", predicateForRequiredLabelProperty, c.Label)
+	}
+
+	if c.Property.Type == "" {
+		return nil, c.errorf(join(path, "property"), "type must be set")
+	}
+	return cache.PropertyPredicate(c.Property.Type, c.Property.Value), nil
+}
+
+func (c *constraint) leaf(path, field string, predicate func(string) (cache.Predicate, error), value json.RawMessage) (cache.Predicate, error) {
+	p, err := predicate(string(value))
+	if err != nil {
+		return nil, c.errorf(join(path, field), "%v", err)
+	}
+	return p, nil
+}
+
+func (c *constraint) errorf(path, format string, args ...interface{}) error {
+	expr, _ := json.Marshal(c)
+	if path == "" {
+		path = "."
+	}
+	return fmt.Errorf("invalid constraint at %s (%s): %s", path, expr, fmt.Sprintf(format, args...))
+}
+
+func (c *compoundConstraint) predicates(path string) ([]cache.Predicate, error) {
+	if len(c.Constraints) == 0 {
+		return nil, fmt.Errorf("invalid constraint at %s: constraints must not be empty", path)
+	}
+	ps := make([]cache.Predicate, len(c.Constraints))
+	for i := range c.Constraints {
+		p, err := c.Constraints[i].predicate(fmt.Sprintf("%s.constraints[%d]", path, i))
+		if err != nil {
+			return nil, err
+		}
+		ps[i] = p
+	}
+	return ps, nil
+}
+
+func join(path, field string) string {
+	return strings.TrimPrefix(path+"."+field, ".")
+}
+
+// validateConstraintProperties checks that every olm.constraint property
+// among the given properties is well-formed.
+func validateConstraintProperties(properties []*api.Property) error {
+	for _, property := range properties {
+		if property == nil || property.Type != constraintPropertyType {
+			continue
+		}
+		if _, err := predicateForConstraintProperty(property.Value); err != nil {
+			return fmt.Errorf("invalid %s property: %w", constraintPropertyType, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package resolver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver/cache"
+)
+
+func TestPredicateForConstraintProperty(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		value    string
+		expected string
+		err      string
+	}{
+		{
+			name:     "GVK",
+			value:    `{"gvk":{"group":"g","version":"v1","kind":"K"}}`,
+			expected: "providing an API with group: g, version: v1, kind: K",
+		},
+		{
+			name:     "Compound",
+			value:    `{"any":{"constraints":[{"package":{"packageName":"a","versionRange":">=2.0.0"}},{"not":{"label":{"label":"beta"}}}]}}`,
+			expected: "(with package: a and with version in range: >=2.0.0) or not with label: beta",
+		},
+		{
+			name:     "Property",
+			value:    `{"all":{"constraints":[{"property":{"type":"olm.maxOpenShiftVersion","value":"4.9"}},{"label":{"label":"lts"}}]}}`,
+			expected: `with property olm.maxOpenShiftVersion: "4.9" and with label: lts`,
+		},
+		{
+			name:  "Malformed",
+			value: `{"all":`,
+			err:   "unexpected end of JSON input",
+		},
+		{
+			name:  "NoExpression",
+			value: `{"failureMessage":"m"}`,
+			err:   `invalid constraint at . ({"failureMessage":"m"}): exactly one of all, any, not, gvk, package, label or property must be set`,
+		},
+		{
+			name:  "SeveralExpressions",
+			value: `{"label":{"label":"lts"},"not":{"label":{"label":"beta"}}}`,
+			err:   `invalid constraint at . ({"not":{"label":{"label":"beta"}},"label":{"label":"lts"}}): exactly one of all, any, not, gvk, package, label or property must be set`,
+		},
+		{
+			name:  "EmptyCompound",
+			value: `{"not":{"all":{"constraints":[]}}}`,
+			err:   "invalid constraint at not.all: constraints must not be empty",
+		},
+		{
+			name:  "InvalidVersionRange",
+			value: `{"any":{"constraints":[{"label":{"label":"lts"}},{"package":{"packageName":"a","versionRange":"two"}}]}}`,
+			err:   `invalid constraint at any.constraints[1].package ({"package":{"packageName":"a","versionRange":"two"}}): Could not get version from string: "two"`,
+		},
+		{
+			name:  "TopLevelNot",
+			value: `{"not":{"label":{"label":"beta"}}}`,
+			err:   `invalid constraint at . ({"not":{"label":{"label":"beta"}}}): not must be nested in all or any, a dependency cannot exclude operators`,
+		},
+		{
+			name:     "NestedNot",
+			value:    `{"all":{"constraints":[{"gvk":{"group":"g","version":"v1","kind":"K"}},{"not":{"label":{"label":"beta"}}}]}}`,
+			expected: "providing an API with group: g, version: v1, kind: K and not with label: beta",
+		},
+		{
+			name:  "PropertyWithoutType",
+			value: `{"not":{"property":{"value":"x"}}}`,
+			err:   `invalid constraint at not.property ({"property":{"type":"","value":"x"}}): type must be set`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := predicateForConstraintProperty(tt.value)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, p.String())
+		})
+	}
+}
+
+func TestNewOperatorFromBundleInvalidConstraint(t *testing.T) {
+	b := bundle("a.v1", "a", "alpha", "", nil, nil, nil, nil)
+	b.Properties = append(b.Properties, &api.Property{Type: constraintPropertyType, Value: `{"not":{}}`})
+
+	_, err := newOperatorFromBundle(b, "", cache.SourceKey{Name: "source", Namespace: "testNamespace"}, "alpha")
+	require.EqualError(t, err, `invalid olm.constraint property: invalid constraint at not ({}): exactly one of all, any, not, gvk, package, label or property must be set`)
+}
@@ -0,0 +1,192 @@
+package resolver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver/cache"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver/solver"
+)
+
+// Explanation is a structured account of why a set of Subscriptions
+// could not be resolved. It is derived from the minimal set of
+// conflicting constraints reported by the solver.
+type Explanation struct {
+	// Subscriptions lists each Subscription that participates in
+	// the conflict, together with its candidate bundles.
+	Subscriptions []SubscriptionExplanation `json:"subscriptions,omitempty"`
+	// Constraints lists the conflicting constraints that could
+	// not be attributed to any Subscription or candidate bundle.
+	Constraints []string `json:"constraints,omitempty"`
+}
+
+// SubscriptionExplanation describes what a single Subscription asked
+// for and why none of its candidates could be selected.
+type SubscriptionExplanation struct {
+	Name       string                 `json:"name"`
+	Package    string                 `json:"package,omitempty"`
+	Channel    string                 `json:"channel,omitempty"`
+	Catalog    cache.SourceKey        `json:"catalog"`
+	Reasons    []string               `json:"reasons,omitempty"`
+	Candidates []CandidateExplanation `json:"candidates,omitempty"`
+}
+
+// CandidateExplanation describes a bundle that could have satisfied a
+// Subscription and the constraints that excluded it.
+type CandidateExplanation struct {
+	Bundle  string          `json:"bundle"`
+	Channel string          `json:"channel,omitempty"`
+	Catalog cache.SourceKey `json:"catalog"`
+	Reasons []string        `json:"reasons,omitempty"`
+}
+
+// Explain builds an Explanation for a resolution failure from the
+// given conflict set and the Subscriptions that were being resolved.
+func Explain(conflicts solver.NotSatisfiable, subs []*v1alpha1.Subscription) Explanation {
+	var e Explanation
+
+	// The solver reports the conflict set in no particular order; sort it
+	// so that the same failure is always explained the same way.
+	conflicts = append(solver.NotSatisfiable(nil), conflicts...)
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		return conflicts[i].String() < conflicts[j].String()
+	})
+
+	// Track which applied constraints have been attributed to a
+	// Subscription or candidate so the remainder can be reported
+	// separately.
+	attributed := make([]bool, len(conflicts))
+
+	for _, sub := range subs {
+		id := subscriptionId(sub.GetName())
+
+		var subConstraints []solver.AppliedConstraint
+		for i, a := range conflicts {
+			if a.Installable.Identifier() != id {
+				continue
+			}
+			subConstraints = append(subConstraints, a)
+			attributed[i] = true
+		}
+		if len(subConstraints) == 0 {
+			// This Subscription did not contribute to the conflict.
+			continue
+		}
+
+		se := SubscriptionExplanation{
+			Name: sub.GetName(),
+		}
+		if sub.Spec != nil {
+			se.Package = sub.Spec.Package
+			se.Channel = sub.Spec.Channel
+			se.Catalog = cache.SourceKey{Name: sub.Spec.CatalogSource, Namespace: sub.Spec.CatalogSourceNamespace}
+		}
+
+		candidates := make(map[solver.Identifier]struct{})
+		for _, a := range subConstraints {
+			se.Reasons = append(se.Reasons, a.String())
+			for _, c := range a.Identifiers() {
+				if _, ok := candidates[c]; ok {
+					continue
+				}
+				candidates[c] = struct{}{}
+				se.Candidates = append(se.Candidates, explainCandidate(c, id, conflicts, attributed))
+			}
+		}
+
+		e.Subscriptions = append(e.Subscriptions, se)
+	}
+
+	for i, a := range conflicts {
+		if !attributed[i] {
+			e.Constraints = append(e.Constraints, a.String())
+		}
+	}
+
+	return e
+}
+
+// explainCandidate collects the constraints that reference the
+// candidate identified by id, other than those applied to the
+// Subscription that selected it.
+func explainCandidate(id, subscription solver.Identifier, conflicts solver.NotSatisfiable, attributed []bool) CandidateExplanation {
+	ce := CandidateExplanation{
+		Bundle: id.String(),
+	}
+	bi := BundleInstallable{identifier: id}
+	if csvName, channel, catalog, err := bi.BundleSourceInfo(); err == nil {
+		ce.Bundle = csvName
+		ce.Channel = channel
+		ce.Catalog = catalog
+	}
+
+	for i, a := range conflicts {
+		if a.Installable.Identifier() == subscription {
+			continue
+		}
+		if a.Installable.Identifier() == id || referencesIdentifier(a, id) {
+			ce.Reasons = append(ce.Reasons, a.String())
+			attributed[i] = true
+		}
+	}
+
+	return ce
+}
+
+func referencesIdentifier(a solver.AppliedConstraint, id solver.Identifier) bool {
+	for _, each := range a.Identifiers() {
+		if each == id {
+			return true
+		}
+	}
+	return false
+}
+
+func subscriptionId(name string) solver.Identifier {
+	return solver.IdentifierFromString(fmt.Sprintf("subscription:%s", name))
+}
+
+// String renders the receiver as an indented, human-readable tree.
+func (e Explanation) String() string {
+	var b strings.Builder
+	for _, se := range e.Subscriptions {
+		fmt.Fprintf(&b, "subscription %s", se.Name)
+		if se.Package != "" {
+			fmt.Fprintf(&b, " requests package %s", se.Package)
+			if se.Channel != "" {
+				fmt.Fprintf(&b, " in channel %s", se.Channel)
+			}
+			if !se.Catalog.Empty() {
+				fmt.Fprintf(&b, " from catalog %s", se.Catalog.String())
+			}
+		}
+		b.WriteString("\n")
+		for _, r := range se.Reasons {
+			fmt.Fprintf(&b, "  - %s\n", r)
+		}
+		for _, ce := range se.Candidates {
+			fmt.Fprintf(&b, "  candidate %s", ce.Bundle)
+			if ce.Channel != "" || !ce.Catalog.Empty() {
+				if ce.Catalog.Virtual() {
+					b.WriteString(" (installed)")
+				} else {
+					fmt.Fprintf(&b, " (channel %s, catalog %s)", ce.Channel, ce.Catalog.String())
+				}
+			}
+			b.WriteString("\n")
+			for _, r := range ce.Reasons {
+				fmt.Fprintf(&b, "    - %s\n", r)
+			}
+		}
+	}
+	if len(e.Constraints) > 0 {
+		b.WriteString("other conflicting constraints\n")
+		for _, c := range e.Constraints {
+			fmt.Fprintf(&b, "  - %s\n", c)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
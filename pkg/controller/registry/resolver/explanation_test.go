@@ -0,0 +1,87 @@
+package resolver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver/cache"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver/solver"
+)
+
+func TestExplain(t *testing.T) {
+	const namespace = "ns"
+	catalog := cache.SourceKey{Name: "catsrc", Namespace: namespace}
+	sub := newSub(namespace, "a", "alpha", catalog)
+
+	a1 := bundleId("a.v1", "alpha", catalog)
+	b1 := bundleId("b.v1", "alpha", catalog)
+	subInstallable := NewSubscriptionInstallable(sub.GetName(), []solver.Identifier{a1})
+	bundleB := &BundleInstallable{identifier: b1}
+	gvk := NewSingleAPIProviderInstallable("g", "v", "k", []solver.Identifier{a1, b1})
+
+	conflicts := solver.NotSatisfiable{
+		{Installable: subInstallable, Constraint: subInstallable.Constraints()[0]},
+		{Installable: subInstallable, Constraint: subInstallable.Constraints()[1]},
+		{Installable: bundleB, Constraint: PrettyConstraint(solver.Mandatory(), "clusterserviceversion b.v1 exists and is not referenced by a subscription")},
+		{Installable: gvk, Constraint: gvk.Constraints()[1]},
+		{Installable: gvk, Constraint: gvk.Constraints()[0]},
+	}
+
+	explanation := Explain(conflicts, []*v1alpha1.Subscription{sub})
+
+	require.Len(t, explanation.Subscriptions, 1)
+	se := explanation.Subscriptions[0]
+	assert.Equal(t, sub.GetName(), se.Name)
+	assert.Equal(t, "a", se.Package)
+	assert.Equal(t, "alpha", se.Channel)
+	assert.Equal(t, catalog, se.Catalog)
+	assert.Equal(t, []string{
+		"subscription a-alpha exists",
+		"subscription a-alpha requires catsrc/ns/alpha/a.v1",
+	}, se.Reasons)
+
+	require.Len(t, se.Candidates, 1)
+	ce := se.Candidates[0]
+	assert.Equal(t, "a.v1", ce.Bundle)
+	assert.Equal(t, "alpha", ce.Channel)
+	assert.Equal(t, catalog, ce.Catalog)
+	assert.Equal(t, []string{"catsrc/ns/alpha/a.v1 and catsrc/ns/alpha/b.v1 provide k (g/v)"}, ce.Reasons)
+
+	assert.Equal(t, []string{
+		"clusterserviceversion b.v1 exists and is not referenced by a subscription",
+		"there can be only one provider of k (g/v)",
+	}, explanation.Constraints)
+
+	assert.Equal(t, `subscription a-alpha requests package a in channel alpha from catalog catsrc/ns
+  - subscription a-alpha exists
+  - subscription a-alpha requires catsrc/ns/alpha/a.v1
+  candidate a.v1 (channel alpha, catalog catsrc/ns)
+    - catsrc/ns/alpha/a.v1 and catsrc/ns/alpha/b.v1 provide k (g/v)
+other conflicting constraints
+  - clusterserviceversion b.v1 exists and is not referenced by a subscription
+  - there can be only one provider of k (g/v)`, explanation.String())
+}
+
+func TestExplainIgnoresUninvolvedSubscriptions(t *testing.T) {
+	const namespace = "ns"
+	catalog := cache.SourceKey{Name: "catsrc", Namespace: namespace}
+	invalid := NewInvalidSubscriptionInstallable("a-alpha", "no operators found in package a in the catalog referenced by subscription a-alpha")
+
+	conflicts := solver.NotSatisfiable{
+		{Installable: invalid, Constraint: invalid.Constraints()[0]},
+		{Installable: invalid, Constraint: invalid.Constraints()[1]},
+	}
+
+	explanation := Explain(conflicts, []*v1alpha1.Subscription{
+		newSub(namespace, "a", "alpha", catalog),
+		newSub(namespace, "b", "alpha", catalog),
+	})
+
+	require.Len(t, explanation.Subscriptions, 1)
+	assert.Equal(t, "a-alpha", explanation.Subscriptions[0].Name)
+	assert.Empty(t, explanation.Subscriptions[0].Candidates)
+	assert.Empty(t, explanation.Constraints)
+}
@@ -100,7 +100,7 @@ func (i GenericInstallable) Constraints() []solver.Constraint {
 
 func NewInvalidSubscriptionInstallable(name string, reason string) solver.Installable {
 	return GenericInstallable{
-		identifier: solver.IdentifierFromString(fmt.Sprintf("subscription:%s", name)),
+		identifier: subscriptionId(name),
 		constraints: []solver.Constraint{
 			PrettyConstraint(solver.Mandatory(), fmt.Sprintf("subscription %s exists", name)),
 			PrettyConstraint(solver.Prohibited(), reason),
@@ -110,7 +110,7 @@ func NewInvalidSubscriptionInstallable(name string, reason string) solver.Instal
 
 func NewSubscriptionInstallable(name string, dependencies []solver.Identifier) solver.Installable {
 	result := GenericInstallable{
-		identifier: solver.IdentifierFromString(fmt.Sprintf("subscription:%s", name)),
+		identifier: subscriptionId(name),
 		constraints: []solver.Constraint{
 			PrettyConstraint(solver.Mandatory(), fmt.Sprintf("subscription %s exists", name)),
 		},
@@ -1,6 +1,7 @@
 package resolver
 
 import (
+	"context"
 	"time"
 
 	"github.com/operator-framework/api/pkg/operators/v1alpha1"
@@ -23,9 +24,9 @@ func NewInstrumentedResolver(resolver StepResolver, successMetricsEmitter, failu
 	}
 }
 
-func (ir *InstrumentedResolver) ResolveSteps(namespace string) ([]*v1alpha1.Step, []v1alpha1.BundleLookup, []*v1alpha1.Subscription, error) {
+func (ir *InstrumentedResolver) ResolveSteps(ctx context.Context, namespace string) ([]*v1alpha1.Step, []v1alpha1.BundleLookup, []*v1alpha1.Subscription, error) {
 	start := time.Now()
-	steps, lookups, subs, err := ir.resolver.ResolveSteps(namespace)
+	steps, lookups, subs, err := ir.resolver.ResolveSteps(ctx, namespace)
 	if err != nil {
 		ir.failureMetricsEmitter(time.Now().Sub(start))
 	} else {
@@ -37,3 +38,7 @@ func (ir *InstrumentedResolver) ResolveSteps(namespace string) ([]*v1alpha1.Step
 func (ir *InstrumentedResolver) Expire(key cache.SourceKey) {
 	ir.resolver.Expire(key)
 }
+
+func (ir *InstrumentedResolver) Deprecations(sub *v1alpha1.Subscription) (cache.Deprecations, error) {
+	return ir.resolver.Deprecations(sub)
+}
@@ -1,6 +1,7 @@
 package resolver
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
@@ -19,20 +20,28 @@ const (
 type fakeResolverWithError struct{}
 type fakeResolverWithoutError struct{}
 
-func (r *fakeResolverWithError) ResolveSteps(namespace string) ([]*v1alpha1.Step, []v1alpha1.BundleLookup, []*v1alpha1.Subscription, error) {
+func (r *fakeResolverWithError) ResolveSteps(ctx context.Context, namespace string) ([]*v1alpha1.Step, []v1alpha1.BundleLookup, []*v1alpha1.Subscription, error) {
 	return nil, nil, nil, errors.New("Fake error")
 }
 
 func (r *fakeResolverWithError) Expire(key cache.SourceKey) {
 }
 
-func (r *fakeResolverWithoutError) ResolveSteps(namespace string) ([]*v1alpha1.Step, []v1alpha1.BundleLookup, []*v1alpha1.Subscription, error) {
+func (r *fakeResolverWithError) Deprecations(sub *v1alpha1.Subscription) (cache.Deprecations, error) {
+	return cache.Deprecations{}, nil
+}
+
+func (r *fakeResolverWithoutError) ResolveSteps(ctx context.Context, namespace string) ([]*v1alpha1.Step, []v1alpha1.BundleLookup, []*v1alpha1.Subscription, error) {
 	return nil, nil, nil, nil
 }
 
 func (r *fakeResolverWithoutError) Expire(key cache.SourceKey) {
 }
 
+func (r *fakeResolverWithoutError) Deprecations(sub *v1alpha1.Subscription) (cache.Deprecations, error) {
+	return cache.Deprecations{}, nil
+}
+
 func newFakeResolverWithError() *fakeResolverWithError {
 	return &fakeResolverWithError{}
 }
@@ -53,7 +62,7 @@ func TestInstrumentedResolverFailure(t *testing.T) {
 	}
 
 	instrumentedResolver := NewInstrumentedResolver(newFakeResolverWithError(), changeToSuccess, changeToFailure)
-	instrumentedResolver.ResolveSteps("")
+	instrumentedResolver.ResolveSteps(context.TODO(), "")
 	require.Equal(t, len(result), 1)     // check that only one call was made to a change function
 	require.Equal(t, result[0], failure) // check that the call was made to changeToFailure function
 }
@@ -70,7 +79,7 @@ func TestInstrumentedResolverSuccess(t *testing.T) {
 	}
 
 	instrumentedResolver := NewInstrumentedResolver(newFakeResolverWithoutError(), changeToSuccess, changeToFailure)
-	instrumentedResolver.ResolveSteps("")
+	instrumentedResolver.ResolveSteps(context.TODO(), "")
 	require.Equal(t, len(result), 1)     // check that only one call was made to a change function
 	require.Equal(t, result[0], success) // check that the call was made to changeToSuccess function
 }
@@ -4,18 +4,23 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"sort"
 	"strings"
 
 	"github.com/blang/semver/v4"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
 	"github.com/operator-framework/api/pkg/operators/v1alpha1"
 	v1alpha1listers "github.com/operator-framework/operator-lifecycle-manager/pkg/api/client/listers/operators/v1alpha1"
+	v2listers "github.com/operator-framework/operator-lifecycle-manager/pkg/api/client/listers/operators/v2"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver/cache"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver/projection"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver/solver"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/operatorcondition"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/tracing"
 	"github.com/operator-framework/operator-registry/pkg/api"
 	opregistry "github.com/operator-framework/operator-registry/pkg/registry"
 )
@@ -25,15 +30,104 @@ type OperatorResolver interface {
 }
 
 type SatResolver struct {
-	cache cache.OperatorCacheProvider
-	log   logrus.FieldLogger
+	cache           cache.OperatorCacheProvider
+	log             logrus.FieldLogger
+	preference      ResolutionPreference
+	sourceProviders []cache.SourceProvider
+	cacheOptions    []cache.Option
+	// ignoreUnneededCatalogErrors only fails resolution on errors of
+	// the catalogs of the Subscriptions being resolved.
+	ignoreUnneededCatalogErrors bool
+	// operatorConditionLister, if set, is used to read the upgrade
+	// gates of installed operators.
+	operatorConditionLister v2listers.OperatorConditionLister
 }
 
-func NewDefaultSatResolver(rcp cache.SourceProvider, catsrcLister v1alpha1listers.CatalogSourceLister, logger logrus.FieldLogger) *SatResolver {
-	return &SatResolver{
-		cache: cache.New(rcp, cache.WithLogger(logger), cache.WithCatalogSourceLister(catsrcLister)),
-		log:   logger,
+// ResolutionPreference determines which of several valid resolutions
+// is selected.
+type ResolutionPreference string
+
+const (
+	// PreferChannelHead selects, for each Subscription, the
+	// latest bundle in its channel that satisfies all constraints.
+	// Resolving a new Subscription may upgrade unrelated operators.
+	PreferChannelHead ResolutionPreference = "channel-head"
+	// PreferMinimalChange selects a resolution that installs or
+	// upgrades as few operators as possible. Among resolutions
+	// with equally few changes, the latest bundles are preferred.
+	// Installed operators are only upgraded when that is required
+	// to satisfy some constraint.
+	PreferMinimalChange ResolutionPreference = "minimal-change"
+)
+
+// ParseResolutionPreference returns the ResolutionPreference named by s.
+func ParseResolutionPreference(s string) (ResolutionPreference, error) {
+	switch p := ResolutionPreference(s); p {
+	case PreferChannelHead, PreferMinimalChange:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown resolution preference %q, must be one of %q or %q", s, PreferChannelHead, PreferMinimalChange)
+}
+
+type SatResolverOption func(*SatResolver)
+
+// WithResolutionPreference configures the ResolutionPreference of a
+// SatResolver. The default is PreferChannelHead.
+func WithResolutionPreference(preference ResolutionPreference) SatResolverOption {
+	return func(r *SatResolver) {
+		r.preference = preference
+	}
+}
+
+// WithSourceProvider adds the sources of the given provider to the
+// sources a SatResolver resolves from.
+func WithSourceProvider(sp cache.SourceProvider) SatResolverOption {
+	return func(r *SatResolver) {
+		r.sourceProviders = append(r.sourceProviders, sp)
+	}
+}
+
+// WithCacheOptions configures the operator cache of a SatResolver
+// with the given options.
+func WithCacheOptions(options ...cache.Option) SatResolverOption {
+	return func(r *SatResolver) {
+		r.cacheOptions = append(r.cacheOptions, options...)
+	}
+}
+
+// WithUnneededCatalogErrorsIgnored makes a SatResolver resolve without
+// the content of catalogs that fail to be read, unless a Subscription
+// being resolved names them. By default, any failing catalog in scope
+// fails resolution.
+func WithUnneededCatalogErrorsIgnored() SatResolverOption {
+	return func(r *SatResolver) {
+		r.ignoreUnneededCatalogErrors = true
+	}
+}
+
+// WithOperatorConditionLister makes a SatResolver hold the upgrades of
+// installed operators as their OperatorConditions require: operators
+// with pending migrations aren't upgraded, and operators are only
+// upgraded within their upgradeable version range.
+func WithOperatorConditionLister(lister v2listers.OperatorConditionLister) SatResolverOption {
+	return func(r *SatResolver) {
+		r.operatorConditionLister = lister
+	}
+}
+
+func NewDefaultSatResolver(rcp cache.SourceProvider, catsrcLister v1alpha1listers.CatalogSourceLister, logger logrus.FieldLogger, options ...SatResolverOption) *SatResolver {
+	r := &SatResolver{
+		log:        logger,
+		preference: PreferChannelHead,
 	}
+	for _, option := range options {
+		option(r)
+	}
+	if len(r.sourceProviders) > 0 {
+		rcp = cache.SourceProviders(append([]cache.SourceProvider{rcp}, r.sourceProviders...))
+	}
+	r.cache = cache.New(rcp, append([]cache.Option{cache.WithLogger(logger), cache.WithCatalogSourceLister(catsrcLister)}, r.cacheOptions...)...)
+	return r
 }
 
 type debugWriter struct {
@@ -46,7 +140,44 @@ func (w *debugWriter) Write(b []byte) (int, error) {
 	return n, nil
 }
 
-func (r *SatResolver) SolveOperators(namespaces []string, csvs []*v1alpha1.ClusterServiceVersion, subs []*v1alpha1.Subscription) (cache.OperatorSet, error) {
+// maxPartialTraceBytes bounds the amount of solver trace output that is
+// retained in case a resolution does not complete.
+const maxPartialTraceBytes = 64 * 1024
+
+// tailBuffer retains the last limit bytes written to it.
+type tailBuffer struct {
+	limit int
+	buf   []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	if over := len(b.buf) - b.limit; over > 0 {
+		b.buf = b.buf[over:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	return string(b.buf)
+}
+
+// SolveOperators resolves the given Subscriptions in the first of the
+// given namespaces. If ctx is done before a resolution is found, it
+// returns solver.Incomplete and logs the last part of the solver's
+// trace.
+func (r *SatResolver) SolveOperators(ctx context.Context, namespaces []string, csvs []*v1alpha1.ClusterServiceVersion, subs []*v1alpha1.Subscription) (cache.OperatorSet, error) {
+	ctx, span := tracing.Start(ctx, "SolveOperators",
+		attribute.Array("namespaces", namespaces),
+		attribute.Int("subscriptions", len(subs)),
+	)
+	operators, err := r.solveOperators(ctx, namespaces, csvs, subs)
+	span.SetAttributes(attribute.Int("operators", len(operators)))
+	tracing.End(span, err)
+	return operators, err
+}
+
+func (r *SatResolver) solveOperators(ctx context.Context, namespaces []string, csvs []*v1alpha1.ClusterServiceVersion, subs []*v1alpha1.Subscription) (cache.OperatorSet, error) {
 	var errs []error
 
 	installables := make(map[solver.Identifier]solver.Installable, 0)
@@ -74,6 +205,7 @@ func (r *SatResolver) SolveOperators(namespaces []string, csvs []*v1alpha1.Clust
 	for _, sub := range subs {
 		// find the currently installed operator (if it exists)
 		var current *cache.Entry
+		var gates operatorcondition.Gates
 		for _, csv := range csvs {
 			if csv.Name == sub.Status.InstalledCSV {
 				op, err := newOperatorFromV1Alpha1CSV(csv)
@@ -81,6 +213,12 @@ func (r *SatResolver) SolveOperators(namespaces []string, csvs []*v1alpha1.Clust
 					return nil, err
 				}
 				current = op
+				if r.operatorConditionLister != nil {
+					gates, err = operatorcondition.GatesFor(r.operatorConditionLister, csv.GetNamespace(), csv.GetName())
+					if err != nil {
+						return nil, err
+					}
+				}
 				break
 			}
 		}
@@ -90,7 +228,7 @@ func (r *SatResolver) SolveOperators(namespaces []string, csvs []*v1alpha1.Clust
 		}
 
 		// find operators, in channel order, that can skip from the current version or list the current in "replaces"
-		subInstallables, err := r.getSubscriptionInstallables(sub, current, namespacedCache, visited)
+		subInstallables, err := r.getSubscriptionInstallables(sub, current, gates, namespacedCache, visited)
 		if err != nil {
 			errs = append(errs, err)
 			continue
@@ -103,7 +241,7 @@ func (r *SatResolver) SolveOperators(namespaces []string, csvs []*v1alpha1.Clust
 
 	r.addInvariants(namespacedCache, installables)
 
-	if err := namespacedCache.Error(); err != nil {
+	if err := r.catalogError(namespacedCache, subs); err != nil {
 		return nil, err
 	}
 
@@ -115,11 +253,19 @@ func (r *SatResolver) SolveOperators(namespaces []string, csvs []*v1alpha1.Clust
 	if len(errs) > 0 {
 		return nil, utilerrors.NewAggregate(errs)
 	}
-	s, err := solver.New(solver.WithInput(input), solver.WithTracer(solver.LoggingTracer{Writer: &debugWriter{r.log}}))
+	trace := &tailBuffer{limit: maxPartialTraceBytes}
+	options := []solver.Option{solver.WithInput(input), solver.WithTracer(solver.LoggingTracer{Writer: io.MultiWriter(&debugWriter{r.log}, trace)})}
+	if r.preference == PreferMinimalChange {
+		options = append(options, solver.WithObjectives(minimalChange))
+	}
+	s, err := solver.New(options...)
 	if err != nil {
 		return nil, err
 	}
-	solvedInstallables, err := s.Solve(context.TODO())
+	solvedInstallables, err := s.Solve(ctx)
+	if err == solver.Incomplete {
+		r.log.Warnf("resolution in namespace %s did not complete: %v, partial solver trace:\n%s", namespaces[0], ctx.Err(), trace)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -172,7 +318,101 @@ func (r *SatResolver) SolveOperators(namespaces []string, csvs []*v1alpha1.Clust
 	return operators, nil
 }
 
-func (r *SatResolver) getSubscriptionInstallables(sub *v1alpha1.Subscription, current *cache.Entry, namespacedCache cache.MultiCatalogOperatorFinder, visited map[*cache.Entry]*BundleInstallable) (map[solver.Identifier]solver.Installable, error) {
+// minimalChange assigns a cost to each installable that represents a
+// bundle that is not already installed, so that solutions that install
+// or upgrade fewer operators are preferred.
+var minimalChange = solver.ObjectiveFunc(func(i solver.Installable) int {
+	bi, ok := i.(*BundleInstallable)
+	if !ok {
+		return 0
+	}
+	_, _, catalog, err := bi.BundleSourceInfo()
+	if err != nil || catalog.Virtual() {
+		return 0
+	}
+	return 1
+})
+
+// VersionRangeAnnotationKey is the key of an optional annotation on a
+// Subscription whose value is a semver range, for example ">=1.4.0
+// <1.6.0". When present, only bundles whose version is within the range
+// are installed or upgraded to on behalf of the Subscription.
+//
+// The Subscription API is defined and released in operator-framework/api, so
+// the range is an annotation until the API gains a spec field for it.
+const VersionRangeAnnotationKey = "operatorframework.io/version-range"
+
+// SubscriptionVersionRange returns the version range that sub is pinned
+// to, along with its string form. The returned range is nil if sub is
+// not pinned to a range.
+func SubscriptionVersionRange(sub *v1alpha1.Subscription) (semver.Range, string, error) {
+	str, ok := sub.GetAnnotations()[VersionRangeAnnotationKey]
+	if !ok {
+		return nil, "", nil
+	}
+	r, err := semver.ParseRange(str)
+	if err != nil {
+		return nil, str, fmt.Errorf("invalid version range %q in annotation %s: %w", str, VersionRangeAnnotationKey, err)
+	}
+	return r, str, nil
+}
+
+// catalogError returns an error if catalogs used for resolution
+// couldn't be read. If unneeded catalog errors are ignored, only the
+// catalogs of the given Subscriptions are checked.
+func (r *SatResolver) catalogError(namespacedCache cache.MultiCatalogOperatorFinder, subs []*v1alpha1.Subscription) error {
+	if !r.ignoreUnneededCatalogErrors {
+		return namespacedCache.Error()
+	}
+	var needed []cache.SourceKey
+	for _, sub := range subs {
+		needed = append(needed, cache.SourceKey{Name: sub.Spec.CatalogSource, Namespace: sub.Spec.CatalogSourceNamespace})
+	}
+	if err := namespacedCache.Error(); err != nil {
+		r.log.Debugf("resolving without catalogs that could not be read: %v", err)
+	}
+	return namespacedCache.ErrorFor(needed...)
+}
+
+// SubscriptionDeprecations returns the deprecation notices of the package
+// and channel of the given Subscription, and of the bundle it has
+// installed, as found in its catalog in the given namespaces.
+func (r *SatResolver) SubscriptionDeprecations(namespaces []string, sub *v1alpha1.Subscription) (cache.Deprecations, error) {
+	catalog := cache.SourceKey{
+		Name:      sub.Spec.CatalogSource,
+		Namespace: sub.Spec.CatalogSourceNamespace,
+	}
+	namespacedCache := r.cache.Namespaced(namespaces...)
+	entries := namespacedCache.Catalog(catalog).Find(cache.PkgPredicate(sub.Spec.Package))
+	if err := namespacedCache.ErrorFor(catalog); err != nil {
+		return cache.Deprecations{}, err
+	}
+
+	bundle := sub.Status.InstalledCSV
+	if bundle == "" {
+		bundle = sub.Status.CurrentCSV
+	}
+
+	var deprecations cache.Deprecations
+	for _, e := range entries {
+		d, err := e.Deprecations()
+		if err != nil {
+			return cache.Deprecations{}, err
+		}
+		if deprecations.Package == "" {
+			deprecations.Package = d.Package
+		}
+		if deprecations.Channel == "" && (e.Channel() == sub.Spec.Channel || sub.Spec.Channel == "" && e.SourceInfo != nil && e.SourceInfo.DefaultChannel) {
+			deprecations.Channel = d.Channel
+		}
+		if deprecations.Bundle == "" && bundle != "" && e.Name == bundle {
+			deprecations.Bundle = d.Bundle
+		}
+	}
+	return deprecations, nil
+}
+
+func (r *SatResolver) getSubscriptionInstallables(sub *v1alpha1.Subscription, current *cache.Entry, gates operatorcondition.Gates, namespacedCache cache.MultiCatalogOperatorFinder, visited map[*cache.Entry]*BundleInstallable) (map[solver.Identifier]solver.Installable, error) {
 	var cachePredicates, channelPredicates []cache.Predicate
 	installables := make(map[solver.Identifier]solver.Installable, 0)
 
@@ -181,14 +421,37 @@ func (r *SatResolver) getSubscriptionInstallables(sub *v1alpha1.Subscription, cu
 		Namespace: sub.Spec.CatalogSourceNamespace,
 	}
 
+	versionRange, versionRangeStr, err := SubscriptionVersionRange(sub)
+	if err != nil {
+		si := NewInvalidSubscriptionInstallable(sub.GetName(), fmt.Sprintf("subscription %s has an %v", sub.GetName(), err))
+		installables[si.Identifier()] = si
+		return installables, nil
+	}
+
 	var entries []*cache.Entry
 	{
 		var nall, npkg, nch, ncsv int
 
+		rangePredicate := cache.True()
+		if versionRange != nil {
+			// The range filters the sorted channel rather than the
+			// cache: a range with gaps, e.g. "!=1.5.0", would
+			// otherwise break the channel's replacement chain.
+			rangePredicate = cache.VersionInRangePredicate(versionRange, versionRangeStr)
+			channelPredicates = append(channelPredicates, rangePredicate)
+		}
+
 		csvPredicate := cache.True()
 		if current != nil {
 			// if we found an existing installed operator, we should filter the channel by operators that can replace it
 			channelPredicates = append(channelPredicates, cache.Or(cache.SkipRangeIncludesPredicate(*current.Version), cache.ReplacesPredicate(current.Name)))
+			// the operator keeps its current version until its pending migrations complete
+			if len(gates.PendingMigrations) > 0 {
+				channelPredicates = append(channelPredicates, cache.False())
+			}
+			if gates.VersionRange != nil {
+				channelPredicates = append(channelPredicates, cache.VersionInRangePredicate(gates.VersionRange, gates.VersionRangeString))
+			}
 		} else if sub.Spec.StartingCSV != "" {
 			// if no operator is installed and we have a startingCSV, filter for it
 			csvPredicate = cache.CSVNamePredicate(sub.Spec.StartingCSV)
@@ -201,6 +464,7 @@ func (r *SatResolver) getSubscriptionInstallables(sub *v1alpha1.Subscription, cu
 			cache.CountingPredicate(csvPredicate, &ncsv),
 		))
 		entries = namespacedCache.Catalog(catalog).Find(cachePredicates...)
+		nrange := len(cache.Filter(entries, rangePredicate))
 
 		var si solver.Installable
 		switch {
@@ -212,6 +476,10 @@ func (r *SatResolver) getSubscriptionInstallables(sub *v1alpha1.Subscription, cu
 			si = NewInvalidSubscriptionInstallable(sub.GetName(), fmt.Sprintf("no operators found in channel %s of package %s in the catalog referenced by subscription %s", sub.Spec.Channel, sub.Spec.Package, sub.GetName()))
 		case ncsv == 0:
 			si = NewInvalidSubscriptionInstallable(sub.GetName(), fmt.Sprintf("no operators found with name %s in channel %s of package %s in the catalog referenced by subscription %s", sub.Spec.StartingCSV, sub.Spec.Channel, sub.Spec.Package, sub.GetName()))
+		case nrange == 0 && current == nil:
+			// An installed operator remains acceptable when
+			// nothing in its channel is within range.
+			si = NewInvalidSubscriptionInstallable(sub.GetName(), fmt.Sprintf("no operators found in version range %s of channel %s of package %s in the catalog referenced by subscription %s", versionRangeStr, sub.Spec.Channel, sub.Spec.Package, sub.GetName()))
 		}
 
 		if si != nil {
@@ -250,7 +518,8 @@ func (r *SatResolver) getSubscriptionInstallables(sub *v1alpha1.Subscription, cu
 		if err != nil {
 			return nil, err
 		}
-		sortedBundles = append(sortedBundles, channel...)
+		// deprecated bundles are only chosen if nothing else in their channel satisfies the subscription
+		sortedBundles = append(sortedBundles, preferNotDeprecated(channel)...)
 
 		if i != len(entries) {
 			lastChannel = entries[i].Channel()
@@ -393,9 +662,13 @@ func (r *SatResolver) getBundleInstallables(preferredNamespace string, bundleSta
 				bundleDependencies = append(bundleDependencies, i.Identifier())
 				bundleStack = append(bundleStack, b)
 			}
+			message := fmt.Sprintf("bundle %s requires an operator %s", bundle.Name, d.String())
+			if fm, ok := d.(failureMessager); ok && fm.FailureMessage() != "" {
+				message = fmt.Sprintf("%s: %s", message, fm.FailureMessage())
+			}
 			bundleInstallable.AddConstraint(PrettyConstraint(
 				solver.Dependency(bundleDependencies...),
-				fmt.Sprintf("bundle %s requires an operator %s", bundle.Name, d.String()),
+				message,
 			))
 		}
 
@@ -612,11 +885,19 @@ func (r *SatResolver) sortBundles(bundles []*cache.Entry) ([]*cache.Entry, error
 	}
 
 	for catalog := range partitionedBundles {
+		// deprecated channels and packages are tried after the others, within the default channel preference
+		deprecated := map[PackageChannel]bool{}
+		for channel, bundles := range partitionedBundles[catalog] {
+			deprecated[channel] = len(cache.Filter(bundles, cache.Not(cache.DeprecatedPredicate()))) == 0
+		}
 		sort.SliceStable(channelOrder[catalog], func(i, j int) bool {
 			pi, pj := channelOrder[catalog][i], channelOrder[catalog][j]
 			if pi.DefaultChannel != pj.DefaultChannel {
 				return pi.DefaultChannel
 			}
+			if deprecated[pi] != deprecated[pj] {
+				return !deprecated[pi]
+			}
 			if pi.Package != pj.Package {
 				return pi.Package < pj.Package
 			}
@@ -627,7 +908,8 @@ func (r *SatResolver) sortBundles(bundles []*cache.Entry) ([]*cache.Entry, error
 			if err != nil {
 				return nil, err
 			}
-			partitionedBundles[catalog][channel] = sorted
+			// deprecation reorders a channel, it doesn't override the channel preference
+			partitionedBundles[catalog][channel] = preferNotDeprecated(sorted)
 		}
 	}
 	all := make([]*cache.Entry, 0)
@@ -639,6 +921,23 @@ func (r *SatResolver) sortBundles(bundles []*cache.Entry) ([]*cache.Entry, error
 	return all, nil
 }
 
+// preferNotDeprecated moves the deprecated bundles after the others,
+// keeping the relative order of both.
+func preferNotDeprecated(bundles []*cache.Entry) []*cache.Entry {
+	var preferred, deprecated []*cache.Entry
+	for _, b := range bundles {
+		if cache.DeprecatedPredicate().Test(b) {
+			deprecated = append(deprecated, b)
+		} else {
+			preferred = append(preferred, b)
+		}
+	}
+	if len(deprecated) == 0 {
+		return bundles
+	}
+	return append(preferred, deprecated...)
+}
+
 // Sorts bundle in a channel by replaces. All entries in the argument
 // are assumed to have the same Package and Channel.
 func sortChannel(bundles []*cache.Entry) ([]*cache.Entry, error) {
@@ -763,6 +1062,7 @@ var predicates = map[string]func(string) (cache.Predicate, error){
 	"olm.gvk.required":     predicateForRequiredGVKProperty,
 	"olm.package.required": predicateForRequiredPackageProperty,
 	"olm.label.required":   predicateForRequiredLabelProperty,
+	constraintPropertyType: predicateForConstraintProperty,
 }
 
 func predicateForRequiredGVKProperty(value string) (cache.Predicate, error) {
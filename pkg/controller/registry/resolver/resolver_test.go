@@ -1,6 +1,8 @@
 package resolver
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"testing"
@@ -13,13 +15,17 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	toolscache "k8s.io/client-go/tools/cache"
 
 	"github.com/operator-framework/api/pkg/lib/version"
 	opver "github.com/operator-framework/api/pkg/lib/version"
 	"github.com/operator-framework/api/pkg/operators/v1alpha1"
+	operatorsv2 "github.com/operator-framework/api/pkg/operators/v2"
 	listersv1alpha1 "github.com/operator-framework/operator-lifecycle-manager/pkg/api/client/listers/operators/v1alpha1"
+	listersv2 "github.com/operator-framework/operator-lifecycle-manager/pkg/api/client/listers/operators/v2"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver/cache"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver/solver"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/operatorcondition"
 	"github.com/operator-framework/operator-registry/pkg/api"
 	opregistry "github.com/operator-framework/operator-registry/pkg/registry"
 )
@@ -49,7 +55,7 @@ func TestSolveOperators(t *testing.T) {
 		log: logrus.New(),
 	}
 
-	operators, err := satResolver.SolveOperators([]string{namespace}, csvs, subs)
+	operators, err := satResolver.SolveOperators(context.TODO(), []string{namespace}, csvs, subs)
 	assert.NoError(t, err)
 
 	expected := cache.OperatorSet{
@@ -58,6 +64,133 @@ func TestSolveOperators(t *testing.T) {
 	require.EqualValues(t, expected, operators)
 }
 
+func TestSolveOperators_ResolutionPreference(t *testing.T) {
+	const namespace = "test-namespace"
+	catalog := cache.SourceKey{Name: "test-catalog", Namespace: namespace}
+
+	csv := existingOperator(namespace, "packageA.v1", "packageA", "alpha", "", nil, nil, nil, nil)
+	csvs := []*v1alpha1.ClusterServiceVersion{csv}
+	subs := []*v1alpha1.Subscription{
+		existingSub(namespace, "packageA.v1", "packageA", "alpha", catalog),
+		newSub(namespace, "packageB", "alpha", catalog),
+	}
+
+	entries := func() []*cache.Entry {
+		return []*cache.Entry{
+			genOperator("packageA.v1", "0.0.1", "", "packageA", "alpha", catalog.Name, catalog.Namespace, nil, nil, nil, "", false),
+			genOperator("packageA.v2", "0.0.2", "packageA.v1", "packageA", "alpha", catalog.Name, catalog.Namespace, nil, nil, nil, "", false),
+			genOperator("packageB.v1", "1.0.1", "", "packageB", "alpha", catalog.Name, catalog.Namespace, nil, nil, nil, "", false),
+		}
+	}
+
+	for _, tt := range []struct {
+		name       string
+		preference ResolutionPreference
+		expected   []string
+	}{
+		{
+			name:       "channel head upgrades installed operators",
+			preference: PreferChannelHead,
+			expected:   []string{"packageA.v2", "packageB.v1"},
+		},
+		{
+			name:       "minimal change leaves installed operators alone",
+			preference: PreferMinimalChange,
+			expected:   []string{"packageB.v1"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			satResolver := SatResolver{
+				cache: cache.New(cache.StaticSourceProvider{
+					catalog: &cache.Snapshot{Entries: entries()},
+				}),
+				log:        logrus.New(),
+				preference: tt.preference,
+			}
+
+			operators, err := satResolver.SolveOperators(context.TODO(), []string{namespace}, csvs, subs)
+			require.NoError(t, err)
+
+			var names []string
+			for name := range operators {
+				names = append(names, name)
+			}
+			assert.ElementsMatch(t, tt.expected, names)
+		})
+	}
+}
+
+type failingSource struct {
+	err error
+}
+
+func (s failingSource) Snapshot(context.Context) (*cache.Snapshot, error) {
+	return nil, s.err
+}
+
+func TestSolveOperators_UnneededCatalogErrors(t *testing.T) {
+	const namespace = "test-namespace"
+	catalog := cache.SourceKey{Name: "test-catalog", Namespace: namespace}
+	broken := cache.SourceKey{Name: "broken-catalog", Namespace: namespace}
+
+	sources := cache.StaticSourceProvider{
+		catalog: &cache.Snapshot{
+			Entries: []*cache.Entry{
+				genOperator("packageB.v1", "1.0.1", "", "packageB", "alpha", catalog.Name, catalog.Namespace, nil, nil, nil, "", false),
+			},
+		},
+		broken: failingSource{err: errors.New("connection refused")},
+	}
+
+	for _, tt := range []struct {
+		name    string
+		ignore  bool
+		subs    []*v1alpha1.Subscription
+		wantErr string
+	}{
+		{
+			name:    "Fail",
+			subs:    []*v1alpha1.Subscription{newSub(namespace, "packageB", "alpha", catalog)},
+			wantErr: "error using catalog broken-catalog (in namespace test-namespace): connection refused",
+		},
+		{
+			name:   "IgnoreUnneeded",
+			ignore: true,
+			subs:   []*v1alpha1.Subscription{newSub(namespace, "packageB", "alpha", catalog)},
+		},
+		{
+			name:    "FailNeeded",
+			ignore:  true,
+			subs:    []*v1alpha1.Subscription{newSub(namespace, "packageB", "alpha", catalog), newSub(namespace, "packageC", "alpha", broken)},
+			wantErr: "error using catalog broken-catalog (in namespace test-namespace): connection refused",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			satResolver := SatResolver{
+				cache:                       cache.New(sources),
+				log:                         logrus.New(),
+				ignoreUnneededCatalogErrors: tt.ignore,
+			}
+			operators, err := satResolver.SolveOperators(context.TODO(), []string{namespace}, nil, tt.subs)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Contains(t, operators, "packageB.v1")
+		})
+	}
+}
+
+func TestParseResolutionPreference(t *testing.T) {
+	p, err := ParseResolutionPreference("minimal-change")
+	require.NoError(t, err)
+	assert.Equal(t, PreferMinimalChange, p)
+
+	_, err = ParseResolutionPreference("fastest")
+	assert.EqualError(t, err, `unknown resolution preference "fastest", must be one of "channel-head" or "minimal-change"`)
+}
+
 func TestDisjointChannelGraph(t *testing.T) {
 	const namespace = "test-namespace"
 	catalog := cache.SourceKey{Name: "test-catalog", Namespace: namespace}
@@ -79,7 +212,7 @@ func TestDisjointChannelGraph(t *testing.T) {
 		log: logrus.New(),
 	}
 
-	_, err := satResolver.SolveOperators([]string{namespace}, nil, subs)
+	_, err := satResolver.SolveOperators(context.TODO(), []string{namespace}, nil, subs)
 	require.Error(t, err, "a unique replacement chain within a channel is required to determine the relative order between channel entries, but 2 replacement chains were found in channel \"alpha\" of package \"packageA\": packageA.side1.v2...packageA.side1.v1, packageA.side2.v2...packageA.side2.v1")
 }
 
@@ -107,7 +240,7 @@ func TestPropertiesAnnotationHonored(t *testing.T) {
 		log: logrus.New(),
 	}
 
-	operators, err := satResolver.SolveOperators([]string{"olm"}, csvs, subs)
+	operators, err := satResolver.SolveOperators(context.TODO(), []string{"olm"}, csvs, subs)
 	assert.NoError(t, err)
 
 	expected := cache.OperatorSet{
@@ -142,7 +275,7 @@ func TestSolveOperators_MultipleChannels(t *testing.T) {
 		log: logrus.New(),
 	}
 
-	operators, err := satResolver.SolveOperators([]string{"olm"}, csvs, subs)
+	operators, err := satResolver.SolveOperators(context.TODO(), []string{"olm"}, csvs, subs)
 	assert.NoError(t, err)
 	expected := cache.OperatorSet{
 		"packageB.v1": genOperator("packageB.v1", "1.0.0", "", "packageB", "alpha", "community", "olm", nil, nil, nil, "", false),
@@ -183,7 +316,7 @@ func TestSolveOperators_FindLatestVersion(t *testing.T) {
 		log: logrus.New(),
 	}
 
-	operators, err := satResolver.SolveOperators([]string{"olm"}, csvs, subs)
+	operators, err := satResolver.SolveOperators(context.TODO(), []string{"olm"}, csvs, subs)
 	assert.NoError(t, err)
 	assert.Equal(t, 2, len(operators))
 	for _, op := range operators {
@@ -243,7 +376,7 @@ func TestSolveOperators_FindLatestVersionWithDependencies(t *testing.T) {
 		log: logrus.New(),
 	}
 
-	operators, err := satResolver.SolveOperators([]string{"olm"}, csvs, subs)
+	operators, err := satResolver.SolveOperators(context.TODO(), []string{"olm"}, csvs, subs)
 	assert.NoError(t, err)
 	assert.Equal(t, 4, len(operators))
 
@@ -308,7 +441,7 @@ func TestSolveOperators_FindLatestVersionWithNestedDependencies(t *testing.T) {
 		log: logrus.New(),
 	}
 
-	operators, err := satResolver.SolveOperators([]string{"olm"}, csvs, subs)
+	operators, err := satResolver.SolveOperators(context.TODO(), []string{"olm"}, csvs, subs)
 	assert.NoError(t, err)
 	assert.Equal(t, 5, len(operators))
 
@@ -409,7 +542,7 @@ func TestSolveOperators_CatsrcPrioritySorting(t *testing.T) {
 		})),
 	}
 
-	operators, err := satResolver.SolveOperators([]string{"olm"}, []*v1alpha1.ClusterServiceVersion{}, subs)
+	operators, err := satResolver.SolveOperators(context.TODO(), []string{"olm"}, []*v1alpha1.ClusterServiceVersion{}, subs)
 	assert.NoError(t, err)
 	expected := cache.OperatorSet{
 		"packageA.v1": genOperator("packageA.v1", "0.0.1", "", "packageA", "alpha", "community", "olm",
@@ -458,7 +591,7 @@ func TestSolveOperators_CatsrcPrioritySorting(t *testing.T) {
 		})),
 	}
 
-	operators, err = satResolver.SolveOperators([]string{"olm"}, []*v1alpha1.ClusterServiceVersion{}, subs)
+	operators, err = satResolver.SolveOperators(context.TODO(), []string{"olm"}, []*v1alpha1.ClusterServiceVersion{}, subs)
 	assert.NoError(t, err)
 	expected = cache.OperatorSet{
 		"packageA.v1": genOperator("packageA.v1", "0.0.1", "", "packageA", "alpha", "community", "olm",
@@ -488,7 +621,7 @@ func TestSolveOperators_CatsrcPrioritySorting(t *testing.T) {
 		cache: cache.New(ssp),
 	}
 
-	operators, err = satResolver.SolveOperators([]string{"olm"}, []*v1alpha1.ClusterServiceVersion{}, subs)
+	operators, err = satResolver.SolveOperators(context.TODO(), []string{"olm"}, []*v1alpha1.ClusterServiceVersion{}, subs)
 	assert.NoError(t, err)
 	expected = cache.OperatorSet{
 		"packageA.v1": genOperator("packageA.v1", "0.0.1", "", "packageA", "alpha", "community", "olm",
@@ -536,7 +669,7 @@ func TestSolveOperators_WithDependencies(t *testing.T) {
 		log: logrus.New(),
 	}
 
-	operators, err := satResolver.SolveOperators([]string{"olm"}, csvs, subs)
+	operators, err := satResolver.SolveOperators(context.TODO(), []string{"olm"}, csvs, subs)
 	assert.NoError(t, err)
 	assert.Equal(t, 3, len(operators))
 
@@ -586,7 +719,7 @@ func TestSolveOperators_WithGVKDependencies(t *testing.T) {
 		log: logrus.New(),
 	}
 
-	operators, err := satResolver.SolveOperators([]string{"olm"}, csvs, subs)
+	operators, err := satResolver.SolveOperators(context.TODO(), []string{"olm"}, csvs, subs)
 	assert.NoError(t, err)
 
 	expected := cache.OperatorSet{
@@ -637,7 +770,7 @@ func TestSolveOperators_WithLabelDependencies(t *testing.T) {
 		}),
 	}
 
-	operators, err := satResolver.SolveOperators([]string{"olm"}, nil, subs)
+	operators, err := satResolver.SolveOperators(context.TODO(), []string{"olm"}, nil, subs)
 	assert.NoError(t, err)
 	assert.Equal(t, 2, len(operators))
 
@@ -676,11 +809,75 @@ func TestSolveOperators_WithUnsatisfiableLabelDependencies(t *testing.T) {
 		}),
 	}
 
-	operators, err := satResolver.SolveOperators([]string{"olm"}, nil, subs)
+	operators, err := satResolver.SolveOperators(context.TODO(), []string{"olm"}, nil, subs)
 	assert.Error(t, err)
 	assert.Equal(t, 0, len(operators))
 }
 
+func TestSolveOperators_WithConstraintDependencies(t *testing.T) {
+	namespace := "olm"
+	catalog := cache.SourceKey{"community", namespace}
+
+	newSub := newSub(namespace, "packageA", "alpha", catalog)
+	subs := []*v1alpha1.Subscription{newSub}
+
+	constraint := func(value string) *api.Property {
+		return &api.Property{Type: "olm.constraint", Value: value}
+	}
+
+	for _, tt := range []struct {
+		name       string
+		constraint *api.Property
+		expected   []string
+		err        string
+	}{
+		{
+			name:       "AnySatisfiedBySecond",
+			constraint: constraint(`{"any":{"constraints":[{"package":{"packageName":"packageB","versionRange":">=2.0.0"}},{"package":{"packageName":"packageC","versionRange":">=1.0.0"}}]}}`),
+			expected:   []string{"packageA", "packageC.v1"},
+		},
+		{
+			name:       "AllWithNot",
+			constraint: constraint(`{"all":{"constraints":[{"property":{"type":"olm.package","value":{"packageName":"packageB"}}},{"not":{"package":{"packageName":"packageB","versionRange":">=2.0.0"}}}]}}`),
+			expected:   []string{"packageA", "packageB.v1"},
+		},
+		{
+			name:       "Unsatisfiable",
+			constraint: constraint(`{"failureMessage":"packageB 2.0 or later is required","package":{"packageName":"packageB","versionRange":">=2.0.0"}}`),
+			err:        "packageB 2.0 or later is required",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			operatorA := genOperator("packageA", "0.0.1", "", "packageA", "alpha", "community", "olm", nil, nil, nil, "", false)
+			operatorA.Properties = append(operatorA.Properties, tt.constraint)
+
+			satResolver := SatResolver{
+				cache: cache.New(cache.StaticSourceProvider{
+					catalog: &cache.Snapshot{
+						Entries: []*cache.Entry{
+							operatorA,
+							genOperator("packageB.v1", "1.0.0", "", "packageB", "alpha", "community", "olm", nil, nil, nil, "", false),
+							genOperator("packageC.v1", "1.0.0", "", "packageC", "alpha", "community", "olm", nil, nil, nil, "", false),
+						},
+					},
+				}),
+			}
+
+			operators, err := satResolver.SolveOperators(context.TODO(), []string{"olm"}, nil, subs)
+			if tt.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, len(tt.expected), len(operators))
+			for _, name := range tt.expected {
+				assert.NotNil(t, operators[name], name)
+			}
+		})
+	}
+}
+
 func TestSolveOperators_WithNestedGVKDependencies(t *testing.T) {
 	APISet := cache.APISet{opregistry.APIKey{"g", "v", "k", "ks"}: struct{}{}}
 	Provides := APISet
@@ -737,7 +934,7 @@ func TestSolveOperators_WithNestedGVKDependencies(t *testing.T) {
 		log: logrus.New(),
 	}
 
-	operators, err := satResolver.SolveOperators([]string{"olm"}, csvs, subs)
+	operators, err := satResolver.SolveOperators(context.TODO(), []string{"olm"}, csvs, subs)
 	assert.NoError(t, err)
 	assert.Equal(t, 4, len(operators))
 	expected := cache.OperatorSet{
@@ -804,7 +1001,7 @@ func TestSolveOperators_IgnoreUnsatisfiableDependencies(t *testing.T) {
 		log: logrus.New(),
 	}
 
-	operators, err := satResolver.SolveOperators([]string{"olm"}, csvs, subs)
+	operators, err := satResolver.SolveOperators(context.TODO(), []string{"olm"}, csvs, subs)
 	assert.NoError(t, err)
 	expected := cache.OperatorSet{
 		"packageB.v1": genOperator("packageB.v1", "1.0.0", "", "packageB", "alpha", "community", "olm", nil, nil, opToAddVersionDeps, "", false),
@@ -849,7 +1046,7 @@ func TestSolveOperators_PreferCatalogInSameNamespace(t *testing.T) {
 		log: logrus.New(),
 	}
 
-	operators, err := satResolver.SolveOperators([]string{namespace}, csvs, subs)
+	operators, err := satResolver.SolveOperators(context.TODO(), []string{namespace}, csvs, subs)
 	assert.NoError(t, err)
 
 	expected := cache.OperatorSet{
@@ -884,7 +1081,7 @@ func TestSolveOperators_ResolveOnlyInCachedNamespaces(t *testing.T) {
 		log: logrus.New(),
 	}
 
-	operators, err := satResolver.SolveOperators([]string{namespace}, csvs, subs)
+	operators, err := satResolver.SolveOperators(context.TODO(), []string{namespace}, csvs, subs)
 	assert.Error(t, err)
 	assert.Equal(t, err.Error(), "expected exactly one operator, got 0", "did not expect to receive a resolution")
 	assert.Len(t, operators, 0)
@@ -918,7 +1115,7 @@ func TestSolveOperators_PreferDefaultChannelInResolution(t *testing.T) {
 		log: logrus.New(),
 	}
 
-	operators, err := satResolver.SolveOperators([]string{namespace}, csvs, subs)
+	operators, err := satResolver.SolveOperators(context.TODO(), []string{namespace}, csvs, subs)
 	assert.NoError(t, err)
 
 	// operator should be from the default stable channel
@@ -956,7 +1153,7 @@ func TestSolveOperators_PreferDefaultChannelInResolutionForTransitiveDependencie
 		log: logrus.New(),
 	}
 
-	operators, err := satResolver.SolveOperators([]string{namespace}, csvs, subs)
+	operators, err := satResolver.SolveOperators(context.TODO(), []string{namespace}, csvs, subs)
 	assert.NoError(t, err)
 
 	// operator should be from the default stable channel
@@ -998,7 +1195,7 @@ func TestSolveOperators_SubscriptionlessOperatorsSatisfyDependencies(t *testing.
 		log: logrus.New(),
 	}
 
-	operators, err := satResolver.SolveOperators([]string{"olm"}, csvs, subs)
+	operators, err := satResolver.SolveOperators(context.TODO(), []string{"olm"}, csvs, subs)
 	assert.NoError(t, err)
 	expected := cache.OperatorSet{
 		"packageB.v1.0.1": genOperator("packageB.v1.0.1", "1.0.1", "packageB.v1.0.0", "packageB", "alpha", catalog.Name, catalog.Namespace, Provides, nil, apiSetToDependencies(Provides, nil), "", false),
@@ -1034,7 +1231,7 @@ func TestSolveOperators_SubscriptionlessOperatorsCanConflict(t *testing.T) {
 		log: logrus.New(),
 	}
 
-	_, err := satResolver.SolveOperators([]string{"olm"}, csvs, subs)
+	_, err := satResolver.SolveOperators(context.TODO(), []string{"olm"}, csvs, subs)
 	assert.Error(t, err)
 }
 
@@ -1076,7 +1273,7 @@ func TestSolveOperators_PackageCannotSelfSatisfy(t *testing.T) {
 		log: logrus.New(),
 	}
 
-	operators, err := satResolver.SolveOperators([]string{"olm"}, nil, subs)
+	operators, err := satResolver.SolveOperators(context.TODO(), []string{"olm"}, nil, subs)
 	assert.NoError(t, err)
 	expected := cache.OperatorSet{
 		"opA.v1.0.0": genOperator("opA.v1.0.0", "1.0.0", "", "packageA", "stable", catalog.Name, catalog.Namespace, RequiresBoth, nil, nil, "", false),
@@ -1174,7 +1371,7 @@ func TestSolveOperators_TransferApiOwnership(t *testing.T) {
 			}
 
 			var err error
-			operators, err = satResolver.SolveOperators([]string{"olm"}, csvs, p.subs)
+			operators, err = satResolver.SolveOperators(context.TODO(), []string{"olm"}, csvs, p.subs)
 			assert.NoError(t, err)
 			for k := range p.expected {
 				require.NotNil(t, operators[k])
@@ -1240,7 +1437,7 @@ func TestSolveOperators_WithoutDeprecated(t *testing.T) {
 		log: logrus.New(),
 	}
 
-	operators, err := satResolver.SolveOperators([]string{catalog.Namespace}, nil, subs)
+	operators, err := satResolver.SolveOperators(context.TODO(), []string{catalog.Namespace}, nil, subs)
 	assert.Empty(t, operators)
 	assert.IsType(t, solver.NotSatisfiable{}, err)
 }
@@ -1265,12 +1462,145 @@ func TestSolveOperatorsWithDeprecatedInnerChannelEntry(t *testing.T) {
 		log: logger,
 	}
 
-	operators, err := resolver.SolveOperators([]string{catalog.Namespace}, nil, subs)
+	operators, err := resolver.SolveOperators(context.TODO(), []string{catalog.Namespace}, nil, subs)
 	assert.NoError(t, err)
 	assert.Len(t, operators, 1)
 	assert.Contains(t, operators, "a-3")
 }
 
+func withDeprecation(o *cache.Entry, scope, message string) *cache.Entry {
+	value, err := json.Marshal(cache.DeprecationProperty{Scope: scope, Message: message})
+	if err != nil {
+		panic(err)
+	}
+	o.Properties = append(o.Properties, &api.Property{Type: cache.DeprecationType, Value: string(value)})
+	return o
+}
+
+func TestSolveOperatorsPrefersNotDeprecated(t *testing.T) {
+	catalog := cache.SourceKey{Name: "catalog", Namespace: "namespace"}
+	APISet := cache.APISet{opregistry.APIKey{Group: "g", Version: "v", Kind: "k", Plural: "ks"}: struct{}{}}
+
+	for _, tt := range []struct {
+		name     string
+		entries  []*cache.Entry
+		expected []string
+	}{
+		{
+			name: "DeprecatedHeadWithAlternative",
+			entries: []*cache.Entry{
+				genOperator("a-1", "1.0.0", "", "a", "c", catalog.Name, catalog.Namespace, nil, nil, nil, "", false),
+				withDeprecation(genOperator("a-2", "2.0.0", "a-1", "a", "c", catalog.Name, catalog.Namespace, nil, nil, nil, "", false), cache.DeprecationScopeBundle, "a-2 is broken"),
+			},
+			expected: []string{"a-1"},
+		},
+		{
+			name: "DeprecatedChannelWithoutAlternative",
+			entries: []*cache.Entry{
+				withDeprecation(genOperator("a-1", "1.0.0", "", "a", "c", catalog.Name, catalog.Namespace, nil, nil, nil, "", false), cache.DeprecationScopeChannel, ""),
+				withDeprecation(genOperator("a-2", "2.0.0", "a-1", "a", "c", catalog.Name, catalog.Namespace, nil, nil, nil, "", false), cache.DeprecationScopeChannel, ""),
+			},
+			expected: []string{"a-2"},
+		},
+		{
+			name: "DeprecatedDependency",
+			entries: []*cache.Entry{
+				genOperator("a-1", "1.0.0", "", "a", "c", catalog.Name, catalog.Namespace, APISet, nil, nil, "", false),
+				withDeprecation(genOperator("b-1", "1.0.0", "", "b", "c", catalog.Name, catalog.Namespace, nil, APISet, nil, "", false), cache.DeprecationScopePackage, "use c"),
+				genOperator("c-1", "1.0.0", "", "c", "c", catalog.Name, catalog.Namespace, nil, APISet, nil, "", false),
+			},
+			expected: []string{"a-1", "c-1"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := SatResolver{
+				cache: cache.New(cache.StaticSourceProvider{
+					catalog: &cache.Snapshot{Entries: tt.entries},
+				}),
+				log: logrus.New(),
+			}
+
+			operators, err := resolver.SolveOperators(context.TODO(), []string{catalog.Namespace}, nil, []*v1alpha1.Subscription{
+				newSub(catalog.Namespace, "a", "c", catalog),
+			})
+			require.NoError(t, err)
+			var names []string
+			for name := range operators {
+				names = append(names, name)
+			}
+			assert.ElementsMatch(t, tt.expected, names)
+		})
+	}
+}
+
+func TestSortBundlesDeprecatedWithinChannel(t *testing.T) {
+	catalog := cache.SourceKey{Name: "catalog", Namespace: "namespace"}
+	bundles := []*cache.Entry{
+		genOperator("b-1", "1.0.0", "", "b", "alpha", catalog.Name, catalog.Namespace, nil, nil, nil, "stable", false),
+		genOperator("b-1-stable", "1.0.0", "", "b", "stable", catalog.Name, catalog.Namespace, nil, nil, nil, "stable", false),
+		withDeprecation(genOperator("b-2-stable", "2.0.0", "b-1-stable", "b", "stable", catalog.Name, catalog.Namespace, nil, nil, nil, "stable", false), cache.DeprecationScopeBundle, "b-2 is broken"),
+	}
+
+	sorted, err := (&SatResolver{}).sortBundles(bundles)
+	require.NoError(t, err)
+	var names []string
+	for _, b := range sorted {
+		names = append(names, b.Name)
+	}
+	// the default channel still comes first, with its deprecated bundle last
+	require.Equal(t, []string{"b-1-stable", "b-2-stable", "b-1"}, names)
+}
+
+func TestSubscriptionDeprecations(t *testing.T) {
+	catalog := cache.SourceKey{Name: "catalog", Namespace: "namespace"}
+	resolver := SatResolver{
+		cache: cache.New(cache.StaticSourceProvider{
+			catalog: &cache.Snapshot{
+				Entries: []*cache.Entry{
+					withDeprecation(genOperator("a-1", "1.0.0", "", "a", "stable", catalog.Name, catalog.Namespace, nil, nil, nil, "stable", false), cache.DeprecationScopeBundle, "a-1 is broken"),
+					genOperator("a-2", "2.0.0", "a-1", "a", "stable", catalog.Name, catalog.Namespace, nil, nil, nil, "stable", false),
+					withDeprecation(genOperator("a-1", "1.0.0", "", "a", "alpha", catalog.Name, catalog.Namespace, nil, nil, nil, "stable", false), cache.DeprecationScopeChannel, ""),
+					withDeprecation(genOperator("b-1", "1.0.0", "", "b", "stable", catalog.Name, catalog.Namespace, nil, nil, nil, "stable", false), cache.DeprecationScopePackage, "use a"),
+				},
+			},
+		}),
+		log: logrus.New(),
+	}
+
+	for _, tt := range []struct {
+		name     string
+		sub      *v1alpha1.Subscription
+		expected cache.Deprecations
+	}{
+		{
+			name:     "NotInstalled",
+			sub:      newSub(catalog.Namespace, "a", "stable", catalog),
+			expected: cache.Deprecations{},
+		},
+		{
+			name:     "InstalledBundle",
+			sub:      updatedSub(catalog.Namespace, "a-2", "a-1", "a", "stable", catalog),
+			expected: cache.Deprecations{Bundle: "a-1 is broken"},
+		},
+		{
+			name:     "Channel",
+			sub:      updatedSub(catalog.Namespace, "a-1", "a-1", "a", "alpha", catalog),
+			expected: cache.Deprecations{Channel: "channel alpha is deprecated", Bundle: "a-1 is broken"},
+		},
+		{
+			name:     "PackageInDefaultChannel",
+			sub:      newSub(catalog.Namespace, "b", "", catalog),
+			expected: cache.Deprecations{Package: "use a"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			deprecations, err := resolver.SubscriptionDeprecations([]string{catalog.Namespace}, tt.sub)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, deprecations)
+		})
+	}
+}
+
 func TestSolveOperators_WithSkipsAndStartingCSV(t *testing.T) {
 	APISet := cache.APISet{opregistry.APIKey{"g", "v", "k", "ks"}: struct{}{}}
 	Provides := APISet
@@ -1311,7 +1641,7 @@ func TestSolveOperators_WithSkipsAndStartingCSV(t *testing.T) {
 		log: logrus.New(),
 	}
 
-	operators, err := satResolver.SolveOperators([]string{"olm"}, nil, subs)
+	operators, err := satResolver.SolveOperators(context.TODO(), []string{"olm"}, nil, subs)
 	assert.NoError(t, err)
 	opB.SourceInfo.StartingCSV = "packageB.v1"
 	expected := cache.OperatorSet{
@@ -1343,7 +1673,7 @@ func TestSolveOperators_WithSkips(t *testing.T) {
 		log: logrus.New(),
 	}
 
-	operators, err := satResolver.SolveOperators([]string{namespace}, nil, subs)
+	operators, err := satResolver.SolveOperators(context.TODO(), []string{namespace}, nil, subs)
 	assert.NoError(t, err)
 	expected := cache.OperatorSet{
 		"packageB.v2": opB2,
@@ -1376,10 +1706,95 @@ func TestSolveOperatorsWithSkipsPreventingSelection(t *testing.T) {
 		log: logger,
 	}
 
-	_, err := satResolver.SolveOperators([]string{namespace}, nil, subs)
+	_, err := satResolver.SolveOperators(context.TODO(), []string{namespace}, nil, subs)
 	assert.IsType(t, solver.NotSatisfiable{}, err)
 }
 
+func TestSolveOperators_WithVersionRange(t *testing.T) {
+	const namespace = "test-namespace"
+	catalog := cache.SourceKey{Name: "test-catalog", Namespace: namespace}
+
+	entries := func() []*cache.Entry {
+		return []*cache.Entry{
+			genOperator("a-1", "1.0.0", "", "a", "channel", catalog.Name, catalog.Namespace, nil, nil, nil, "", false),
+			genOperator("a-2", "2.0.0", "a-1", "a", "channel", catalog.Name, catalog.Namespace, nil, nil, nil, "", false),
+			genOperator("a-3", "3.0.0", "a-2", "a", "channel", catalog.Name, catalog.Namespace, nil, nil, nil, "", false),
+		}
+	}
+
+	installed := existingOperator(namespace, "a-1", "a", "channel", "", nil, nil, nil, nil)
+	installed.Spec.Version = opver.OperatorVersion{Version: semver.MustParse("1.0.0")}
+	installedSub := existingSub(namespace, "a-1", "a", "channel", catalog)
+	installedSub.SetAnnotations(map[string]string{VersionRangeAnnotationKey: "<2.0.0"})
+
+	for _, tt := range []struct {
+		name     string
+		csvs     []*v1alpha1.ClusterServiceVersion
+		subs     []*v1alpha1.Subscription
+		expected []string
+		err      string
+	}{
+		{
+			name:     "latest bundle within range is installed",
+			subs:     []*v1alpha1.Subscription{newSub(namespace, "a", "channel", catalog, withVersionRange(">=1.0.0 <3.0.0"))},
+			expected: []string{"a-2"},
+		},
+		{
+			name:     "exclusion range leaves a gap in the channel",
+			subs:     []*v1alpha1.Subscription{newSub(namespace, "a", "channel", catalog, withVersionRange("!=2.0.0"))},
+			expected: []string{"a-3"},
+		},
+		{
+			name:     "range with a gap",
+			subs:     []*v1alpha1.Subscription{newSub(namespace, "a", "channel", catalog, withVersionRange("<2.0.0 || >=3.0.0"))},
+			expected: []string{"a-3"},
+		},
+		{
+			name:     "range excluding the channel head",
+			subs:     []*v1alpha1.Subscription{newSub(namespace, "a", "channel", catalog, withVersionRange("<2.0.0 || >2.0.0 <3.0.0"))},
+			expected: []string{"a-1"},
+		},
+		{
+			name: "upgrade outside of range is blocked",
+			csvs: []*v1alpha1.ClusterServiceVersion{installed},
+			subs: []*v1alpha1.Subscription{installedSub},
+		},
+		{
+			name: "no bundle within range",
+			subs: []*v1alpha1.Subscription{newSub(namespace, "a", "channel", catalog, withVersionRange(">4.0.0"))},
+			err:  "no operators found in version range >4.0.0 of channel channel of package a in the catalog referenced by subscription a-channel",
+		},
+		{
+			name: "invalid range",
+			subs: []*v1alpha1.Subscription{newSub(namespace, "a", "channel", catalog, withVersionRange("one-ish"))},
+			err:  `subscription a-channel has an invalid version range "one-ish" in annotation operatorframework.io/version-range`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			satResolver := SatResolver{
+				cache: cache.New(cache.StaticSourceProvider{
+					catalog: &cache.Snapshot{Entries: entries()},
+				}),
+				log: logrus.New(),
+			}
+
+			operators, err := satResolver.SolveOperators(context.TODO(), []string{namespace}, tt.csvs, tt.subs)
+			if tt.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.err)
+				return
+			}
+			require.NoError(t, err)
+
+			var names []string
+			for name := range operators {
+				names = append(names, name)
+			}
+			assert.ElementsMatch(t, tt.expected, names)
+		})
+	}
+}
+
 func TestSolveOperatorsWithClusterServiceVersionHavingDependency(t *testing.T) {
 	const namespace = "test-namespace"
 	catalog := cache.SourceKey{Name: "test-catalog", Namespace: namespace}
@@ -1411,7 +1826,7 @@ func TestSolveOperatorsWithClusterServiceVersionHavingDependency(t *testing.T) {
 		log: log,
 	}
 
-	operators, err := r.SolveOperators([]string{namespace}, csvs, subs)
+	operators, err := r.SolveOperators(context.TODO(), []string{namespace}, csvs, subs)
 	assert.NoError(t, err)
 	require.Empty(t, operators)
 }
@@ -2067,3 +2482,85 @@ func TestNewOperatorFromCSV(t *testing.T) {
 		})
 	}
 }
+
+func TestSolveOperatorsHonorsOperatorConditionGates(t *testing.T) {
+	const namespace = "olm"
+	catalog := cache.SourceKey{Name: "community", Namespace: namespace}
+
+	for _, tt := range []struct {
+		name       string
+		conditions []metav1.Condition
+		// expected is empty when the installed operator isn't upgraded
+		expected []string
+	}{
+		{
+			name:     "NoGates",
+			expected: []string{"a-2"},
+		},
+		{
+			name: "PendingMigration",
+			conditions: []metav1.Condition{
+				{Type: operatorcondition.MigrationConditionPrefix + "storage", Status: metav1.ConditionFalse},
+			},
+		},
+		{
+			name: "CompletedMigration",
+			conditions: []metav1.Condition{
+				{Type: operatorcondition.MigrationConditionPrefix + "storage", Status: metav1.ConditionTrue},
+			},
+			expected: []string{"a-2"},
+		},
+		{
+			name: "VersionRangeExcludesUpgrade",
+			conditions: []metav1.Condition{
+				{Type: operatorcondition.UpgradeableVersionRange, Status: metav1.ConditionTrue, Message: "<2.0.0"},
+			},
+		},
+		{
+			name: "VersionRangeIncludesUpgrade",
+			conditions: []metav1.Condition{
+				{Type: operatorcondition.UpgradeableVersionRange, Status: metav1.ConditionTrue, Message: "<3.0.0"},
+			},
+			expected: []string{"a-2"},
+		},
+		{
+			// Upgradeable is enforced when the new CSV is installed.
+			name: "NotUpgradeable",
+			conditions: []metav1.Condition{
+				{Type: operatorsv2.Upgradeable, Status: metav1.ConditionFalse},
+			},
+			expected: []string{"a-2"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			indexer := toolscache.NewIndexer(toolscache.MetaNamespaceKeyFunc, toolscache.Indexers{toolscache.NamespaceIndex: toolscache.MetaNamespaceIndexFunc})
+			require.NoError(t, indexer.Add(&operatorsv2.OperatorCondition{
+				ObjectMeta: metav1.ObjectMeta{Name: "a-1", Namespace: namespace},
+				Status:     operatorsv2.OperatorConditionStatus{Conditions: tt.conditions},
+			}))
+
+			resolver := SatResolver{
+				cache: cache.New(cache.StaticSourceProvider{
+					catalog: &cache.Snapshot{
+						Entries: []*cache.Entry{
+							genOperator("a-1", "1.0.0", "", "a", "alpha", catalog.Name, catalog.Namespace, nil, nil, nil, "", false),
+							genOperator("a-2", "2.0.0", "a-1", "a", "alpha", catalog.Name, catalog.Namespace, nil, nil, nil, "", false),
+						},
+					},
+				}),
+				log:                     logrus.New(),
+				operatorConditionLister: listersv2.NewOperatorConditionLister(indexer),
+			}
+
+			operators, err := resolver.SolveOperators(context.TODO(), []string{namespace},
+				[]*v1alpha1.ClusterServiceVersion{existingOperator(namespace, "a-1", "a", "alpha", "", nil, nil, nil, nil)},
+				[]*v1alpha1.Subscription{existingSub(namespace, "a-1", "a", "alpha", catalog)})
+			require.NoError(t, err)
+			var names []string
+			for name := range operators {
+				names = append(names, name)
+			}
+			assert.ElementsMatch(t, tt.expected, names)
+		})
+	}
+}
@@ -15,6 +15,7 @@ type Constraint interface {
 	apply(c *logic.C, lm *litMapping, subject Identifier) z.Lit
 	order() []Identifier
 	anchor() bool
+	identifiers() []Identifier
 }
 
 // zeroConstraint is returned by ConstraintOf in error cases.
@@ -38,6 +39,10 @@ func (zeroConstraint) anchor() bool {
 	return false
 }
 
+func (zeroConstraint) identifiers() []Identifier {
+	return nil
+}
+
 // AppliedConstraint values compose a single Constraint with the
 // Installable it applies to.
 type AppliedConstraint struct {
@@ -51,6 +56,13 @@ func (a AppliedConstraint) String() string {
 	return a.Constraint.String(a.Installable.Identifier())
 }
 
+// Identifiers returns the Identifiers of the Installables referenced
+// by the receiver's Constraint, not including the Installable to
+// which the Constraint is applied.
+func (a AppliedConstraint) Identifiers() []Identifier {
+	return a.Constraint.identifiers()
+}
+
 type mandatory struct{}
 
 func (constraint mandatory) String(subject Identifier) string {
@@ -65,6 +77,10 @@ func (constraint mandatory) order() []Identifier {
 	return nil
 }
 
+func (constraint mandatory) identifiers() []Identifier {
+	return nil
+}
+
 func (constraint mandatory) anchor() bool {
 	return true
 }
@@ -89,6 +105,10 @@ func (constraint prohibited) order() []Identifier {
 	return nil
 }
 
+func (constraint prohibited) identifiers() []Identifier {
+	return nil
+}
+
 func (constraint prohibited) anchor() bool {
 	return false
 }
@@ -126,6 +146,10 @@ func (constraint dependency) order() []Identifier {
 	return constraint
 }
 
+func (constraint dependency) identifiers() []Identifier {
+	return constraint
+}
+
 func (constraint dependency) anchor() bool {
 	return false
 }
@@ -153,6 +177,10 @@ func (constraint conflict) order() []Identifier {
 	return nil
 }
 
+func (constraint conflict) identifiers() []Identifier {
+	return []Identifier{Identifier(constraint)}
+}
+
 func (constraint conflict) anchor() bool {
 	return false
 }
@@ -189,6 +217,10 @@ func (constraint leq) order() []Identifier {
 	return nil
 }
 
+func (constraint leq) identifiers() []Identifier {
+	return constraint.ids
+}
+
 func (constraint leq) anchor() bool {
 	return false
 }
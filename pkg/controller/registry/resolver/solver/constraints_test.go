@@ -37,3 +37,45 @@ func TestOrder(t *testing.T) {
 		})
 	}
 }
+
+func TestIdentifiers(t *testing.T) {
+	type tc struct {
+		Name       string
+		Constraint Constraint
+		Expected   []Identifier
+	}
+
+	for _, tt := range []tc{
+		{
+			Name:       "mandatory",
+			Constraint: Mandatory(),
+		},
+		{
+			Name:       "prohibited",
+			Constraint: Prohibited(),
+		},
+		{
+			Name:       "dependency",
+			Constraint: Dependency("a", "b", "c"),
+			Expected:   []Identifier{"a", "b", "c"},
+		},
+		{
+			Name:       "conflict",
+			Constraint: Conflict("a"),
+			Expected:   []Identifier{"a"},
+		},
+		{
+			Name:       "atmost",
+			Constraint: AtMost(1, "a", "b"),
+			Expected:   []Identifier{"a", "b"},
+		},
+	} {
+		t.Run(tt.Name, func(t *testing.T) {
+			a := AppliedConstraint{
+				Installable: installable("x"),
+				Constraint:  tt.Constraint,
+			}
+			assert.Equal(t, tt.Expected, a.Identifiers())
+		})
+	}
+}
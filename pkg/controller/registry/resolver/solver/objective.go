@@ -0,0 +1,122 @@
+package solver
+
+import (
+	"context"
+
+	"github.com/go-air/gini/z"
+)
+
+// Objective assigns a non-negative cost to each Installable. When a
+// Solver is configured with Objectives, it only considers solutions
+// whose total cost (the sum of the costs of the selected
+// Installables) is minimal before applying its usual input-order
+// preferences.
+type Objective interface {
+	Cost(Installable) int
+}
+
+// ObjectiveFunc adapts an ordinary function to the Objective
+// interface.
+type ObjectiveFunc func(Installable) int
+
+func (f ObjectiveFunc) Cost(i Installable) int {
+	return f(i)
+}
+
+// Minimize returns an Objective that assigns a cost of one to each of
+// the Installables identified by the given Identifiers, and zero to
+// all others. It prefers solutions that contain as few of them as
+// possible.
+func Minimize(ids ...Identifier) Objective {
+	set := make(map[Identifier]struct{}, len(ids))
+	for _, id := range ids {
+		set[id] = struct{}{}
+	}
+	return ObjectiveFunc(func(i Installable) int {
+		if _, ok := set[i.Identifier()]; ok {
+			return 1
+		}
+		return 0
+	})
+}
+
+// minimize computes, for each of the solver's objectives in turn,
+// the lowest total cost that is satisfiable given the provided
+// assumptions and the bounds found for all previous objectives. The
+// returned literals bound each objective to its minimum cost and
+// should be assumed for the remainder of the solve. Objectives are
+// therefore optimized lexicographically: earlier objectives take
+// precedence over later ones.
+//
+// Each objective is first solved without a bound: if that fails, no
+// solution exists at any cost. Otherwise the cost of the solution
+// found is an upper bound on the minimum, which is then found by
+// binary search, so that an objective takes O(log N) solves rather
+// than one per possible cost.
+//
+// Like CardinalityConstrainer, this must not be called in a test
+// context. If ctx is done before an objective's minimum is found, that
+// objective and all later ones are left unbounded.
+func (s *solver) minimize(ctx context.Context, assumptions []z.Lit) []z.Lit {
+	var bounds []z.Lit
+	for _, objective := range s.objectives {
+		var ms []z.Lit
+		for _, installable := range s.litMap.inorder {
+			m := s.litMap.LitOf(installable.Identifier())
+			// A weight is expressed by repeating the
+			// literal in the input to the sorting
+			// network.
+			for w := objective.Cost(installable); w > 0; w-- {
+				ms = append(ms, m)
+			}
+		}
+		if len(ms) == 0 {
+			continue
+		}
+
+		cs := s.litMap.CardinalityConstrainer(s.g, ms)
+		solve := func(bound z.Lit) int {
+			s.g.Assume(assumptions...)
+			s.g.Assume(bounds...)
+			s.litMap.AssumeConstraints(s.g)
+			if bound != z.LitNull {
+				s.g.Assume(bound)
+			}
+			return solveContext(ctx, s.g)
+		}
+		if solve(z.LitNull) != satisfiable {
+			// Either no solution exists at any cost or the
+			// solve was cancelled. Leave it to the search
+			// to report the conflict or cancellation.
+			break
+		}
+		lo, hi := 0, s.cost(ms)
+		for lo < hi {
+			mid := lo + (hi-lo)/2
+			switch solve(cs.Leq(mid)) {
+			case satisfiable:
+				// The solution found may cost less
+				// than the bound.
+				hi = s.cost(ms)
+			case unsatisfiable:
+				lo = mid + 1
+			default:
+				return bounds
+			}
+		}
+		bounds = append(bounds, cs.Leq(hi))
+	}
+	return bounds
+}
+
+// cost returns the number of the given literals that are true in the
+// last solution found.
+func (s *solver) cost(ms []z.Lit) int {
+	var c int
+	for _, m := range ms {
+		if s.g.Value(m) {
+			c++
+		}
+	}
+	return c
+}
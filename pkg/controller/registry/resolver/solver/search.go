@@ -167,7 +167,15 @@ func (h *search) Do(ctx context.Context, anchors []z.Lit) (int, []z.Lit, map[z.L
 		// have been made to decide whether to end or
 		// backtrack.
 		if h.headChoice == nil && h.result == unknown {
-			h.result = h.s.Solve()
+			h.result = solveContext(ctx, h.s)
+		}
+
+		if ctx.Err() != nil {
+			// Record how far the search got before
+			// giving up.
+			h.result = unknown
+			h.tracer.Trace(h)
+			break
 		}
 
 		// Backtrack if possible, otherwise end.
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/go-air/gini"
 	"github.com/go-air/gini/inter"
@@ -34,10 +35,11 @@ type Solver interface {
 }
 
 type solver struct {
-	g      inter.S
-	litMap *litMapping
-	tracer Tracer
-	buffer []z.Lit
+	g          inter.S
+	litMap     *litMapping
+	tracer     Tracer
+	objectives []Objective
+	buffer     []z.Lit
 }
 
 const (
@@ -46,6 +48,40 @@ const (
 	unknown       = 0
 )
 
+// maxSolvePollInterval bounds the interval at which an in-progress
+// call to Solve is checked for completion when it can be cancelled.
+const maxSolvePollInterval = 10 * time.Millisecond
+
+// solveContext is like g.Solve, but gives up and returns unknown once
+// ctx is done.
+func solveContext(ctx context.Context, g inter.S) int {
+	if ctx.Done() == nil {
+		return g.Solve()
+	}
+	if ctx.Err() != nil {
+		return unknown
+	}
+	gs := g.GoSolve()
+	t := time.NewTimer(time.Microsecond)
+	defer t.Stop()
+	for wait := time.Microsecond; ; {
+		if result, ok := gs.Test(); ok {
+			return result
+		}
+		select {
+		case <-ctx.Done():
+			return gs.Stop()
+		case <-t.C:
+		}
+		// Most calls complete almost immediately, so poll
+		// frequently at first and back off from there.
+		if wait < maxSolvePollInterval {
+			wait *= 2
+		}
+		t.Reset(wait)
+	}
+}
+
 // Solve takes a slice containing all Installables and returns a slice
 // containing only those Installables that were selected for
 // installation. If no solution is possible, or if the provided
@@ -69,9 +105,13 @@ func (s *solver) Solve(ctx context.Context) (result []Installable, err error) {
 		assumptions = append(assumptions, s.litMap.LitOf(anchor))
 	}
 
+	// bound each objective to its minimum cost
+	bounds := s.minimize(ctx, assumptions)
+
 	// assume that all constraints hold
 	s.litMap.AssumeConstraints(s.g)
 	s.g.Assume(assumptions...)
+	s.g.Assume(bounds...)
 
 	var aset map[z.Lit]struct{}
 	// push a new test scope with the baseline assumptions, to prevent them from being cleared during search
@@ -79,7 +119,7 @@ func (s *solver) Solve(ctx context.Context) (result []Installable, err error) {
 	if outcome != satisfiable && outcome != unsatisfiable {
 		// searcher for solutions in input order, so that preferences
 		// can be taken into acount (i.e. prefer one catalog to another)
-		outcome, assumptions, aset = (&search{s: s.g, lits: s.litMap, tracer: s.tracer}).Do(context.Background(), assumptions)
+		outcome, assumptions, aset = (&search{s: s.g, lits: s.litMap, tracer: s.tracer}).Do(ctx, assumptions)
 	}
 	switch outcome {
 	case satisfiable:
@@ -98,13 +138,17 @@ func (s *solver) Solve(ctx context.Context) (result []Installable, err error) {
 		s.g.Untest()
 		cs := s.litMap.CardinalityConstrainer(s.g, extras)
 		s.g.Assume(assumptions...)
+		s.g.Assume(bounds...)
 		s.g.Assume(excluded...)
 		s.litMap.AssumeConstraints(s.g)
 		_, s.buffer = s.g.Test(s.buffer)
 		for w := 0; w <= cs.N(); w++ {
 			s.g.Assume(cs.Leq(w))
-			if s.g.Solve() == satisfiable {
+			switch solveContext(ctx, s.g) {
+			case satisfiable:
 				return s.litMap.Installables(s.g), nil
+			case unknown:
+				return nil, Incomplete
 			}
 		}
 		// Something is wrong if we can't find a model anymore
@@ -144,6 +188,16 @@ func WithTracer(t Tracer) Option {
 	}
 }
 
+// WithObjectives configures the Solver to minimize the total cost
+// of a solution under each of the given Objectives, in order, before
+// considering preferences expressed by input order.
+func WithObjectives(objectives ...Objective) Option {
+	return func(s *solver) error {
+		s.objectives = append(s.objectives, objectives...)
+		return nil
+	}
+}
+
 var defaults = []Option{
 	func(s *solver) error {
 		if s.litMap == nil {
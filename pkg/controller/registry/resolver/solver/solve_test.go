@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"math/bits"
 	"reflect"
 	"sort"
 	"testing"
 
+	"github.com/go-air/gini/inter"
+	"github.com/go-air/gini/z"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -89,6 +92,7 @@ func TestSolve(t *testing.T) {
 	type tc struct {
 		Name         string
 		Installables []Installable
+		Objectives   []Objective
 		Installed    []Identifier
 		Error        error
 	}
@@ -294,12 +298,65 @@ func TestSolve(t *testing.T) {
 			},
 			Installed: []Identifier{"a", "x1", "y1"},
 		},
+		{
+			Name: "objective overrides preference for earlier dependency",
+			Installables: []Installable{
+				installable("a", Mandatory(), Dependency("b", "c")),
+				installable("b"),
+				installable("c"),
+			},
+			Objectives: []Objective{Minimize("b")},
+			Installed:  []Identifier{"a", "c"},
+		},
+		{
+			Name: "objective prefers fewer installables over input order",
+			Installables: []Installable{
+				installable("a", Mandatory(), Dependency("b", "c")),
+				installable("b", Dependency("d")),
+				installable("c"),
+				installable("d"),
+			},
+			Objectives: []Objective{Minimize("a", "b", "c", "d")},
+			Installed:  []Identifier{"a", "c"},
+		},
+		{
+			Name: "earlier objectives take precedence over later objectives",
+			Installables: []Installable{
+				installable("a", Mandatory(), Dependency("b", "c")),
+				installable("b"),
+				installable("c"),
+			},
+			Objectives: []Objective{Minimize("c"), Minimize("b")},
+			Installed:  []Identifier{"a", "b"},
+		},
+		{
+			Name: "objective does not hide conflicts",
+			Installables: []Installable{
+				installable("a", Mandatory(), Dependency("b")),
+				installable("b", Prohibited()),
+			},
+			Objectives: []Objective{Minimize("b")},
+			Error: NotSatisfiable{
+				{
+					Installable: installable("a", Mandatory(), Dependency("b")),
+					Constraint:  Mandatory(),
+				},
+				{
+					Installable: installable("a", Mandatory(), Dependency("b")),
+					Constraint:  Dependency("b"),
+				},
+				{
+					Installable: installable("b", Prohibited()),
+					Constraint:  Prohibited(),
+				},
+			},
+		},
 	} {
 		t.Run(tt.Name, func(t *testing.T) {
 			assert := assert.New(t)
 
 			var traces bytes.Buffer
-			s, err := New(WithInput(tt.Installables), WithTracer(LoggingTracer{Writer: &traces}))
+			s, err := New(WithInput(tt.Installables), WithObjectives(tt.Objectives...), WithTracer(LoggingTracer{Writer: &traces}))
 			if err != nil {
 				t.Fatalf("failed to initialize solver: %s", err)
 			}
@@ -354,6 +411,76 @@ func TestSolve(t *testing.T) {
 	}
 }
 
+func TestSolveCancelled(t *testing.T) {
+	var traces bytes.Buffer
+	s, err := New(WithInput([]Installable{
+		installable("a", Mandatory(), Dependency("b", "c")),
+		installable("b", Dependency("d", "e")),
+		installable("c", Dependency("d", "e")),
+		installable("d"),
+		installable("e"),
+	}), WithTracer(LoggingTracer{Writer: &traces}))
+	if err != nil {
+		t.Fatalf("failed to initialize solver: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	installed, err := s.Solve(ctx)
+	assert.Nil(t, installed)
+	assert.Equal(t, Incomplete, err)
+	// The position at which the search was abandoned is traced.
+	assert.Contains(t, traces.String(), "Assumptions:\n")
+}
+
+// countingS counts the calls to Solve of the wrapped solver.
+type countingS struct {
+	inter.S
+	solves int
+}
+
+func (c *countingS) Solve() int {
+	c.solves++
+	return c.S.Solve()
+}
+
+func TestMinimizeSolveCount(t *testing.T) {
+	// The mandatory installable depends on n others, so the
+	// minimum cost is n.
+	const n = 40
+	input := []Installable{}
+	var constraints []Constraint
+	var ids []Identifier
+	for i := 0; i < n; i++ {
+		id := Identifier(fmt.Sprintf("b%d", i))
+		ids = append(ids, id)
+		constraints = append(constraints, Dependency(id))
+		input = append(input, installable(id))
+	}
+	input = append(input, installable("a", append(constraints, Mandatory())...))
+
+	s, err := New(WithInput(input), WithObjectives(Minimize(ids...)))
+	if err != nil {
+		t.Fatalf("failed to initialize solver: %s", err)
+	}
+	installed, err := s.Solve(context.TODO())
+	assert.NoError(t, err)
+	assert.Len(t, installed, n+1)
+
+	s, err = New(WithInput(input), WithObjectives(Minimize(ids...)))
+	if err != nil {
+		t.Fatalf("failed to initialize solver: %s", err)
+	}
+	g := &countingS{S: s.(*solver).g}
+	s.(*solver).g = g
+	s.(*solver).litMap.AddConstraints(g)
+	bounds := s.(*solver).minimize(context.TODO(), []z.Lit{s.(*solver).litMap.LitOf("a")})
+	assert.Len(t, bounds, 1)
+	// One unbounded solve, then a binary search over [0, n].
+	assert.LessOrEqual(t, g.solves, 1+bits.Len(n+1))
+}
+
 func TestDuplicateIdentifier(t *testing.T) {
 	_, err := New(WithInput([]Installable{
 		installable("a"),
@@ -0,0 +1,516 @@
+package resolver
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/operator-framework/operator-registry/pkg/api"
+	opregistry "github.com/operator-framework/operator-registry/pkg/registry"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver/cache"
+)
+
+const (
+	fbcSchemaPackage      = "olm.package"
+	fbcSchemaBundle       = "olm.bundle"
+	fbcSchemaChannel      = "olm.channel"
+	fbcSchemaDeprecations = "olm.deprecations"
+
+	fbcPropertyChannel      = "olm.channel"
+	fbcPropertySkips        = "olm.skips"
+	fbcPropertySkipRange    = "olm.skipRange"
+	fbcPropertyBundleObject = "olm.bundle.object"
+	fbcPropertyGVKRequired  = "olm.gvk.required"
+)
+
+// FileBasedCatalog is the content of a file-based (declarative
+// config) catalog, as read from olm.package, olm.bundle and
+// olm.deprecations documents.
+type FileBasedCatalog struct {
+	Packages     []FileBasedPackage
+	Bundles      []FileBasedBundle
+	Deprecations []FileBasedDeprecations
+}
+
+// FileBasedPackage is an olm.package document.
+type FileBasedPackage struct {
+	Schema         string `json:"schema"`
+	Name           string `json:"name"`
+	DefaultChannel string `json:"defaultChannel"`
+}
+
+// FileBasedBundle is an olm.bundle document.
+type FileBasedBundle struct {
+	Schema     string              `json:"schema"`
+	Name       string              `json:"name"`
+	Package    string              `json:"package"`
+	Image      string              `json:"image"`
+	Properties []FileBasedProperty `json:"properties,omitempty"`
+}
+
+// FileBasedProperty is a single typed property of an olm.bundle
+// document.
+type FileBasedProperty struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// FileBasedDeprecations is an olm.deprecations document, holding the
+// deprecation notices of a package, its channels and its bundles.
+type FileBasedDeprecations struct {
+	Schema  string                      `json:"schema"`
+	Package string                      `json:"package"`
+	Entries []FileBasedDeprecationEntry `json:"entries"`
+}
+
+// FileBasedDeprecationEntry is the deprecation notice of the package,
+// the channel or the bundle it references.
+type FileBasedDeprecationEntry struct {
+	Reference struct {
+		// Schema is olm.package, olm.channel or olm.bundle.
+		Schema string `json:"schema"`
+		// Name is the name of the channel or bundle, and is
+		// empty for the package.
+		Name string `json:"name,omitempty"`
+	} `json:"reference"`
+	Message string `json:"message"`
+}
+
+// LoadFileBasedCatalog reads every JSON and YAML file in fsys and
+// collects the olm.package, olm.bundle and olm.deprecations documents
+// they contain.
+// Documents with any other schema are ignored.
+func LoadFileBasedCatalog(fsys fs.FS) (*FileBasedCatalog, error) {
+	var fbc FileBasedCatalog
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		switch filepath.Ext(path) {
+		case ".json", ".yaml", ".yml":
+		default:
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+		if err := fbc.load(bytes.NewReader(data)); err != nil {
+			return fmt.Errorf("error loading %s: %w", path, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &fbc, nil
+}
+
+// LoadFileBasedCatalogFiles collects the olm.package, olm.bundle and
+// olm.deprecations documents of the JSON and YAML files among the given file contents,
+// keyed by file name. Files are read in the order of their names.
+func LoadFileBasedCatalogFiles(files map[string][]byte) (*FileBasedCatalog, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		switch filepath.Ext(name) {
+		case ".json", ".yaml", ".yml":
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var fbc FileBasedCatalog
+	for _, name := range names {
+		if err := fbc.load(bytes.NewReader(files[name])); err != nil {
+			return nil, fmt.Errorf("error loading %s: %w", name, err)
+		}
+	}
+	return &fbc, nil
+}
+
+func (c *FileBasedCatalog) load(r io.Reader) error {
+	dec := utilyaml.NewYAMLOrJSONDecoder(r, 4096)
+	for {
+		var doc json.RawMessage
+		if err := dec.Decode(&doc); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if len(doc) == 0 {
+			continue
+		}
+
+		var meta struct {
+			Schema string `json:"schema"`
+		}
+		if err := json.Unmarshal(doc, &meta); err != nil {
+			return err
+		}
+		switch meta.Schema {
+		case fbcSchemaPackage:
+			var p FileBasedPackage
+			if err := json.Unmarshal(doc, &p); err != nil {
+				return fmt.Errorf("failed to parse %s: %w", fbcSchemaPackage, err)
+			}
+			c.Packages = append(c.Packages, p)
+		case fbcSchemaBundle:
+			var b FileBasedBundle
+			if err := json.Unmarshal(doc, &b); err != nil {
+				return fmt.Errorf("failed to parse %s: %w", fbcSchemaBundle, err)
+			}
+			c.Bundles = append(c.Bundles, b)
+		case fbcSchemaDeprecations:
+			var d FileBasedDeprecations
+			if err := json.Unmarshal(doc, &d); err != nil {
+				return fmt.Errorf("failed to parse %s: %w", fbcSchemaDeprecations, err)
+			}
+			for _, e := range d.Entries {
+				switch e.Reference.Schema {
+				case fbcSchemaPackage, fbcSchemaChannel, fbcSchemaBundle:
+				default:
+					return fmt.Errorf("invalid %s of package %q: unknown reference schema %q", fbcSchemaDeprecations, d.Package, e.Reference.Schema)
+				}
+			}
+			c.Deprecations = append(c.Deprecations, d)
+		}
+	}
+}
+
+// APIBundles converts the receiver's bundles into registry API
+// bundles, one for each channel a bundle belongs to, mirroring the
+// output of the registry's ListBundles. The deprecation notices that
+// apply to each of them are added to their properties.
+func (c *FileBasedCatalog) APIBundles() ([]*api.Bundle, error) {
+	var result []*api.Bundle
+	for _, b := range c.Bundles {
+		bundles, err := b.apiBundles()
+		if err != nil {
+			return nil, fmt.Errorf("invalid bundle %q in package %q: %w", b.Name, b.Package, err)
+		}
+		for _, bundle := range bundles {
+			deprecations, err := c.deprecationProperties(bundle)
+			if err != nil {
+				return nil, fmt.Errorf("invalid bundle %q in package %q: %w", b.Name, b.Package, err)
+			}
+			if len(deprecations) > 0 {
+				// the properties are shared by the bundles of all channels
+				bundle.Properties = append(append([]*api.Property{}, bundle.Properties...), deprecations...)
+			}
+		}
+		result = append(result, bundles...)
+	}
+	return result, nil
+}
+
+// deprecationProperties returns the olm.deprecation properties of the
+// notices for the package, the channel and the name of the given bundle.
+func (c *FileBasedCatalog) deprecationProperties(b *api.Bundle) ([]*api.Property, error) {
+	var properties []*api.Property
+	for _, d := range c.Deprecations {
+		if d.Package != b.PackageName {
+			continue
+		}
+		for _, e := range d.Entries {
+			prop := cache.DeprecationProperty{Scope: e.Reference.Schema, Message: e.Message}
+			switch e.Reference.Schema {
+			case fbcSchemaChannel:
+				if e.Reference.Name != b.ChannelName {
+					continue
+				}
+				prop.Channel = e.Reference.Name
+			case fbcSchemaBundle:
+				if e.Reference.Name != b.CsvName {
+					continue
+				}
+			}
+			value, err := json.Marshal(prop)
+			if err != nil {
+				return nil, err
+			}
+			properties = append(properties, &api.Property{
+				Type:  cache.DeprecationType,
+				Value: string(value),
+			})
+		}
+	}
+	return properties, nil
+}
+
+// DefaultChannels returns the default channel of each package in the
+// receiver, keyed by package name.
+func (c *FileBasedCatalog) DefaultChannels() map[string]string {
+	defaults := make(map[string]string, len(c.Packages))
+	for _, p := range c.Packages {
+		defaults[p.Name] = p.DefaultChannel
+	}
+	return defaults
+}
+
+func (b FileBasedBundle) apiBundles() ([]*api.Bundle, error) {
+	var (
+		version, skipRange, csvJson string
+		skips, objects              []string
+		provided, required          []*api.GroupVersionKind
+		properties                  []*api.Property
+	)
+
+	type channel struct {
+		Name     string `json:"name"`
+		Replaces string `json:"replaces"`
+	}
+	var channels []channel
+
+	for _, p := range b.Properties {
+		switch p.Type {
+		case fbcPropertyChannel:
+			var ch channel
+			if err := json.Unmarshal(p.Value, &ch); err != nil {
+				return nil, fmt.Errorf("failed to parse %s property: %w", p.Type, err)
+			}
+			channels = append(channels, ch)
+			continue
+		case fbcPropertySkips:
+			var skip string
+			if err := json.Unmarshal(p.Value, &skip); err != nil {
+				return nil, fmt.Errorf("failed to parse %s property: %w", p.Type, err)
+			}
+			skips = append(skips, skip)
+			continue
+		case fbcPropertySkipRange:
+			if err := json.Unmarshal(p.Value, &skipRange); err != nil {
+				return nil, fmt.Errorf("failed to parse %s property: %w", p.Type, err)
+			}
+			continue
+		case fbcPropertyBundleObject:
+			var obj struct {
+				Data string `json:"data"`
+			}
+			if err := json.Unmarshal(p.Value, &obj); err != nil {
+				return nil, fmt.Errorf("failed to parse %s property: %w", p.Type, err)
+			}
+			manifest, err := bundleObjectManifest(obj.Data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s property: %w", p.Type, err)
+			}
+			if manifest.GetKind() == "ClusterServiceVersion" {
+				csvJson = manifest.content
+			}
+			objects = append(objects, manifest.content)
+			continue
+		case opregistry.PackageType:
+			var pkg opregistry.PackageProperty
+			if err := json.Unmarshal(p.Value, &pkg); err != nil {
+				return nil, fmt.Errorf("failed to parse %s property: %w", p.Type, err)
+			}
+			version = pkg.Version
+		case opregistry.GVKType, fbcPropertyGVKRequired:
+			var gvk opregistry.GVKProperty
+			if err := json.Unmarshal(p.Value, &gvk); err != nil {
+				return nil, fmt.Errorf("failed to parse %s property: %w", p.Type, err)
+			}
+			g := &api.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind}
+			if p.Type == fbcPropertyGVKRequired {
+				// Required APIs are projected back into
+				// properties by newOperatorFromBundle.
+				required = append(required, g)
+				continue
+			}
+			provided = append(provided, g)
+		}
+
+		var value bytes.Buffer
+		if err := json.Compact(&value, p.Value); err != nil {
+			return nil, fmt.Errorf("failed to parse %s property: %w", p.Type, err)
+		}
+		properties = append(properties, &api.Property{
+			Type:  p.Type,
+			Value: value.String(),
+		})
+	}
+
+	if len(channels) == 0 {
+		return nil, fmt.Errorf("bundle is not a member of any channel")
+	}
+
+	bundlePath := b.Image
+	if len(objects) > 0 {
+		// The manifests are embedded in the catalog, so the
+		// bundle does not have to be unpacked from its image.
+		bundlePath = ""
+	}
+
+	bundles := make([]*api.Bundle, len(channels))
+	for i, ch := range channels {
+		bundles[i] = &api.Bundle{
+			CsvName:      b.Name,
+			PackageName:  b.Package,
+			ChannelName:  ch.Name,
+			CsvJson:      csvJson,
+			Object:       objects,
+			BundlePath:   bundlePath,
+			ProvidedApis: provided,
+			RequiredApis: required,
+			Version:      version,
+			SkipRange:    skipRange,
+			Properties:   properties,
+			Replaces:     ch.Replaces,
+			Skips:        skips,
+		}
+	}
+	return bundles, nil
+}
+
+type bundleObject struct {
+	unstructured.Unstructured
+	content string
+}
+
+// bundleObjectManifest decodes the base64-encoded value of an
+// olm.bundle.object property.
+func bundleObjectManifest(data string) (*bundleObject, error) {
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, err
+	}
+	var obj bundleObject
+	if err := obj.UnmarshalJSON(raw); err != nil {
+		return nil, err
+	}
+	obj.content = string(raw)
+	return &obj, nil
+}
+
+// FileBasedSource is a cache.Source of the bundles of a file-based catalog.
+type FileBasedSource struct {
+	entries []*cache.Entry
+	skipped []string
+}
+
+// NewFileBasedSource returns a source whose snapshots contain the bundles of
+// the given file-based catalog, attributed to the catalog identified by key.
+// Entries are built once, so that bundles that can't be resolved, e.g.
+// because of an invalid olm.constraint property, are found here rather than
+// in every snapshot. Like the gRPC source, such bundles are left out of the
+// snapshots; Skipped reports them.
+func NewFileBasedSource(key cache.SourceKey, fbc *FileBasedCatalog) (*FileBasedSource, error) {
+	bundles, err := fbc.APIBundles()
+	if err != nil {
+		return nil, err
+	}
+	defaultChannels := fbc.DefaultChannels()
+	source := &FileBasedSource{}
+	skipped := map[string]struct{}{}
+	for _, b := range bundles {
+		o, err := newOperatorFromBundle(b, "", key, defaultChannels[b.PackageName])
+		if err != nil {
+			// a bundle is converted once per channel, report it once
+			if _, ok := skipped[b.PackageName+"/"+b.CsvName]; !ok {
+				skipped[b.PackageName+"/"+b.CsvName] = struct{}{}
+				source.skipped = append(source.skipped, fmt.Sprintf("bundle %q in package %q: %v", b.CsvName, b.PackageName, err))
+			}
+			continue
+		}
+		o.ProvidedAPIs = o.ProvidedAPIs.StripPlural()
+		o.RequiredAPIs = o.RequiredAPIs.StripPlural()
+		EnsurePackageProperty(o, b.PackageName, b.Version)
+		source.entries = append(source.entries, o)
+	}
+	return source, nil
+}
+
+// Skipped returns a description of each bundle of the catalog left out of
+// the snapshots because it couldn't be resolved.
+func (s *FileBasedSource) Skipped() []string {
+	return s.skipped
+}
+
+func (s *FileBasedSource) Snapshot(ctx context.Context) (*cache.Snapshot, error) {
+	return &cache.Snapshot{Entries: append([]*cache.Entry(nil), s.entries...)}, nil
+}
+
+// FileBasedSourceStore is a cache.SourceProvider of the file-based
+// catalogs served by the catalog operator itself.
+type FileBasedSourceStore struct {
+	mu       sync.RWMutex
+	sources  map[cache.SourceKey]fileBasedStoreEntry
+	onChange func(key cache.SourceKey)
+}
+
+type fileBasedStoreEntry struct {
+	source   cache.Source
+	revision string
+}
+
+var _ cache.SourceProvider = &FileBasedSourceStore{}
+
+// NewFileBasedSourceStore returns an empty FileBasedSourceStore. The
+// given function, if not nil, is called with the key of every catalog
+// whose content changes or is removed.
+func NewFileBasedSourceStore(onChange func(key cache.SourceKey)) *FileBasedSourceStore {
+	return &FileBasedSourceStore{
+		sources:  map[cache.SourceKey]fileBasedStoreEntry{},
+		onChange: onChange,
+	}
+}
+
+// Set serves the given source for the catalog identified by key. The
+// revision identifies the content of the source.
+func (s *FileBasedSourceStore) Set(key cache.SourceKey, source cache.Source, revision string) {
+	s.mu.Lock()
+	s.sources[key] = fileBasedStoreEntry{source: source, revision: revision}
+	s.mu.Unlock()
+	if s.onChange != nil {
+		s.onChange(key)
+	}
+}
+
+// Revision returns the revision of the source served for the catalog
+// identified by key, and whether one is served.
+func (s *FileBasedSourceStore) Revision(key cache.SourceKey) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entry, ok := s.sources[key]
+	return entry.revision, ok
+}
+
+// Remove stops serving the catalog identified by key.
+func (s *FileBasedSourceStore) Remove(key cache.SourceKey) {
+	s.mu.Lock()
+	_, ok := s.sources[key]
+	delete(s.sources, key)
+	s.mu.Unlock()
+	if ok && s.onChange != nil {
+		s.onChange(key)
+	}
+}
+
+// Sources returns the sources served for catalogs in the given
+// namespaces.
+func (s *FileBasedSourceStore) Sources(namespaces ...string) map[cache.SourceKey]cache.Source {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make(map[cache.SourceKey]cache.Source)
+	for key, entry := range s.sources {
+		for _, namespace := range namespaces {
+			if key.Namespace == namespace {
+				result[key] = entry.source
+				break
+			}
+		}
+	}
+	return result
+}
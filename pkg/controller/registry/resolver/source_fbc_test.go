@@ -0,0 +1,244 @@
+package resolver
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry/resolver/cache"
+)
+
+const testFileBasedCatalogYAML = `---
+schema: olm.package
+name: etcd
+defaultChannel: stable
+---
+schema: olm.bundle
+name: etcdoperator.v0.9.2
+package: etcd
+image: quay.io/etcd/bundle:v0.9.2
+properties:
+- type: olm.package
+  value:
+    packageName: etcd
+    version: 0.9.2
+- type: olm.channel
+  value:
+    name: stable
+- type: olm.gvk
+  value:
+    group: etcd.database.coreos.com
+    kind: EtcdCluster
+    version: v1beta2
+---
+schema: olm.bundle
+name: etcdoperator.v0.9.4
+package: etcd
+image: quay.io/etcd/bundle:v0.9.4
+properties:
+- type: olm.package
+  value:
+    packageName: etcd
+    version: 0.9.4
+- type: olm.channel
+  value:
+    name: stable
+    replaces: etcdoperator.v0.9.2
+- type: olm.channel
+  value:
+    name: alpha
+- type: olm.skipRange
+  value: <0.9.4
+- type: olm.gvk.required
+  value:
+    group: monitoring.coreos.com
+    kind: Prometheus
+    version: v1
+- type: olm.bundle.object
+  value:
+    data: ` + "%s" + `
+---
+schema: olm.channel
+name: ignored
+`
+
+func TestFileBasedSource(t *testing.T) {
+	csv := `{"apiVersion":"operators.coreos.com/v1alpha1","kind":"ClusterServiceVersion","metadata":{"name":"etcdoperator.v0.9.4"}}`
+	fsys := fstest.MapFS{
+		"etcd/catalog.yaml": &fstest.MapFile{
+			Data: []byte(fmt.Sprintf(testFileBasedCatalogYAML, base64.StdEncoding.EncodeToString([]byte(csv)))),
+		},
+		"README.md": &fstest.MapFile{
+			Data: []byte("not a catalog"),
+		},
+	}
+
+	fbc, err := LoadFileBasedCatalog(fsys)
+	require.NoError(t, err)
+	require.Len(t, fbc.Packages, 1)
+	require.Len(t, fbc.Bundles, 2)
+	assert.Equal(t, map[string]string{"etcd": "stable"}, fbc.DefaultChannels())
+
+	key := cache.SourceKey{Name: "fbc", Namespace: "olm"}
+	source, err := NewFileBasedSource(key, fbc)
+	require.NoError(t, err)
+
+	snapshot, err := source.Snapshot(context.Background())
+	require.NoError(t, err)
+	require.Len(t, snapshot.Entries, 3)
+
+	byChannel := make(map[string]*cache.Entry)
+	for _, e := range snapshot.Entries {
+		byChannel[e.Name+"/"+e.Channel()] = e
+	}
+
+	old := byChannel["etcdoperator.v0.9.2/stable"]
+	require.NotNil(t, old)
+	assert.True(t, old.SourceInfo.DefaultChannel)
+	assert.Equal(t, key, old.SourceInfo.Catalog)
+	assert.Equal(t, "quay.io/etcd/bundle:v0.9.2", old.BundlePath)
+	assert.Nil(t, old.Bundle)
+
+	head := byChannel["etcdoperator.v0.9.4/stable"]
+	require.NotNil(t, head)
+	assert.Equal(t, "etcdoperator.v0.9.2", head.Replaces)
+	assert.Equal(t, "0.9.4", head.Version.String())
+	assert.True(t, head.SkipRange != nil && head.SkipRange(*old.Version))
+	assert.Empty(t, head.BundlePath)
+	require.NotNil(t, head.Bundle)
+	assert.Equal(t, csv, head.Bundle.CsvJson)
+	assert.Equal(t, []string{csv}, head.Bundle.Object)
+	assert.Len(t, head.RequiredAPIs, 1)
+
+	alpha := byChannel["etcdoperator.v0.9.4/alpha"]
+	require.NotNil(t, alpha)
+	assert.False(t, alpha.SourceInfo.DefaultChannel)
+	assert.Empty(t, alpha.Replaces)
+}
+
+func TestFileBasedSourceRequiresChannel(t *testing.T) {
+	fsys := fstest.MapFS{
+		"catalog.json": &fstest.MapFile{
+			Data: []byte(`{"schema":"olm.bundle","name":"a.v1","package":"a","properties":[{"type":"olm.package","value":{"packageName":"a","version":"1.0.0"}}]}`),
+		},
+	}
+
+	fbc, err := LoadFileBasedCatalog(fsys)
+	require.NoError(t, err)
+
+	_, err = NewFileBasedSource(cache.SourceKey{Name: "fbc", Namespace: "olm"}, fbc)
+	require.EqualError(t, err, `invalid bundle "a.v1" in package "a": bundle is not a member of any channel`)
+}
+
+func TestFileBasedSourceSkipsInvalidBundles(t *testing.T) {
+	fbc, err := LoadFileBasedCatalogFiles(map[string][]byte{
+		"catalog.json": []byte(`{"schema":"olm.package","name":"a","defaultChannel":"stable"}
+{"schema":"olm.bundle","name":"a.v1","package":"a","properties":[{"type":"olm.package","value":{"packageName":"a","version":"1.0.0"}},{"type":"olm.channel","value":{"name":"stable"}}]}
+{"schema":"olm.bundle","name":"a.v2","package":"a","properties":[{"type":"olm.package","value":{"packageName":"a","version":"2.0.0"}},{"type":"olm.channel","value":{"name":"stable","replaces":"a.v1"}},{"type":"olm.channel","value":{"name":"fast"}},{"type":"olm.constraint","value":{"failureMessage":"no constraint"}}]}`),
+	})
+	require.NoError(t, err)
+
+	source, err := NewFileBasedSource(cache.SourceKey{Name: "fbc", Namespace: "olm"}, fbc)
+	require.NoError(t, err)
+	require.Len(t, source.Skipped(), 1)
+	assert.Contains(t, source.Skipped()[0], `bundle "a.v2" in package "a": invalid olm.constraint property`)
+
+	snapshot, err := source.Snapshot(context.Background())
+	require.NoError(t, err)
+	require.Len(t, snapshot.Entries, 1)
+	assert.Equal(t, "a.v1", snapshot.Entries[0].Name)
+}
+
+func TestLoadFileBasedCatalogFiles(t *testing.T) {
+	fbc, err := LoadFileBasedCatalogFiles(map[string][]byte{
+		"b/bundles.json": []byte(`{"schema":"olm.bundle","name":"a.v1","package":"a"}`),
+		"a/package.yaml": []byte("schema: olm.package\nname: a\ndefaultChannel: stable\n"),
+		"a/README.md":    []byte("not a catalog"),
+	})
+	require.NoError(t, err)
+	require.Len(t, fbc.Packages, 1)
+	require.Len(t, fbc.Bundles, 1)
+	assert.Equal(t, "a.v1", fbc.Bundles[0].Name)
+
+	_, err = LoadFileBasedCatalogFiles(map[string][]byte{"broken.json": []byte(`{"schema":`)})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "error loading broken.json")
+}
+
+func TestFileBasedSourceStore(t *testing.T) {
+	var changed []cache.SourceKey
+	store := NewFileBasedSourceStore(func(key cache.SourceKey) {
+		changed = append(changed, key)
+	})
+
+	a := cache.SourceKey{Name: "a", Namespace: "ns-a"}
+	b := cache.SourceKey{Name: "b", Namespace: "ns-b"}
+	store.Set(a, &cache.Snapshot{}, "1")
+	store.Set(b, &cache.Snapshot{}, "2")
+	assert.Equal(t, []cache.SourceKey{a, b}, changed)
+
+	revision, ok := store.Revision(a)
+	assert.True(t, ok)
+	assert.Equal(t, "1", revision)
+
+	assert.Len(t, store.Sources("ns-a"), 1)
+	assert.Contains(t, store.Sources("ns-a"), a)
+	assert.Len(t, store.Sources("ns-a", "ns-b"), 2)
+	assert.Empty(t, store.Sources("other"))
+
+	store.Remove(a)
+	store.Remove(a)
+	assert.Equal(t, []cache.SourceKey{a, b, a}, changed)
+	_, ok = store.Revision(a)
+	assert.False(t, ok)
+}
+
+func TestFileBasedSourceDeprecations(t *testing.T) {
+	csv := `{"apiVersion":"operators.coreos.com/v1alpha1","kind":"ClusterServiceVersion","metadata":{"name":"etcdoperator.v0.9.4"}}`
+	fbc, err := LoadFileBasedCatalogFiles(map[string][]byte{
+		"etcd/catalog.yaml": []byte(fmt.Sprintf(testFileBasedCatalogYAML, base64.StdEncoding.EncodeToString([]byte(csv)))),
+		"etcd/deprecations.yaml": []byte(`---
+schema: olm.deprecations
+package: etcd
+entries:
+- reference:
+    schema: olm.channel
+    name: alpha
+  message: alpha is no longer updated, use stable
+- reference:
+    schema: olm.bundle
+    name: etcdoperator.v0.9.2
+  message: etcdoperator.v0.9.2 has a known data loss bug
+`),
+	})
+	require.NoError(t, err)
+	require.Len(t, fbc.Deprecations, 1)
+
+	source, err := NewFileBasedSource(cache.SourceKey{Name: "fbc", Namespace: "olm"}, fbc)
+	require.NoError(t, err)
+	snapshot, err := source.Snapshot(context.Background())
+	require.NoError(t, err)
+
+	deprecations := make(map[string]cache.Deprecations)
+	for _, e := range snapshot.Entries {
+		d, err := e.Deprecations()
+		require.NoError(t, err)
+		deprecations[e.Name+"/"+e.Channel()] = d
+	}
+	assert.Equal(t, map[string]cache.Deprecations{
+		"etcdoperator.v0.9.2/stable": {Bundle: "etcdoperator.v0.9.2 has a known data loss bug"},
+		"etcdoperator.v0.9.4/stable": {},
+		"etcdoperator.v0.9.4/alpha":  {Channel: "alpha is no longer updated, use stable"},
+	}, deprecations)
+
+	_, err = LoadFileBasedCatalogFiles(map[string][]byte{
+		"deprecations.json": []byte(`{"schema":"olm.deprecations","package":"etcd","entries":[{"reference":{"schema":"olm.csv"}}]}`),
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown reference schema "olm.csv"`)
+}
@@ -61,7 +61,7 @@ func (s *registrySource) Snapshot(ctx context.Context) (*cache.Snapshot, error)
 		}
 		o, err := newOperatorFromBundle(b, "", s.key, defaultChannel)
 		if err != nil {
-			s.logger.Printf("failed to construct operator from bundle, continuing: %v", err)
+			s.logger.Printf("failed to construct operator from bundle %s, continuing: %v", b.CsvName, err)
 			continue
 		}
 		o.ProvidedAPIs = o.ProvidedAPIs.StripPlural()
@@ -152,6 +152,9 @@ func newOperatorFromBundle(bundle *api.Bundle, startingCSV string, sourceKey cac
 		}
 		properties = append(properties, ps...)
 	}
+	if err := validateConstraintProperties(properties); err != nil {
+		return nil, err
+	}
 
 	o := &cache.Entry{
 		Name:         bundle.CsvName,
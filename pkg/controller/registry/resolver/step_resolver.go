@@ -29,8 +29,9 @@ const (
 var timeNow = func() metav1.Time { return metav1.NewTime(time.Now().UTC()) }
 
 type StepResolver interface {
-	ResolveSteps(namespace string) ([]*v1alpha1.Step, []v1alpha1.BundleLookup, []*v1alpha1.Subscription, error)
+	ResolveSteps(ctx context.Context, namespace string) ([]*v1alpha1.Step, []v1alpha1.BundleLookup, []*v1alpha1.Subscription, error)
 	Expire(key cache.SourceKey)
+	Deprecations(sub *v1alpha1.Subscription) (cache.Deprecations, error)
 }
 
 type OperatorStepResolver struct {
@@ -47,7 +48,7 @@ type OperatorStepResolver struct {
 var _ StepResolver = &OperatorStepResolver{}
 
 func NewOperatorStepResolver(lister operatorlister.OperatorLister, client versioned.Interface, kubeclient kubernetes.Interface,
-	globalCatalogNamespace string, provider RegistryClientProvider, log logrus.FieldLogger) *OperatorStepResolver {
+	globalCatalogNamespace string, provider RegistryClientProvider, log logrus.FieldLogger, options ...SatResolverOption) *OperatorStepResolver {
 	return &OperatorStepResolver{
 		subLister:              lister.OperatorsV1alpha1().SubscriptionLister(),
 		csvLister:              lister.OperatorsV1alpha1().ClusterServiceVersionLister(),
@@ -55,7 +56,7 @@ func NewOperatorStepResolver(lister operatorlister.OperatorLister, client versio
 		client:                 client,
 		kubeclient:             kubeclient,
 		globalCatalogNamespace: globalCatalogNamespace,
-		satResolver:            NewDefaultSatResolver(SourceProviderFromRegistryClientProvider(provider, log), lister.OperatorsV1alpha1().CatalogSourceLister(), log),
+		satResolver:            NewDefaultSatResolver(SourceProviderFromRegistryClientProvider(provider, log), lister.OperatorsV1alpha1().CatalogSourceLister(), log, options...),
 		log:                    log,
 	}
 }
@@ -64,7 +65,13 @@ func (r *OperatorStepResolver) Expire(key cache.SourceKey) {
 	r.satResolver.cache.Expire(key)
 }
 
-func (r *OperatorStepResolver) ResolveSteps(namespace string) ([]*v1alpha1.Step, []v1alpha1.BundleLookup, []*v1alpha1.Subscription, error) {
+// Deprecations returns the deprecation notices the catalog of the given
+// Subscription has for its package, its channel and its installed bundle.
+func (r *OperatorStepResolver) Deprecations(sub *v1alpha1.Subscription) (cache.Deprecations, error) {
+	return r.satResolver.SubscriptionDeprecations([]string{sub.GetNamespace(), r.globalCatalogNamespace}, sub)
+}
+
+func (r *OperatorStepResolver) ResolveSteps(ctx context.Context, namespace string) ([]*v1alpha1.Step, []v1alpha1.BundleLookup, []*v1alpha1.Subscription, error) {
 	// create a generation - a representation of the current set of installed operators and their provided/required apis
 	allCSVs, err := r.csvLister.ClusterServiceVersions(namespace).List(labels.Everything())
 	if err != nil {
@@ -87,7 +94,7 @@ func (r *OperatorStepResolver) ResolveSteps(namespace string) ([]*v1alpha1.Step,
 
 	var operators cache.OperatorSet
 	namespaces := []string{namespace, r.globalCatalogNamespace}
-	operators, err = r.satResolver.SolveOperators(namespaces, csvs, subs)
+	operators, err = r.satResolver.SolveOperators(ctx, namespaces, csvs, subs)
 	if err != nil {
 		return nil, nil, nil, err
 	}
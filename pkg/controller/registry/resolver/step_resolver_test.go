@@ -1,6 +1,7 @@
 package resolver
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"testing"
@@ -845,7 +846,7 @@ func TestResolver(t *testing.T) {
 			resolver := NewOperatorStepResolver(lister, clientFake, kClientFake, "", nil, log)
 			resolver.satResolver = satresolver
 
-			steps, lookups, subs, err := resolver.ResolveSteps(namespace)
+			steps, lookups, subs, err := resolver.ResolveSteps(context.TODO(), namespace)
 			if tt.out.solverError == nil {
 				if tt.out.errAssert == nil {
 					assert.NoError(t, err)
@@ -987,7 +988,7 @@ func TestNamespaceResolverRBAC(t *testing.T) {
 			}
 			resolver := NewOperatorStepResolver(lister, clientFake, kClientFake, "", nil, logrus.New())
 			resolver.satResolver = satresolver
-			steps, _, subs, err := resolver.ResolveSteps(namespace)
+			steps, _, subs, err := resolver.ResolveSteps(context.TODO(), namespace)
 			require.Equal(t, tt.out.err, err)
 			RequireStepsEqual(t, expectedSteps, steps)
 			require.ElementsMatch(t, tt.out.subs, subs)
@@ -1027,6 +1028,12 @@ func withStartingCSV(name string) subOption {
 	}
 }
 
+func withVersionRange(r string) subOption {
+	return func(s *v1alpha1.Subscription) {
+		s.SetAnnotations(map[string]string{VersionRangeAnnotationKey: r})
+	}
+}
+
 func newSub(namespace, pkg, channel string, catalog resolvercache.SourceKey, option ...subOption) *v1alpha1.Subscription {
 	s := &v1alpha1.Subscription{
 		ObjectMeta: metav1.ObjectMeta{
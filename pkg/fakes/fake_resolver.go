@@ -2,6 +2,7 @@
 package fakes
 
 import (
+	"context"
 	"sync"
 
 	"github.com/operator-framework/api/pkg/operators/v1alpha1"
@@ -10,15 +11,29 @@ import (
 )
 
 type FakeStepResolver struct {
+	DeprecationsStub        func(*v1alpha1.Subscription) (cache.Deprecations, error)
+	deprecationsMutex       sync.RWMutex
+	deprecationsArgsForCall []struct {
+		arg1 *v1alpha1.Subscription
+	}
+	deprecationsReturns struct {
+		result1 cache.Deprecations
+		result2 error
+	}
+	deprecationsReturnsOnCall map[int]struct {
+		result1 cache.Deprecations
+		result2 error
+	}
 	ExpireStub        func(cache.SourceKey)
 	expireMutex       sync.RWMutex
 	expireArgsForCall []struct {
 		arg1 cache.SourceKey
 	}
-	ResolveStepsStub        func(string) ([]*v1alpha1.Step, []v1alpha1.BundleLookup, []*v1alpha1.Subscription, error)
+	ResolveStepsStub        func(context.Context, string) ([]*v1alpha1.Step, []v1alpha1.BundleLookup, []*v1alpha1.Subscription, error)
 	resolveStepsMutex       sync.RWMutex
 	resolveStepsArgsForCall []struct {
-		arg1 string
+		arg1 context.Context
+		arg2 string
 	}
 	resolveStepsReturns struct {
 		result1 []*v1alpha1.Step
@@ -36,6 +51,69 @@ type FakeStepResolver struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeStepResolver) Deprecations(arg1 *v1alpha1.Subscription) (cache.Deprecations, error) {
+	fake.deprecationsMutex.Lock()
+	ret, specificReturn := fake.deprecationsReturnsOnCall[len(fake.deprecationsArgsForCall)]
+	fake.deprecationsArgsForCall = append(fake.deprecationsArgsForCall, struct {
+		arg1 *v1alpha1.Subscription
+	}{arg1})
+	fake.recordInvocation("Deprecations", []interface{}{arg1})
+	fake.deprecationsMutex.Unlock()
+	if fake.DeprecationsStub != nil {
+		return fake.DeprecationsStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.deprecationsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeStepResolver) DeprecationsCallCount() int {
+	fake.deprecationsMutex.RLock()
+	defer fake.deprecationsMutex.RUnlock()
+	return len(fake.deprecationsArgsForCall)
+}
+
+func (fake *FakeStepResolver) DeprecationsCalls(stub func(*v1alpha1.Subscription) (cache.Deprecations, error)) {
+	fake.deprecationsMutex.Lock()
+	defer fake.deprecationsMutex.Unlock()
+	fake.DeprecationsStub = stub
+}
+
+func (fake *FakeStepResolver) DeprecationsArgsForCall(i int) *v1alpha1.Subscription {
+	fake.deprecationsMutex.RLock()
+	defer fake.deprecationsMutex.RUnlock()
+	argsForCall := fake.deprecationsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeStepResolver) DeprecationsReturns(result1 cache.Deprecations, result2 error) {
+	fake.deprecationsMutex.Lock()
+	defer fake.deprecationsMutex.Unlock()
+	fake.DeprecationsStub = nil
+	fake.deprecationsReturns = struct {
+		result1 cache.Deprecations
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeStepResolver) DeprecationsReturnsOnCall(i int, result1 cache.Deprecations, result2 error) {
+	fake.deprecationsMutex.Lock()
+	defer fake.deprecationsMutex.Unlock()
+	fake.DeprecationsStub = nil
+	if fake.deprecationsReturnsOnCall == nil {
+		fake.deprecationsReturnsOnCall = make(map[int]struct {
+			result1 cache.Deprecations
+			result2 error
+		})
+	}
+	fake.deprecationsReturnsOnCall[i] = struct {
+		result1 cache.Deprecations
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeStepResolver) Expire(arg1 cache.SourceKey) {
 	fake.expireMutex.Lock()
 	fake.expireArgsForCall = append(fake.expireArgsForCall, struct {
@@ -67,16 +145,17 @@ func (fake *FakeStepResolver) ExpireArgsForCall(i int) cache.SourceKey {
 	return argsForCall.arg1
 }
 
-func (fake *FakeStepResolver) ResolveSteps(arg1 string) ([]*v1alpha1.Step, []v1alpha1.BundleLookup, []*v1alpha1.Subscription, error) {
+func (fake *FakeStepResolver) ResolveSteps(arg1 context.Context, arg2 string) ([]*v1alpha1.Step, []v1alpha1.BundleLookup, []*v1alpha1.Subscription, error) {
 	fake.resolveStepsMutex.Lock()
 	ret, specificReturn := fake.resolveStepsReturnsOnCall[len(fake.resolveStepsArgsForCall)]
 	fake.resolveStepsArgsForCall = append(fake.resolveStepsArgsForCall, struct {
-		arg1 string
-	}{arg1})
-	fake.recordInvocation("ResolveSteps", []interface{}{arg1})
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("ResolveSteps", []interface{}{arg1, arg2})
 	fake.resolveStepsMutex.Unlock()
 	if fake.ResolveStepsStub != nil {
-		return fake.ResolveStepsStub(arg1)
+		return fake.ResolveStepsStub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2, ret.result3, ret.result4
@@ -91,17 +170,17 @@ func (fake *FakeStepResolver) ResolveStepsCallCount() int {
 	return len(fake.resolveStepsArgsForCall)
 }
 
-func (fake *FakeStepResolver) ResolveStepsCalls(stub func(string) ([]*v1alpha1.Step, []v1alpha1.BundleLookup, []*v1alpha1.Subscription, error)) {
+func (fake *FakeStepResolver) ResolveStepsCalls(stub func(context.Context, string) ([]*v1alpha1.Step, []v1alpha1.BundleLookup, []*v1alpha1.Subscription, error)) {
 	fake.resolveStepsMutex.Lock()
 	defer fake.resolveStepsMutex.Unlock()
 	fake.ResolveStepsStub = stub
 }
 
-func (fake *FakeStepResolver) ResolveStepsArgsForCall(i int) string {
+func (fake *FakeStepResolver) ResolveStepsArgsForCall(i int) (context.Context, string) {
 	fake.resolveStepsMutex.RLock()
 	defer fake.resolveStepsMutex.RUnlock()
 	argsForCall := fake.resolveStepsArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeStepResolver) ResolveStepsReturns(result1 []*v1alpha1.Step, result2 []v1alpha1.BundleLookup, result3 []*v1alpha1.Subscription, result4 error) {
@@ -139,6 +218,8 @@ func (fake *FakeStepResolver) ResolveStepsReturnsOnCall(i int, result1 []*v1alph
 func (fake *FakeStepResolver) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.deprecationsMutex.RLock()
+	defer fake.deprecationsMutex.RUnlock()
 	fake.expireMutex.RLock()
 	defer fake.expireMutex.RUnlock()
 	fake.resolveStepsMutex.RLock()
@@ -0,0 +1,163 @@
+package leaderelection
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+const (
+	// DefaultLeaseDuration is the default duration followers wait before
+	// trying to acquire a lease that wasn't renewed.
+	DefaultLeaseDuration = 15 * time.Second
+	// DefaultRenewDeadline is the default duration the leader retries
+	// renewing its lease before giving up leadership.
+	DefaultRenewDeadline = 10 * time.Second
+	// DefaultRetryPeriod is the default duration between attempts to acquire
+	// or renew a lease.
+	DefaultRetryPeriod = 2 * time.Second
+
+	// healthzTolerance is how long the lease of the leader may stay expired
+	// before its health check fails.
+	healthzTolerance = 20 * time.Second
+)
+
+// Config configures Lease based leader election.
+type Config struct {
+	// Namespace and Name identify the Lease candidates compete for.
+	Namespace string
+	Name      string
+	// Identity identifies the candidate in the Lease. Defaults to the
+	// hostname, made unique.
+	Identity string
+
+	LeaseDuration time.Duration
+	RenewDeadline time.Duration
+	RetryPeriod   time.Duration
+}
+
+// Elector elects one leader among the replicas of an operator.
+type Elector struct {
+	logger   *logrus.Logger
+	elector  *leaderelection.LeaderElector
+	watchdog *leaderelection.HealthzAdaptor
+	identity string
+
+	elected     chan struct{}
+	electedOnce sync.Once
+}
+
+// NewElector returns an Elector competing for the Lease of the given config.
+func NewElector(client kubernetes.Interface, logger *logrus.Logger, config Config) (*Elector, error) {
+	if config.Namespace == "" || config.Name == "" {
+		return nil, fmt.Errorf("leader election lease namespace and name must be set")
+	}
+	if config.Identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("error getting hostname for leader election identity: %v", err)
+		}
+		config.Identity = hostname + "_" + string(uuid.NewUUID())
+	}
+	if config.LeaseDuration == 0 {
+		config.LeaseDuration = DefaultLeaseDuration
+	}
+	if config.RenewDeadline == 0 {
+		config.RenewDeadline = DefaultRenewDeadline
+	}
+	if config.RetryPeriod == 0 {
+		config.RetryPeriod = DefaultRetryPeriod
+	}
+
+	e := &Elector{
+		logger:   logger,
+		watchdog: leaderelection.NewLeaderHealthzAdaptor(healthzTolerance),
+		identity: config.Identity,
+		elected:  make(chan struct{}),
+	}
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock: &resourcelock.LeaseLock{
+			LeaseMeta:  metav1.ObjectMeta{Namespace: config.Namespace, Name: config.Name},
+			Client:     client.CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{Identity: config.Identity},
+		},
+		LeaseDuration:   config.LeaseDuration,
+		RenewDeadline:   config.RenewDeadline,
+		RetryPeriod:     config.RetryPeriod,
+		ReleaseOnCancel: true,
+		WatchDog:        e.watchdog,
+		Name:            config.Name,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(context.Context) {
+				logger.WithField("identity", config.Identity).Info("became leader")
+				e.electedOnce.Do(func() { close(e.elected) })
+			},
+			OnStoppedLeading: func() {
+				logger.WithField("identity", config.Identity).Info("stopped leading")
+			},
+			OnNewLeader: func(identity string) {
+				if identity != config.Identity {
+					logger.WithField("leader", identity).Info("following leader")
+				}
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid leader election config: %v", err)
+	}
+	e.elector = elector
+
+	return e, nil
+}
+
+// Run competes for leadership until the context is cancelled or leadership is
+// lost, and releases the Lease if held. Once leadership was lost, workers may
+// still be running, so the process is expected to exit when Run returns.
+func (e *Elector) Run(ctx context.Context) {
+	e.logger.WithField("identity", e.identity).Info("waiting to become leader")
+	e.elector.Run(ctx)
+}
+
+// Elected returns a channel that is closed when this replica becomes leader.
+func (e *Elector) Elected() <-chan struct{} {
+	return e.elected
+}
+
+// IsLeader returns true if this replica currently holds the Lease.
+func (e *Elector) IsLeader() bool {
+	return e.elector.IsLeader()
+}
+
+// Leader returns the identity of the last observed leader.
+func (e *Elector) Leader() string {
+	return e.elector.GetLeader()
+}
+
+// Check returns an error if this replica is the leader, but hasn't been able
+// to renew its Lease for a while.
+func (e *Elector) Check(req *http.Request) error {
+	return e.watchdog.Check(req)
+}
+
+// LeaderOnly returns a handler that serves requests with the given handler
+// while this replica is the leader, and answers 503 otherwise so that
+// clients retry against the leader.
+func (e *Elector) LeaderOnly(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !e.IsLeader() {
+			http.Error(w, "not the leader", http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,82 @@
+package leaderelection
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func testConfig(identity string) Config {
+	return Config{
+		Namespace:     "olm",
+		Name:          "olm-operator-leader",
+		Identity:      identity,
+		LeaseDuration: 2 * time.Second,
+		RenewDeadline: time.Second,
+		RetryPeriod:   100 * time.Millisecond,
+	}
+}
+
+func TestElector(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	logger := logrus.New()
+
+	leader, err := NewElector(client, logger, testConfig("a"))
+	require.NoError(t, err)
+	follower, err := NewElector(client, logger, testConfig("b"))
+	require.NoError(t, err)
+
+	leaderCtx, stopLeader := context.WithCancel(context.Background())
+	leaderDone := make(chan struct{})
+	go func() {
+		defer close(leaderDone)
+		leader.Run(leaderCtx)
+	}()
+	select {
+	case <-leader.Elected():
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for election")
+	}
+	require.True(t, leader.IsLeader())
+	require.NoError(t, leader.Check(nil))
+
+	followerCtx, stopFollower := context.WithCancel(context.Background())
+	defer stopFollower()
+	go follower.Run(followerCtx)
+	require.Eventually(t, func() bool { return follower.Leader() == "a" }, 10*time.Second, 50*time.Millisecond)
+	require.False(t, follower.IsLeader())
+
+	handler := follower.LeaderOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	// the lease is released on shutdown, and taken over
+	stopLeader()
+	<-leaderDone
+	require.False(t, leader.IsLeader())
+	select {
+	case <-follower.Elected():
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for failover")
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestNewElectorInvalid(t *testing.T) {
+	config := testConfig("a")
+	config.RenewDeadline = config.LeaseDuration
+	_, err := NewElector(fake.NewSimpleClientset(), logrus.New(), config)
+	require.Error(t, err)
+
+	_, err = NewElector(fake.NewSimpleClientset(), logrus.New(), Config{Name: "lease"})
+	require.EqualError(t, err, "leader election lease namespace and name must be set")
+}
@@ -0,0 +1,197 @@
+package maintenancewindow
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSearch bounds how far ahead the start of a window is looked for, so that
+// schedules that rarely match, like "0 0 29 2 *", are still found.
+const maxSearch = 5 * 366 * 24 * time.Hour
+
+// Window is a recurring period of time. Each occurrence starts at a time of a
+// cron schedule and lasts for a fixed duration.
+type Window struct {
+	schedule *schedule
+	duration time.Duration
+	location *time.Location
+}
+
+// New returns the window starting at the times of the given cron schedule, in
+// the given time zone, and lasting for the given duration. The schedule has
+// the five fields of crontab(5): minute, hour, day of month, month and day of
+// week. Each field is "*" or a list of values, ranges and steps, e.g. "1-5",
+// "*/15" or "0,30". Names of months and days aren't supported. An empty time
+// zone is UTC.
+func New(spec string, duration time.Duration, timeZone string) (*Window, error) {
+	s, err := parseSchedule(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %v", spec, err)
+	}
+	if duration <= 0 {
+		return nil, fmt.Errorf("invalid duration %s: must be positive", duration)
+	}
+	location, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %v", timeZone, err)
+	}
+
+	w := &Window{schedule: s, duration: duration, location: location}
+	if _, ok := w.schedule.next(time.Date(2000, time.January, 1, 0, 0, 0, 0, location)); !ok {
+		return nil, fmt.Errorf("invalid schedule %q: it never matches", spec)
+	}
+	return w, nil
+}
+
+// Contains returns true if the given time is within an occurrence of the
+// window.
+func (w *Window) Contains(t time.Time) bool {
+	start, ok := w.schedule.next(t.In(w.location).Add(-w.duration))
+	return ok && !start.After(t)
+}
+
+// Next returns the start of the occurrence of the window that contains the
+// given time, or of the next occurrence if none does.
+func (w *Window) Next(t time.Time) time.Time {
+	t = t.In(w.location)
+	start, ok := w.schedule.next(t.Add(-w.duration))
+	if ok && !start.After(t) {
+		return start
+	}
+	start, _ = w.schedule.next(t)
+	return start
+}
+
+// schedule is a parsed cron schedule. Each field is a bit set of the values it
+// matches.
+type schedule struct {
+	minute, hour, dom, month, dow uint64
+	// domAll and dowAll are true if the day of month or the day of week
+	// field starts with "*", e.g. "*" or "*/2". As in cron, when both are
+	// restricted, a day matches if either matches.
+	domAll, dowAll bool
+}
+
+var fields = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+func parseSchedule(spec string) (*schedule, error) {
+	parts := strings.Fields(spec)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("expected %d fields, found %d", len(fields), len(parts))
+	}
+
+	var bits [5]uint64
+	for i, f := range fields {
+		b, err := parseField(parts[i], f.min, f.max)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %v", f.name, parts[i], err)
+		}
+		bits[i] = b
+	}
+
+	s := &schedule{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		domAll: strings.HasPrefix(parts[2], "*"),
+		dowAll: strings.HasPrefix(parts[4], "*"),
+	}
+	// both 0 and 7 are Sunday
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	return s, nil
+}
+
+// parseField returns the bit set of the values matched by a comma separated
+// list of "*", values, and ranges, each optionally followed by a step.
+func parseField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(field, ",") {
+		rangeSpec, step := item, 1
+		if parts := strings.SplitN(item, "/", 2); len(parts) == 2 {
+			rangeSpec = parts[0]
+			s, err := strconv.Atoi(parts[1])
+			if err != nil || s <= 0 {
+				return 0, fmt.Errorf("invalid step %q", parts[1])
+			}
+			step = s
+		}
+
+		lo, hi := min, max
+		if rangeSpec != "*" {
+			bounds := strings.SplitN(rangeSpec, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid value %q", bounds[0])
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("invalid value %q", bounds[1])
+				}
+			} else if step > 1 {
+				// "a/n" is every n from a to the maximum
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q is outside of %d-%d", rangeSpec, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// next returns the first time matching the schedule strictly after the given
+// time, in the location of the given time.
+func (s *schedule) next(t time.Time) (time.Time, bool) {
+	limit := t.Add(maxSearch)
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+func (s *schedule) matchesDay(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAll || s.dowAll {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,178 @@
+package maintenancewindow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		duration time.Duration
+		timeZone string
+		err      string
+	}{
+		{
+			name:     "Valid",
+			spec:     "*/15 2-4 1,15 * 1-5",
+			duration: time.Hour,
+			timeZone: "Europe/Berlin",
+		},
+		{
+			name:     "TooFewFields",
+			spec:     "0 2 * *",
+			duration: time.Hour,
+			err:      `invalid schedule "0 2 * *": expected 5 fields, found 4`,
+		},
+		{
+			name:     "OutOfRange",
+			spec:     "0 24 * * *",
+			duration: time.Hour,
+			err:      `invalid schedule "0 24 * * *": invalid hour "24": "24" is outside of 0-23`,
+		},
+		{
+			name:     "InvalidStep",
+			spec:     "*/0 * * * *",
+			duration: time.Hour,
+			err:      `invalid schedule "*/0 * * * *": invalid minute "*/0": invalid step "0"`,
+		},
+		{
+			name:     "NeverMatches",
+			spec:     "0 0 31 4 *",
+			duration: time.Hour,
+			err:      `invalid schedule "0 0 31 4 *": it never matches`,
+		},
+		{
+			name:     "InvalidDuration",
+			spec:     "0 2 * * *",
+			duration: 0,
+			err:      "invalid duration 0s: must be positive",
+		},
+		{
+			name:     "InvalidTimeZone",
+			spec:     "0 2 * * *",
+			duration: time.Hour,
+			timeZone: "Mars/Olympus_Mons",
+			err:      `invalid time zone "Mars/Olympus_Mons": unknown time zone Mars/Olympus_Mons`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.spec, tt.duration, tt.timeZone)
+			if tt.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestWindow(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		spec     string
+		duration time.Duration
+		timeZone string
+		now      time.Time
+		contains bool
+		next     time.Time
+	}{
+		{
+			name:     "BeforeWindow",
+			spec:     "0 2 * * 6",
+			duration: 4 * time.Hour,
+			// a Friday
+			now:  time.Date(2021, time.June, 4, 12, 0, 0, 0, time.UTC),
+			next: time.Date(2021, time.June, 5, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "StartOfWindow",
+			spec:     "0 2 * * 6",
+			duration: 4 * time.Hour,
+			now:      time.Date(2021, time.June, 5, 2, 0, 0, 0, time.UTC),
+			contains: true,
+			next:     time.Date(2021, time.June, 5, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "InWindow",
+			spec:     "0 2 * * 6",
+			duration: 4 * time.Hour,
+			now:      time.Date(2021, time.June, 5, 5, 59, 0, 0, time.UTC),
+			contains: true,
+			next:     time.Date(2021, time.June, 5, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "EndOfWindow",
+			spec:     "0 2 * * 6",
+			duration: 4 * time.Hour,
+			now:      time.Date(2021, time.June, 5, 6, 0, 0, 0, time.UTC),
+			next:     time.Date(2021, time.June, 12, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "AcrossMidnight",
+			spec:     "0 22 * * 0,7",
+			duration: 6 * time.Hour,
+			// a Monday
+			now:      time.Date(2021, time.June, 7, 1, 0, 0, 0, time.UTC),
+			contains: true,
+			next:     time.Date(2021, time.June, 6, 22, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "TimeZone",
+			spec:     "0 2 * * *",
+			duration: time.Hour,
+			timeZone: "Europe/Berlin",
+			// 02:30 in Berlin
+			now:      time.Date(2021, time.June, 5, 0, 30, 0, 0, time.UTC),
+			contains: true,
+			next:     time.Date(2021, time.June, 5, 2, 0, 0, 0, berlin),
+		},
+		{
+			name:     "DayOfMonthOrDayOfWeek",
+			spec:     "0 0 1 * 1",
+			duration: time.Hour,
+			// a Wednesday; the next Monday comes before the 1st
+			now:  time.Date(2021, time.June, 2, 12, 0, 0, 0, time.UTC),
+			next: time.Date(2021, time.June, 7, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "DayOfMonthStepAndDayOfWeek",
+			spec:     "0 0 */2 * 1",
+			duration: time.Hour,
+			// a Wednesday; a step over all days of the month restricts
+			// the days of week like "*" does
+			now:  time.Date(2021, time.June, 2, 12, 0, 0, 0, time.UTC),
+			next: time.Date(2021, time.June, 7, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "DayOfMonthAndDayOfWeekStep",
+			spec:     "0 0 1 * */2",
+			duration: time.Hour,
+			// July 1st is the next 1st on an even day of week, a Thursday
+			now:  time.Date(2021, time.June, 2, 12, 0, 0, 0, time.UTC),
+			next: time.Date(2021, time.July, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "LeapDay",
+			spec:     "30 1 29 2 *",
+			duration: time.Hour,
+			now:      time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC),
+			next:     time.Date(2024, time.February, 29, 1, 30, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := New(tt.spec, tt.duration, tt.timeZone)
+			require.NoError(t, err)
+			require.Equal(t, tt.contains, w.Contains(tt.now))
+			require.True(t, tt.next.Equal(w.Next(tt.now)), "expected %s, got %s", tt.next, w.Next(tt.now))
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package operatorcondition
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	operatorsv2 "github.com/operator-framework/api/pkg/operators/v2"
+	listers "github.com/operator-framework/operator-lifecycle-manager/pkg/api/client/listers/operators/v2"
+)
+
+const (
+	// UpgradeableVersionRange is the type of the OperatorCondition condition
+	// bounding the versions an operator may be upgraded to. While its status
+	// is True, its message is a semver range, e.g. "<2.0.0", and only
+	// versions within the range may replace the operator.
+	UpgradeableVersionRange = "UpgradeableVersionRange"
+
+	// MigrationConditionPrefix prefixes the types of OperatorCondition
+	// conditions that each report a named migration, e.g.
+	// "migration.operatorframework.io/storage-v2". Until the status of such a
+	// condition is True, the migration is pending and the operator isn't
+	// upgraded.
+	MigrationConditionPrefix = "migration.operatorframework.io/"
+)
+
+// Gates are the restrictions the OperatorCondition of an operator puts on
+// upgrading it.
+type Gates struct {
+	// NotUpgradeable explains why the Upgradeable condition holds upgrades.
+	// Empty if it doesn't.
+	NotUpgradeable string
+	// PendingMigrations explains, for each pending migration, why it holds
+	// upgrades.
+	PendingMigrations []string
+	// VersionRange, if not nil, bounds the versions the operator may be
+	// upgraded to.
+	VersionRange semver.Range
+	// VersionRangeString is the string form of VersionRange.
+	VersionRangeString string
+}
+
+// Held returns true if the operator may not be upgraded at all.
+func (g Gates) Held() bool {
+	return g.NotUpgradeable != "" || len(g.PendingMigrations) > 0
+}
+
+// Allows returns true if the operator may be upgraded to the given version.
+func (g Gates) Allows(version semver.Version) bool {
+	return !g.Held() && (g.VersionRange == nil || g.VersionRange(version))
+}
+
+// Explain returns why upgrading the operator to the given version is held,
+// or an empty string if it isn't.
+func (g Gates) Explain(version semver.Version) string {
+	var reasons []string
+	if g.NotUpgradeable != "" {
+		reasons = append(reasons, g.NotUpgradeable)
+	}
+	reasons = append(reasons, g.PendingMigrations...)
+	if g.VersionRange != nil && !g.VersionRange(version) {
+		reasons = append(reasons, fmt.Sprintf("version %s is outside of the upgradeable version range %s", version, g.VersionRangeString))
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// Equal returns true if both gates put the same restrictions on upgrades.
+func (g Gates) Equal(other Gates) bool {
+	return g.NotUpgradeable == other.NotUpgradeable &&
+		reflect.DeepEqual(g.PendingMigrations, other.PendingMigrations) &&
+		g.VersionRangeString == other.VersionRangeString
+}
+
+// GatesFor returns the gates of the OperatorCondition of the given operator.
+// An operator without OperatorCondition has no gates.
+func GatesFor(lister listers.OperatorConditionLister, namespace, name string) (Gates, error) {
+	cond, err := lister.OperatorConditions(namespace).Get(name)
+	if k8serrors.IsNotFound(err) {
+		return Gates{}, nil
+	}
+	if err != nil {
+		return Gates{}, err
+	}
+	return GatesFrom(cond), nil
+}
+
+// GatesFrom returns the gates of the given OperatorCondition. For each gate,
+// an override takes precedence over the condition reported by the operator.
+// A reported Upgradeable or migration condition that hasn't been observed for
+// the current generation of the OperatorCondition holds upgrades.
+func GatesFrom(cond *operatorsv2.OperatorCondition) Gates {
+	var gates Gates
+
+	if c, outdated := effectiveCondition(cond, operatorsv2.Upgradeable); c != nil {
+		switch {
+		case outdated:
+			gates.NotUpgradeable = fmt.Sprintf("The operatorcondition status %q=%q is outdated", c.Type, c.Status)
+		case c.Status == metav1.ConditionFalse:
+			gates.NotUpgradeable = fmt.Sprintf("The operator is not upgradeable: %s", c.Message)
+		}
+	}
+
+	migrations := map[string]struct{}{}
+	for _, conditions := range [][]metav1.Condition{cond.Spec.Overrides, cond.Status.Conditions} {
+		for _, c := range conditions {
+			if strings.HasPrefix(c.Type, MigrationConditionPrefix) {
+				migrations[c.Type] = struct{}{}
+			}
+		}
+	}
+	var types []string
+	for t := range migrations {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		c, outdated := effectiveCondition(cond, t)
+		name := strings.TrimPrefix(t, MigrationConditionPrefix)
+		switch {
+		case outdated:
+			gates.PendingMigrations = append(gates.PendingMigrations, fmt.Sprintf("the status of migration %s is outdated", name))
+		case c.Status != metav1.ConditionTrue:
+			gates.PendingMigrations = append(gates.PendingMigrations, fmt.Sprintf("migration %s has not completed: %s", name, c.Message))
+		}
+	}
+
+	// An outdated range still applies: it's only lifted once the operator
+	// reports so.
+	if c, _ := effectiveCondition(cond, UpgradeableVersionRange); c != nil && c.Status == metav1.ConditionTrue {
+		r, err := semver.ParseRange(c.Message)
+		if err != nil {
+			invalid := fmt.Sprintf("invalid version range %q in condition %s: %v", c.Message, UpgradeableVersionRange, err)
+			if gates.NotUpgradeable != "" {
+				invalid = gates.NotUpgradeable + ", " + invalid
+			}
+			gates.NotUpgradeable = invalid
+		} else {
+			gates.VersionRange, gates.VersionRangeString = r, c.Message
+		}
+	}
+
+	return gates
+}
+
+// effectiveCondition returns the override of the given type if any, or the
+// reported condition of that type, along with whether that condition is
+// outdated.
+func effectiveCondition(cond *operatorsv2.OperatorCondition, conditionType string) (*metav1.Condition, bool) {
+	if o := meta.FindStatusCondition(cond.Spec.Overrides, conditionType); o != nil {
+		return o, false
+	}
+	c := meta.FindStatusCondition(cond.Status.Conditions, conditionType)
+	if c == nil {
+		return nil, false
+	}
+	return c, c.ObservedGeneration != cond.ObjectMeta.Generation
+}
@@ -0,0 +1,148 @@
+package operatorcondition
+
+import (
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	operatorsv2 "github.com/operator-framework/api/pkg/operators/v2"
+)
+
+func TestGatesFrom(t *testing.T) {
+	tests := []struct {
+		name        string
+		generation  int64
+		overrides   []metav1.Condition
+		conditions  []metav1.Condition
+		held        bool
+		allows      map[string]bool
+		explanation string
+	}{
+		{
+			name:   "NoConditions",
+			allows: map[string]bool{"2.0.0": true},
+		},
+		{
+			name: "NotUpgradeable",
+			conditions: []metav1.Condition{
+				{Type: operatorsv2.Upgradeable, Status: metav1.ConditionFalse, Message: "busy"},
+			},
+			held:        true,
+			allows:      map[string]bool{"2.0.0": false},
+			explanation: "The operator is not upgradeable: busy",
+		},
+		{
+			name:       "OutdatedUpgradeable",
+			generation: 2,
+			conditions: []metav1.Condition{
+				{Type: operatorsv2.Upgradeable, Status: metav1.ConditionTrue, ObservedGeneration: 1},
+			},
+			held:        true,
+			allows:      map[string]bool{"2.0.0": false},
+			explanation: `The operatorcondition status "Upgradeable"="True" is outdated`,
+		},
+		{
+			name: "UpgradeableOverridden",
+			overrides: []metav1.Condition{
+				{Type: operatorsv2.Upgradeable, Status: metav1.ConditionTrue},
+			},
+			conditions: []metav1.Condition{
+				{Type: operatorsv2.Upgradeable, Status: metav1.ConditionFalse, Message: "busy"},
+			},
+			allows: map[string]bool{"2.0.0": true},
+		},
+		{
+			name: "PendingMigrations",
+			conditions: []metav1.Condition{
+				{Type: MigrationConditionPrefix + "schema", Status: metav1.ConditionTrue},
+				{Type: MigrationConditionPrefix + "storage", Status: metav1.ConditionFalse, Message: "copying data"},
+				{Type: MigrationConditionPrefix + "backup", Status: metav1.ConditionUnknown, Message: "not started"},
+			},
+			held:        true,
+			allows:      map[string]bool{"2.0.0": false},
+			explanation: "migration backup has not completed: not started, migration storage has not completed: copying data",
+		},
+		{
+			name: "MigrationOverridden",
+			overrides: []metav1.Condition{
+				{Type: MigrationConditionPrefix + "storage", Status: metav1.ConditionTrue},
+			},
+			conditions: []metav1.Condition{
+				{Type: MigrationConditionPrefix + "storage", Status: metav1.ConditionFalse, Message: "copying data"},
+			},
+			allows: map[string]bool{"2.0.0": true},
+		},
+		{
+			name:       "OutdatedMigration",
+			generation: 2,
+			conditions: []metav1.Condition{
+				{Type: MigrationConditionPrefix + "storage", Status: metav1.ConditionTrue, ObservedGeneration: 1},
+			},
+			held:        true,
+			allows:      map[string]bool{"2.0.0": false},
+			explanation: "the status of migration storage is outdated",
+		},
+		{
+			name: "VersionRange",
+			conditions: []metav1.Condition{
+				{Type: UpgradeableVersionRange, Status: metav1.ConditionTrue, Message: "<2.0.0"},
+			},
+			allows:      map[string]bool{"1.5.0": true, "2.0.0": false},
+			explanation: "version 2.0.0 is outside of the upgradeable version range <2.0.0",
+		},
+		{
+			name: "VersionRangeLifted",
+			conditions: []metav1.Condition{
+				{Type: UpgradeableVersionRange, Status: metav1.ConditionFalse, Message: "<2.0.0"},
+			},
+			allows: map[string]bool{"2.0.0": true},
+		},
+		{
+			name: "InvalidVersionRange",
+			conditions: []metav1.Condition{
+				{Type: UpgradeableVersionRange, Status: metav1.ConditionTrue, Message: "not a range"},
+			},
+			held:        true,
+			allows:      map[string]bool{"2.0.0": false},
+			explanation: `invalid version range "not a range" in condition UpgradeableVersionRange: Could not get version from string: "not"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cond := &operatorsv2.OperatorCondition{
+				ObjectMeta: metav1.ObjectMeta{Generation: tt.generation},
+				Spec:       operatorsv2.OperatorConditionSpec{Overrides: tt.overrides},
+				Status:     operatorsv2.OperatorConditionStatus{Conditions: tt.conditions},
+			}
+			gates := GatesFrom(cond)
+			require.Equal(t, tt.held, gates.Held())
+			for v, allowed := range tt.allows {
+				require.Equal(t, allowed, gates.Allows(semver.MustParse(v)), "version %s", v)
+			}
+			require.Equal(t, tt.explanation, gates.Explain(semver.MustParse("2.0.0")))
+		})
+	}
+}
+
+func TestGatesEqual(t *testing.T) {
+	gates := GatesFrom(&operatorsv2.OperatorCondition{
+		Status: operatorsv2.OperatorConditionStatus{
+			Conditions: []metav1.Condition{
+				{Type: MigrationConditionPrefix + "storage", Status: metav1.ConditionFalse, Message: "copying data"},
+				{Type: UpgradeableVersionRange, Status: metav1.ConditionTrue, Message: "<2.0.0"},
+			},
+		},
+	})
+	require.True(t, gates.Equal(gates))
+	require.False(t, gates.Equal(Gates{}))
+
+	other := gates
+	other.VersionRangeString = "<3.0.0"
+	require.False(t, gates.Equal(other))
+
+	other = gates
+	other.NotUpgradeable = "busy"
+	require.False(t, gates.Equal(other))
+}
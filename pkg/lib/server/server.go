@@ -13,13 +13,61 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-func GetListenAndServeFunc(logger *logrus.Logger, tlsCertPath, tlsKeyPath, clientCAPath *string) (func() error, error) {
+// LeaderStatus reports the leadership of an operator replica.
+type LeaderStatus interface {
+	// IsLeader returns true if the replica is the leader.
+	IsLeader() bool
+	// Leader returns the identity of the current leader.
+	Leader() string
+	// Check returns an error if the replica is the leader but can't keep
+	// its leadership.
+	Check(req *http.Request) error
+}
+
+type serverConfig struct {
+	leaderStatus LeaderStatus
+}
+
+// Option configures the health/metric/pprof server.
+type Option func(*serverConfig)
+
+// WithLeaderStatus reports the given leadership on /healthz.
+func WithLeaderStatus(status LeaderStatus) Option {
+	return func(c *serverConfig) {
+		c.leaderStatus = status
+	}
+}
+
+func healthzHandler(status LeaderStatus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if status == nil {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		if err := status.Check(r); err != nil {
+			http.Error(w, fmt.Sprintf("leader: %v", err), http.StatusInternalServerError)
+			return
+		}
+		// followers are healthy: they are ready to take over
+		w.WriteHeader(http.StatusOK)
+		if status.IsLeader() {
+			fmt.Fprintln(w, "leader")
+		} else {
+			fmt.Fprintf(w, "follower of %q\n", status.Leader())
+		}
+	}
+}
+
+func GetListenAndServeFunc(logger *logrus.Logger, tlsCertPath, tlsKeyPath, clientCAPath *string, options ...Option) (func() error, error) {
+	config := &serverConfig{}
+	for _, option := range options {
+		option(config)
+	}
+
 	mux := http.NewServeMux()
 	profile.RegisterHandlers(mux)
 	mux.Handle("/metrics", promhttp.Handler())
-	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-	})
+	mux.HandleFunc("/healthz", healthzHandler(config.leaderStatus))
 
 	s := http.Server{
 		Handler: mux,
@@ -78,3 +126,35 @@ func GetListenAndServeFunc(logger *logrus.Logger, tlsCertPath, tlsKeyPath, clien
 	}
 	return listenAndServe, nil
 }
+
+// GetTLSListenAndServeFunc returns a function that serves the given handler at
+// the given address over TLS, with the certificate and key at the given paths.
+// They are reloaded when they change.
+func GetTLSListenAndServeFunc(logger *logrus.Logger, addr string, handler http.Handler, tlsCertPath, tlsKeyPath string) (func() error, error) {
+	if tlsCertPath == "" || tlsKeyPath == "" {
+		return nil, fmt.Errorf("both --tls-key and --tls-cert must be provided")
+	}
+
+	certStore, err := filemonitor.NewCertStore(tlsCertPath, tlsKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("certificate monitoring failed: %v", err)
+	}
+	csw, err := filemonitor.NewWatch(logger, []string{filepath.Dir(tlsCertPath), filepath.Dir(tlsKeyPath)}, certStore.HandleFilesystemUpdate)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cert file watcher: %v", err)
+	}
+	csw.Run(context.Background())
+
+	s := http.Server{
+		Handler: handler,
+		Addr:    addr,
+		TLSConfig: &tls.Config{
+			GetCertificate: func(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+				return certStore.GetCertificate(), nil
+			},
+		},
+	}
+	return func() error {
+		return s.ListenAndServeTLS("", "")
+	}, nil
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLeaderStatus struct {
+	leader bool
+	err    error
+}
+
+func (s fakeLeaderStatus) IsLeader() bool            { return s.leader }
+func (s fakeLeaderStatus) Leader() string            { return "olm-operator-1" }
+func (s fakeLeaderStatus) Check(*http.Request) error { return s.err }
+
+func TestHealthzHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   LeaderStatus
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "NoLeaderElection",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Leader",
+			status:   fakeLeaderStatus{leader: true},
+			wantCode: http.StatusOK,
+			wantBody: "leader\n",
+		},
+		{
+			name:     "Follower",
+			status:   fakeLeaderStatus{},
+			wantCode: http.StatusOK,
+			wantBody: "follower of \"olm-operator-1\"\n",
+		},
+		{
+			name:     "LeaseExpired",
+			status:   fakeLeaderStatus{leader: true, err: errors.New("failed election to renew leadership on lease olm-operator-leader")},
+			wantCode: http.StatusInternalServerError,
+			wantBody: "leader: failed election to renew leadership on lease olm-operator-leader\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			healthzHandler(tt.status).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			require.Equal(t, tt.wantCode, rec.Code)
+			require.Equal(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
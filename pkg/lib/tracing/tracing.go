@@ -0,0 +1,149 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpgrpc"
+	"go.opentelemetry.io/otel/propagation"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/semconv"
+	"go.opentelemetry.io/otel/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// TraceParentAnnotationKey holds the W3C traceparent of the trace an object
+	// was generated in, so that work on the object done by another operator
+	// joins that trace.
+	TraceParentAnnotationKey = "operatorframework.io/traceparent"
+
+	instrumentationName = "github.com/operator-framework/operator-lifecycle-manager"
+	traceParentHeader   = "traceparent"
+)
+
+var propagator = propagation.TraceContext{}
+
+// Config configures the export of spans.
+type Config struct {
+	// ServiceName identifies the operator in exported spans.
+	ServiceName string
+	// Endpoint is the address of an OTLP gRPC collector. Spans aren't
+	// recorded if empty.
+	Endpoint string
+	// Insecure disables TLS to the collector.
+	Insecure bool
+	// SampleRatio is the share of traces started by the operator that are
+	// recorded. Traces continued from an annotation follow the sampling
+	// decision of their parent.
+	SampleRatio float64
+}
+
+// Setup installs a global tracer provider exporting spans as configured, and
+// returns a function that flushes and stops the export. Without an endpoint,
+// the default no-op provider is kept.
+func Setup(ctx context.Context, config Config) (func(context.Context) error, error) {
+	if config.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	if config.SampleRatio < 0 || config.SampleRatio > 1 {
+		return nil, fmt.Errorf("invalid sample ratio %v: must be between 0 and 1", config.SampleRatio)
+	}
+
+	options := []otlpgrpc.Option{otlpgrpc.WithEndpoint(config.Endpoint)}
+	if config.Insecure {
+		options = append(options, otlpgrpc.WithInsecure())
+	}
+	exporter, err := otlp.NewExporter(ctx, otlpgrpc.NewDriver(options...))
+	if err != nil {
+		return nil, fmt.Errorf("error creating otlp exporter: %v", err)
+	}
+
+	return Install(sdktrace.WithBatcher(exporter), config), nil
+}
+
+// Install installs a global tracer provider passing spans to the given
+// processor, sampled and described as configured, and returns a function that
+// flushes and stops it. The endpoint of the config is ignored.
+func Install(processor sdktrace.TracerProviderOption, config Config) func(context.Context) error {
+	provider := sdktrace.NewTracerProvider(
+		processor,
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+		sdktrace.WithResource(sdkresource.NewWithAttributes(semconv.ServiceNameKey.String(config.ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagator)
+
+	return provider.Shutdown
+}
+
+// Start starts a span with the given name and attributes, as a child of the
+// span in the given context if any.
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// End ends the given span, and records the given error on it, if any.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Inject records the span in the given context in the traceparent annotation
+// of the given object. It's a no-op if no span is recorded.
+func Inject(ctx context.Context, obj metav1.Object) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return
+	}
+	propagator.Inject(ctx, annotationCarrier{obj: obj})
+}
+
+// Extract returns a context holding the span recorded in the traceparent
+// annotation of the given object, if any, so that spans started from it join
+// the trace the object was generated in.
+func Extract(ctx context.Context, obj metav1.Object) context.Context {
+	if _, ok := obj.GetAnnotations()[TraceParentAnnotationKey]; !ok {
+		return ctx
+	}
+	return propagator.Extract(ctx, annotationCarrier{obj: obj})
+}
+
+// annotationCarrier carries the traceparent of a trace context in an
+// annotation of an object. The tracestate isn't carried.
+type annotationCarrier struct {
+	obj metav1.Object
+}
+
+func (c annotationCarrier) Get(key string) string {
+	if key != traceParentHeader {
+		return ""
+	}
+	return c.obj.GetAnnotations()[TraceParentAnnotationKey]
+}
+
+func (c annotationCarrier) Set(key, value string) {
+	if key != traceParentHeader {
+		return
+	}
+	annotations := c.obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[TraceParentAnnotationKey] = value
+	c.obj.SetAnnotations(annotations)
+}
+
+func (c annotationCarrier) Keys() []string {
+	if _, ok := c.obj.GetAnnotations()[TraceParentAnnotationKey]; !ok {
+		return nil
+	}
+	return []string{traceParentHeader}
+}
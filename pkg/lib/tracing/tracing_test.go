@@ -0,0 +1,75 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/semconv"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/tracing"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/tracing/tracingtest"
+)
+
+func TestInjectExtract(t *testing.T) {
+	// nothing is recorded by default
+	obj := &corev1.ConfigMap{}
+	ctx, span := tracing.Start(context.Background(), "noop")
+	tracing.Inject(ctx, obj)
+	tracing.End(span, nil)
+	require.Empty(t, obj.GetAnnotations())
+	require.Equal(t, context.Background(), tracing.Extract(context.Background(), obj))
+
+	recorder := tracingtest.InstallRecorder("test")
+	defer recorder.Uninstall()
+
+	ctx, parent := tracing.Start(context.Background(), "parent")
+	obj = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"other": "value"}}}
+	tracing.Inject(ctx, obj)
+	tracing.End(parent, nil)
+	require.Equal(t, "value", obj.GetAnnotations()["other"])
+	require.Contains(t, obj.GetAnnotations(), tracing.TraceParentAnnotationKey)
+
+	// spans started from the object join the trace of the parent
+	_, child := tracing.Start(tracing.Extract(context.Background(), obj), "child")
+	tracing.End(child, errors.New("failed"))
+
+	parentSpan, childSpan := recorder.Span("parent"), recorder.Span("child")
+	require.NotNil(t, parentSpan)
+	require.NotNil(t, childSpan)
+	require.Equal(t, parentSpan.SpanContext.TraceID(), childSpan.SpanContext.TraceID())
+	require.Equal(t, parentSpan.SpanContext.SpanID(), childSpan.Parent.SpanID())
+	require.True(t, childSpan.Parent.IsRemote())
+	require.Equal(t, codes.Error, childSpan.StatusCode)
+	require.Equal(t, "failed", childSpan.StatusMessage)
+	require.Equal(t, "test", resourceServiceName(childSpan.Resource.Attributes()))
+}
+
+func TestExtractInvalid(t *testing.T) {
+	obj := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{tracing.TraceParentAnnotationKey: "invalid"}}}
+	require.False(t, trace.SpanContextFromContext(tracing.Extract(context.Background(), obj)).IsValid())
+}
+
+func TestSetup(t *testing.T) {
+	shutdown, err := tracing.Setup(context.Background(), tracing.Config{})
+	require.NoError(t, err)
+	require.NoError(t, shutdown(context.Background()))
+
+	_, err = tracing.Setup(context.Background(), tracing.Config{Endpoint: "localhost:4317", SampleRatio: 2})
+	require.EqualError(t, err, "invalid sample ratio 2: must be between 0 and 1")
+}
+
+func resourceServiceName(attributes []attribute.KeyValue) string {
+	for _, kv := range attributes {
+		if kv.Key == semconv.ServiceNameKey {
+			return kv.Value.AsString()
+		}
+	}
+	return ""
+}
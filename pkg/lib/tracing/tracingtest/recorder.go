@@ -0,0 +1,51 @@
+// Package tracingtest records the spans of the tracing package in memory, for
+// tests.
+package tracingtest
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/tracing"
+)
+
+// Recorder records spans in memory in place of a collector, for tests.
+type Recorder struct {
+	exporter *tracetest.InMemoryExporter
+	shutdown func(context.Context) error
+}
+
+// InstallRecorder installs a global tracer provider recording every span in
+// the returned Recorder until it is uninstalled.
+func InstallRecorder(serviceName string) *Recorder {
+	exporter := tracetest.NewInMemoryExporter()
+	return &Recorder{
+		exporter: exporter,
+		shutdown: tracing.Install(sdktrace.WithSyncer(exporter), tracing.Config{ServiceName: serviceName, SampleRatio: 1}),
+	}
+}
+
+// Spans returns the spans ended so far.
+func (r *Recorder) Spans() []*sdktrace.SpanSnapshot {
+	return r.exporter.GetSpans()
+}
+
+// Span returns the first ended span with the given name, nil if there is none.
+func (r *Recorder) Span(name string) *sdktrace.SpanSnapshot {
+	for _, span := range r.Spans() {
+		if span.Name == name {
+			return span
+		}
+	}
+	return nil
+}
+
+// Uninstall restores the no-op tracer provider.
+func (r *Recorder) Uninstall() {
+	r.shutdown(context.Background())
+	otel.SetTracerProvider(trace.NewNoopTracerProvider())
+}
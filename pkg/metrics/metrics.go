@@ -1,15 +1,20 @@
 package metrics
 
 import (
+	"bytes"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"google.golang.org/grpc/connectivity"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
 
 	olmv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
 	v1alpha1 "github.com/operator-framework/operator-lifecycle-manager/pkg/api/client/listers/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/certs"
 )
 
 const (
@@ -102,6 +107,87 @@ func (m *metricsCatalogSource) HandleMetrics() error {
 	return nil
 }
 
+// CertExpiry tracks the expiry of the serving certificates in Secrets and
+// exports the soonest. As a handler of Secret informers, it parses the
+// certificate of each Secret that changes only.
+type CertExpiry struct {
+	mu       sync.Mutex
+	expiries map[string]time.Time
+}
+
+var _ cache.ResourceEventHandler = &CertExpiry{}
+
+func NewCertExpiry() *CertExpiry {
+	return &CertExpiry{expiries: map[string]time.Time{}}
+}
+
+func (c *CertExpiry) OnAdd(obj interface{}) {
+	c.update(obj)
+}
+
+func (c *CertExpiry) OnUpdate(oldObj, newObj interface{}) {
+	oldSecret, ok := oldObj.(*corev1.Secret)
+	newSecret, ok2 := newObj.(*corev1.Secret)
+	if ok && ok2 && bytes.Equal(oldSecret.Data[corev1.TLSCertKey], newSecret.Data[corev1.TLSCertKey]) {
+		// Resyncs and changes to other keys don't change the expiry.
+		return
+	}
+	c.update(newObj)
+}
+
+func (c *CertExpiry) OnDelete(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.expiries, key)
+	c.export()
+}
+
+func (c *CertExpiry) update(obj interface{}) {
+	secret, ok := obj.(*corev1.Secret)
+	if !ok {
+		return
+	}
+	key, err := cache.MetaNamespaceKeyFunc(secret)
+	if err != nil {
+		return
+	}
+	cert, err := certs.PEMToCert(secret.Data[corev1.TLSCertKey])
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		delete(c.expiries, key)
+	} else {
+		c.expiries[key] = cert.NotAfter
+	}
+	c.export()
+}
+
+// soonest returns the soonest expiry, or the zero time if no certificates are
+// tracked. c.mu must be held.
+func (c *CertExpiry) soonest() time.Time {
+	var soonest time.Time
+	for _, expiry := range c.expiries {
+		if soonest.IsZero() || expiry.Before(soonest) {
+			soonest = expiry
+		}
+	}
+	return soonest
+}
+
+func (c *CertExpiry) export() {
+	soonest := c.soonest()
+	if soonest.IsZero() {
+		certSoonestExpiry.Set(0)
+		return
+	}
+	certSoonestExpiry.Set(float64(soonest.Unix()))
+}
+
 type MetricsNil struct{}
 
 func NewMetricsNil() MetricsProvider {
@@ -152,6 +238,37 @@ var (
 		[]string{NAMESPACE_LABEL, NAME_LABEL},
 	)
 
+	catalogSourceHealthScore = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "catalogsource_health_score",
+			Help: "Share of the recent attempts to read the content of a CatalogSource for resolution that succeeded, between 0 and 1",
+		},
+		[]string{NAMESPACE_LABEL, NAME_LABEL},
+	)
+
+	catalogSourceSnapshotDuration = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "catalogsource_snapshot_duration_seconds",
+			Help: "Duration of the last attempt to read the content of a CatalogSource for resolution",
+		},
+		[]string{NAMESPACE_LABEL, NAME_LABEL},
+	)
+
+	catalogSourceLastGoodSnapshot = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "catalogsource_last_good_snapshot_timestamp_seconds",
+			Help: "Unix time of the last successful read of the content of a CatalogSource for resolution, or 0 if there was none",
+		},
+		[]string{NAMESPACE_LABEL, NAME_LABEL},
+	)
+
+	certSoonestExpiry = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "olm_serving_cert_soonest_expiry_timestamp_seconds",
+			Help: "Unix time at which the first of the serving certificates OLM generated for operator APIServices and webhooks expires, or 0 if there are none",
+		},
+	)
+
 	// exported since it's not handled by HandleMetrics
 	CSVUpgradeCount = prometheus.NewCounter(
 		prometheus.CounterOpts{
@@ -193,6 +310,14 @@ var (
 		[]string{Outcome},
 	)
 
+	dependencyResolutionTimeoutCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "olm_resolution_timeouts_total",
+			Help: "Monotonic count of dependency resolution attempts that did not complete within the resolution timeout",
+		},
+		[]string{NAMESPACE_LABEL},
+	)
+
 	installPlanWarningCount = prometheus.NewCounter(
 		prometheus.CounterOpts{
 			Name: "installplan_warnings_total",
@@ -218,6 +343,7 @@ func RegisterOLM() {
 	prometheus.MustRegister(csvSucceeded)
 	prometheus.MustRegister(csvAbnormal)
 	prometheus.MustRegister(CSVUpgradeCount)
+	prometheus.MustRegister(certSoonestExpiry)
 }
 
 func RegisterCatalog() {
@@ -225,8 +351,12 @@ func RegisterCatalog() {
 	prometheus.MustRegister(subscriptionCount)
 	prometheus.MustRegister(catalogSourceCount)
 	prometheus.MustRegister(catalogSourceReady)
+	prometheus.MustRegister(catalogSourceHealthScore)
+	prometheus.MustRegister(catalogSourceSnapshotDuration)
+	prometheus.MustRegister(catalogSourceLastGoodSnapshot)
 	prometheus.MustRegister(SubscriptionSyncCount)
 	prometheus.MustRegister(dependencyResolutionSummary)
+	prometheus.MustRegister(dependencyResolutionTimeoutCount)
 	prometheus.MustRegister(installPlanWarningCount)
 }
 
@@ -247,6 +377,24 @@ func DeleteCatalogSourceStateMetric(name, namespace string) {
 	catalogSourceReady.DeleteLabelValues(namespace, name)
 }
 
+// RegisterCatalogSourceHealth records the health of the given CatalogSource
+// as observed when reading its content for resolution.
+func RegisterCatalogSourceHealth(name, namespace string, score float64, snapshotDuration time.Duration, lastGoodSnapshot time.Time) {
+	catalogSourceHealthScore.WithLabelValues(namespace, name).Set(score)
+	catalogSourceSnapshotDuration.WithLabelValues(namespace, name).Set(snapshotDuration.Seconds())
+	var lastGood float64
+	if !lastGoodSnapshot.IsZero() {
+		lastGood = float64(lastGoodSnapshot.Unix())
+	}
+	catalogSourceLastGoodSnapshot.WithLabelValues(namespace, name).Set(lastGood)
+}
+
+func DeleteCatalogSourceHealthMetric(name, namespace string) {
+	catalogSourceHealthScore.DeleteLabelValues(namespace, name)
+	catalogSourceSnapshotDuration.DeleteLabelValues(namespace, name)
+	catalogSourceLastGoodSnapshot.DeleteLabelValues(namespace, name)
+}
+
 func DeleteCSVMetric(oldCSV *olmv1alpha1.ClusterServiceVersion) {
 	// Delete the old CSV metrics
 	csvAbnormal.DeleteLabelValues(oldCSV.Namespace, oldCSV.Name, oldCSV.Spec.Version.String(), string(oldCSV.Status.Phase), string(oldCSV.Status.Reason))
@@ -331,6 +479,10 @@ func RegisterDependencyResolutionFailure(duration time.Duration) {
 	dependencyResolutionSummary.WithLabelValues(Failed).Observe(duration.Seconds())
 }
 
+func RegisterDependencyResolutionTimeout(namespace string) {
+	dependencyResolutionTimeoutCount.WithLabelValues(namespace).Inc()
+}
+
 func EmitInstallPlanWarning() {
 	installPlanWarningCount.Inc()
 }
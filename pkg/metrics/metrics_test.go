@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/certs"
+)
+
+func TestCertExpiry(t *testing.T) {
+	secret := func(name string, notAfter time.Time) *corev1.Secret {
+		ca, err := certs.GenerateCA(notAfter, "org", certs.DefaultKeyAlgorithm)
+		require.NoError(t, err)
+		certPEM, _, err := ca.ToPEM()
+		require.NoError(t, err)
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name},
+			Data:       map[string][]byte{corev1.TLSCertKey: certPEM},
+		}
+	}
+	soonest := func(c *CertExpiry) time.Time {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.soonest()
+	}
+
+	now := time.Now().Truncate(time.Second)
+	a := secret("a", now.Add(48*time.Hour))
+	b := secret("b", now.Add(24*time.Hour))
+
+	c := NewCertExpiry()
+	require.True(t, soonest(c).IsZero())
+
+	c.OnAdd(a)
+	c.OnAdd(b)
+	require.True(t, now.Add(24*time.Hour).Equal(soonest(c)))
+
+	// The rotated certificate of b now expires last.
+	c.OnUpdate(b, secret("b", now.Add(72*time.Hour)))
+	require.True(t, now.Add(48*time.Hour).Equal(soonest(c)))
+
+	// Secrets without a certificate aren't tracked.
+	c.OnUpdate(a, &corev1.Secret{ObjectMeta: a.ObjectMeta})
+	require.True(t, now.Add(72*time.Hour).Equal(soonest(c)))
+
+	c.OnDelete(cache.DeletedFinalStateUnknown{Key: "ns/b", Obj: b})
+	require.True(t, soonest(c).IsZero())
+}
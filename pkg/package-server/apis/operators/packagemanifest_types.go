@@ -142,3 +142,58 @@ type Icon struct {
 func (pc PackageChannel) IsDefaultChannel(pm PackageManifest) bool {
 	return pc.Name == pm.Status.DefaultChannel || len(pm.Status.Channels) == 1
 }
+
+// PackageManifestGraph is the upgrade graph of a package: every bundle in each of its channels.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type PackageManifestGraph struct {
+	metav1.TypeMeta
+	metav1.ObjectMeta
+
+	// Channels are the channels of the package.
+	Channels []PackageGraphChannel
+}
+
+// PackageGraphChannel lists the bundles of a single channel of a package.
+type PackageGraphChannel struct {
+	// Name is the name of the channel, e.g. `alpha` or `stable`
+	Name string
+
+	// Head is the name of the CSV of the channel's head, which is the bundle new subscriptions install.
+	Head string
+
+	// Entries are the bundles in the channel.
+	Entries []PackageGraphEntry
+}
+
+// PackageGraphEntry is a bundle in a channel, along with the edges of the upgrade graph that lead to it.
+type PackageGraphEntry struct {
+	// Name is the name of the bundle's CSV.
+	Name string
+
+	// Version is the version of the bundle.
+	Version string
+
+	// Replaces is the name of the CSV the bundle replaces.
+	Replaces string
+
+	// Skips are the names of the CSVs the bundle can replace in addition to Replaces.
+	Skips []string
+
+	// SkipRange is the range of versions the bundle can replace.
+	SkipRange string
+
+	// Deprecated is true if the bundle has the olm.deprecated property.
+	Deprecated bool
+
+	// Properties are the properties of the bundle.
+	Properties []PackageGraphProperty
+}
+
+// PackageGraphProperty is a typed property of a bundle.
+type PackageGraphProperty struct {
+	// Type is the type of the property, e.g. `olm.gvk`.
+	Type string
+
+	// Value is the JSON encoded value of the property.
+	Value string
+}
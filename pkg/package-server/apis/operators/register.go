@@ -30,6 +30,7 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&PackageManifest{},
 		&PackageManifestList{},
+		&PackageManifestGraph{},
 	)
 
 	return nil
@@ -147,3 +147,64 @@ type Icon struct {
 func (pc PackageChannel) IsDefaultChannel(pm PackageManifest) bool {
 	return pc.Name == pm.Status.DefaultChannel || len(pm.Status.Channels) == 1
 }
+
+// PackageManifestGraph is the upgrade graph of a package: every bundle in each of its channels.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type PackageManifestGraph struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Channels are the channels of the package.
+	// +listType=map
+	// +listMapKey=name
+	Channels []PackageGraphChannel `json:"channels"`
+}
+
+// PackageGraphChannel lists the bundles of a single channel of a package.
+type PackageGraphChannel struct {
+	// Name is the name of the channel, e.g. `alpha` or `stable`
+	Name string `json:"name"`
+
+	// Head is the name of the CSV of the channel's head, which is the bundle new subscriptions install.
+	Head string `json:"head"`
+
+	// Entries are the bundles in the channel.
+	// +listType=map
+	// +listMapKey=name
+	Entries []PackageGraphEntry `json:"entries"`
+}
+
+// PackageGraphEntry is a bundle in a channel, along with the edges of the upgrade graph that lead to it.
+type PackageGraphEntry struct {
+	// Name is the name of the bundle's CSV.
+	Name string `json:"name"`
+
+	// Version is the version of the bundle.
+	Version string `json:"version,omitempty"`
+
+	// Replaces is the name of the CSV the bundle replaces.
+	Replaces string `json:"replaces,omitempty"`
+
+	// Skips are the names of the CSVs the bundle can replace in addition to Replaces.
+	// +listType=set
+	Skips []string `json:"skips,omitempty"`
+
+	// SkipRange is the range of versions the bundle can replace.
+	SkipRange string `json:"skipRange,omitempty"`
+
+	// Deprecated is true if the bundle has the olm.deprecated property.
+	Deprecated bool `json:"deprecated,omitempty"`
+
+	// Properties are the properties of the bundle.
+	// +listType=atomic
+	Properties []PackageGraphProperty `json:"properties,omitempty"`
+}
+
+// PackageGraphProperty is a typed property of a bundle.
+type PackageGraphProperty struct {
+	// Type is the type of the property, e.g. `olm.gvk`.
+	Type string `json:"type"`
+
+	// Value is the JSON encoded value of the property.
+	Value string `json:"value"`
+}
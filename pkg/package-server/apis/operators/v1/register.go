@@ -1,6 +1,8 @@
 package v1
 
 import (
+	"fmt"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -9,17 +11,18 @@ import (
 )
 
 const (
-	Group                   = "packages." + operators.GroupName
-	Version                 = "v1"
-	PackageManifestKind     = "PackageManifest"
-	PackageManifestListKind = "PackageManifestList"
+	Group                    = "packages." + operators.GroupName
+	Version                  = "v1"
+	PackageManifestKind      = "PackageManifest"
+	PackageManifestListKind  = "PackageManifestList"
+	PackageManifestGraphKind = "PackageManifestGraph"
 )
 
 // SchemeGroupVersion is the group version used to register these objects.
 var SchemeGroupVersion = schema.GroupVersion{Group: Group, Version: Version}
 
 var (
-	SchemeBuilder      = runtime.NewSchemeBuilder(addKnownTypes)
+	SchemeBuilder      = runtime.NewSchemeBuilder(addKnownTypes, addFieldLabelConversionFuncs)
 	localSchemeBuilder = &SchemeBuilder
 	AddToScheme        = localSchemeBuilder.AddToScheme
 )
@@ -39,7 +42,37 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		SchemeGroupVersion.WithKind(PackageManifestListKind),
 		&PackageManifestList{},
 	)
+	scheme.AddKnownTypeWithName(
+		SchemeGroupVersion.WithKind(PackageManifestGraphKind),
+		&PackageManifestGraph{},
+	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 
 	return nil
 }
+
+// packageManifestFieldLabels are the fields by which PackageManifests can be selected.
+var packageManifestFieldLabels = map[string]struct{}{
+	"metadata.name":                 {},
+	"metadata.namespace":            {},
+	"status.catalogSource":          {},
+	"status.catalogSourceNamespace": {},
+	"status.defaultChannel":         {},
+	"status.provider.name":          {},
+}
+
+// IsPackageManifestFieldLabel returns true if PackageManifests can be selected by the given field.
+func IsPackageManifestFieldLabel(label string) bool {
+	_, ok := packageManifestFieldLabels[label]
+	return ok
+}
+
+// addFieldLabelConversionFuncs allows field selectors on the fields by which PackageManifests can be selected.
+func addFieldLabelConversionFuncs(scheme *runtime.Scheme) error {
+	return scheme.AddFieldLabelConversionFunc(SchemeGroupVersion.WithKind(PackageManifestKind), func(label, value string) (string, string, error) {
+		if !IsPackageManifestFieldLabel(label) {
+			return "", "", fmt.Errorf("field label not supported: %s", label)
+		}
+		return label, value, nil
+	})
+}
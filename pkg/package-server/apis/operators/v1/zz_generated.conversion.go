@@ -87,6 +87,36 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*PackageGraphChannel)(nil), (*operators.PackageGraphChannel)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1_PackageGraphChannel_To_operators_PackageGraphChannel(a.(*PackageGraphChannel), b.(*operators.PackageGraphChannel), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*operators.PackageGraphChannel)(nil), (*PackageGraphChannel)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_operators_PackageGraphChannel_To_v1_PackageGraphChannel(a.(*operators.PackageGraphChannel), b.(*PackageGraphChannel), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*PackageGraphEntry)(nil), (*operators.PackageGraphEntry)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1_PackageGraphEntry_To_operators_PackageGraphEntry(a.(*PackageGraphEntry), b.(*operators.PackageGraphEntry), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*operators.PackageGraphEntry)(nil), (*PackageGraphEntry)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_operators_PackageGraphEntry_To_v1_PackageGraphEntry(a.(*operators.PackageGraphEntry), b.(*PackageGraphEntry), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*PackageGraphProperty)(nil), (*operators.PackageGraphProperty)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1_PackageGraphProperty_To_operators_PackageGraphProperty(a.(*PackageGraphProperty), b.(*operators.PackageGraphProperty), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*operators.PackageGraphProperty)(nil), (*PackageGraphProperty)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_operators_PackageGraphProperty_To_v1_PackageGraphProperty(a.(*operators.PackageGraphProperty), b.(*PackageGraphProperty), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*PackageManifest)(nil), (*operators.PackageManifest)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1_PackageManifest_To_operators_PackageManifest(a.(*PackageManifest), b.(*operators.PackageManifest), scope)
 	}); err != nil {
@@ -97,6 +127,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*PackageManifestGraph)(nil), (*operators.PackageManifestGraph)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1_PackageManifestGraph_To_operators_PackageManifestGraph(a.(*PackageManifestGraph), b.(*operators.PackageManifestGraph), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*operators.PackageManifestGraph)(nil), (*PackageManifestGraph)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_operators_PackageManifestGraph_To_v1_PackageManifestGraph(a.(*operators.PackageManifestGraph), b.(*PackageManifestGraph), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*PackageManifestList)(nil), (*operators.PackageManifestList)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1_PackageManifestList_To_operators_PackageManifestList(a.(*PackageManifestList), b.(*operators.PackageManifestList), scope)
 	}); err != nil {
@@ -278,6 +318,84 @@ func Convert_operators_PackageChannel_To_v1_PackageChannel(in *operators.Package
 	return autoConvert_operators_PackageChannel_To_v1_PackageChannel(in, out, s)
 }
 
+func autoConvert_v1_PackageGraphChannel_To_operators_PackageGraphChannel(in *PackageGraphChannel, out *operators.PackageGraphChannel, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Head = in.Head
+	out.Entries = *(*[]operators.PackageGraphEntry)(unsafe.Pointer(&in.Entries))
+	return nil
+}
+
+// Convert_v1_PackageGraphChannel_To_operators_PackageGraphChannel is an autogenerated conversion function.
+func Convert_v1_PackageGraphChannel_To_operators_PackageGraphChannel(in *PackageGraphChannel, out *operators.PackageGraphChannel, s conversion.Scope) error {
+	return autoConvert_v1_PackageGraphChannel_To_operators_PackageGraphChannel(in, out, s)
+}
+
+func autoConvert_operators_PackageGraphChannel_To_v1_PackageGraphChannel(in *operators.PackageGraphChannel, out *PackageGraphChannel, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Head = in.Head
+	out.Entries = *(*[]PackageGraphEntry)(unsafe.Pointer(&in.Entries))
+	return nil
+}
+
+// Convert_operators_PackageGraphChannel_To_v1_PackageGraphChannel is an autogenerated conversion function.
+func Convert_operators_PackageGraphChannel_To_v1_PackageGraphChannel(in *operators.PackageGraphChannel, out *PackageGraphChannel, s conversion.Scope) error {
+	return autoConvert_operators_PackageGraphChannel_To_v1_PackageGraphChannel(in, out, s)
+}
+
+func autoConvert_v1_PackageGraphEntry_To_operators_PackageGraphEntry(in *PackageGraphEntry, out *operators.PackageGraphEntry, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Version = in.Version
+	out.Replaces = in.Replaces
+	out.Skips = *(*[]string)(unsafe.Pointer(&in.Skips))
+	out.SkipRange = in.SkipRange
+	out.Deprecated = in.Deprecated
+	out.Properties = *(*[]operators.PackageGraphProperty)(unsafe.Pointer(&in.Properties))
+	return nil
+}
+
+// Convert_v1_PackageGraphEntry_To_operators_PackageGraphEntry is an autogenerated conversion function.
+func Convert_v1_PackageGraphEntry_To_operators_PackageGraphEntry(in *PackageGraphEntry, out *operators.PackageGraphEntry, s conversion.Scope) error {
+	return autoConvert_v1_PackageGraphEntry_To_operators_PackageGraphEntry(in, out, s)
+}
+
+func autoConvert_operators_PackageGraphEntry_To_v1_PackageGraphEntry(in *operators.PackageGraphEntry, out *PackageGraphEntry, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Version = in.Version
+	out.Replaces = in.Replaces
+	out.Skips = *(*[]string)(unsafe.Pointer(&in.Skips))
+	out.SkipRange = in.SkipRange
+	out.Deprecated = in.Deprecated
+	out.Properties = *(*[]PackageGraphProperty)(unsafe.Pointer(&in.Properties))
+	return nil
+}
+
+// Convert_operators_PackageGraphEntry_To_v1_PackageGraphEntry is an autogenerated conversion function.
+func Convert_operators_PackageGraphEntry_To_v1_PackageGraphEntry(in *operators.PackageGraphEntry, out *PackageGraphEntry, s conversion.Scope) error {
+	return autoConvert_operators_PackageGraphEntry_To_v1_PackageGraphEntry(in, out, s)
+}
+
+func autoConvert_v1_PackageGraphProperty_To_operators_PackageGraphProperty(in *PackageGraphProperty, out *operators.PackageGraphProperty, s conversion.Scope) error {
+	out.Type = in.Type
+	out.Value = in.Value
+	return nil
+}
+
+// Convert_v1_PackageGraphProperty_To_operators_PackageGraphProperty is an autogenerated conversion function.
+func Convert_v1_PackageGraphProperty_To_operators_PackageGraphProperty(in *PackageGraphProperty, out *operators.PackageGraphProperty, s conversion.Scope) error {
+	return autoConvert_v1_PackageGraphProperty_To_operators_PackageGraphProperty(in, out, s)
+}
+
+func autoConvert_operators_PackageGraphProperty_To_v1_PackageGraphProperty(in *operators.PackageGraphProperty, out *PackageGraphProperty, s conversion.Scope) error {
+	out.Type = in.Type
+	out.Value = in.Value
+	return nil
+}
+
+// Convert_operators_PackageGraphProperty_To_v1_PackageGraphProperty is an autogenerated conversion function.
+func Convert_operators_PackageGraphProperty_To_v1_PackageGraphProperty(in *operators.PackageGraphProperty, out *PackageGraphProperty, s conversion.Scope) error {
+	return autoConvert_operators_PackageGraphProperty_To_v1_PackageGraphProperty(in, out, s)
+}
+
 func autoConvert_v1_PackageManifest_To_operators_PackageManifest(in *PackageManifest, out *operators.PackageManifest, s conversion.Scope) error {
 	out.ObjectMeta = in.ObjectMeta
 	if err := Convert_v1_PackageManifestSpec_To_operators_PackageManifestSpec(&in.Spec, &out.Spec, s); err != nil {
@@ -310,6 +428,28 @@ func Convert_operators_PackageManifest_To_v1_PackageManifest(in *operators.Packa
 	return autoConvert_operators_PackageManifest_To_v1_PackageManifest(in, out, s)
 }
 
+func autoConvert_v1_PackageManifestGraph_To_operators_PackageManifestGraph(in *PackageManifestGraph, out *operators.PackageManifestGraph, s conversion.Scope) error {
+	out.ObjectMeta = in.ObjectMeta
+	out.Channels = *(*[]operators.PackageGraphChannel)(unsafe.Pointer(&in.Channels))
+	return nil
+}
+
+// Convert_v1_PackageManifestGraph_To_operators_PackageManifestGraph is an autogenerated conversion function.
+func Convert_v1_PackageManifestGraph_To_operators_PackageManifestGraph(in *PackageManifestGraph, out *operators.PackageManifestGraph, s conversion.Scope) error {
+	return autoConvert_v1_PackageManifestGraph_To_operators_PackageManifestGraph(in, out, s)
+}
+
+func autoConvert_operators_PackageManifestGraph_To_v1_PackageManifestGraph(in *operators.PackageManifestGraph, out *PackageManifestGraph, s conversion.Scope) error {
+	out.ObjectMeta = in.ObjectMeta
+	out.Channels = *(*[]PackageGraphChannel)(unsafe.Pointer(&in.Channels))
+	return nil
+}
+
+// Convert_operators_PackageManifestGraph_To_v1_PackageManifestGraph is an autogenerated conversion function.
+func Convert_operators_PackageManifestGraph_To_v1_PackageManifestGraph(in *operators.PackageManifestGraph, out *PackageManifestGraph, s conversion.Scope) error {
+	return autoConvert_operators_PackageManifestGraph_To_v1_PackageManifestGraph(in, out, s)
+}
+
 func autoConvert_v1_PackageManifestList_To_operators_PackageManifestList(in *PackageManifestList, out *operators.PackageManifestList, s conversion.Scope) error {
 	out.ListMeta = in.ListMeta
 	out.Items = *(*[]operators.PackageManifest)(unsafe.Pointer(&in.Items))
@@ -153,6 +153,71 @@ func (in *PackageChannel) DeepCopy() *PackageChannel {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PackageGraphChannel) DeepCopyInto(out *PackageGraphChannel) {
+	*out = *in
+	if in.Entries != nil {
+		in, out := &in.Entries, &out.Entries
+		*out = make([]PackageGraphEntry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PackageGraphChannel.
+func (in *PackageGraphChannel) DeepCopy() *PackageGraphChannel {
+	if in == nil {
+		return nil
+	}
+	out := new(PackageGraphChannel)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PackageGraphEntry) DeepCopyInto(out *PackageGraphEntry) {
+	*out = *in
+	if in.Skips != nil {
+		in, out := &in.Skips, &out.Skips
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Properties != nil {
+		in, out := &in.Properties, &out.Properties
+		*out = make([]PackageGraphProperty, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PackageGraphEntry.
+func (in *PackageGraphEntry) DeepCopy() *PackageGraphEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(PackageGraphEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PackageGraphProperty) DeepCopyInto(out *PackageGraphProperty) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PackageGraphProperty.
+func (in *PackageGraphProperty) DeepCopy() *PackageGraphProperty {
+	if in == nil {
+		return nil
+	}
+	out := new(PackageGraphProperty)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PackageManifest) DeepCopyInto(out *PackageManifest) {
 	*out = *in
@@ -181,6 +246,39 @@ func (in *PackageManifest) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PackageManifestGraph) DeepCopyInto(out *PackageManifestGraph) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.Channels != nil {
+		in, out := &in.Channels, &out.Channels
+		*out = make([]PackageGraphChannel, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PackageManifestGraph.
+func (in *PackageManifestGraph) DeepCopy() *PackageManifestGraph {
+	if in == nil {
+		return nil
+	}
+	out := new(PackageManifestGraph)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PackageManifestGraph) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PackageManifestList) DeepCopyInto(out *PackageManifestList) {
 	*out = *in
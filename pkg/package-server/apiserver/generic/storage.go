@@ -65,9 +65,11 @@ func BuildStorage(providers *ProviderConfig) []generic.APIGroupInfo {
 	operatorInfo := generic.NewDefaultAPIGroupInfo(operatorsv1.Group, Scheme, metav1.ParameterCodec, Codecs)
 	operatorStorage := storage.NewStorage(operatorsv1.Resource("packagemanifests"), providers.Provider, Scheme)
 	iconStorage := storage.NewLogoStorage(operatorsv1.Resource("packagemanifests/icon"), providers.Provider)
+	graphStorage := storage.NewGraphStorage(operatorsv1.Resource("packagemanifests/graph"), providers.Provider)
 	operatorResources := map[string]rest.Storage{
-		"packagemanifests":      operatorStorage,
-		"packagemanifests/icon": iconStorage,
+		"packagemanifests":       operatorStorage,
+		"packagemanifests/icon":  iconStorage,
+		"packagemanifests/graph": graphStorage,
 	}
 	operatorInfo.VersionedResourcesStorageMap[operatorsv1.Version] = operatorResources
 
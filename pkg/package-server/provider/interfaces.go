@@ -3,9 +3,12 @@ package provider
 import (
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/operators"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/watch"
 )
 
 type PackageManifestProvider interface {
 	Get(namespace, name string) (*operators.PackageManifest, error)
 	List(namespace string, selector labels.Selector) (*operators.PackageManifestList, error)
+	Watch(namespace, resourceVersion string) (watch.Interface, error)
+	Graph(namespace, name string) (*operators.PackageManifestGraph, error)
 }
@@ -5,19 +5,23 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry"
 
+	"github.com/blang/semver/v4"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/connectivity"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/tools/cache"
 
 	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
@@ -30,6 +34,7 @@ import (
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/operators"
 	pkglisters "github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/client/listers/operators/internalversion"
 	"github.com/operator-framework/operator-registry/pkg/api"
+	opregistry "github.com/operator-framework/operator-registry/pkg/registry"
 )
 
 const (
@@ -37,6 +42,11 @@ const (
 	cacheTimeout = 5 * time.Minute
 	readyTimeout = 10 * time.Minute
 	stateTimeout = 20 * time.Second
+
+	// watchQueueLength is the number of events buffered for each watcher,
+	// and the number of past events kept to start watches from. Watchers that
+	// fall further behind are closed.
+	watchQueueLength = 1000
 )
 
 func getSourceKey(pkg *operators.PackageManifest) (key *registry.CatalogKey) {
@@ -119,8 +129,11 @@ type RegistryProvider struct {
 	globalNamespace string
 	sources         *registrygrpc.SourceStore
 	cache           cache.Indexer
+	cacheLock       sync.Mutex
 	pkgLister       pkglisters.PackageManifestLister
 	catsrcLister    operatorslisters.CatalogSourceLister
+	watchers        *packageWatchers
+	bundles         *catalogBundles
 }
 
 var _ PackageManifestProvider = &RegistryProvider{}
@@ -134,6 +147,8 @@ func NewRegistryProvider(ctx context.Context, crClient versioned.Interface, oper
 			cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
 			catalogIndex:         catalogIndexFunc,
 		}),
+		watchers: newPackageWatchers(),
+		bundles:  newCatalogBundles(),
 	}
 	p.sources = registrygrpc.NewSourceStore(logrus.New(), stateTimeout, readyTimeout, p.syncSourceState)
 	p.pkgLister = pkglisters.NewPackageManifestLister(p.cache)
@@ -313,7 +328,7 @@ func (p *RegistryProvider) refreshCache(ctx context.Context, client *registryCli
 				return
 			}
 
-			if err := p.cache.Add(newPkg); err != nil {
+			if err := p.cachePackage(newPkg); err != nil {
 				logger.WithField("err", err.Error()).Warnf("eliding package: failed to add to cache")
 				return
 			}
@@ -338,6 +353,10 @@ func (p *RegistryProvider) gcPackages(key registry.CatalogKey, keep map[string]s
 		"source": key.String(),
 	})
 
+	// The packages of the catalog were refreshed or dropped, so are the
+	// bundles of their graphs.
+	p.bundles.invalidate(key)
+
 	storedPkgKeys, err := p.cache.IndexKeys(catalogIndex, key.String())
 	if err != nil {
 		return err
@@ -351,7 +370,7 @@ func (p *RegistryProvider) gcPackages(key registry.CatalogKey, keep map[string]s
 				continue
 			}
 		}
-		if err := p.cache.Delete(string(storedPkgKey)); err != nil {
+		if err := p.uncachePackage(storedPkgKey); err != nil {
 			logger.WithField("pkg", name).WithError(err).Warn("failed to delete cache entry")
 			errs = append(errs, err)
 		}
@@ -360,6 +379,54 @@ func (p *RegistryProvider) gcPackages(key registry.CatalogKey, keep map[string]s
 	return utilerrors.NewAggregate(errs)
 }
 
+// cachePackage adds or updates a package in the cache and notifies watchers
+// of the change. Packages that have not changed are left untouched.
+func (p *RegistryProvider) cachePackage(pkg *operators.PackageManifest) error {
+	p.cacheLock.Lock()
+	defer p.cacheLock.Unlock()
+
+	old, exists, err := p.cache.Get(pkg)
+	if err != nil {
+		return err
+	}
+	if exists {
+		pkg.SetResourceVersion(old.(*operators.PackageManifest).GetResourceVersion())
+		if equality.Semantic.DeepEqual(old, pkg) {
+			return nil
+		}
+	}
+	pkg.SetResourceVersion(p.watchers.nextResourceVersion())
+	if err := p.cache.Add(pkg); err != nil {
+		return err
+	}
+
+	event := watch.Added
+	if exists {
+		event = watch.Modified
+	}
+	p.watchers.action(event, pkg)
+	return nil
+}
+
+// uncachePackage deletes the package with the given key from the cache and
+// notifies watchers of its deletion.
+func (p *RegistryProvider) uncachePackage(key string) error {
+	p.cacheLock.Lock()
+	defer p.cacheLock.Unlock()
+
+	old, exists, err := p.cache.GetByKey(key)
+	if err != nil || !exists {
+		return err
+	}
+	if err := p.cache.Delete(old); err != nil {
+		return err
+	}
+	deleted := old.(*operators.PackageManifest).DeepCopy()
+	deleted.SetResourceVersion(p.watchers.nextResourceVersion())
+	p.watchers.action(watch.Deleted, deleted)
+	return nil
+}
+
 func (p *RegistryProvider) catalogSourceDeleted(obj interface{}) {
 	catsrc, ok := obj.(metav1.Object)
 	if !ok {
@@ -419,6 +486,31 @@ func (p *RegistryProvider) Get(namespace, name string) (*operators.PackageManife
 }
 
 func (p *RegistryProvider) List(namespace string, selector labels.Selector) (*operators.PackageManifestList, error) {
+	p.cacheLock.Lock()
+	defer p.cacheLock.Unlock()
+
+	pkgs, err := p.visiblePackages(namespace, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	pkgList := &operators.PackageManifestList{}
+	pkgList.SetResourceVersion(p.watchers.currentResourceVersion())
+	for _, pkg := range pkgs {
+		out := pkg.DeepCopy()
+		// Set request namespace to stop k8s clients from complaining about namespace mismatch.
+		if namespace != metav1.NamespaceAll {
+			out.SetNamespace(namespace)
+		}
+		pkgList.Items = append(pkgList.Items, *out)
+	}
+
+	return pkgList, nil
+}
+
+// visiblePackages returns the cached packages that are visible in the given
+// namespace: those in the namespace and in the global namespace.
+func (p *RegistryProvider) visiblePackages(namespace string, selector labels.Selector) ([]*operators.PackageManifest, error) {
 	var pkgs []*operators.PackageManifest
 	if namespace == metav1.NamespaceAll {
 		all, err := p.pkgLister.List(selector)
@@ -443,17 +535,223 @@ func (p *RegistryProvider) List(namespace string, selector labels.Selector) (*op
 		}
 	}
 
-	pkgList := &operators.PackageManifestList{}
-	for _, pkg := range pkgs {
+	return pkgs, nil
+}
+
+// Watch returns a watch on the changes to the packages that are visible in
+// the given namespace, as listed by List, after the given resource version.
+// Without a resource version, the watch starts with the packages in the
+// cache. Resource versions that are too old to be watched from return a Gone
+// error.
+func (p *RegistryProvider) Watch(namespace, resourceVersion string) (watch.Interface, error) {
+	p.cacheLock.Lock()
+	defer p.cacheLock.Unlock()
+
+	var current []*operators.PackageManifest
+	if resourceVersion == "" || resourceVersion == "0" {
+		var err error
+		if current, err = p.visiblePackages(namespace, labels.Everything()); err != nil {
+			return nil, err
+		}
+	}
+	w, err := p.watchers.watch(resourceVersion, current)
+	if err != nil {
+		return nil, err
+	}
+
+	return watch.Filter(w, func(in watch.Event) (watch.Event, bool) {
+		pkg, ok := in.Object.(*operators.PackageManifest)
+		if !ok {
+			return in, false
+		}
+		if namespace == metav1.NamespaceAll {
+			in.Object = pkg.DeepCopy()
+			return in, true
+		}
+		if pkg.GetNamespace() != namespace && pkg.GetNamespace() != p.globalNamespace {
+			return in, false
+		}
+
 		out := pkg.DeepCopy()
 		// Set request namespace to stop k8s clients from complaining about namespace mismatch.
-		if namespace != metav1.NamespaceAll {
-			out.SetNamespace(namespace)
+		out.SetNamespace(namespace)
+		in.Object = out
+		return in, true
+	}), nil
+}
+
+// Graph returns the upgrade graph of the package with the given name that is
+// visible in the given namespace, built from every bundle its catalog serves.
+// It returns nil if there is no such package.
+func (p *RegistryProvider) Graph(namespace, name string) (*operators.PackageManifestGraph, error) {
+	pkg, err := p.Get(namespace, name)
+	if err != nil || pkg == nil {
+		return nil, err
+	}
+
+	entries, err := p.packageGraphEntries(*getSourceKey(pkg), pkg.Status.PackageName)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := &operators.PackageManifestGraph{
+		ObjectMeta: *pkg.ObjectMeta.DeepCopy(),
+	}
+	channels := map[string]*operators.PackageGraphChannel{}
+	graph.Channels = make([]operators.PackageGraphChannel, len(pkg.Status.Channels))
+	for i, ch := range pkg.Status.Channels {
+		graph.Channels[i] = operators.PackageGraphChannel{Name: ch.Name, Head: ch.CurrentCSV}
+		channels[ch.Name] = &graph.Channels[i]
+	}
+
+	for _, entry := range entries {
+		// Bundles of channels that were elided from the package are left out.
+		ch, ok := channels[entry.channel]
+		if !ok {
+			continue
 		}
-		pkgList.Items = append(pkgList.Items, *out)
+		ch.Entries = append(ch.Entries, *entry.DeepCopy())
 	}
 
-	return pkgList, nil
+	for i := range graph.Channels {
+		sortPackageGraphEntries(graph.Channels[i].Entries)
+	}
+
+	return graph, nil
+}
+
+// packageGraphEntries returns the graph entries of the bundles of the given
+// package that the given catalog serves. The bundles of the whole catalog are
+// listed once, and their entries kept until the packages of the catalog are
+// refreshed.
+func (p *RegistryProvider) packageGraphEntries(key registry.CatalogKey, pkgName string) ([]channelGraphEntry, error) {
+	packages, generation, ok := p.bundles.get(key)
+	if ok {
+		return packages[pkgName], nil
+	}
+
+	client, err := p.registryClient(key)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout, cancel := context.WithTimeout(context.Background(), cacheTimeout)
+	defer cancel()
+	stream, err := client.ListBundles(timeout, &api.ListBundlesRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing bundles of catalog %s: %v", key, err)
+	}
+
+	packages = map[string][]channelGraphEntry{}
+	for {
+		bundle, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error listing bundles of catalog %s: %v", key, err)
+		}
+		packages[bundle.GetPackageName()] = append(packages[bundle.GetPackageName()], channelGraphEntry{
+			PackageGraphEntry: newPackageGraphEntry(bundle),
+			channel:           bundle.GetChannelName(),
+		})
+	}
+	p.bundles.set(key, generation, packages)
+
+	return packages[pkgName], nil
+}
+
+// sortPackageGraphEntries sorts the given entries by increasing version. Entries
+// whose version isn't valid semver come first, and ties are broken by name.
+func sortPackageGraphEntries(entries []operators.PackageGraphEntry) {
+	versions := make(map[string]*semver.Version, len(entries))
+	for _, entry := range entries {
+		if v, err := semver.Parse(entry.Version); err == nil {
+			versions[entry.Name] = &v
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		vi, vj := versions[entries[i].Name], versions[entries[j].Name]
+		switch {
+		case vi == nil && vj != nil:
+			return true
+		case vi != nil && vj == nil:
+			return false
+		case vi != nil && vj != nil && !vi.EQ(*vj):
+			return vi.LT(*vj)
+		}
+		return entries[i].Name < entries[j].Name
+	})
+}
+
+// channelGraphEntry is the graph entry of a bundle in a channel.
+type channelGraphEntry struct {
+	operators.PackageGraphEntry
+	channel string
+}
+
+// catalogBundles caches the graph entries of the bundles served by each
+// catalog, by package.
+type catalogBundles struct {
+	mu sync.Mutex
+	// generations counts the invalidations of each catalog, so that
+	// bundles listed before an invalidation aren't cached after it.
+	generations map[registry.CatalogKey]uint64
+	packages    map[registry.CatalogKey]map[string][]channelGraphEntry
+}
+
+func newCatalogBundles() *catalogBundles {
+	return &catalogBundles{
+		generations: map[registry.CatalogKey]uint64{},
+		packages:    map[registry.CatalogKey]map[string][]channelGraphEntry{},
+	}
+}
+
+// get returns the cached bundles of the given catalog, if any, and the
+// generation to set them with otherwise.
+func (c *catalogBundles) get(key registry.CatalogKey) (map[string][]channelGraphEntry, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	packages, ok := c.packages[key]
+	return packages, c.generations[key], ok
+}
+
+// set caches the bundles of the given catalog, unless it was invalidated
+// since the given generation.
+func (c *catalogBundles) set(key registry.CatalogKey, generation uint64, packages map[string][]channelGraphEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generations[key] == generation {
+		c.packages[key] = packages
+	}
+}
+
+// invalidate drops the cached bundles of the given catalog.
+func (c *catalogBundles) invalidate(key registry.CatalogKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generations[key]++
+	delete(c.packages, key)
+}
+
+func newPackageGraphEntry(bundle *api.Bundle) operators.PackageGraphEntry {
+	entry := operators.PackageGraphEntry{
+		Name:      bundle.GetCsvName(),
+		Version:   bundle.GetVersion(),
+		Replaces:  bundle.GetReplaces(),
+		Skips:     bundle.GetSkips(),
+		SkipRange: bundle.GetSkipRange(),
+	}
+	for _, property := range bundle.GetProperties() {
+		if property.GetType() == opregistry.DeprecatedType {
+			entry.Deprecated = true
+		}
+		entry.Properties = append(entry.Properties, operators.PackageGraphProperty{
+			Type:  property.GetType(),
+			Value: property.GetValue(),
+		})
+	}
+	return entry
 }
 
 func newPackageManifest(ctx context.Context, logger *logrus.Entry, pkg *api.Package, client *registryClient) (*operators.PackageManifest, error) {
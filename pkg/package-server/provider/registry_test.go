@@ -22,13 +22,18 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
 
 	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/client/clientset/versioned/fake"
+	operatorslisters "github.com/operator-framework/operator-lifecycle-manager/pkg/api/client/listers/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/operatorclient"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/queueinformer"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/operators"
@@ -634,6 +639,10 @@ func TestRegistryProviderGet(t *testing.T) {
 				require.Nil(t, err)
 			}
 
+			if packageManifest != nil {
+				// Resource versions depend on when the provider was started.
+				packageManifest.SetResourceVersion("")
+			}
 			require.EqualValues(t, test.expected, packageManifest)
 		})
 	}
@@ -1073,6 +1082,9 @@ func TestRegistryProviderList(t *testing.T) {
 			}
 
 			require.Equal(t, len(test.expected.Items), len(packageManifestList.Items))
+			for i := range packageManifestList.Items {
+				packageManifestList.Items[i].SetResourceVersion("")
+			}
 			require.ElementsMatch(t, test.expected.Items, packageManifestList.Items)
 		})
 	}
@@ -1383,6 +1395,9 @@ func TestRegistryProviderListLabels(t *testing.T) {
 			}
 
 			require.Equal(t, len(test.expected.Items), len(packageManifestList.Items))
+			for i := range packageManifestList.Items {
+				packageManifestList.Items[i].SetResourceVersion("")
+			}
 			require.ElementsMatch(t, test.expected.Items, packageManifestList.Items)
 		})
 	}
@@ -1393,3 +1408,226 @@ func newTestRegistryClient(t *testing.T, catsrc *operatorsv1alpha1.CatalogSource
 	require.NoError(t, err, "could not set up test grpc connection")
 	return newRegistryClient(catsrc, conn)
 }
+
+func TestRegistryProviderWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	provider, err := NewFakeRegistryProvider(ctx, nil, nil, "global")
+	require.NoError(t, err)
+
+	pkg := func(name, namespace, defaultChannel string) *operators.PackageManifest {
+		return &operators.PackageManifest{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    map[string]string{"catalog": "cs", "catalog-namespace": namespace},
+			},
+			Status: operators.PackageManifestStatus{
+				CatalogSource:          "cs",
+				CatalogSourceNamespace: namespace,
+				DefaultChannel:         defaultChannel,
+			},
+		}
+	}
+
+	w, err := provider.Watch("ns", "")
+	require.NoError(t, err)
+	defer w.Stop()
+
+	require.NoError(t, provider.cachePackage(pkg("etcd", "ns", "alpha")))
+	require.NoError(t, provider.cachePackage(pkg("other", "other-ns", "alpha")))
+	// Unchanged packages are not sent again.
+	require.NoError(t, provider.cachePackage(pkg("etcd", "ns", "alpha")))
+	require.NoError(t, provider.cachePackage(pkg("etcd", "ns", "stable")))
+	require.NoError(t, provider.cachePackage(pkg("prometheus", "global", "beta")))
+	require.NoError(t, provider.gcPackages(registry.CatalogKey{Name: "cs", Namespace: "ns"}, nil))
+
+	expected := []struct {
+		event          watch.EventType
+		name           string
+		defaultChannel string
+	}{
+		{watch.Added, "etcd", "alpha"},
+		{watch.Modified, "etcd", "stable"},
+		{watch.Added, "prometheus", "beta"},
+		{watch.Deleted, "etcd", "stable"},
+	}
+	for _, e := range expected {
+		select {
+		case event := <-w.ResultChan():
+			out := event.Object.(*operators.PackageManifest)
+			require.Equal(t, e.event, event.Type)
+			require.Equal(t, e.name, out.GetName())
+			require.Equal(t, e.defaultChannel, out.Status.DefaultChannel)
+			require.Equal(t, "ns", out.GetNamespace())
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %s event for %s", e.event, e.name)
+		}
+	}
+}
+
+func TestRegistryProviderWatchResourceVersion(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	provider, err := NewFakeRegistryProvider(ctx, nil, nil, "global")
+	require.NoError(t, err)
+
+	pkg := func(name string) *operators.PackageManifest {
+		return &operators.PackageManifest{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+			Status:     operators.PackageManifestStatus{CatalogSource: "cs", CatalogSourceNamespace: "ns"},
+		}
+	}
+	names := func(t *testing.T, w watch.Interface, n int) []string {
+		var names []string
+		for i := 0; i < n; i++ {
+			select {
+			case event := <-w.ResultChan():
+				names = append(names, event.Object.(*operators.PackageManifest).GetName())
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timed out waiting for event %d", i)
+			}
+		}
+		return names
+	}
+
+	require.NoError(t, provider.cachePackage(pkg("etcd")))
+	list, err := provider.List("ns", labels.Everything())
+	require.NoError(t, err)
+	require.NoError(t, provider.cachePackage(pkg("prometheus")))
+
+	// Watches without a resource version start with the current packages.
+	w, err := provider.Watch("ns", "")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"etcd", "prometheus"}, names(t, w, 2))
+	w.Stop()
+
+	// Watches from the resource version of a list are sent the changes made
+	// after the list.
+	w, err = provider.Watch("ns", list.GetResourceVersion())
+	require.NoError(t, err)
+	require.Equal(t, []string{"prometheus"}, names(t, w, 1))
+	w.Stop()
+
+	// Watches from versions that aren't known are gone.
+	_, err = provider.Watch("ns", "1")
+	require.True(t, k8serrors.IsResourceExpired(err), "expected expired, got %v", err)
+	_, err = provider.Watch("ns", "latest")
+	require.True(t, k8serrors.IsBadRequest(err), "expected bad request, got %v", err)
+
+	// Watches that fall behind are closed.
+	w, err = provider.Watch("ns", list.GetResourceVersion())
+	require.NoError(t, err)
+	defer w.Stop()
+	for i := 0; i < 2*watchQueueLength; i++ {
+		require.NoError(t, provider.cachePackage(pkg(fmt.Sprintf("pkg-%d", i))))
+	}
+	closed := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-w.ResultChan():
+			if !ok {
+				return
+			}
+		case <-closed:
+			t.Fatal("timed out waiting for watch to close")
+		}
+	}
+}
+
+func TestRegistryProviderGraph(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	provider, err := NewFakeRegistryProvider(ctx, nil, nil, "global")
+	require.NoError(t, err)
+
+	catsrc := withRegistryServiceStatus(catalogSource("cool-operators", "ns"), "grpc", "cool-operators", "ns", port, metav1.NewTime(time.Now()))
+	require.NoError(t, provider.refreshCache(ctx, newTestRegistryClient(t, catsrc)))
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.NoError(t, indexer.Add(catsrc))
+	provider.catsrcLister = operatorslisters.NewCatalogSourceLister(indexer)
+	_, err = provider.sources.Add(registry.CatalogKey{Name: "cool-operators", Namespace: "ns"}, address+port)
+	require.NoError(t, err)
+
+	graph, err := provider.Graph("ns", "missing")
+	require.NoError(t, err)
+	require.Nil(t, graph)
+
+	graph, err = provider.Graph("ns", "etcd")
+	require.NoError(t, err)
+	require.NotNil(t, graph)
+	require.Equal(t, "etcd", graph.GetName())
+	require.Len(t, graph.Channels, 1)
+
+	channel := graph.Channels[0]
+	require.Equal(t, "alpha", channel.Name)
+	require.Equal(t, "etcdoperator.v0.9.2", channel.Head)
+
+	type edge struct {
+		name, version, replaces string
+	}
+	var edges []edge
+	for _, entry := range channel.Entries {
+		edges = append(edges, edge{entry.Name, entry.Version, entry.Replaces})
+		require.False(t, entry.Deprecated)
+		require.Contains(t, entry.Properties, operators.PackageGraphProperty{
+			Type:  "olm.package",
+			Value: fmt.Sprintf(`{"packageName":"etcd","version":"%s"}`, entry.Version),
+		})
+	}
+	require.Equal(t, []edge{
+		{"etcdoperator.v0.6.1", "0.6.1", ""},
+		{"etcdoperator.v0.9.0", "0.9.0", "etcdoperator.v0.6.1"},
+		{"etcdoperator.v0.9.2", "0.9.2", "etcdoperator.v0.9.0"},
+	}, edges)
+}
+
+func TestSortPackageGraphEntries(t *testing.T) {
+	entries := []operators.PackageGraphEntry{
+		{Name: "etcdoperator.v0.10.0", Version: "0.10.0"},
+		{Name: "etcdoperator.v0.9.0", Version: "0.9.0"},
+		{Name: "etcdoperator.v0.10.0-rc.1", Version: "0.10.0-rc.1"},
+		{Name: "etcdoperator.v0.9.0-b", Version: "0.9.0"},
+		{Name: "etcdoperator.unversioned"},
+	}
+	sortPackageGraphEntries(entries)
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name)
+	}
+	require.Equal(t, []string{
+		"etcdoperator.unversioned",
+		"etcdoperator.v0.9.0",
+		"etcdoperator.v0.9.0-b",
+		"etcdoperator.v0.10.0-rc.1",
+		"etcdoperator.v0.10.0",
+	}, names)
+}
+
+func TestCatalogBundles(t *testing.T) {
+	key := registry.CatalogKey{Name: "cool-operators", Namespace: "ns"}
+	entries := map[string][]channelGraphEntry{
+		"etcd": {{PackageGraphEntry: operators.PackageGraphEntry{Name: "etcdoperator.v0.9.2"}, channel: "alpha"}},
+	}
+	c := newCatalogBundles()
+
+	_, generation, ok := c.get(key)
+	require.False(t, ok)
+	c.set(key, generation, entries)
+	cached, _, ok := c.get(key)
+	require.True(t, ok)
+	require.Equal(t, entries, cached)
+
+	// bundles listed before an invalidation aren't cached after it
+	c.invalidate(key)
+	_, _, ok = c.get(key)
+	require.False(t, ok)
+	c.set(key, generation, entries)
+	_, _, ok = c.get(key)
+	require.False(t, ok)
+}
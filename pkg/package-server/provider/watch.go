@@ -0,0 +1,160 @@
+package provider
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/operators"
+)
+
+// packageWatchers sends the changes to the package cache to watches.
+//
+// Each change is given the next resource version. The versions of a package
+// server start at the time it was started, in nanoseconds, so that versions
+// handed out by another replica are far from its own. The last
+// watchQueueLength changes are kept, so that watches can start from the
+// resource version of a list that is a little behind.
+//
+// Watches that fall more than watchQueueLength events behind are closed
+// rather than dropping events, so that their clients list the packages again.
+type packageWatchers struct {
+	mu              sync.Mutex
+	resourceVersion uint64
+	history         []watch.Event
+	watches         map[*packageWatch]struct{}
+}
+
+func newPackageWatchers() *packageWatchers {
+	return &packageWatchers{
+		resourceVersion: uint64(time.Now().UnixNano()),
+		watches:         map[*packageWatch]struct{}{},
+	}
+}
+
+// currentResourceVersion returns the resource version of the last change.
+func (ws *packageWatchers) currentResourceVersion() string {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return strconv.FormatUint(ws.resourceVersion, 10)
+}
+
+// nextResourceVersion returns the resource version of a new change, to set
+// on the package before it is cached and sent with action.
+func (ws *packageWatchers) nextResourceVersion() string {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.resourceVersion++
+	return strconv.FormatUint(ws.resourceVersion, 10)
+}
+
+// action sends a change to all watches. The package must carry the resource
+// version of the change.
+func (ws *packageWatchers) action(eventType watch.EventType, pkg *operators.PackageManifest) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	event := watch.Event{Type: eventType, Object: pkg}
+	ws.history = append(ws.history, event)
+	if len(ws.history) > watchQueueLength {
+		ws.history = ws.history[len(ws.history)-watchQueueLength:]
+	}
+
+	for w := range ws.watches {
+		select {
+		case w.result <- event:
+		default:
+			ws.stop(w)
+		}
+	}
+}
+
+// watch starts a watch after the change with the given resource version. As
+// for other resources, watches without a resource version, or at "0", start
+// with an Added event for each of the current packages.
+//
+// Callers must hold the lock of the package cache, so that no change is made
+// between reading the current packages and starting the watch.
+func (ws *packageWatchers) watch(resourceVersion string, current []*operators.PackageManifest) (watch.Interface, error) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	var events []watch.Event
+	switch resourceVersion {
+	case "", "0":
+		for _, pkg := range current {
+			events = append(events, watch.Event{Type: watch.Added, Object: pkg})
+		}
+	default:
+		since, err := strconv.ParseUint(resourceVersion, 10, 64)
+		if err != nil {
+			return nil, k8serrors.NewBadRequest(fmt.Sprintf("invalid resource version %q", resourceVersion))
+		}
+		if events, err = ws.since(since); err != nil {
+			return nil, err
+		}
+	}
+
+	w := &packageWatch{
+		watchers: ws,
+		result:   make(chan watch.Event, len(events)+watchQueueLength),
+	}
+	for _, event := range events {
+		w.result <- event
+	}
+	ws.watches[w] = struct{}{}
+	return w, nil
+}
+
+// since returns the changes made after the given resource version, or a Gone
+// error if they are no longer known.
+func (ws *packageWatchers) since(resourceVersion uint64) ([]watch.Event, error) {
+	if resourceVersion == ws.resourceVersion {
+		return nil, nil
+	}
+	if resourceVersion < ws.resourceVersion {
+		for i, event := range ws.history {
+			version, err := strconv.ParseUint(event.Object.(*operators.PackageManifest).GetResourceVersion(), 10, 64)
+			if err != nil {
+				break
+			}
+			if version <= resourceVersion {
+				continue
+			}
+			if i == 0 && version > resourceVersion+1 {
+				break
+			}
+			return ws.history[i:], nil
+		}
+	}
+	return nil, k8serrors.NewResourceExpired(fmt.Sprintf("too old resource version: %d (%d)", resourceVersion, ws.resourceVersion))
+}
+
+func (ws *packageWatchers) stop(w *packageWatch) {
+	if _, ok := ws.watches[w]; !ok {
+		return
+	}
+	delete(ws.watches, w)
+	close(w.result)
+}
+
+type packageWatch struct {
+	watchers *packageWatchers
+	result   chan watch.Event
+}
+
+var _ watch.Interface = &packageWatch{}
+
+func (w *packageWatch) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+func (w *packageWatch) Stop() {
+	w.watchers.mu.Lock()
+	defer w.watchers.mu.Unlock()
+	w.watchers.stop(w)
+}
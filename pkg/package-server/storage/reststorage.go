@@ -14,10 +14,12 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
 	genericreq "k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/apiserver/pkg/registry/rest"
 
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/operators"
+	v1 "github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/operators/v1"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/provider"
 )
 
@@ -32,6 +34,7 @@ var _ rest.Storage = &PackageManifestStorage{}
 var _ rest.KindProvider = &PackageManifestStorage{}
 var _ rest.Lister = &PackageManifestStorage{}
 var _ rest.Getter = &PackageManifestStorage{}
+var _ rest.Watcher = &PackageManifestStorage{}
 var _ rest.Scoper = &PackageManifestStorage{}
 var _ rest.TableConvertor = &PackageManifestStorage{}
 
@@ -64,12 +67,7 @@ func (m *PackageManifestStorage) NewList() runtime.Object {
 func (m *PackageManifestStorage) List(ctx context.Context, options *metainternalversion.ListOptions) (runtime.Object, error) {
 	namespace := genericreq.NamespaceValue(ctx)
 
-	labelSelector := labels.Everything()
-	if options != nil && options.LabelSelector != nil {
-		labelSelector = options.LabelSelector
-	}
-
-	name, err := nameFor(options.FieldSelector)
+	labelSelector, fieldSelector, err := selectorsFor(options)
 	if err != nil {
 		return nil, err
 	}
@@ -81,21 +79,49 @@ func (m *PackageManifestStorage) List(ctx context.Context, options *metainternal
 
 	filtered := []operators.PackageManifest{}
 	for _, manifest := range res.Items {
-		if matches(manifest, name) {
+		if fieldSelector.Matches(fieldsFor(&manifest)) {
 			filtered = append(filtered, manifest)
 		}
 	}
 
 	for i := range filtered {
-		for j := range filtered[i].Status.Channels {
-			filtered[i].Status.Channels[j].CurrentCSVDesc.Icon = []operators.Icon{}
-		}
+		stripIcons(&filtered[i])
 	}
 	res.Items = filtered
 
 	return res, nil
 }
 
+// Watch satisfies the Watcher interface
+func (m *PackageManifestStorage) Watch(ctx context.Context, options *metainternalversion.ListOptions) (watch.Interface, error) {
+	namespace := genericreq.NamespaceValue(ctx)
+
+	labelSelector, fieldSelector, err := selectorsFor(options)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := m.prov.Watch(namespace, options.ResourceVersion)
+	if err != nil {
+		if _, ok := err.(k8serrors.APIStatus); ok {
+			return nil, err
+		}
+		return nil, k8serrors.NewInternalError(err)
+	}
+
+	return watch.Filter(w, func(in watch.Event) (watch.Event, bool) {
+		manifest, ok := in.Object.(*operators.PackageManifest)
+		if !ok {
+			return in, false
+		}
+		if !labelSelector.Matches(labels.Set(manifest.GetLabels())) || !fieldSelector.Matches(fieldsFor(manifest)) {
+			return in, false
+		}
+		stripIcons(manifest)
+		return in, true
+	}), nil
+}
+
 // Get satisfies the Getter interface
 func (m *PackageManifestStorage) Get(ctx context.Context, name string, opts *metav1.GetOptions) (runtime.Object, error) {
 	namespace := genericreq.NamespaceValue(ctx)
@@ -103,10 +129,7 @@ func (m *PackageManifestStorage) Get(ctx context.Context, name string, opts *met
 	if err != nil || manifest == nil {
 		return nil, k8serrors.NewNotFound(m.groupResource, name)
 	}
-	// Strip logo icons
-	for i := range manifest.Status.Channels {
-		manifest.Status.Channels[i].CurrentCSVDesc.Icon = []operators.Icon{}
-	}
+	stripIcons(manifest)
 
 	return manifest, nil
 }
@@ -116,22 +139,42 @@ func (m *PackageManifestStorage) NamespaceScoped() bool {
 	return true
 }
 
-func nameFor(fs fields.Selector) (string, error) {
-	if fs == nil {
-		fs = fields.Everything()
+// selectorsFor returns the label and field selectors of the given options,
+// defaulting to selecting everything.
+func selectorsFor(options *metainternalversion.ListOptions) (labels.Selector, fields.Selector, error) {
+	labelSelector := labels.Everything()
+	fieldSelector := fields.Everything()
+	if options != nil && options.LabelSelector != nil {
+		labelSelector = options.LabelSelector
+	}
+	if options != nil && options.FieldSelector != nil {
+		fieldSelector = options.FieldSelector
 	}
-	name := ""
-	if value, found := fs.RequiresExactMatch("metadata.name"); found {
-		name = value
-	} else if !fs.Empty() {
-		return "", fmt.Errorf("field label not supported: %s", fs.Requirements()[0].Field)
+
+	for _, r := range fieldSelector.Requirements() {
+		if !v1.IsPackageManifestFieldLabel(r.Field) {
+			return nil, nil, k8serrors.NewBadRequest(fmt.Sprintf("field label not supported: %s", r.Field))
+		}
+	}
+	return labelSelector, fieldSelector, nil
+}
+
+// fieldsFor returns the fields of a PackageManifest that can be selected.
+func fieldsFor(pm *operators.PackageManifest) fields.Set {
+	return fields.Set{
+		"metadata.name":                 pm.GetName(),
+		"metadata.namespace":            pm.GetNamespace(),
+		"status.catalogSource":          pm.Status.CatalogSource,
+		"status.catalogSourceNamespace": pm.Status.CatalogSourceNamespace,
+		"status.defaultChannel":         pm.Status.DefaultChannel,
+		"status.provider.name":          pm.Status.Provider.Name,
 	}
-	return name, nil
 }
 
-func matches(pm operators.PackageManifest, name string) bool {
-	if name == "" {
-		name = pm.GetName()
+// stripIcons removes the logo icons of a PackageManifest, which are served by
+// the icon subresource.
+func stripIcons(pm *operators.PackageManifest) {
+	for i := range pm.Status.Channels {
+		pm.Status.Channels[i].CurrentCSVDesc.Icon = []operators.Icon{}
 	}
-	return pm.GetName() == name
 }
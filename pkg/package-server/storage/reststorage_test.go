@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	genericreq "k8s.io/apiserver/pkg/endpoints/request"
+
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/operators"
+	v1 "github.com/operator-framework/operator-lifecycle-manager/pkg/package-server/apis/operators/v1"
+)
+
+func selectorTestPackage(name, catalog, provider string) *operators.PackageManifest {
+	pkg := testPackage()
+	pkg.SetName(name)
+	pkg.SetNamespace("ns")
+	pkg.SetLabels(map[string]string{"catalog": catalog})
+	pkg.Status.CatalogSource = catalog
+	pkg.Status.CatalogSourceNamespace = "olm"
+	pkg.Status.Provider.Name = provider
+	return pkg
+}
+
+func TestPackageManifestStorageList(t *testing.T) {
+	prov := &fakeProvider{packages: []*operators.PackageManifest{
+		selectorTestPackage("pkg-a", "community", "Red Hat"),
+		selectorTestPackage("pkg-b", "community", "Acme"),
+		selectorTestPackage("pkg-c", "certified", "Acme"),
+	}}
+	storage := NewStorage(v1.Resource("packagemanifests"), prov, runtime.NewScheme())
+	ctx := genericreq.WithNamespace(context.TODO(), "ns")
+
+	for _, tt := range []struct {
+		name     string
+		fields   string
+		labels   string
+		expected []string
+		invalid  bool
+	}{
+		{
+			name:     "Everything",
+			expected: []string{"pkg-a", "pkg-b", "pkg-c"},
+		},
+		{
+			name:     "Name",
+			fields:   "metadata.name=pkg-b",
+			expected: []string{"pkg-b"},
+		},
+		{
+			name:     "CatalogSource",
+			fields:   "status.catalogSource=community,status.catalogSourceNamespace=olm",
+			expected: []string{"pkg-a", "pkg-b"},
+		},
+		{
+			name:     "ProviderNotEqual",
+			fields:   "status.provider.name!=Acme",
+			expected: []string{"pkg-a"},
+		},
+		{
+			name:     "DefaultChannelAndLabels",
+			fields:   "status.defaultChannel=stable",
+			labels:   "catalog=certified",
+			expected: []string{"pkg-c"},
+		},
+		{
+			name:    "Unsupported",
+			fields:  "status.packageName=pkg-a",
+			invalid: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &metainternalversion.ListOptions{}
+			var err error
+			options.FieldSelector, err = fields.ParseSelector(tt.fields)
+			require.NoError(t, err)
+			options.LabelSelector, err = labels.Parse(tt.labels)
+			require.NoError(t, err)
+
+			out, err := storage.List(ctx, options)
+			if tt.invalid {
+				require.True(t, k8serrors.IsBadRequest(err), "expected bad request, got %v", err)
+				return
+			}
+			require.NoError(t, err)
+
+			var names []string
+			for _, pkg := range out.(*operators.PackageManifestList).Items {
+				names = append(names, pkg.GetName())
+				for _, channel := range pkg.Status.Channels {
+					require.Empty(t, channel.CurrentCSVDesc.Icon)
+				}
+			}
+			require.Equal(t, tt.expected, names)
+		})
+	}
+}
+
+func TestPackageManifestStorageWatch(t *testing.T) {
+	prov := &fakeProvider{watcher: watch.NewFake()}
+	storage := NewStorage(v1.Resource("packagemanifests"), prov, runtime.NewScheme())
+	ctx := genericreq.WithNamespace(context.TODO(), "ns")
+
+	selector, err := fields.ParseSelector("status.catalogSource=community")
+	require.NoError(t, err)
+	w, err := storage.Watch(ctx, &metainternalversion.ListOptions{FieldSelector: selector, ResourceVersion: "42"})
+	require.NoError(t, err)
+	defer w.Stop()
+	require.Equal(t, "42", prov.resourceVersion)
+
+	go func() {
+		prov.watcher.Add(selectorTestPackage("pkg-c", "certified", "Acme"))
+		prov.watcher.Add(selectorTestPackage("pkg-a", "community", "Acme"))
+		prov.watcher.Delete(selectorTestPackage("pkg-a", "community", "Acme"))
+	}()
+
+	event := <-w.ResultChan()
+	require.Equal(t, watch.Added, event.Type)
+	pkg := event.Object.(*operators.PackageManifest)
+	require.Equal(t, "pkg-a", pkg.GetName())
+	for _, channel := range pkg.Status.Channels {
+		require.Empty(t, channel.CurrentCSVDesc.Icon)
+	}
+
+	event = <-w.ResultChan()
+	require.Equal(t, watch.Deleted, event.Type)
+	require.Equal(t, "pkg-a", event.Object.(metav1.Object).GetName())
+
+	_, err = storage.Watch(ctx, &metainternalversion.ListOptions{FieldSelector: fields.OneTermEqualSelector("spec.foo", "bar")})
+	require.True(t, k8serrors.IsBadRequest(err), "expected bad request, got %v", err)
+}
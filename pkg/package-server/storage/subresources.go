@@ -3,11 +3,14 @@ package storage
 import (
 	"context"
 	"encoding/base64"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strconv"
 	"strings"
 
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	genericreq "k8s.io/apiserver/pkg/endpoints/request"
@@ -109,6 +112,38 @@ func (s *LogoStorage) ProducesObject(verb string) interface{} {
 	return ""
 }
 
+// GraphStorage implements Kubernetes methods needed to provide the `packagemanifests/graph` subresource
+type GraphStorage struct {
+	groupResource schema.GroupResource
+	prov          provider.PackageManifestProvider
+}
+
+var _ rest.Getter = &GraphStorage{}
+
+// NewGraphStorage returns struct which implements Kubernetes methods needed to provide the `packagemanifests/graph` subresource
+func NewGraphStorage(groupResource schema.GroupResource, prov provider.PackageManifestProvider) *GraphStorage {
+	return &GraphStorage{groupResource, prov}
+}
+
+// New satisfies the Storage interface
+func (s *GraphStorage) New() runtime.Object {
+	return &operators.PackageManifestGraph{}
+}
+
+// Get satisfies the Getter interface and returns the upgrade graph of a given `PackageManifest`
+func (s *GraphStorage) Get(ctx context.Context, name string, opts *metav1.GetOptions) (runtime.Object, error) {
+	namespace := genericreq.NamespaceValue(ctx)
+	graph, err := s.prov.Graph(namespace, name)
+	if err != nil {
+		return nil, k8serrors.NewServiceUnavailable(fmt.Sprintf("unable to get graph of %s: %v", name, err))
+	}
+	if graph == nil {
+		return nil, k8serrors.NewNotFound(s.groupResource, name)
+	}
+
+	return graph, nil
+}
+
 const defaultIcon string = `
 <svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 258.51 258.51"><defs><style>.cls-1{fill:#d1d1d1;}.cls-2{fill:#8d8d8f;}</style></defs><title>Asset 4</title><g id="Layer_2" data-name="Layer 2"><g id="Layer_1-2" data-name="Layer 1"><path class="cls-1" d="M129.25,20A109.1,109.1,0,0,1,206.4,206.4,109.1,109.1,0,1,1,52.11,52.11,108.45,108.45,0,0,1,129.25,20m0-20h0C58.16,0,0,58.16,0,129.25H0c0,71.09,58.16,129.26,129.25,129.26h0c71.09,0,129.26-58.17,129.26-129.26h0C258.51,58.16,200.34,0,129.25,0Z"/><path class="cls-2" d="M177.54,103.41H141.66L154.9,65.76c1.25-4.4-2.33-8.76-7.21-8.76H102.93a7.32,7.32,0,0,0-7.4,6l-10,69.61c-.59,4.17,2.89,7.89,7.4,7.89h36.9L115.55,197c-1.12,4.41,2.48,8.55,7.24,8.55a7.58,7.58,0,0,0,6.47-3.48L184,113.85C186.86,109.24,183.29,103.41,177.54,103.41Z"/></g></g></svg>
 `
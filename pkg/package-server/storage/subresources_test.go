@@ -2,11 +2,17 @@ package storage
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	genericreq "k8s.io/apiserver/pkg/endpoints/request"
 
 	"github.com/stretchr/testify/require"
 
@@ -17,8 +23,12 @@ import (
 
 type fakeProvider struct {
 	packages []*operators.PackageManifest
+	watcher  *watch.FakeWatcher
+	graph    *operators.PackageManifestGraph
+	graphErr error
 
-	getCalls int
+	getCalls        int
+	resourceVersion string
 }
 
 func (p *fakeProvider) Get(namespace, name string) (*operators.PackageManifest, error) {
@@ -28,7 +38,22 @@ func (p *fakeProvider) Get(namespace, name string) (*operators.PackageManifest,
 }
 
 func (p *fakeProvider) List(namespace string, selector labels.Selector) (*operators.PackageManifestList, error) {
-	return &operators.PackageManifestList{}, nil
+	list := &operators.PackageManifestList{}
+	for _, pkg := range p.packages {
+		if selector.Matches(labels.Set(pkg.GetLabels())) {
+			list.Items = append(list.Items, *pkg.DeepCopy())
+		}
+	}
+	return list, nil
+}
+
+func (p *fakeProvider) Watch(namespace, resourceVersion string) (watch.Interface, error) {
+	p.resourceVersion = resourceVersion
+	return p.watcher, nil
+}
+
+func (p *fakeProvider) Graph(namespace, name string) (*operators.PackageManifestGraph, error) {
+	return p.graph, p.graphErr
 }
 
 var _ provider.PackageManifestProvider = &fakeProvider{}
@@ -93,6 +118,58 @@ func TestLogoStorageConnectDefaultIcon(t *testing.T) {
 	require.Equal(t, 1, provider.getCalls, "PackageManifestProvider.Get() should be called for missing icon")
 }
 
+func TestGraphStorageGet(t *testing.T) {
+	graph := &operators.PackageManifestGraph{
+		Channels: []operators.PackageGraphChannel{
+			{
+				Name: "stable",
+				Head: "csv-b",
+				Entries: []operators.PackageGraphEntry{
+					{Name: "csv-a", Version: "1.0.0"},
+					{Name: "csv-b", Version: "1.1.0", Replaces: "csv-a"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		provider fakeProvider
+		want     runtime.Object
+		wantErr  func(error) bool
+	}{
+		{
+			name:     "Found",
+			provider: fakeProvider{graph: graph},
+			want:     graph,
+		},
+		{
+			name:     "NotFound",
+			provider: fakeProvider{},
+			wantErr:  k8serrors.IsNotFound,
+		},
+		{
+			name:     "RegistryUnavailable",
+			provider: fakeProvider{graphErr: fmt.Errorf("connection refused")},
+			wantErr:  k8serrors.IsServiceUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := NewGraphStorage(v1.Resource("packagemanifests/graph"), &tt.provider)
+			ctx := genericreq.WithNamespace(context.TODO(), "ns")
+
+			got, err := storage.Get(ctx, "pkg-a", &metav1.GetOptions{})
+			if tt.wantErr != nil {
+				require.True(t, tt.wantErr(err), "unexpected error: %v", err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func testPackage() *operators.PackageManifest {
 	return &operators.PackageManifest{
 		Status: operators.PackageManifestStatus{